@@ -0,0 +1,775 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"l2/config"
+	"l2/demo"
+	"l2/storage"
+	"l2/tools"
+
+	"github.com/joho/godotenv"
+)
+
+// subcommand is a non-interactive action run as `l2 <name> [args]`
+type subcommand struct {
+	usage       string
+	description string
+	run         func(args []string) error
+}
+
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"anki": {
+			usage:       "l2 anki [-deck name] [-ipa] [file]",
+			description: "Export the lexicon as an Anki-importable flashcard file",
+			run:         ankiCommand,
+		},
+		"badges": {
+			usage:       "l2 badges",
+			description: "Regenerate the stat badges in the data directory, as SVG (PNG isn't supported)",
+			run:         badgesCommand,
+		},
+		"cldf": {
+			usage:       "l2 cldf [dir]",
+			description: "Export the lexicon, its descendants and their cognate sets as a CLDF Wordlist dataset (default dir cldf)",
+			run:         cldfCommand,
+		},
+		"dedupe": {
+			usage:       "l2 dedupe",
+			description: "Report data files that hold the same content",
+			run:         dedupeCommand,
+		},
+		"digest": {
+			usage:       "l2 digest [-days n] [-webhook url] [-email address] [file]",
+			description: "Summarize the last week's sessions, new words and decisions as Markdown, optionally posting it to a webhook or mailing it",
+			run:         digestCommand,
+		},
+		"demo": {
+			usage:       "l2 demo [-keep]",
+			description: "Chat about Sema, a bundled example language, in a temporary workspace",
+			run:         demoCommand,
+		},
+		"export": {
+			usage:       "l2 export [-format csv|tsv] [-columns word,definition,...] [file]",
+			description: "Export the lexicon as CSV or TSV to a file or stdout",
+			run:         exportCommand,
+		},
+		"graph": {
+			usage:       "l2 graph [-svg] <family | derivations [word]>",
+			description: "Print the language family tree or the word derivation graph as Graphviz DOT, or save it under graphs/ in the data files rendered as SVG",
+			run:         graphCommand,
+		},
+		"reconcile": {
+			usage:       "l2 reconcile [-list] [-yes]",
+			description: "Find words the stored conversation defined or tried to add that never reached the lexicon, and offer to add them",
+			run:         reconcileCommand,
+		},
+		"scan": {
+			usage:       "l2 scan [-list] [-yes] <image | file.pdf>",
+			description: "Transcribe paradigm tables from a photo or scanned PDF with a vision model, and save each one after review",
+			run:         scanCommand,
+		},
+		"simulate": {
+			usage:       "l2 simulate [-texts n] [-words n] [-seed n] [rules-file]",
+			description: "Preview sound change rules on random pseudo-texts",
+			run:         simulateCommand,
+		},
+		"stats": {
+			usage:       "l2 stats",
+			description: "Print word, phoneme and part-of-speech counts",
+			run:         statsCommand,
+		},
+		"transcript": {
+			usage:       "l2 transcript [file]",
+			description: "Export the saved conversation as Markdown to a file or stdout",
+			run:         transcriptCommand,
+		},
+		"import": {
+			usage:       "l2 import [-replace] <file.json | file.md>",
+			description: "Load a conversation exported as conversation.json or with /export as the chat history",
+			run:         importCommand,
+		},
+		"lexicon": {
+			usage:       "l2 lexicon <list [filters] | add [flags] <word> <definition> | rm <word> | export [flags] [file] | import [-list] <file.db>>",
+			description: "List, add, remove, export or import lexicon entries without a chat session; import reads SIL Toolbox (MDF) lexicons",
+			run:         lexiconCommand,
+		},
+		"query": {
+			usage:       "l2 query [<name> | <filters> | save <name> <filters>]",
+			description: "Run a saved or ad hoc lexicon query, or save a new one",
+			run:         queryCommand,
+		},
+	}
+}
+
+// usage prints the flags and subcommands accepted by the binary
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: l2 [flags] [command]\n\nRun without a command to start the chat interface.\n\nCommands:\n")
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-30s %s\n", subcommands[name].usage, subcommands[name].description)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// runSubcommand dispatches command line arguments to the matching subcommand
+func runSubcommand(args []string) error {
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		usage()
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	return cmd.run(args[1:])
+}
+
+func badgesCommand(args []string) error {
+	paths, err := tools.GenerateBadges()
+	if err != nil {
+		return err
+	}
+	dataDir, err := storage.GetPath(storage.DataFile)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		fmt.Fprintf(os.Stdout, "Wrote %s/%s\n", dataDir, path)
+	}
+	return nil
+}
+
+func statsCommand(args []string) error {
+	stats, err := tools.CollectLanguageStats()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("words\t%d\nphonemes\t%d\ngrammar\t%d%%\n", stats.Words, stats.Phonemes, stats.GrammarCompletion)
+
+	// Declared tags are listed even when no word uses them yet
+	tags, err := storage.ReadPartsOfSpeech()
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for pos, n := range stats.PartsOfSpeech {
+		counts[pos] = n
+	}
+	for _, t := range tags {
+		counts[strings.ToLower(t.Tag)] += 0
+	}
+	names := make([]string, 0, len(counts))
+	for pos := range counts {
+		names = append(names, pos)
+	}
+	sort.Strings(names)
+	for _, pos := range names {
+		fmt.Printf("  %s\t%d\n", pos, counts[pos])
+	}
+	return nil
+}
+
+func cldfCommand(args []string) error {
+	fs := flag.NewFlagSet("cldf", flag.ExitOnError)
+	fs.Parse(args)
+	dir := "cldf"
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	files, err := tools.WriteCLDF(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		fmt.Printf("Wrote %s\n", filepath.Join(dir, file))
+	}
+	return nil
+}
+
+func graphCommand(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	svg := fs.Bool("svg", false, "save the DOT and an SVG rendered with Graphviz under graphs/ in the data files")
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 || (fs.Arg(0) == tools.GraphFamily && fs.NArg() > 1) {
+		return fmt.Errorf("usage: %s", subcommands["graph"].usage)
+	}
+
+	if *svg {
+		result, err := tools.ExportGraph(context.Background(), &tools.ExportGraphRequest{Graph: fs.Arg(0), Word: fs.Arg(1), Render: true})
+		if err != nil {
+			return err
+		}
+		if !result.Success || result.SVG == "" {
+			return fmt.Errorf("%s", result.Message)
+		}
+		dataDir, err := storage.GetPath(storage.DataFile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\nWrote %s\n", filepath.Join(dataDir, result.Path), filepath.Join(dataDir, result.SVG))
+		return nil
+	}
+
+	var dot string
+	var err error
+	switch fs.Arg(0) {
+	case tools.GraphFamily:
+		dot, err = tools.FamilyDOT()
+	case tools.GraphDerivations:
+		dot, err = tools.DerivationDOT(fs.Arg(1))
+	default:
+		return fmt.Errorf("usage: %s", subcommands["graph"].usage)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Print(dot)
+	return nil
+}
+
+func dedupeCommand(args []string) error {
+	groups, err := storage.FindDuplicates()
+	if err != nil {
+		return err
+	}
+	fmt.Println(tools.DescribeDuplicates(groups))
+	return nil
+}
+
+func queryCommand(args []string) error {
+	if len(args) == 0 {
+		names, queries, err := tools.SavedQueries()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Printf("%s\t%s\n", name, tools.DescribeQuery(queries[name]))
+		}
+		return nil
+	}
+
+	if args[0] == "save" {
+		if len(args) < 3 {
+			return fmt.Errorf("usage: l2 query save <name> <filters>")
+		}
+		q, err := tools.ParseLexiconQuery(args[2:])
+		if err != nil {
+			return err
+		}
+		return tools.SaveQuery(args[1], q)
+	}
+
+	entries, err := tools.QueryLexicon(args)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		fmt.Printf("%s\t%s\t%s\n", entry.Word, entry.PartOfSpeech, entry.Definition)
+	}
+	return nil
+}
+
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "csv", "output format, csv or tsv")
+	columns := fs.String("columns", strings.Join(tools.DefaultExportColumns, ","), "comma separated column order")
+	fs.Parse(args)
+
+	entries, err := tools.LoadLexicon()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if fs.NArg() > 0 {
+		file, err := os.Create(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return tools.WriteLexiconTable(out, entries, *format, strings.Split(*columns, ","))
+}
+
+func digestCommand(args []string) error {
+	settings, err := storage.ReadConfig()
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	days := fs.Int("days", tools.DefaultDigestDays, "how many days back the digest covers")
+	webhook := fs.String("webhook", settings.Digest.Webhook, "post the digest to this URL")
+	email := fs.String("email", settings.Digest.Email, "mail the digest to this address")
+	fs.Parse(args)
+	if *days < 1 {
+		return fmt.Errorf("-days must be at least 1")
+	}
+
+	to := time.Now()
+	digest, err := tools.BuildDigest(to.AddDate(0, 0, -*days), to)
+	if err != nil {
+		return err
+	}
+	markdown := digest.Markdown()
+
+	if fs.NArg() > 0 {
+		if err := os.WriteFile(fs.Arg(0), []byte(markdown), 0644); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(markdown)
+	}
+	// SMTP_USER and SMTP_PASSWORD may be kept in .env with the API keys
+	godotenv.Load()
+	if *webhook != "" {
+		if err := tools.PostDigest(context.Background(), *webhook, markdown); err != nil {
+			return fmt.Errorf("failed to post the digest: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Posted the digest to the webhook")
+	}
+	if *email != "" {
+		if err := tools.EmailDigest(settings.Digest, *email, digest.Title(), markdown); err != nil {
+			return fmt.Errorf("failed to mail the digest: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Mailed the digest to %s\n", *email)
+	}
+	return nil
+}
+
+func transcriptCommand(args []string) error {
+	fs := flag.NewFlagSet("transcript", flag.ExitOnError)
+	fs.Parse(args)
+
+	exists, err := storage.CheckFile(storage.ConversationFile)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no conversation has been saved yet")
+	}
+	history, err := storage.ReadConversation()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if fs.NArg() > 0 {
+		file, err := os.Create(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return tools.WriteTranscript(out, history)
+}
+
+func importCommand(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	replace := fs.Bool("replace", false, "replace the current conversation, keeping a copy of it under conversations/ in the data files")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s", subcommands["import"].usage)
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	markdown := strings.HasSuffix(strings.ToLower(fs.Arg(0)), ".md")
+	history, err := tools.ReadTranscript(data, markdown)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	exists, err := storage.CheckFile(storage.ConversationFile)
+	if err != nil {
+		return err
+	}
+	if exists {
+		current, err := storage.ReadConversation()
+		if err != nil {
+			return err
+		}
+		if len(current) > 0 {
+			if !*replace {
+				return fmt.Errorf("a conversation of %d messages is already stored; run with -replace to keep a copy of it and start from the import", len(current))
+			}
+			backup := "conversations/conversation-" + time.Now().Format("2006-01-02-150405") + ".json"
+			old, err := storage.ReadFile(storage.ConversationFile)
+			if err != nil {
+				return err
+			}
+			if err := storage.WriteDataFile(backup, old); err != nil {
+				return err
+			}
+			fmt.Printf("Kept the previous conversation as %s\n", backup)
+		}
+	}
+
+	if err := storage.WriteConversation(history); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d messages from %s\n", len(history), fs.Arg(0))
+	return nil
+}
+
+func reconcileCommand(args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	list := fs.Bool("list", false, "only list the missing words")
+	yes := fs.Bool("yes", false, "add every missing word without asking")
+	fs.Parse(args)
+
+	exists, err := storage.CheckFile(storage.ConversationFile)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no conversation has been saved yet")
+	}
+	history, err := storage.ReadConversation()
+	if err != nil {
+		return err
+	}
+	unsaved, err := tools.ReconcileConversation(history)
+	if err != nil {
+		return err
+	}
+	if len(unsaved) == 0 {
+		fmt.Println("Every word the conversation defined is in the lexicon")
+		return nil
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	added := 0
+	for _, u := range unsaved {
+		entry := u.Entry
+		fmt.Printf("%s\t%s\t%s\t(%s)\n", entry.Word, entry.PartOfSpeech, entry.Definition, u.Source)
+		if *list {
+			continue
+		}
+		if !*yes {
+			fmt.Printf("Add %s? [y/N] ", entry.Word)
+			answer, _ := input.ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				continue
+			}
+		}
+		result, err := tools.AddLexiconEntry(context.Background(), &entry)
+		if err != nil {
+			return err
+		}
+		if !result.Success {
+			fmt.Fprintf(os.Stderr, "Skipped %s: %s\n", entry.Word, result.Message)
+			continue
+		}
+		added++
+	}
+	if !*list {
+		fmt.Printf("Added %d of %d missing words\n", added, len(unsaved))
+	}
+	return nil
+}
+
+func scanCommand(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	list := fs.Bool("list", false, "only show the transcribed tables")
+	yes := fs.Bool("yes", false, "save every table without asking")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s", subcommands["scan"].usage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	images, err := tools.ScanImages(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Transcribing %d page(s)...\n", len(images))
+	reply, err := config.ReadImages(ctx, tools.ParadigmScanPrompt, images)
+	if err != nil {
+		return err
+	}
+	paradigms, err := tools.ParseParadigmScan(reply)
+	if err != nil {
+		return err
+	}
+	if len(paradigms) == 0 {
+		fmt.Println("No paradigm tables were found")
+		return nil
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	saved := 0
+	for _, p := range paradigms {
+		for {
+			fmt.Println(p.Table())
+			if p.Notes != "" {
+				fmt.Println("Notes: " + p.Notes)
+			}
+			if *list || *yes {
+				break
+			}
+			fmt.Printf("Save the paradigm of %s? [y/N/e to edit] ", p.Lexeme)
+			answer, _ := input.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer == "e" || answer == "edit" {
+				if p, err = editParadigm(p); err != nil {
+					fmt.Fprintf(os.Stderr, "Keeping the transcription as it was: %v\n", err)
+				}
+				continue
+			}
+			if answer != "y" && answer != "yes" {
+				p.Lexeme = "" // Skipped
+			}
+			break
+		}
+		if *list || p.Lexeme == "" {
+			continue
+		}
+		path, err := tools.SaveImportedParadigm(p)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s\n", path)
+		saved++
+	}
+	if !*list {
+		fmt.Printf("Saved %d of %d paradigms\n", saved, len(paradigms))
+	}
+	return nil
+}
+
+// editParadigm opens a transcribed paradigm as JSON in $EDITOR and reads
+// back the corrected version
+func editParadigm(p tools.ImportedParadigm) (tools.ImportedParadigm, error) {
+	file, err := os.CreateTemp("", "l2-paradigm-*.json")
+	if err != nil {
+		return p, err
+	}
+	defer os.Remove(file.Name())
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		file.Close()
+		return p, err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return p, err
+	}
+	file.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return p, err
+	}
+	if data, err = os.ReadFile(file.Name()); err != nil {
+		return p, err
+	}
+	edited, err := tools.ReadImportedParadigm(data)
+	if err != nil {
+		return p, err
+	}
+	return edited, nil
+}
+
+func ankiCommand(args []string) error {
+	fs := flag.NewFlagSet("anki", flag.ExitOnError)
+	deck := fs.String("deck", "", "name of the Anki deck to import into")
+	ipa := fs.Bool("ipa", false, "show IPA pronunciations on the front of cards")
+	fs.Parse(args)
+
+	entries, err := tools.LoadLexicon()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if fs.NArg() > 0 {
+		file, err := os.Create(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return tools.WriteAnkiDeck(out, entries, *deck, *ipa)
+}
+
+func simulateCommand(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	texts := fs.Int("texts", 50, "number of pseudo-texts to generate")
+	words := fs.Int("words", 20, "words per pseudo-text")
+	seed := fs.Int64("seed", 1, "random seed, to compare rule sets on the same texts")
+	fs.Parse(args)
+
+	report, err := tools.SimulateSoundChanges(context.Background(), fs.Arg(0), *texts, *words, *seed)
+	if err != nil {
+		return err
+	}
+	fmt.Print(tools.FormatSimulationReport(report))
+	return nil
+}
+
+func demoCommand(args []string) error {
+	fs := flag.NewFlagSet("demo", flag.ExitOnError)
+	keep := fs.Bool("keep", false, "keep the demo workspace after quitting instead of deleting it")
+	fs.Parse(args)
+
+	dir, err := os.MkdirTemp("", "l2-demo-")
+	if err != nil {
+		return err
+	}
+	if err := demo.Install(dir); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to install the demo language: %w", err)
+	}
+	// Everything the session writes stays in the temporary workspace
+	storage.SetRoot(dir)
+	storage.SetLanguage(storage.DefaultLanguage)
+
+	err = runChat()
+	if *keep {
+		fmt.Printf("The demo workspace is kept in %s\n", dir)
+	} else {
+		os.RemoveAll(dir)
+	}
+	return err
+}
+
+func lexiconCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s", subcommands["lexicon"].usage)
+	}
+
+	switch args[0] {
+	case "list":
+		entries, err := tools.LoadLexicon()
+		if len(args) > 1 {
+			entries, err = tools.QueryLexicon(args[1:])
+		}
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s\t%s\n", entry.Word, entry.PartOfSpeech, entry.Definition)
+		}
+		return nil
+
+	case "add":
+		fs := flag.NewFlagSet("lexicon add", flag.ExitOnError)
+		pos := fs.String("pos", "", "part of speech")
+		etymology := fs.String("etymology", "", "notes on the word's history")
+		ipa := fs.String("ipa", "", "pronunciation in IPA")
+		tags := fs.String("tags", "", "comma separated topic tags")
+		fs.Parse(args[1:])
+		if fs.NArg() < 2 {
+			return fmt.Errorf("usage: l2 lexicon add [-pos p] [-etymology e] [-ipa i] [-tags a,b] <word> <definition>")
+		}
+
+		entry := &tools.LexiconEntry{
+			Word:         fs.Arg(0),
+			Definition:   strings.Join(fs.Args()[1:], " "),
+			PartOfSpeech: *pos,
+			Etymology:    *etymology,
+			IPA:          *ipa,
+		}
+		if *tags != "" {
+			entry.Tags = strings.Split(*tags, ",")
+		}
+		result, err := tools.AddLexiconEntry(context.Background(), entry)
+		if err != nil {
+			return err
+		}
+		if !result.Success {
+			return fmt.Errorf("%s", result.Message)
+		}
+		fmt.Printf("Added %s\n", entry.Word)
+		return nil
+
+	case "rm":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: l2 lexicon rm <word>")
+		}
+		result, err := tools.DeleteLexiconEntry(context.Background(), &tools.DeleteLexiconRequest{Word: args[1]})
+		if err != nil {
+			return err
+		}
+		if !result.Success {
+			return fmt.Errorf("%s", result.Message)
+		}
+		fmt.Printf("Moved %s to the trash; /trash in the chat restores it\n", args[1])
+		return nil
+
+	case "export":
+		return exportCommand(args[1:])
+
+	case "import":
+		return importToolbox(args[1:])
+	}
+	return fmt.Errorf("usage: %s", subcommands["lexicon"].usage)
+}
+
+// importToolbox adds the entries of a SIL Toolbox (Shoebox) lexicon in MDF
+func importToolbox(args []string) error {
+	fs := flag.NewFlagSet("lexicon import", flag.ExitOnError)
+	list := fs.Bool("list", false, "only list the entries read from the file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: l2 lexicon import [-list] <file.db>")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	entries, warnings := tools.ParseToolbox(data)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Skipped %s\n", warning)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("found no \\lx records with a definition in %s", fs.Arg(0))
+	}
+	if *list {
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s\t%s\n", entry.Word, entry.PartOfSpeech, entry.Definition)
+		}
+		return nil
+	}
+
+	result, err := tools.ImportToolbox(entries)
+	if err != nil {
+		return err
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(os.Stderr, "Skipped %s\n", skipped)
+	}
+	fmt.Printf("Added %d of %d entries\n", len(result.Added), len(entries))
+	return nil
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"l2/tools"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// maxAgentSteps is how many rounds of tool calls one request may make
+// before the model is told to answer with what it has
+const maxAgentSteps = 8
+
+// terseMaxTokens caps each model call when terse answers are asked for
+const terseMaxTokens = 1024
+
+// stepLimitPrompt tells the model it has run out of tool rounds
+const stepLimitPrompt = "You have reached the limit of tool calls for this request. Answer now with what you have, without calling more tools."
+
+// errOutputClosed means the reader of a streamed response went away
+var errOutputClosed = errors.New("response stream closed by its reader")
+
+// agentLoop runs the chat model and its tools in a loop: tool results are
+// appended as tool messages and the model is called again until it answers
+// without calling tools. Without tools, for models that can't call them,
+// the first answer is the only one.
+type agentLoop struct {
+	model model.BaseChatModel
+	tools *compose.ToolsNode
+}
+
+// lambda wraps the loop as a chain node that can be invoked or streamed
+func (a *agentLoop) lambda() (*compose.Lambda, error) {
+	return compose.AnyLambda(a.invoke, a.stream, nil, nil)
+}
+
+// generationOptions returns the model options for the request's verbosity,
+// where terse answers are capped and kept close to the point, and for any
+// model or temperature chosen for the request alone
+func generationOptions(ctx context.Context) []model.Option {
+	var options []model.Option
+	if tools.VerbosityFrom(ctx) == tools.VerbosityTerse {
+		options = append(options, model.WithMaxTokens(terseMaxTokens), model.WithTemperature(0.4))
+	}
+	sampling := tools.SamplingFrom(ctx)
+	if sampling.Model != "" {
+		options = append(options, model.WithModel(sampling.Model))
+	}
+	if sampling.Temperature != nil {
+		options = append(options, model.WithTemperature(*sampling.Temperature))
+	}
+	return options
+}
+
+// requestModel names the model answering the request: one chosen for the
+// request alone, or the configured one
+func requestModel(ctx context.Context) string {
+	if name := tools.SamplingFrom(ctx).Model; name != "" {
+		return name
+	}
+	name, _ := tools.ActiveModel()
+	return name
+}
+
+// continueWith returns the conversation extended by a round of tool calls and
+// their results, and whether the model may call tools again
+func continueWith(messages []*schema.Message, reply *schema.Message, results []*schema.Message, step int) ([]*schema.Message, bool) {
+	messages = append(messages, reply)
+	messages = append(messages, results...)
+	if step >= maxAgentSteps {
+		return append(messages, schema.SystemMessage(stepLimitPrompt)), false
+	}
+	return messages, true
+}
+
+// invoke runs the loop and returns the model's final answer
+func (a *agentLoop) invoke(ctx context.Context, input []*schema.Message, _ ...any) ([]*schema.Message, error) {
+	messages := slices.Clone(input)
+	options := generationOptions(ctx)
+	name := requestModel(ctx)
+	for step := 1; ; step++ {
+		started := time.Now()
+		reply, err := a.model.Generate(ctx, messages, options...)
+		if err != nil {
+			return nil, err
+		}
+		tools.RecordUsage(ctx, name, messages, reply, time.Since(started))
+		if len(reply.ToolCalls) == 0 || a.tools == nil {
+			return []*schema.Message{reply}, nil
+		}
+		if step > maxAgentSteps {
+			// Tool calls made after the limit are dropped
+			reply.ToolCalls = nil
+			return []*schema.Message{reply}, nil
+		}
+		results, err := a.tools.Invoke(ctx, reply)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run tools: %w", err)
+		}
+		messages, _ = continueWith(messages, reply, results, step)
+	}
+}
+
+// stream runs the loop, passing on the model's chunks as they arrive and
+// every tool result as its own message, so the reader sees the tool calls,
+// their results and the final answer in order
+func (a *agentLoop) stream(ctx context.Context, input []*schema.Message, _ ...any) (*schema.StreamReader[[]*schema.Message], error) {
+	// The first call is made up front so connection errors reach the caller
+	options := generationOptions(ctx)
+	// Latency runs until the whole reply has arrived
+	started := time.Now()
+	response, err := a.model.Stream(ctx, input, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[[]*schema.Message](16)
+	go func() {
+		defer writer.Close()
+		messages := slices.Clone(input)
+		toolsAllowed := a.tools != nil
+		name := requestModel(ctx)
+		for step := 1; ; step++ {
+			// Tool calls made after the limit are dropped before they reach
+			// the reader, so the history has no calls without results
+			reply, err := forwardReply(response, writer, !toolsAllowed)
+			if err != nil {
+				writer.Send(nil, err)
+				return
+			}
+			tools.RecordUsage(ctx, name, messages, reply, time.Since(started))
+			if len(reply.ToolCalls) == 0 || !toolsAllowed {
+				return
+			}
+			results, err := a.tools.Invoke(ctx, reply)
+			if err != nil {
+				writer.Send(nil, fmt.Errorf("failed to run tools: %w", err))
+				return
+			}
+			for _, result := range results {
+				if writer.Send([]*schema.Message{result}, nil) {
+					return
+				}
+			}
+
+			messages, toolsAllowed = continueWith(messages, reply, results, step)
+			started = time.Now()
+			if response, err = a.model.Stream(ctx, messages, options...); err != nil {
+				writer.Send(nil, err)
+				return
+			}
+		}
+	}()
+	return reader, nil
+}
+
+// forwardReply copies a streamed reply to the output chunk by chunk and
+// returns the whole reply, without its tool calls if dropToolCalls is set
+func forwardReply(response *schema.StreamReader[*schema.Message], writer *schema.StreamWriter[[]*schema.Message], dropToolCalls bool) (*schema.Message, error) {
+	defer response.Close()
+	chunks := []*schema.Message{}
+	for {
+		chunk, err := response.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if dropToolCalls && len(chunk.ToolCalls) > 0 {
+			stripped := *chunk
+			stripped.ToolCalls = nil
+			chunk = &stripped
+		}
+		chunks = append(chunks, chunk)
+		if writer.Send([]*schema.Message{chunk}, nil) {
+			return nil, errOutputClosed
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, errors.New("the model returned an empty response")
+	}
+	return schema.ConcatMessages(chunks)
+}
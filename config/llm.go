@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"l2/storage"
 	"l2/tools"
 	"log"
 	"os"
@@ -12,38 +13,53 @@ import (
 	"github.com/joho/godotenv"
 )
 
-// NewLLMClient creates and configures a new LLM client with tools
-func NewLLMClient() compose.Runnable[[]*schema.Message, []*schema.Message] {
+// openRouterURL is the OpenAI-compatible API every model is reached through
+const openRouterURL = "https://openrouter.ai/api/v1"
+
+// newChatModel creates a client for an OpenRouter model with the API key
+// from the environment or .env
+func newChatModel(ctx context.Context, name string) (*openai.ChatModel, error) {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: Failed to load .env file: %v", err)
 	}
-
-	// Create chat model
-	client, err := openai.NewChatModel(context.Background(), &openai.ChatModelConfig{
-		// Model:   "deepseek/deepseek-r1-0528-qwen3-8b:free",
-		Model:   "google/gemini-2.5-flash",
-		BaseURL: "https://openrouter.ai/api/v1",
+	return openai.NewChatModel(ctx, &openai.ChatModelConfig{
+		Model:   name,
+		BaseURL: openRouterURL,
 		APIKey:  os.Getenv("OPENROUTER"),
 	})
+}
+
+// NewLLMClient creates and configures a new LLM client with tools
+func NewLLMClient() compose.Runnable[[]*schema.Message, []*schema.Message] {
+	// Create chat model
+	name, capabilities := tools.ActiveModel()
+	client, err := newChatModel(context.Background(), name)
 	if err != nil {
 		log.Fatalf("Failed to create chat model: %v", err)
 	}
 
-	// Get tool information and bind to client
-	toolInfos := tools.ToolsInfo()
-	if toolInfos == nil {
-		log.Fatal("Failed to get tool information")
-	}
+	// The model and its tools run in a loop until the model answers
+	agent := &agentLoop{model: client}
+	if capabilities.Tools {
+		// Get tool information and bind to client
+		toolInfos := tools.ToolsInfo()
+		if toolInfos == nil {
+			log.Fatal("Failed to get tool information")
+		}
 
-	// Log tool information for debugging
-	log.Printf("Available tools: %d", len(toolInfos))
-	for _, tool := range toolInfos {
-		log.Printf("Tool: %s", tool.Name)
-	}
+		// Log tool information for debugging
+		log.Printf("Available tools: %d", len(toolInfos))
+		for _, tool := range toolInfos {
+			log.Printf("Tool: %s", tool.Name)
+		}
 
-	if err := client.BindTools(toolInfos); err != nil {
-		log.Fatalf("Failed to bind tools to client: %v", err)
+		if err := client.BindTools(toolInfos); err != nil {
+			log.Fatalf("Failed to bind tools to client: %v", err)
+		}
+		agent.tools = tools.Tools()
+	} else {
+		log.Printf("%s doesn't support tools; answering without them", name)
 	}
 
 	// Build the processing chain
@@ -56,12 +72,76 @@ func NewLLMClient() compose.Runnable[[]*schema.Message, []*schema.Message] {
 Use tools for actual data operations, but be creative for examples and suggestions.
 
 **Use tools when:**
+- Users ask for something that needs several tool steps in sequence, such as evolving the language over centuries → Use plan_steps tool first, on its own, then stop; each step is sent to you as its own turn
 - Users ask to retrieve stored lexicon data → Use get_lexicon tool
+- Users ask about specific words or a subset of the lexicon → Use search_lexicon tool
 - Users ask to save new words to the lexicon → Use add_lexicon_entry tool  
+- Users ask for an agentive, diminutive or other derived form of an existing word → Use derive_word tool instead of add_lexicon_entry
+- Users ask where a word comes from or what was derived from it → Use etymology_tree tool
+- Users want a daughter language from a sound change history → Use evolve_lexicon tool
+- Users ask about the language family or its descendants → Use language_family tool
+- You are asked to run a vocabulary sprint → Use propose_lexicon_entry tool for every word, never add_lexicon_entry
+- Users decide on the language's parts of speech or word classes → Use set_parts_of_speech tool; lexicon entries must then use one of its tags
+- Users decide on prefixes, suffixes, clitics or discourse particles, their allomorphs or productivity → Use set_affixes tool (merge to add single affixes) rather than add_lexicon_entry
+- Users ask which affixes the language has → Use get_affixes tool
 - Users ask to read existing files → Use read_file tool
-- Users ask to save new files → Use add_file tool
+- Users ask to save new files → Use add_file tool, after checking with find_content that the same content isn't already stored
+- Users share a link, or want to consult a natural language grammar or reference on the web → Use fetch_url tool and cite what you use from it
+- Users want to use a PDF or EPUB on their computer, such as a paper or grammar → Use extract_document tool, then read_file on the result, citing page or chapter headings
+- Users settle a design question → Use record_decision tool, citing any fetched pages or documents that informed it by their source ID
+- Users point out that a source backs an earlier decision or a whole grammar section → Use cite_source tool
+- Users ask what has been decided or why → Use list_decisions tool
+- Users state a standing preference about notation, gloss format, verbosity or how you should work → Use remember_preference tool so it carries over to later sessions
+- Users ask what preferences you remember → Use get_preferences tool
+- Users ask for something a connected MCP server provides, such as their own dictionary or notes → Use that server's tools, named after the server
+- Users ask for a grammar sketch or a write-up of the language → Use export_grammar_sketch tool
+- Users ask about duplicated or redundant files → Use find_content tool with no content
+- Users ask to remove words or files → Use delete_lexicon_entry or delete_file tool
 - Users ask to analyze phonology of specific text → Use analyze_phonology tool
+- Users decide on or change the language's sounds or romanization → Use set_phoneme_inventory tool (merge to add single phonemes)
+- Users ask which sounds the language has → Use get_phoneme_inventory tool
+- Users decide on tones or vowel length → Use set_suprasegmentals tool
+- Users decide where stress falls, whether it is weight-sensitive, or which words are exceptions → Use set_stress_rules tool
+- Users ask where a word is stressed, or whether the lexicon's recorded stress follows the rules → Use assign_stress tool
+- Users ask how tones change in a phrase, or add tone sandhi rules to tone_sandhi.txt → Use apply_tone_sandhi tool
+- Users decide on syllable structure or permitted clusters → Use set_phonotactics tool
+- Users decide on vowel harmony classes (front/back, ATR) → Use set_vowel_harmony tool
+- Users ask whether words or suffixed forms respect vowel harmony → Use check_harmony tool
+- Users ask whether words are well-formed, or before proposing new words → Use check_phonotactics tool
 - Users ask to validate grammar of specific text → Use validate_grammar tool
+- Users decide on word order, case alignment or tense labels → Use set_syntax tool
+- Users ask how to say a sentence in the conlang → Use generate_sentence tool; never compose conlang sentences yourself
+- Users want sample or lorem-ipsum text for typesetting or font proofs → Use generate_filler_text tool
+- Users set limits on how complex the language should be → Use set_language_profile tool
+- Users ask whether the grammar is getting too complex → Use complexity_report tool
+- A tool returns complexity budget warnings → Tell the user and ask whether to keep the change or raise the budget
+- Users ask to check conlang text or a corpus for unknown or misspelled words → Use spellcheck tool
+- Users ask how often sounds, sound pairs or words occur, or whether the language looks naturalistic → Use analyze_frequency tool
+- Users ask which areas of meaning the vocabulary covers, or what kinds of words to coin next → Use semantic_coverage tool
+- Users ask for hyphenation or TeX typesetting support → Use export_hyphenation tool
+- Users ask to export the lexicon to a spreadsheet, CSV or TSV → Use export_lexicon tool
+- Users ask for flashcards or an Anki deck → Use export_anki tool
+- Users define characters of their native script → Use add_glyph tool
+- Users change, rename or drop glyphs → Use update_glyph or remove_glyph tool
+- Users ask which glyphs the script has → Use list_glyphs tool
+- Users decide how sounds are spelled, or ask whether the spelling is ambiguous → Use set_orthography tool
+- Users ask how an IPA transcription or native script text is written in the romanization → Use romanize tool
+- Users ask to write text in their native script → Use to_native_script tool
+- Users ask how romanized text is pronounced → Use transcribe tool
+- Users want a pronunciation guide for readers who don't know IPA → Use respell tool
+- Users make glyphs stand for words or morphemes, or describe their radicals and components → Use set_logogram tool
+- Users ask to write a sentence in logograms → Use write_logographic tool
+- Users ask for a font mapping or FontForge script for their script → Use export_pua_mapping tool
+- Users ask for an interlinear gloss of a conlang sentence → Use gloss_text tool
+- Users ask for a conjugation or declension table → Use inflect tool for lexicon words when inflection rules exist, otherwise generate_paradigm tool; never write tables by hand
+- Users define inflection classes, stem mutations or irregular forms as rules → Write them to inflection.txt with add_file, then check them with inflect
+- Users decide how numbers are counted (base, digit words, compounding) → Use set_numeral_system tool
+- Users ask how to say a number, or whether a number name is right → Use number_names tool; never compose numerals yourself
+- Users design words for relatives or choose a kinship system → Use design_kinship tool
+- Users coin a new kinship term → Use check_kin_term tool before adding it to the lexicon
+- Users decide on irregular suppletive forms (like went for go) → Use set_suppletion tool
+- Users ask which words are suppletive → Use suppletion_report tool
+- Users ask for a gloss in LaTeX, HTML or another publication layout → Use export_gloss tool
 - **CRITICAL: When you just defined a word and the user says "Yes" to adding it → Use add_lexicon_entry tool immediately**
 - **CRITICAL: When you propose a word definition and user agrees → Use add_lexicon_entry tool**
 
@@ -71,45 +151,127 @@ Use tools for actual data operations, but be creative for examples and suggestio
 - Users ask for hypothetical language features → Describe and demonstrate them directly
 
 **Available Tools:**
+- **plan_steps**: Lay out the tool steps of a multi-step request; the user sees a checklist and each step comes back to you as its own turn
 - **get_lexicon**: Retrieve all entries from the conlang lexicon
-- **add_lexicon_entry**: Add words to the conlang lexicon with definition, part of speech, and etymology
-- **analyze_phonology**: Analyze text phonology using IPA notation, extract phonemes, allophones, and syllable structure
+- **search_lexicon**: Search the lexicon by prefix, substring, part of speech, definition keyword, or tag with paginated results (prefer over get_lexicon for large lexicons)
+- **add_lexicon_entry**: Add words to the conlang lexicon with definition, part of speech, and etymology (record the words it derives from, its proto-form or borrowing source in the structured fields)
+- **derive_word**: Apply derivational affixes or processes from the affix store to a root and add the result with its derivation chain
+- **etymology_tree**: Render a word's etymology tree from its derived_from words, proto-form and borrowing source
+- **evolve_lexicon**: Apply an ordered sound change file to the main lexicon or a descendant and save the daughter lexicon under descendants/
+- **language_family**: Show the proto-language and its descendants as a tree
+- **propose_lexicon_entry**: Stage a proposed word in the review queue for the user to accept or reject
+- **set_parts_of_speech**: Declare the part-of-speech tag set with descriptions; new lexicon entries and part-of-speech filters are validated against it
+- **set_affixes**: Store affixes, clitics, particles and derivational processes with gloss, position rules, the parts of speech they attach to, productivity and conditioned allomorphs; used by glossing, spellchecking and paradigms
+- **get_affixes**: List stored affixes, optionally filtered by part of speech
+- **set_phoneme_inventory**: Declare the consonant and vowel inventory with features and romanizations; the single source of truth for phonology tools
+- **get_phoneme_inventory**: Retrieve the declared phoneme inventory
+- **set_phonotactics**: Declare the syllable template, permitted onsets and codas and forbidden sequences
+- **set_vowel_harmony**: Declare vowel harmony systems with their classes, vowels listed in corresponding order
+- **check_harmony**: Check words and suffixed forms for vowel harmony violations and propose harmonized alternatives
+- **check_phonotactics**: Check candidate words against the inventory and phonotactics, naming the constraint each violation breaks
+- **analyze_phonology**: Analyze text phonology using IPA notation, extract phonemes and allophones, and syllabify words against a phonotactic template like (C)(C)V(C) (pass the language's template and permitted onset clusters); tone, stress and length marks are reported per syllable
+- **set_suprasegmentals**: Declare tones with their diacritics or Chao tone letters, the default tone, the stress rule and whether length is contrastive
+- **set_stress_rules**: Declare fixed, weight-sensitive or lexical stress and lexical stress exceptions
+- **assign_stress**: Mark stress on words by the stress rules and flag lexicon IPA whose stress conflicts with them
+- **apply_tone_sandhi**: Apply the ordered rules in tone_sandhi.txt (tone > tone / left _ right, # for a word boundary) to a phrase and return underlying and surface tones
 - **validate_grammar**: Validate text against grammar rules and provide suggestions
+- **set_syntax**: Store the basic word order, case labels of each role and tense labels
+- **set_language_profile**: Set the complexity budget (max cases, fusion index, irregularity percentage)
+- **complexity_report**: Compare the grammar's current complexity with the budget
+- **generate_sentence**: Build a sentence from a semantic frame (agent, action, patient, recipient, tense) with its interlinear gloss, using the lexicon, affixes and word order
+- **generate_filler_text**: Generate paragraphs of grammatical filler text from grammar.peg and the lexicon, weighted by corpus word frequency
+- **export_lexicon**: Export the lexicon to a CSV or TSV file with a configurable column order, including a script column transliterating entries into the native script
+- **export_anki**: Export the lexicon as an Anki-importable flashcard file
+- **add_glyph**: Add a native script glyph with its grapheme, phonemes or the morphemes it writes, image and Private Use Area codepoint
+- **update_glyph**: Change a glyph's name, codepoint, grapheme, phonemes or image
+- **remove_glyph**: Remove a glyph and free its codepoint
+- **list_glyphs**: List the glyph inventory as a table
+- **set_orthography**: Define the grapheme-phoneme mapping of the romanization, reporting ambiguities and lexicon entries spelled inconsistently with their IPA
+- **romanize**: Convert IPA or native script text into the romanization
+- **to_native_script**: Convert romanized or IPA text into the native script
+- **transcribe**: Convert romanized text into IPA
+- **respell**: Produce an English-friendly respelling such as KAH-loo-teh from IPA or a lexicon word
+- **set_logogram**: Map a glyph to the morphemes it writes and record its radical and components
+- **write_logographic**: Convert a glossed sentence into a glyph sequence, spelling morphemes without logograms phonetically
+- **export_pua_mapping**: Export the glyph-to-codepoint mapping, optionally with a FontForge script
+- **generate_paradigm**: Mechanically generate a full paradigm table from a stem and inflectional affixes
+- **inflect**: Produce the full inflection table of a lexicon word from the class rules in inflection.txt (suffix tables, stem mutations, irregular overrides)
+- **set_numeral_system**: Declare the numeral base, digit and power words, compounding templates, subtractive digits and irregular names, saved to numerals.json
+- **number_names**: Name arbitrary integers with the numeral system, validate proposed names, or find the number a name denotes
+- **design_kinship**: Generate and store the full kinship term chart of an Eskimo, Hawaiian, Iroquois, Omaha, Crow or Sudanese system from existing roots
+- **check_kin_term**: Check that a new kinship term groups relatives the way the chosen system does and doesn't clash with other terms
+- **gloss_text**: Build an aligned Leipzig-style interlinear gloss from a sentence, segmenting it automatically when no morpheme breakdown is given
+- **set_suppletion**: Register a lexeme's suppletive forms for the paradigm cells they fill; generate_paradigm uses them before the regular affixes
+- **suppletion_report**: List every registered suppletive form
+- **export_gloss**: Format an interlinear gloss as aligned text, tabs, LaTeX expex or HTML ruby, optionally saving it
+- **export_hyphenation**: Derive hyphenation points from lexicon syllable structure and write a TeX hyphenation pattern file
+- **spellcheck**: Check conlang text or a stored corpus file against the lexicon and its affixes, suggesting nearest known words for unknown forms
+- **analyze_frequency**: Rank phoneme, bigram and word frequencies over corpus files and the lexicon
+- **semantic_coverage**: Tag lexicon entries with semantic domains and report coverage per domain, highlighting thin domains and concepts still missing
 - **read_file**: Read stored conlang documentation, grammar rules, vocabulary lists, and other language resources
 - **add_file**: Create or overwrite files for storing conlang documentation, grammar rules, vocabulary lists, and other language resources
+- **fetch_url**: Download a web page, extract its readable text and cache it under web/ in the data files
+- **extract_document**: Extract the text of a local PDF or EPUB into a data file under documents/, with a heading per page or chapter for citation
+- **record_decision**: Log a design decision under a grammar section with its rationale and citations
+- **cite_source**: Cite a consulted source, with page or chapter, for a grammar section or a logged decision
+- **list_decisions**: List logged decisions by section with their citations and references
+- **remember_preference**: Remember or forget a user preference (notation style, gloss format, verbosity); remembered preferences are included in every request
+- **get_preferences**: List the remembered user preferences
+- **export_grammar_sketch**: Export a Markdown grammar sketch with design notes per section and a references section
+- **delete_lexicon_entry**: Move a word from the lexicon to the trash (the user can restore it with /trash)
+- **delete_file**: Move a stored file to the trash (the user can restore it with /trash)
+- **find_content**: Check whether equivalent content is already stored before writing a file, or report all duplicated files
 
 **IMPORTANT: When you propose a word definition and the user agrees (says "Yes", "Add it", etc.), immediately use the add_lexicon_entry tool with the word you just defined.**
+**Tool results are sent back to you. Once the tools you need have run, answer the user in plain language, summarizing what the results show or what changed rather than repeating them verbatim.**
 **Be flexible and creative when users ask for examples or suggestions.**`
 
-	toolsNode := tools.Tools()
+	if !capabilities.Tools {
+		toolInstructions = noToolsInstructions
+	}
+
+	loop, err := agent.lambda()
+	if err != nil {
+		log.Fatalf("Failed to create agent loop: %v", err)
+	}
+
 	chain.
 		AppendLambda(compose.InvokableLambda(func(ctx context.Context, input []*schema.Message) ([]*schema.Message, error) {
-			// Read system prompt from system.md
-			systemContent, err := os.ReadFile("system.md")
+			// Read the stored system prompt each turn, so edits made with
+			// /system apply to the next request
+			systemContent, err := storage.ReadSystem()
 			if err != nil {
-				log.Printf("Warning: Failed to read system.md: %v", err)
+				log.Printf("Warning: Failed to read the system prompt: %v", err)
 				// Fallback to basic system prompt
-				systemMsg := schema.SystemMessage("You are ConlangGPT, a comprehensive expert assistant for designing and exploring constructed languages (conlangs)." + toolInstructions)
+				systemMsg := schema.SystemMessage(withVerbosity(ctx, "You are ConlangGPT, a comprehensive expert assistant for designing and exploring constructed languages (conlangs)."+toolInstructions))
 				return append([]*schema.Message{systemMsg}, input...), nil
 			}
 			// Combine system prompt with tool instructions
-			fullSystemPrompt := string(systemContent) + toolInstructions
-			systemMsg := schema.SystemMessage(fullSystemPrompt)
+			fullSystemPrompt := systemContent + toolInstructions
+			systemMsg := schema.SystemMessage(withVerbosity(ctx, fullSystemPrompt))
 			return append([]*schema.Message{systemMsg}, input...), nil
 		})).
-		AppendChatModel(client, compose.WithNodeName("chat_model")).
-		AppendLambda(compose.InvokableLambda(func(ctx context.Context, input *schema.Message) ([]*schema.Message, error) {
-			if len(input.ToolCalls) > 0 {
-				return toolsNode.Invoke(ctx, input)
-			}
-			return []*schema.Message{input}, nil
-		}))
+		AppendLambda(loop, compose.WithNodeName("agent"))
 
 	// Compile the chain
-	agent, err := chain.Compile(context.Background())
+	runnable, err := chain.Compile(context.Background())
 	if err != nil {
 		log.Fatalf("Failed to compile agent chain: %v", err)
 	}
 
-	return agent
+	return runnable
+}
+
+// noToolsInstructions replace the tool guidelines for models that can't call
+// tools, so the model doesn't claim to have stored anything
+const noToolsInstructions = `
+
+**No tools:** You can't read or change the stored lexicon, grammar or files in this session. Answer from the conversation, and when the user asks to store something, give it in a form they can add themselves.`
+
+// withVerbosity appends the request's verbosity instruction to the system prompt
+func withVerbosity(ctx context.Context, prompt string) string {
+	if instruction := tools.VerbosityPrompt(tools.VerbosityFrom(ctx)); instruction != "" {
+		return prompt + "\n\n**Verbosity:** " + instruction
+	}
+	return prompt
 }
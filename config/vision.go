@@ -0,0 +1,37 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"l2/tools"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ReadImages asks the configured model, without tools, to answer a prompt
+// about images, such as transcribing a photographed table
+func ReadImages(ctx context.Context, prompt string, images []schema.ChatMessagePart) (string, error) {
+	name, capabilities := tools.ActiveModel()
+	if !capabilities.Vision {
+		return "", fmt.Errorf("%s can't read images; set model in config.json to one that can", name)
+	}
+	client, err := newChatModel(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	request := &schema.Message{
+		Role:         schema.User,
+		MultiContent: append([]schema.ChatMessagePart{{Type: schema.ChatMessagePartTypeText, Text: prompt}}, images...),
+	}
+	// Transcription should copy, not invent
+	started := time.Now()
+	reply, err := client.Generate(ctx, []*schema.Message{request}, model.WithTemperature(0))
+	if err != nil {
+		return "", err
+	}
+	tools.RecordUsage(ctx, name, []*schema.Message{request}, reply, time.Since(started))
+	return reply.Content, nil
+}
@@ -0,0 +1,40 @@
+// Package demo bundles Sema, a small example language, so every feature can
+// be tried without touching the user's own data
+package demo
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// workspace holds the demo language laid out like a storage directory:
+// inventory, phonotactics, grammar and decision log at the top, the
+// lexicon and sound changes under data/
+//
+//go:embed workspace
+var workspace embed.FS
+
+// Install writes the demo language into dir, which becomes a complete
+// storage directory
+func Install(dir string) error {
+	root, err := fs.Sub(workspace, "workspace")
+	if err != nil {
+		return err
+	}
+	return fs.WalkDir(root, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := fs.ReadFile(root, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
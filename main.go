@@ -1,10 +1,16 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 
 	"l2/config"
+	"l2/storage"
+	"l2/tools"
 	"l2/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,19 +20,128 @@ import (
 func exitStats(m *ui.Model) string {
 	style := lipgloss.NewStyle().Border(lipgloss.ThickBorder()).Padding(1)
 	header := lipgloss.NewStyle().Bold(true).Render("Session stats:")
-	stats := m.GetStats()
-	return style.Render(fmt.Sprintf("%s\nTotal tokens used: %d\n", header, stats.TotalTokens))
+	summary, err := m.SessionSummary()
+	if err != nil {
+		log.Printf("Failed to summarize the session: %v", err)
+		return style.Render(header + "\n" + totalUsage(m.GetStats()))
+	}
+
+	body := tools.FormatSessionSummary(summary) + totalUsage(m.GetStats())
+	if settings, err := storage.ReadConfig(); err == nil && settings.LogSessions {
+		if err := storage.AppendSession(summary); err != nil {
+			log.Printf("Failed to write the session log: %v", err)
+		} else {
+			body += "Saved to the session log\n"
+		}
+	}
+	return style.Render(header + "\n" + body)
+}
+
+// totalUsage reports the tokens and cost of every session so far
+func totalUsage(stats storage.Stats) string {
+	usage := fmt.Sprintf("Total tokens used: %d", stats.TotalTokens)
+	if stats.Cost > 0 {
+		usage += fmt.Sprintf(" (about $%.2f)", stats.Cost)
+	}
+	return usage + "\n"
 }
 
 func main() {
+	os.Exit(run())
+}
+
+// run runs l2 and returns its exit status. It returns instead of exiting, so
+// the deferred unlock, webhook flush and MCP shutdown run on every path.
+func run() int {
+	prompt := flag.String("p", "", "answer a single prompt without the chat interface, printing the answer to stdout (- reads it from stdin)")
+	readOnly := flag.Bool("read-only", false, "open the data without saving anything, alongside another running instance")
+	lang := flag.String("lang", "", "work on this language's lexicon, grammar, data files and conversation instead of the last one chosen with /lang")
+	dataDir := flag.String("data-dir", "", "keep the language's data in this directory, such as a project directory, instead of the default one")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *dataDir != "" {
+		dir, err := filepath.Abs(*dataDir)
+		if err != nil {
+			return fatal(err)
+		}
+		storage.SetDataDir(dir)
+	}
+	settings, err := storage.ReadConfig()
+	if err != nil {
+		log.Printf("Failed to read config: %v", err)
+	}
+	if *lang == "" {
+		*lang = settings.Language
+	}
+	if err := storage.SetLanguage(*lang); err != nil {
+		return fatal(err)
+	}
+	storage.RestoreBranch(settings.Branches)
+
+	// The demo works in its own temporary directory: it needs no lock, and its
+	// changes notify no webhooks
+	if *readOnly {
+		storage.SetReadOnly()
+	} else if flag.Arg(0) != "demo" {
+		if err := storage.Lock(); err != nil {
+			var locked *storage.LockedError
+			if errors.As(err, &locked) {
+				log.Printf("%v\nQuit the other instance first, or run with -read-only to look without saving.", err)
+				return 1
+			}
+			return fatal(err)
+		}
+		defer storage.Unlock()
+		tools.StartWebhooks(settings.Webhooks)
+		defer tools.FlushWebhooks()
+	}
+	defer tools.CloseMCPServers()
+
+	if *prompt != "" {
+		if err := runPrompt(*prompt); err != nil {
+			return fatal(err)
+		}
+		return 0
+	}
+
+	if flag.NArg() > 0 {
+		if err := runSubcommand(flag.Args()); err != nil {
+			return fatal(err)
+		}
+		return 0
+	}
+
+	if err := runChat(); err != nil {
+		return fatal(err)
+	}
+	return 0
+}
+
+// fatal logs an error and the hint at its remedy, when it has one, and
+// returns the exit status for it
+func fatal(err error) int {
+	if hint := tools.Remediation(err); hint != "" {
+		log.Printf("%v\n%s", err, hint)
+	} else {
+		log.Print(err)
+	}
+	return 1
+}
+
+// runChat runs the chat interface until the user quits
+func runChat() error {
 	client := config.NewLLMClient()
 
 	m := ui.NewModel()
 	m.SetLLM(client)
 
 	p := tea.NewProgram(m)
-	if _, err := p.Run(); err != nil {
-		log.Fatal(err)
+	_, err := p.Run()
+	tools.CloseMCPServers()
+	if err != nil {
+		return err
 	}
 	fmt.Print(exitStats(m) + "\n\n")
+	return nil
 }
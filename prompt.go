@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"l2/config"
+	"l2/storage"
+	"l2/tools"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// runPrompt answers a single prompt without the chat interface: the answer
+// streams to stdout while tool calls, their results and the stats line go to
+// stderr, so the answer can be piped on its own. A prompt of "-" is read
+// from stdin.
+func runPrompt(prompt string) error {
+	if prompt == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		prompt = string(data)
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return fmt.Errorf("the prompt is empty")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	defer tools.CloseMCPServers()
+
+	// Tools that wait for approval in the chat can't be approved here
+	settings, err := storage.ReadConfig()
+	if err != nil {
+		return err
+	}
+	if confirm := settings.ConfirmTools; len(confirm) > 0 {
+		ctx = tools.WithApproval(ctx, func(ctx context.Context, name, arguments string) bool {
+			if slices.Contains(confirm, "all") || slices.Contains(confirm, name) {
+				fmt.Fprintf(os.Stderr, "[Declined: %s needs confirmation; run it in the chat]\n", name)
+				return false
+			}
+			return true
+		})
+	}
+
+	ctx = tools.WithVerbosity(ctx, settings.Verbosity)
+
+	messages := []*schema.Message{}
+	if preferences := tools.PreferencesPrompt(); preferences != "" {
+		messages = append(messages, schema.SystemMessage(preferences))
+	}
+	messages = append(messages, schema.UserMessage(prompt))
+
+	// The stats are read before the first model call records its usage
+	before, _ := storage.ReadStats()
+	ctx, usage := tools.WithTurnUsage(ctx)
+	response, err := config.NewLLMClient().Stream(ctx, messages)
+	if err != nil {
+		return err
+	}
+	defer response.Close()
+
+	for {
+		msg, err := response.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(msg) == 0 {
+			continue
+		}
+		message := msg[0]
+		for _, toolCall := range message.ToolCalls {
+			if toolCall.Function.Name != "" {
+				fmt.Fprintf(os.Stderr, "[Tool Call: %s]\n", toolCall.Function.Name)
+			}
+		}
+		if message.Role == schema.Tool {
+			var result tools.Result
+			json.Unmarshal([]byte(message.Content), &result)
+			mark := "✅"
+			if !result.Success {
+				mark = "❌"
+			}
+			fmt.Fprintf(os.Stderr, "%s %s\n", mark, result.Message)
+			continue
+		}
+		fmt.Print(message.Content)
+	}
+	fmt.Println()
+
+	// The agent records each model call's usage as it goes
+	stats, _ := storage.ReadStats()
+	promptTokens, completionTokens, _ := usage.Total()
+	fmt.Fprintf(os.Stderr, "Tokens used: %d (about $%.4f); %d in total (about $%.2f)\n",
+		promptTokens+completionTokens, stats.Cost-before.Cost, stats.TotalTokens, stats.Cost)
+	return nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Productivity levels of an affix
+const (
+	Productive     = "productive"      // Freely used to form new words
+	SemiProductive = "semi-productive" // Used with some stems or in some registers only
+	Unproductive   = "unproductive"    // Found in existing words but no longer used for new ones
+)
+
+// Kinds of morphemes in the affix store
+const (
+	AffixKind    = "affix"    // Bound to a stem: -ni or ta-
+	CliticKind   = "clitic"   // Bound to a whole word: =la or la=
+	ParticleKind = "particle" // Written as a word of its own
+	ProcessKind  = "process"  // A derivational process such as reduplication, named by its form
+)
+
+// Positions in the sentence a clitic or particle attaches to
+const (
+	AnyPosition   = "any"
+	FirstPosition = "first" // Second-position clitics on the first word, sentence-initial particles
+	LastPosition  = "last"  // Clitics on the last word, sentence-final particles
+)
+
+// Allomorph is an alternative form of an affix used next to certain stem segments
+type Allomorph struct {
+	Form     string   `json:"form" jsonschema:"required,description=The allomorph written like the affix such as -ar or ta-"`
+	Adjacent []string `json:"adjacent" jsonschema:"required,description=Stem segments that select this allomorph: the last segment of the stem for suffixes and the first for prefixes. V and C stand for any vowel or consonant"`
+}
+
+// Affix is a grammatical morpheme of the language: an affix, a clitic, a
+// particle or a derivational process. They are kept apart from the lexicon
+// so the morphology tools can tell them from words.
+type Affix struct {
+	Form         string      `json:"form" jsonschema:"required,description=The morpheme marked on the side it attaches: -ni for a suffix, ta- for a prefix, =la for an enclitic, la= for a proclitic and a bare form for a particle. Processes are named reduplication, initial-reduplication or conversion"`
+	Kind         string      `json:"kind,omitempty" jsonschema:"description=affix, clitic, particle or process (defaults to affix)"`
+	Position     string      `json:"position,omitempty" jsonschema:"description=For clitics and particles: any, first (second-position clitics and sentence-initial particles) or last (defaults to any)"`
+	Gloss        string      `json:"gloss" jsonschema:"required,description=Leipzig gloss label such as PL or PST"`
+	AttachesTo   []string    `json:"attaches_to,omitempty" jsonschema:"description=Parts of speech the affix attaches to such as noun or verb"`
+	Productivity string      `json:"productivity,omitempty" jsonschema:"description=productive, semi-productive or unproductive: whether the affix is still used to form new words (defaults to productive)"`
+	Allomorphs   []Allomorph `json:"allomorphs,omitempty" jsonschema:"description=Conditioned alternative forms of the affix"`
+	Derivational bool        `json:"derivational,omitempty" jsonschema:"description=Whether the affix forms new words (agentive, diminutive, nominalizer) rather than inflecting them; processes always are"`
+	Yields       string      `json:"yields,omitempty" jsonschema:"description=Part of speech of words derived with it, such as noun for a nominalizer"`
+}
+
+func ReadAffixes() ([]Affix, error) {
+	affixes := []Affix{}
+	exists, err := CheckFile(AffixFile)
+	if err != nil || !exists {
+		return affixes, err
+	}
+	data, err := ReadFile(AffixFile)
+	if err != nil {
+		return affixes, err
+	}
+	if err := decode(AffixFile, data, &affixes); err != nil {
+		return affixes, err
+	}
+	return affixes, nil
+}
+
+func WriteAffixes(affixes []Affix) error {
+	path, err := GetPath(AffixFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(affixes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(AffixFile, data)
+}
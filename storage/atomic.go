@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// backupSuffix names the copy of a file's previous version kept by each write
+const backupSuffix = ".bak"
+
+// tempPrefix starts the names of files being written, before they replace
+// the real ones
+const tempPrefix = ".tmp-"
+
+// writeAtomic replaces a file without ever leaving it half written: the data
+// goes to a temporary file in the same directory, is synced to disk and then
+// renamed over the original. The previous version is kept as a .bak copy.
+func writeAtomic(path string, data []byte) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, tempPrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Only left behind if the rename didn't happen
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	defer writes.lock(path)()
+	if err := keepBackup(path); err != nil {
+		log.Printf("Failed to back up %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	forget(path)
+	syncDir(dir)
+	return nil
+}
+
+// keepBackup saves the current version of a file as its .bak copy, unless
+// the current version is itself damaged
+func keepBackup(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if isJSON(path) && !json.Valid(data) {
+		return nil
+	}
+	backup, err := os.CreateTemp(filepath.Dir(path), tempPrefix+filepath.Base(path)+backupSuffix+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(backup.Name())
+	if _, err := backup.Write(data); err != nil {
+		backup.Close()
+		return err
+	}
+	if err := backup.Sync(); err != nil {
+		backup.Close()
+		return err
+	}
+	if err := backup.Close(); err != nil {
+		return err
+	}
+	return os.Rename(backup.Name(), path+backupSuffix)
+}
+
+// syncDir flushes a directory so a rename in it survives a crash. Not every
+// platform can sync directories, so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// readRecovering reads a file, falling back to its .bak copy when a JSON
+// file doesn't parse, as after a crash in the middle of an older write. A
+// good backup is put back in place of the damaged file.
+func readRecovering(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !isJSON(path) || json.Valid(data) {
+		return data, err
+	}
+	backup, berr := os.ReadFile(path + backupSuffix)
+	if berr != nil || !json.Valid(backup) {
+		return data, nil // Nothing better; the caller reports the parse error
+	}
+	log.Printf("%s is damaged; restored it from %s%s", path, filepath.Base(path), backupSuffix)
+	if err := os.WriteFile(path+".damaged", data, 0644); err != nil {
+		log.Printf("Failed to keep the damaged copy of %s: %v", path, err)
+	}
+	if err := writeAtomic(path, backup); err != nil {
+		log.Printf("Failed to restore %s: %v", path, err)
+	}
+	return backup, nil
+}
+
+// isJSON reports whether a stored file holds JSON that can be checked
+func isJSON(path string) bool {
+	return strings.HasSuffix(path, ".json")
+}
+
+// isStorageArtifact reports whether a file in the data directory is a
+// backup, damaged copy or unfinished write rather than the user's data
+func isStorageArtifact(name string) bool {
+	base := filepath.Base(name)
+	return strings.HasPrefix(base, tempPrefix) || strings.HasSuffix(base, backupSuffix) || strings.HasSuffix(base, ".damaged")
+}
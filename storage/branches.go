@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MainBranch names a language's original conversation
+const MainBranch = "main"
+
+// branch is the active conversation branch's name, empty for the main one
+var branch string
+
+// SetBranch makes a conversation branch of the active language the one read
+// and written. The main branch, or an empty name, is the conversation kept
+// in conversation.json.
+func SetBranch(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || name == MainBranch {
+		branch = ""
+		return nil
+	}
+	if !validName(name) || name == strings.TrimSuffix(filepath.Base(conversationFilePath), ".json") {
+		return fmt.Errorf("branch names may only hold letters, digits, - and _: %q", name)
+	}
+	branch = name
+	return nil
+}
+
+// Branch returns the name of the active conversation branch
+func Branch() string {
+	if branch == "" {
+		return MainBranch
+	}
+	return branch
+}
+
+// branchPath places the active branch's conversation next to the main one
+func branchPath() string {
+	if branch == "" {
+		return conversationFilePath
+	}
+	return filepath.Join(filepath.Dir(conversationFilePath), branch+".json")
+}
+
+// ListBranches returns the active language's main branch and every branch
+// forked from it, sorted by name
+func ListBranches() ([]string, error) {
+	path, err := GetPath(ConversationFile)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	main := filepath.Base(conversationFilePath)
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == main || !strings.HasSuffix(name, ".json") || isStorageArtifact(name) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(name, ".json"))
+	}
+	sort.Strings(names)
+	return append([]string{MainBranch}, names...), nil
+}
+
+// RestoreBranch makes the branch last chosen for the active language the
+// active one, falling back to the main branch if it no longer exists
+func RestoreBranch(branches map[string]string) {
+	if SetBranch(branches[Language()]) != nil {
+		branch = ""
+		return
+	}
+	if exists, err := CheckFile(ConversationFile); err != nil || !exists {
+		branch = ""
+	}
+}
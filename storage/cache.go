@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// cachedFile is a stored file's contents as last read, with the size and
+// modification time that show whether it has changed on disk since
+type cachedFile struct {
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+// cache holds stored files by path so tools called many times in one turn
+// don't read lexicon.json and the grammar files from disk on every call
+var cache = struct {
+	sync.Mutex
+	files map[string]cachedFile
+}{files: map[string]cachedFile{}}
+
+// readCached returns a stored file from the cache while it is unchanged on
+// disk, reading it again when it isn't. A stat is all a cache hit costs, so
+// edits made outside l2 are still picked up.
+func readCached(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		forget(path)
+		return readRecovering(path)
+	}
+
+	cache.Lock()
+	entry, ok := cache.files[path]
+	cache.Unlock()
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return clone(entry.data), nil
+	}
+
+	data, err := readRecovering(path)
+	if err != nil {
+		return nil, err
+	}
+	// A write renamed over the file during the read, or recovery replacing
+	// it, would leave old contents cached under the new file's details
+	if after, err := os.Stat(path); err == nil && os.SameFile(info, after) &&
+		after.Size() == info.Size() && after.ModTime().Equal(info.ModTime()) {
+		cache.Lock()
+		cache.files[path] = cachedFile{data: clone(data), size: info.Size(), modTime: info.ModTime()}
+		cache.Unlock()
+	}
+	return data, nil
+}
+
+// forget drops a file from the cache after it is written or removed
+func forget(path string) {
+	cache.Lock()
+	delete(cache.files, path)
+	cache.Unlock()
+}
+
+// clone copies cached contents so callers can't change them in place
+func clone(data []byte) []byte {
+	return append([]byte(nil), data...)
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+)
+
+// Change is a write that changed a stored file or a data file
+type Change struct {
+	File     int    // Stored file such as AffixFile, or DataFile for a data file
+	Name     string // Name of the data file, or of the stored file
+	Language string // Language the file belongs to
+	Previous []byte // Content before the write; nil for a new file
+	Current  []byte // Content after the write; nil for a removed file
+}
+
+var (
+	// changeHook is told about changes to the files watch picks, when set
+	changeHook func(Change)
+	watch      func(file int, name string) bool
+)
+
+// OnChange calls hook after each write or removal that changes one of the
+// stored files or data files watched picks, such as to notify others of
+// edits to the lexicon. The hook runs while the writer waits, so it should
+// hand slow work off.
+func OnChange(watched func(file int, name string) bool, hook func(Change)) {
+	watch, changeHook = watched, hook
+}
+
+// previousContent reads what a watched file holds before it is written, for
+// the change hook
+func previousContent(file int, name, path string) []byte {
+	if changeHook == nil || !watch(file, name) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// changed tells the hook about a write, unless the content stayed the same
+func changed(file int, name string, previous, current []byte) {
+	if changeHook == nil || !watch(file, name) || (previous != nil && current != nil && bytes.Equal(previous, current)) {
+		return
+	}
+	changeHook(Change{File: file, Name: name, Language: Language(), Previous: previous, Current: current})
+}
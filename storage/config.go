@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// LexiconQuery is a named lexicon filter. Empty fields match everything.
+type LexiconQuery struct {
+	Prefix           string `json:"prefix,omitempty"`
+	Contains         string `json:"contains,omitempty"`
+	PartOfSpeech     string `json:"part_of_speech,omitempty"`
+	Keyword          string `json:"keyword,omitempty"`
+	Tag              string `json:"tag,omitempty"`
+	MissingEtymology bool   `json:"missing_etymology,omitempty"`
+}
+
+// DefaultSummarizeAboveTokens is the conversation size in tokens above which
+// the chat history is summarized instead of sent verbatim
+const DefaultSummarizeAboveTokens = 6000
+
+// DefaultAutosaveSeconds is how often the conversation is saved while l2 runs
+const DefaultAutosaveSeconds = 30
+
+// SpeechConfig selects the speech-to-text backend used by /record. Empty
+// fields fall back to the backend's defaults.
+type SpeechConfig struct {
+	Backend  string `json:"backend,omitempty"`  // whisper-api or whisper-cpp
+	Model    string `json:"model,omitempty"`    // API model name, or the whisper.cpp model file
+	Endpoint string `json:"endpoint,omitempty"` // API URL, or the whisper.cpp binary
+	Language string `json:"language,omitempty"` // Spoken language code such as en; detected when empty
+	Recorder string `json:"recorder,omitempty"` // Command recording WAV audio to the path appended to it
+}
+
+// MCPServer is an external Model Context Protocol server whose tools are
+// offered to the model alongside the built-in ones
+type MCPServer struct {
+	Name    string            `json:"name"`           // Prefix of the server's tool names
+	Command string            `json:"command"`        // Program speaking MCP over its stdin and stdout
+	Args    []string          `json:"args,omitempty"` // Arguments passed to the command
+	Env     map[string]string `json:"env,omitempty"`  // Extra environment variables such as API keys
+}
+
+// DigestConfig says where l2 digest sends the digest besides printing it.
+// The mail server's login is read from SMTP_USER and SMTP_PASSWORD in .env.
+type DigestConfig struct {
+	Webhook string `json:"webhook,omitempty"` // URL the digest is posted to as JSON {"text": ...}, as Slack and Mattermost accept
+	Email   string `json:"email,omitempty"`   // Address the digest is mailed to
+	SMTP    string `json:"smtp,omitempty"`    // Mail server as host:port, such as smtp.example.com:587
+	From    string `json:"from,omitempty"`    // Sender address; SMTP_USER by default
+}
+
+// Webhook is a URL told when the lexicon or the grammar changes
+type Webhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"` // lexicon, grammar, or both when empty
+}
+
+// ModelPrice is what a model costs in dollars per million tokens
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`     // Tokens sent to the model
+	Completion float64 `json:"completion"` // Tokens the model generated
+}
+
+// ModelCapabilities describes what a chat model supports, so features it
+// lacks are left out instead of failing at runtime
+type ModelCapabilities struct {
+	Tools         bool `json:"tools"`          // Accepts tool definitions and calls tools
+	Vision        bool `json:"vision"`         // Reads images in messages
+	ContextTokens int  `json:"context_tokens"` // Size of the context window
+}
+
+// Config holds user settings that persist across sessions
+type Config struct {
+	SavedQueries         map[string]LexiconQuery      `json:"saved_queries,omitempty"`
+	AnnotateLexicon      bool                         `json:"annotate_lexicon,omitempty"`
+	SummarizeAboveTokens int                          `json:"summarize_above_tokens,omitempty"`
+	Speech               SpeechConfig                 `json:"speech,omitempty"`
+	ConfirmTools         []string                     `json:"confirm_tools,omitempty"` // Tools whose calls wait for approval; "all" for every tool
+	MCPServers           []MCPServer                  `json:"mcp_servers,omitempty"`
+	Lint                 string                       `json:"lint,omitempty"`        // Contradiction checks after each response: local (default), llm or off
+	TokenPrice           float64                      `json:"token_price,omitempty"` // Price per million tokens for models without a known price
+	LogSessions          bool                         `json:"log_sessions,omitempty"`
+	Verbosity            string                       `json:"verbosity,omitempty"`          // How much answers explain: terse, normal (default) or teacher
+	RawMarkdown          bool                         `json:"raw_markdown,omitempty"`       // Show messages as plain text instead of rendered markdown
+	MarkdownStyle        string                       `json:"markdown_style,omitempty"`     // Glamour style name or path of a style JSON file
+	DataDir              string                       `json:"data_dir,omitempty"`           // Directory for the language's data instead of the default; relative to the config directory
+	Language             string                       `json:"language,omitempty"`           // Language whose data is active, as chosen with /lang
+	AutosaveSeconds      int                          `json:"autosave_seconds,omitempty"`   // Seconds between conversation autosaves; negative turns the timer off
+	Branches             map[string]string            `json:"branches,omitempty"`           // Active conversation branch of each language, as chosen with /branch
+	Model                string                       `json:"model,omitempty"`              // OpenRouter model answering the chat; google/gemini-2.5-flash by default
+	ModelCapabilities    map[string]ModelCapabilities `json:"model_capabilities,omitempty"` // Capabilities of models by name, for models the built-in list gets wrong or lacks
+	Digest               DigestConfig                 `json:"digest,omitempty"`
+	ModelPrices          map[string]ModelPrice        `json:"model_prices,omitempty"` // Prices of models by name, for models the built-in list gets wrong or lacks
+	Webhooks             []Webhook                    `json:"webhooks,omitempty"`
+}
+
+func ReadConfig() (Config, error) {
+	config := Config{
+		SavedQueries:         map[string]LexiconQuery{},
+		SummarizeAboveTokens: DefaultSummarizeAboveTokens,
+		AutosaveSeconds:      DefaultAutosaveSeconds,
+	}
+	exists, err := CheckFile(ConfigFile)
+	if err != nil || !exists {
+		return config, err
+	}
+	data, err := ReadFile(ConfigFile)
+	if err != nil {
+		return config, err
+	}
+	if err := decode(ConfigFile, data, &config); err != nil {
+		return config, err
+	}
+	if config.SavedQueries == nil {
+		config.SavedQueries = map[string]LexiconQuery{}
+	}
+	if config.SummarizeAboveTokens <= 0 {
+		config.SummarizeAboveTokens = DefaultSummarizeAboveTokens
+	}
+	if config.AutosaveSeconds == 0 {
+		config.AutosaveSeconds = DefaultAutosaveSeconds
+	}
+	return config, nil
+}
+
+func WriteConfig(config Config) error {
+	path, err := GetPath(ConfigFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(ConfigFile, data)
+}
+
+// UpdateConfig reads the config, lets change modify it and writes it back,
+// holding the config against other updates in between. Nothing is written
+// if change returns an error.
+func UpdateConfig(change func(*Config) error) error {
+	defer BeginUpdate(ConfigFile)()
+	config, err := ReadConfig()
+	if err != nil {
+		return err
+	}
+	if err := change(&config); err != nil {
+		return err
+	}
+	return WriteConfig(config)
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source is an external reference consulted during design, such as a
+// fetched web page or an extracted PDF
+type Source struct {
+	ID       int       `json:"id"`
+	Title    string    `json:"title"`
+	Location string    `json:"location"`       // URL or original file path
+	Path     string    `json:"path,omitempty"` // Data file holding its text
+	Accessed time.Time `json:"accessed"`
+}
+
+// Decision is a design choice recorded in the decision log
+type Decision struct {
+	ID        int       `json:"id"`
+	Section   string    `json:"section"` // Grammar section such as phonology or syntax
+	Summary   string    `json:"summary"`
+	Rationale string    `json:"rationale,omitempty"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Citation ties a source to a grammar section, and optionally to one of
+// its decisions
+type Citation struct {
+	Source   int    `json:"source"`
+	Section  string `json:"section"`
+	Decision int    `json:"decision,omitempty"`
+	Locator  string `json:"locator,omitempty"` // Page, chapter or heading cited
+	Quote    string `json:"quote,omitempty"`
+}
+
+// DecisionLog holds the design decisions and the sources that informed them
+type DecisionLog struct {
+	Decisions []Decision `json:"decisions"`
+	Sources   []Source   `json:"sources"`
+	Citations []Citation `json:"citations"`
+}
+
+func ReadDecisionLog() (DecisionLog, error) {
+	log := DecisionLog{Decisions: []Decision{}, Sources: []Source{}, Citations: []Citation{}}
+	exists, err := CheckFile(DecisionsFile)
+	if err != nil || !exists {
+		return log, err
+	}
+	data, err := ReadFile(DecisionsFile)
+	if err != nil {
+		return log, err
+	}
+	if err := decode(DecisionsFile, data, &log); err != nil {
+		return log, err
+	}
+	return log, nil
+}
+
+func WriteDecisionLog(log DecisionLog) error {
+	path, err := GetPath(DecisionsFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(DecisionsFile, data)
+}
+
+// FindSource looks a source up by ID, location, data file path or title
+func (log DecisionLog) FindSource(ref string) (Source, bool) {
+	ref = strings.TrimSpace(ref)
+	id, _ := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	for _, s := range log.Sources {
+		if s.ID == id || s.Location == ref || (s.Path != "" && s.Path == ref) || strings.EqualFold(s.Title, ref) {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+// AddSource records a source, or refreshes the one with the same location,
+// and returns it with its ID
+func (log *DecisionLog) AddSource(source Source) Source {
+	source.Accessed = time.Now()
+	for i, s := range log.Sources {
+		if s.Location == source.Location {
+			source.ID = s.ID
+			if source.Title == "" {
+				source.Title = s.Title
+			}
+			if source.Path == "" {
+				source.Path = s.Path
+			}
+			log.Sources[i] = source
+			return source
+		}
+	}
+	source.ID = 1
+	for _, s := range log.Sources {
+		source.ID = max(source.ID, s.ID+1)
+	}
+	log.Sources = append(log.Sources, source)
+	return source
+}
+
+// RegisterSource records a consulted source in the decision log so it can
+// be cited later
+func RegisterSource(source Source) (Source, error) {
+	defer BeginUpdate(DecisionsFile)()
+	log, err := ReadDecisionLog()
+	if err != nil {
+		return Source{}, err
+	}
+	source = log.AddSource(source)
+	return source, WriteDecisionLog(log)
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// homeEnv names the variable that keeps everything in one directory, laid
+// out like the old $HOME/l2
+const homeEnv = "L2_HOME"
+
+// Directories resolved on first use: config.json lives in the user's config
+// directory and everything else in the data directory
+var (
+	dirsOnce  sync.Once
+	configDir string
+	dataDir   string
+	dirsErr   error
+)
+
+// userDataDir returns the platform's directory for application data:
+// $XDG_DATA_HOME or ~/.local/share on Unix, ~/Library/Application Support
+// on macOS and %LocalAppData% on Windows
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return os.UserConfigDir()
+	case "darwin", "ios":
+		return os.UserConfigDir()
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// dataDirOverride replaces the data directory when set
+var dataDirOverride string
+
+// SetDataDir keeps the data files under dir, such as a project directory
+// for one language, while config.json stays in the config directory
+func SetDataDir(dir string) {
+	dataDirOverride = dir
+}
+
+// resolveDirs works out the config and data directories, then applies the
+// data_dir setting of config.json
+func resolveDirs() {
+	resolveDefaultDirs()
+	if dirsErr != nil {
+		return
+	}
+
+	// ReadConfig can't be used while the directories are being resolved
+	data, err := os.ReadFile(filepath.Join(configDir, configFilePath))
+	if err != nil {
+		return
+	}
+	var settings struct {
+		DataDir string `json:"data_dir"`
+	}
+	if json.Unmarshal(data, &settings) != nil || settings.DataDir == "" {
+		return
+	}
+	dir := settings.DataDir
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(configDir, dir)
+	}
+	dataDir = dir
+}
+
+// resolveDefaultDirs works out the config and data directories, moving the
+// files of an existing $HOME/l2 into them the first time
+func resolveDefaultDirs() {
+	if dir := os.Getenv(homeEnv); dir != "" {
+		configDir, dataDir = dir, dir
+		return
+	}
+
+	config, err := os.UserConfigDir()
+	if err != nil {
+		dirsErr = err
+		return
+	}
+	data, err := userDataDir()
+	if err != nil {
+		dirsErr = err
+		return
+	}
+	configDir, dataDir = filepath.Join(config, rootPath), filepath.Join(data, rootPath)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	legacy := filepath.Join(home, rootPath)
+	if info, err := os.Stat(legacy); err != nil || !info.IsDir() {
+		return
+	}
+	if _, err := os.Stat(dataDir); err == nil {
+		return // Already migrated, or set up before the old directory was made
+	}
+	if err := migrateLegacy(legacy); err != nil {
+		// The old directory stays in use rather than splitting the data
+		log.Printf("Failed to move %s to %s, still using it: %v", legacy, dataDir, err)
+		configDir, dataDir = legacy, legacy
+		return
+	}
+	log.Printf("Moved %s to %s (config.json to %s)", legacy, dataDir, configDir)
+}
+
+// migrateLegacy moves the old storage directory to the data directory and
+// its config.json to the config directory
+func migrateLegacy(legacy string) error {
+	if err := os.MkdirAll(filepath.Dir(dataDir), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(legacy, dataDir); err != nil {
+		// Renaming fails across file systems; copy instead
+		if err := copyTree(legacy, dataDir); err != nil {
+			os.RemoveAll(dataDir)
+			return err
+		}
+		if err := os.RemoveAll(legacy); err != nil {
+			return err
+		}
+	}
+
+	old := filepath.Join(dataDir, configFilePath)
+	if _, err := os.Stat(old); err != nil || configDir == dataDir {
+		return nil
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(old, filepath.Join(configDir, configFilePath)); err != nil {
+		return copyFile(old, filepath.Join(configDir, configFilePath))
+	}
+	return nil
+}
+
+// copyTree copies a directory with everything under it
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
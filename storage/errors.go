@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// Kinds of storage errors, to be told apart with errors.Is. ErrReadOnly is
+// defined with the storage lock.
+var (
+	// ErrNotFound is returned for a stored file that doesn't exist. It is
+	// fs.ErrNotExist, so errors from the os package match it too.
+	ErrNotFound = fs.ErrNotExist
+
+	// ErrInvalidSchema is returned for a stored file whose contents don't
+	// fit the format it is read as
+	ErrInvalidSchema = errors.New("stored data is not in the expected format")
+
+	// ErrSandboxViolation is returned for a data file path that would reach
+	// outside the data directory
+	ErrSandboxViolation = errors.New("path leaves the data directory")
+)
+
+// decode unmarshals a stored file, reporting contents that don't fit as
+// ErrInvalidSchema
+func decode(file int, data []byte, v any) error {
+	return DecodeDataFile(pathMap[file], data, v)
+}
+
+// DecodeDataFile unmarshals the JSON of a stored file, reporting contents
+// that don't fit as ErrInvalidSchema
+func DecodeDataFile(name string, data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidSchema, name, err)
+	}
+	return nil
+}
+
+// dataFilePath returns where a file in the data directory lives, refusing
+// paths that are absolute or climb out of it with ..
+func dataFilePath(file string) (string, error) {
+	if !filepath.IsLocal(file) {
+		return "", fmt.Errorf("%w: %s", ErrSandboxViolation, file)
+	}
+	path, err := GetPath(DataFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(path, file), nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// HarmonyClass is a set of vowels that may co-occur within a word. Vowels are
+// listed in corresponding order across the classes of a system, so the nth
+// vowel of one class harmonizes to the nth vowel of another.
+type HarmonyClass struct {
+	Name   string   `json:"name" jsonschema:"required,description=Name of the class such as front, back, +ATR or -ATR"`
+	Vowels []string `json:"vowels" jsonschema:"required,description=Vowel phonemes in the class, in the same order as their counterparts in the other classes"`
+}
+
+// HarmonySystem is one harmony dimension such as backness or ATR. A word may
+// only use vowels from one class of each system; vowels in no class are neutral.
+type HarmonySystem struct {
+	Name    string         `json:"name" jsonschema:"required,description=Name of the harmony dimension such as backness, rounding or ATR"`
+	Classes []HarmonyClass `json:"classes" jsonschema:"required,description=The mutually exclusive vowel classes of the dimension"`
+}
+
+func ReadHarmony() ([]HarmonySystem, error) {
+	systems := []HarmonySystem{}
+	exists, err := CheckFile(HarmonyFile)
+	if err != nil || !exists {
+		return systems, err
+	}
+	data, err := ReadFile(HarmonyFile)
+	if err != nil {
+		return systems, err
+	}
+	if err := decode(HarmonyFile, data, &systems); err != nil {
+		return systems, err
+	}
+	return systems, nil
+}
+
+func WriteHarmony(systems []HarmonySystem) error {
+	path, err := GetPath(HarmonyFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(systems, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(HarmonyFile, data)
+}
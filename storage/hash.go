@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// DuplicateGroup is a set of data files with equivalent content
+type DuplicateGroup struct {
+	Hash  string   `json:"hash"`
+	Size  int      `json:"size"`
+	Paths []string `json:"paths"`
+}
+
+// HashContent returns the content address of data. Line endings and trailing
+// whitespace are normalized first so the same resource pasted twice hashes
+// the same even if an editor or terminal reformatted it.
+func HashContent(data []byte) string {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	normalized := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentIndex maps the content hash of every data file to the paths holding it
+func ContentIndex() (map[string][]string, error) {
+	files, err := ListDataFiles()
+	if err != nil {
+		return nil, err
+	}
+	index := map[string][]string{}
+	for _, file := range files {
+		data, err := ReadDataFile(file)
+		if err != nil {
+			return nil, err
+		}
+		hash := HashContent(data)
+		index[hash] = append(index[hash], file)
+	}
+	return index, nil
+}
+
+// FindDataFilesByContent returns the data files whose content is equivalent to data
+func FindDataFilesByContent(data []byte) ([]string, error) {
+	index, err := ContentIndex()
+	if err != nil {
+		return nil, err
+	}
+	paths := index[HashContent(data)]
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// FindDuplicates reports every group of two or more data files with
+// equivalent content, largest files first
+func FindDuplicates() ([]DuplicateGroup, error) {
+	index, err := ContentIndex()
+	if err != nil {
+		return nil, err
+	}
+	groups := []DuplicateGroup{}
+	for hash, paths := range index {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		data, err := ReadDataFile(paths[0])
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, DuplicateGroup{Hash: hash, Size: len(data), Paths: paths})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups, nil
+}
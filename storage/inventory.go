@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Phoneme is a single consonant or vowel of the language
+type Phoneme struct {
+	Symbol       string   `json:"symbol" jsonschema:"required,description=IPA symbol of the phoneme such as p or tʃ or aː"`
+	Romanization string   `json:"romanization,omitempty" jsonschema:"description=How the phoneme is spelled in the romanization such as ch for tʃ; defaults to the symbol"`
+	Features     []string `json:"features,omitempty" jsonschema:"description=Phonological features such as voiceless, bilabial, stop or high, front, rounded"`
+}
+
+// PhonemeInventory is the language's sound inventory. Tools that generate or
+// check words read it as the single source of truth for which sounds exist.
+type PhonemeInventory struct {
+	Consonants      []Phoneme       `json:"consonants"`
+	Vowels          []Phoneme       `json:"vowels"`
+	Suprasegmentals Suprasegmentals `json:"suprasegmentals,omitempty"`
+}
+
+// Tone is a contrastive tone and the ways it is written
+type Tone struct {
+	Name        string   `json:"name" jsonschema:"required,description=Name used in analyses and sandhi rules such as H, L, HL or 214"`
+	Marks       []string `json:"marks" jsonschema:"required,description=How the tone is written: a vowel with a tone diacritic such as á or à, or Chao tone letters such as ˨˩˦"`
+	Description string   `json:"description,omitempty" jsonschema:"description=Pitch description such as high level or low falling"`
+}
+
+// Suprasegmentals are the tone, stress and length properties of syllables
+type Suprasegmentals struct {
+	Tones       []Tone `json:"tones,omitempty" jsonschema:"description=The contrastive tones"`
+	DefaultTone string `json:"default_tone,omitempty" jsonschema:"description=Tone of syllables written without a tone mark, if any"`
+	Stress      string `json:"stress,omitempty" jsonschema:"description=Where stress falls: initial, final, penultimate, antepenultimate, or lexical when it is marked per word with ˈ"`
+	Length      string `json:"length,omitempty" jsonschema:"description=Whether length marked with ː is contrastive or allophonic"`
+	// Weight and exceptions refine the stress position
+	StressWeight     *StressWeight     `json:"stress_weight,omitempty" jsonschema:"description=Makes stress weight-sensitive; kept as is when omitted"`
+	StressExceptions []StressException `json:"stress_exceptions,omitempty" jsonschema:"description=Words whose stress is lexically marked; kept as is when omitted"`
+}
+
+// StressWeight makes stress weight-sensitive: the heavy syllable nearest one
+// edge of the word takes the stress, and the stress position only applies
+// when there is none, as in Latin penultimate-if-heavy stress
+type StressWeight struct {
+	Heavy         string `json:"heavy,omitempty" jsonschema:"description=Which syllables are heavy: closed, long or either (default either)"`
+	Edge          string `json:"edge,omitempty" jsonschema:"description=Edge of the word the stressed heavy syllable is nearest: left or right (default right)"`
+	Window        int    `json:"window,omitempty" jsonschema:"description=How many syllables from the edge a heavy syllable may be to attract stress, after any extrametrical syllable; 0 for the whole word"`
+	Extrametrical bool   `json:"extrametrical,omitempty" jsonschema:"description=The syllable at the edge never takes weight-based stress, as the Latin final syllable"`
+}
+
+// StressException is a word whose stress the rules don't predict
+type StressException struct {
+	Word     string `json:"word" jsonschema:"required,description=The word as spelled in the lexicon or in IPA without stress marks"`
+	Syllable int    `json:"syllable" jsonschema:"required,description=Stressed syllable counting from 1 at the start of the word"`
+}
+
+// Empty reports whether no suprasegmentals have been declared
+func (s Suprasegmentals) Empty() bool {
+	return len(s.Tones) == 0 && s.DefaultTone == "" && s.Stress == "" && s.Length == "" && s.StressWeight == nil && len(s.StressExceptions) == 0
+}
+
+// Empty reports whether no phonemes have been declared
+func (inv PhonemeInventory) Empty() bool {
+	return len(inv.Consonants) == 0 && len(inv.Vowels) == 0
+}
+
+func ReadInventory() (PhonemeInventory, error) {
+	inventory := PhonemeInventory{Consonants: []Phoneme{}, Vowels: []Phoneme{}}
+	exists, err := CheckFile(InventoryFile)
+	if err != nil || !exists {
+		return inventory, err
+	}
+	data, err := ReadFile(InventoryFile)
+	if err != nil {
+		return inventory, err
+	}
+	if err := decode(InventoryFile, data, &inventory); err != nil {
+		return inventory, err
+	}
+	return inventory, nil
+}
+
+func WriteInventory(inventory PhonemeInventory) error {
+	path, err := GetPath(InventoryFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(InventoryFile, data)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// languagesDir holds a directory per language other than the default one,
+// each laid out like the data directory itself
+const languagesDir = "languages"
+
+// DefaultLanguage names the language stored at the top of the data directory
+const DefaultLanguage = "default"
+
+// sharedFiles belong to the user rather than to a language and stay in
+// place whichever language is active
+var sharedFiles = map[int]bool{
+	SystemFile:      true,
+	StatsFile:       true,
+	ConfigFile:      true,
+	PreferencesFile: true,
+	LockFile:        true,
+	TemplatesFile:   true,
+}
+
+// language is the active language's directory name, empty for the default
+var language string
+
+// SetLanguage makes a language's lexicon, grammar, data files, conversation
+// and sessions the ones read and written. The default language, or an empty
+// name, uses the top of the data directory. The language's main
+// conversation branch becomes the active one.
+func SetLanguage(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || name == DefaultLanguage {
+		language, branch = "", ""
+		return nil
+	}
+	if !validName(name) {
+		return fmt.Errorf("language names may only hold letters, digits, - and _: %q", name)
+	}
+	language = name
+	branch = ""
+	return nil
+}
+
+// validName reports whether a name is safe to use as a file or directory name
+func validName(name string) bool {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// Language returns the name of the active language
+func Language() string {
+	if language == "" {
+		return DefaultLanguage
+	}
+	return language
+}
+
+// languagePath places a stored file in the active language's namespace
+func languagePath(base string, file int) string {
+	name := pathMap[file]
+	if file == ConversationFile {
+		name = branchPath()
+	}
+	if language == "" || sharedFiles[file] {
+		return filepath.Join(base, name)
+	}
+	return filepath.Join(base, languagesDir, language, name)
+}
+
+// ListLanguages returns the default language and every language that has
+// been switched to and written to, sorted by name
+func ListLanguages() ([]string, error) {
+	path, err := GetPath(StatsFile) // Shared, so at the top of the data directory
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(path), languagesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultLanguage}, names...), nil
+}
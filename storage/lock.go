@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrReadOnly is returned by writes while another instance holds the lock
+// and this one only reads
+var ErrReadOnly = errors.New("storage is read-only while another l2 instance is running")
+
+// readOnly refuses every write, for an instance started alongside another
+var readOnly bool
+
+// SetReadOnly makes every later write fail with ErrReadOnly
+func SetReadOnly() {
+	readOnly = true
+}
+
+// ReadOnly reports whether writes are refused
+func ReadOnly() bool {
+	return readOnly
+}
+
+// LockedError reports the instance that holds the storage lock
+type LockedError struct {
+	PID  int // Zero when the lock file doesn't name a process
+	Path string
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("another l2 instance is using this data; lock file %s", e.Path)
+	}
+	return fmt.Sprintf("another l2 instance (pid %d) is using this data; lock file %s", e.PID, e.Path)
+}
+
+// Lock claims the data directory for this instance by creating a lock file
+// holding its PID, so a second instance can't overwrite the conversation and
+// lexicon underneath it. The PID is written to a temporary file first and
+// linked into place, so the lock never exists without it. A lock left by an
+// instance that is no longer running is taken over; one that names no
+// process is left alone, since it can't be told apart from a live one.
+func Lock() error {
+	path, err := GetPath(LockFile)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, tempPrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // The lock is a link to it once claimed
+	_, err = tmp.WriteString(strconv.Itoa(os.Getpid()))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(tmp.Name(), path)
+		if err == nil {
+			return nil
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue // Released in the meantime
+		}
+		if err != nil {
+			return err
+		}
+		pid := pidOf(data)
+		if pid == 0 {
+			return &LockedError{Path: path}
+		}
+		if pid != os.Getpid() && processAlive(pid) {
+			return &LockedError{PID: pid, Path: path}
+		}
+		// Stale: the instance that wrote it has exited without cleaning up
+		if err := removeStaleLock(path, data); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to claim %s", path)
+}
+
+// removeStaleLock removes a lock file that held the stale data. It is moved
+// aside first, so that when two instances find the same stale lock only one
+// removes it, and a lock claimed in the meantime by another instance is put
+// back rather than deleted.
+func removeStaleLock(path string, stale []byte) error {
+	aside := fmt.Sprintf("%s.stale-%d", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return nil // Another instance removed it first
+		}
+		return err
+	}
+	defer os.Remove(aside)
+
+	data, err := os.ReadFile(aside)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, stale) {
+		// Another instance took the lock over between the read and the move
+		if err := os.Link(aside, path); err != nil && !os.IsExist(err) {
+			return err
+		}
+		return &LockedError{PID: pidOf(data), Path: path}
+	}
+	return nil
+}
+
+// pidOf returns the PID held in lock file data, or zero if it holds none
+func pidOf(data []byte) int {
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+// Unlock releases the lock taken by Lock
+func Unlock() error {
+	path, err := GetPath(LockFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		return nil // Not ours
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package storage
+
+import "syscall"
+
+// stillActive is the exit code Windows reports for a running process
+const stillActive = 259
+
+// processAlive reports whether a process with the PID is running. Windows
+// keeps an exited process's object while handles to it are open, so the
+// exit code tells whether it is still running.
+func processAlive(pid int) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// Running under another user
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// conversationVersion is the version of the conversation file format. Files
+// of version 1 are a plain array of messages, with metadata mixed into
+// their Extra fields.
+const conversationVersion = 2
+
+// metaKey keeps a message's metadata in its Extra field while in memory
+const metaKey = "l2_meta"
+
+// Outcomes of a tool call
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// MessageMeta is what the conversation file keeps about a message besides
+// the message itself
+type MessageMeta struct {
+	Time time.Time `json:"time,omitzero"` // When it was sent
+
+	// Of an answer: the model, and the tokens and time its requests took,
+	// tool rounds included
+	Model            string `json:"model,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens,omitempty"`
+	CompletionTokens int    `json:"completion_tokens,omitempty"`
+	Estimated        bool   `json:"estimated,omitempty"` // Some tokens were estimated, the provider not reporting them
+	LatencyMS        int64  `json:"latency_ms,omitempty"`
+	Interrupted      string `json:"interrupted,omitempty"` // Why the stream stopped before the model finished
+
+	// Of a tool call
+	Arguments string `json:"arguments,omitempty"`
+	Outcome   string `json:"outcome,omitempty"` // Success or failure, empty if the tool never returned
+}
+
+// storedMessage is a message as the conversation file keeps it
+type storedMessage struct {
+	Message *schema.Message `json:"message"`
+	Meta    *MessageMeta    `json:"meta,omitempty"`
+}
+
+// conversationFile is the layout of the conversation file
+type conversationFile struct {
+	Version  int             `json:"version"`
+	Messages []storedMessage `json:"messages"`
+}
+
+// Meta returns a message's metadata to read or fill in, adding it if the
+// message has none
+func Meta(msg *schema.Message) *MessageMeta {
+	if meta, ok := MetaOf(msg); ok {
+		return meta
+	}
+	meta := &MessageMeta{}
+	if msg.Extra == nil {
+		msg.Extra = map[string]any{}
+	}
+	msg.Extra[metaKey] = meta
+	return meta
+}
+
+// MetaOf returns a message's metadata, if it has any
+func MetaOf(msg *schema.Message) (*MessageMeta, bool) {
+	if msg == nil {
+		return nil, false
+	}
+	meta, ok := msg.Extra[metaKey].(*MessageMeta)
+	return meta, ok
+}
+
+// Conversation is a history of messages that is stored with each message's
+// metadata beside it
+type Conversation []*schema.Message
+
+// MarshalJSON writes the conversation in the current format
+func (c Conversation) MarshalJSON() ([]byte, error) {
+	file := conversationFile{Version: conversationVersion, Messages: make([]storedMessage, 0, len(c))}
+	for _, msg := range c {
+		stored := storedMessage{Message: msg}
+		if meta, ok := MetaOf(msg); ok {
+			// The metadata is written beside the message, not inside it
+			plain := *msg
+			plain.Extra = maps.Clone(msg.Extra)
+			delete(plain.Extra, metaKey)
+			if len(plain.Extra) == 0 {
+				plain.Extra = nil
+			}
+			stored = storedMessage{Message: &plain, Meta: meta}
+		}
+		file.Messages = append(file.Messages, stored)
+	}
+	return json.Marshal(file)
+}
+
+// UnmarshalJSON reads a conversation in the current format or as the plain
+// array of messages of version 1
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var messages []*schema.Message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			if msg != nil {
+				liftLegacyMeta(msg)
+			}
+		}
+		*c = messages
+		return nil
+	}
+
+	var file conversationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if file.Version > conversationVersion {
+		return fmt.Errorf("the conversation was saved by a newer version of l2 (format %d)", file.Version)
+	}
+	messages := make([]*schema.Message, 0, len(file.Messages))
+	for _, stored := range file.Messages {
+		if stored.Message != nil && stored.Meta != nil {
+			if stored.Message.Extra == nil {
+				stored.Message.Extra = map[string]any{}
+			}
+			stored.Message.Extra[metaKey] = stored.Meta
+		}
+		messages = append(messages, stored.Message)
+	}
+	*c = messages
+	return nil
+}
+
+// liftLegacyMeta moves the metadata version 1 kept in a message's Extra
+// field into its MessageMeta
+func liftLegacyMeta(msg *schema.Message) {
+	var meta MessageMeta
+	found := false
+	if stamp, ok := msg.Extra["sent_at"].(string); ok {
+		if at, err := time.Parse(time.RFC3339, stamp); err == nil {
+			meta.Time = at
+		}
+		delete(msg.Extra, "sent_at")
+		found = true
+	}
+	if usage, ok := msg.Extra["usage"].(map[string]any); ok {
+		tokens, _ := usage["prompt_tokens"].(float64)
+		meta.PromptTokens = int(tokens)
+		tokens, _ = usage["completion_tokens"].(float64)
+		meta.CompletionTokens = int(tokens)
+		meta.Estimated, _ = usage["estimated"].(bool)
+		delete(msg.Extra, "usage")
+		found = true
+	}
+	if args, ok := msg.Extra["arguments"].(string); ok {
+		meta.Arguments = args
+		delete(msg.Extra, "arguments")
+		found = true
+	}
+	if msg.Role == schema.Tool {
+		var result struct {
+			Success *bool `json:"success"`
+		}
+		if json.Unmarshal([]byte(msg.Content), &result) == nil && result.Success != nil {
+			meta.Outcome = OutcomeFailure
+			if *result.Success {
+				meta.Outcome = OutcomeSuccess
+			}
+			found = true
+		}
+	}
+	if found {
+		*Meta(msg) = meta
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// GraphemeMapping pairs a grapheme of the romanization with a phoneme it
+// writes. A grapheme may write several phonemes and a phoneme may be written
+// by several graphemes; the orthography tools report such ambiguities.
+type GraphemeMapping struct {
+	Grapheme string `json:"grapheme" jsonschema:"required,description=Letter or letter sequence of the romanization such as c or sh"`
+	Phoneme  string `json:"phoneme" jsonschema:"required,description=IPA symbol of the phoneme it writes such as k or ʃ"`
+}
+
+func ReadOrthography() ([]GraphemeMapping, error) {
+	mappings := []GraphemeMapping{}
+	exists, err := CheckFile(OrthographyFile)
+	if err != nil || !exists {
+		return mappings, err
+	}
+	data, err := ReadFile(OrthographyFile)
+	if err != nil {
+		return mappings, err
+	}
+	if err := decode(OrthographyFile, data, &mappings); err != nil {
+		return mappings, err
+	}
+	return mappings, nil
+}
+
+func WriteOrthography(mappings []GraphemeMapping) error {
+	path, err := GetPath(OrthographyFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(mappings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(OrthographyFile, data)
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Phonotactics declares which sound sequences the language permits
+type Phonotactics struct {
+	Template          string   `json:"template,omitempty" jsonschema:"description=Syllable template using C, V and parentheses for optional segments, e.g. (C)(C)V(C)"`
+	Onsets            []string `json:"onsets,omitempty" jsonschema:"description=Permitted onset clusters of two or more consonants such as pr or st; single consonants are always permitted"`
+	Codas             []string `json:"codas,omitempty" jsonschema:"description=Permitted codas such as n or st; when given, any other coda is a violation"`
+	ForbiddenClusters []string `json:"forbidden_clusters,omitempty" jsonschema:"description=Sequences that may not occur anywhere in a word, even across syllables, such as tl or ii"`
+}
+
+func ReadPhonotactics() (Phonotactics, error) {
+	var phonotactics Phonotactics
+	exists, err := CheckFile(PhonotacticsFile)
+	if err != nil || !exists {
+		return phonotactics, err
+	}
+	data, err := ReadFile(PhonotacticsFile)
+	if err != nil {
+		return phonotactics, err
+	}
+	if err := decode(PhonotacticsFile, data, &phonotactics); err != nil {
+		return phonotactics, err
+	}
+	return phonotactics, nil
+}
+
+func WritePhonotactics(phonotactics Phonotactics) error {
+	path, err := GetPath(PhonotacticsFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(phonotactics, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(PhonotacticsFile, data)
+}
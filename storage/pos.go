@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// PartOfSpeech is one tag of the language's part-of-speech tag set
+type PartOfSpeech struct {
+	Tag         string `json:"tag" jsonschema:"required,description=The tag as used in lexicon entries such as noun, verb or classifier"`
+	Description string `json:"description" jsonschema:"description=What words with this tag do in the language"`
+}
+
+func ReadPartsOfSpeech() ([]PartOfSpeech, error) {
+	tags := []PartOfSpeech{}
+	exists, err := CheckFile(PartsOfSpeechFile)
+	if err != nil || !exists {
+		return tags, err
+	}
+	data, err := ReadFile(PartsOfSpeechFile)
+	if err != nil {
+		return tags, err
+	}
+	if err := decode(PartsOfSpeechFile, data, &tags); err != nil {
+		return tags, err
+	}
+	return tags, nil
+}
+
+func WritePartsOfSpeech(tags []PartOfSpeech) error {
+	path, err := GetPath(PartsOfSpeechFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(PartsOfSpeechFile, data)
+}
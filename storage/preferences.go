@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Preferences are the user's standing instructions, such as their notation
+// style or preferred gloss format, keyed by a short name
+type Preferences map[string]string
+
+func ReadPreferences() (Preferences, error) {
+	preferences := Preferences{}
+	exists, err := CheckFile(PreferencesFile)
+	if err != nil || !exists {
+		return preferences, err
+	}
+	data, err := ReadFile(PreferencesFile)
+	if err != nil {
+		return preferences, err
+	}
+	if err := decode(PreferencesFile, data, &preferences); err != nil {
+		return preferences, err
+	}
+	if preferences == nil {
+		preferences = Preferences{}
+	}
+	return preferences, nil
+}
+
+func WritePreferences(preferences Preferences) error {
+	path, err := GetPath(PreferencesFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(preferences, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(PreferencesFile, data)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Profile holds the design targets of the language. A zero limit means no limit.
+type Profile struct {
+	MaxCases        int     `json:"max_cases,omitempty" jsonschema:"description=Most grammatical cases the language should have"`
+	MaxFusion       float64 `json:"max_fusion,omitempty" jsonschema:"description=Highest fusion index allowed: the average number of categories one inflectional affix expresses, 1 being purely agglutinative"`
+	MaxIrregularity float64 `json:"max_irregularity,omitempty" jsonschema:"description=Highest percentage of lexicon words allowed to have suppletive forms"`
+}
+
+func ReadProfile() (Profile, error) {
+	var profile Profile
+	exists, err := CheckFile(ProfileFile)
+	if err != nil || !exists {
+		return profile, err
+	}
+	data, err := ReadFile(ProfileFile)
+	if err != nil {
+		return profile, err
+	}
+	if err := decode(ProfileFile, data, &profile); err != nil {
+		return profile, err
+	}
+	return profile, nil
+}
+
+func WriteProfile(profile Profile) error {
+	path, err := GetPath(ProfileFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(ProfileFile, data)
+}
@@ -24,6 +24,11 @@ func ReadSystem() (string, error) {
 	return string(data), nil
 }
 
+// WriteSystem replaces the stored system prompt
+func WriteSystem(system string) error {
+	return WriteFile(SystemFile, []byte(system))
+}
+
 func CopySystem() error {
 	systemPath, err := GetPath(SystemFile)
 	if err != nil {
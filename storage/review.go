@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Proposal is a word the model suggested that waits in the review queue
+// until the user accepts it into the lexicon or rejects it
+type Proposal struct {
+	ID           int       `json:"id"`
+	Word         string    `json:"word"`
+	Definition   string    `json:"definition"`
+	PartOfSpeech string    `json:"part_of_speech,omitempty"`
+	Etymology    string    `json:"etymology,omitempty"`
+	DerivedFrom  []string  `json:"derived_from,omitempty"`
+	ProtoForm    string    `json:"proto_form,omitempty"`
+	BorrowedFrom string    `json:"borrowed_from,omitempty"`
+	Pack         string    `json:"pack,omitempty"`    // Concept pack the word was proposed for
+	Concept      string    `json:"concept,omitempty"` // Concept from the pack the word expresses
+	ProposedAt   time.Time `json:"proposed_at"`
+}
+
+func ReadReview() ([]Proposal, error) {
+	exists, err := CheckFile(ReviewFile)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []Proposal{}, nil
+	}
+	data, err := ReadFile(ReviewFile)
+	if err != nil {
+		return nil, err
+	}
+	var proposals []Proposal
+	if err := decode(ReviewFile, data, &proposals); err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+func WriteReview(proposals []Proposal) error {
+	path, err := GetPath(ReviewFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(proposals, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(ReviewFile, data)
+}
+
+// QueueProposal adds a proposal to the review queue with the next free ID
+func QueueProposal(p Proposal) (Proposal, error) {
+	defer BeginUpdate(ReviewFile)()
+	proposals, err := ReadReview()
+	if err != nil {
+		return Proposal{}, err
+	}
+	p.ID = 1
+	for _, existing := range proposals {
+		p.ID = max(p.ID, existing.ID+1)
+	}
+	p.ProposedAt = time.Now()
+	proposals = append(proposals, p)
+	return p, WriteReview(proposals)
+}
+
+// TakeProposal removes a proposal from the review queue and returns it
+func TakeProposal(id string) (Proposal, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return Proposal{}, fmt.Errorf("invalid proposal id %s", id)
+	}
+	defer BeginUpdate(ReviewFile)()
+	proposals, err := ReadReview()
+	if err != nil {
+		return Proposal{}, err
+	}
+	for i, p := range proposals {
+		if p.ID == n {
+			proposals = append(proposals[:i], proposals[i+1:]...)
+			return p, WriteReview(proposals)
+		}
+	}
+	return Proposal{}, fmt.Errorf("no proposal with id %s", id)
+}
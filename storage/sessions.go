@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SessionSummary records what one chat session changed
+type SessionSummary struct {
+	Started      time.Time `json:"started"`
+	Ended        time.Time `json:"ended"`
+	Tokens       int       `json:"tokens"`
+	PromptTokens int       `json:"prompt_tokens,omitempty"` // Of the tokens, those sent to the model; the rest were generated
+	Cost         float64   `json:"cost,omitempty"`
+	Requests     int       `json:"requests,omitempty"`   // Requests made to the model
+	ToolCalls    int       `json:"tool_calls,omitempty"` // Tools the model called
+	LatencyMS    int64     `json:"latency_ms,omitempty"` // Average time a request took
+	WordsAdded   []string  `json:"words_added,omitempty"`
+	WordsRemoved []string  `json:"words_removed,omitempty"`
+	FilesChanged []string  `json:"files_changed,omitempty"`
+	Decisions    []string  `json:"decisions,omitempty"` // Summaries of the decisions recorded
+}
+
+func ReadSessions() ([]SessionSummary, error) {
+	sessions := []SessionSummary{}
+	exists, err := CheckFile(SessionsFile)
+	if err != nil || !exists {
+		return sessions, err
+	}
+	data, err := ReadFile(SessionsFile)
+	if err != nil {
+		return sessions, err
+	}
+	if err := decode(SessionsFile, data, &sessions); err != nil {
+		return sessions, err
+	}
+	return sessions, nil
+}
+
+// AppendSession adds a session to the session log
+func AppendSession(session SessionSummary) error {
+	defer BeginUpdate(SessionsFile)()
+	sessions, err := ReadSessions()
+	if err != nil {
+		return err
+	}
+	path, err := GetPath(SessionsFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(append(sessions, session), "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(SessionsFile, data)
+}
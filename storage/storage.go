@@ -2,9 +2,11 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
 )
@@ -15,13 +17,47 @@ const (
 	statsFilePath        = "stats.json"
 	rootPath             = "l2"
 	dataPath             = "data"
+	trashFilePath        = "trash.json"
+	configFilePath       = "config.json"
+	inventoryFilePath    = "inventory.json"
+	phonotacticsFilePath = "phonotactics.json"
+	harmonyFilePath      = "harmony.json"
+	reviewFilePath       = "review.json"
+	posFilePath          = "parts_of_speech.json"
+	affixFilePath        = "affixes.json"
+	orthographyFilePath  = "orthography.json"
+	suppletionFilePath   = "suppletion.json"
+	syntaxFilePath       = "syntax.json"
+	profileFilePath      = "profile.json"
+	decisionsFilePath    = "decisions.json"
+	preferencesFilePath  = "preferences.json"
+	sessionsFilePath     = "sessions.json"
+	lockFilePath         = "l2.lock"
+	templatesFilePath    = "templates.json"
 )
 
 var pathMap = map[int]string{
-	0: systemFilePath,
-	1: conversationFilePath,
-	2: statsFilePath,
-	3: dataPath,
+	0:  systemFilePath,
+	1:  conversationFilePath,
+	2:  statsFilePath,
+	3:  dataPath,
+	4:  trashFilePath,
+	5:  configFilePath,
+	6:  inventoryFilePath,
+	7:  phonotacticsFilePath,
+	8:  harmonyFilePath,
+	9:  reviewFilePath,
+	10: posFilePath,
+	11: affixFilePath,
+	12: orthographyFilePath,
+	13: suppletionFilePath,
+	14: syntaxFilePath,
+	15: profileFilePath,
+	16: decisionsFilePath,
+	17: preferencesFilePath,
+	18: sessionsFilePath,
+	19: lockFilePath,
+	20: templatesFilePath,
 }
 
 const (
@@ -29,39 +65,132 @@ const (
 	ConversationFile
 	StatsFile
 	DataFile
+	TrashFile
+	ConfigFile
+	InventoryFile
+	PhonotacticsFile
+	HarmonyFile
+	ReviewFile
+	PartsOfSpeechFile
+	AffixFile
+	OrthographyFile
+	SuppletionFile
+	SyntaxFile
+	ProfileFile
+	DecisionsFile
+	PreferencesFile
+	SessionsFile
+	LockFile
+	TemplatesFile
 )
 
+// root replaces the config and data directories when set
+var root string
+
+// SetRoot keeps all data under dir instead of the user's directories, as for
+// the demo workspace
+func SetRoot(dir string) {
+	root = dir
+}
+
+// GetPath returns where a stored file lives: config.json in the config
+// directory and the rest in the data directory, or everything under the
+// root or $L2_HOME when one is set. A data directory chosen with SetDataDir
+// or the data_dir setting takes the place of the default one, and files that
+// belong to a language are kept in the active language's namespace.
 func GetPath(file int) (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+	if root != "" {
+		return languagePath(root, file), nil
+	}
+	dirsOnce.Do(resolveDirs)
+	if dirsErr != nil {
+		return "", dirsErr
+	}
+	if file == ConfigFile {
+		return filepath.Join(configDir, pathMap[file]), nil
 	}
-	return fmt.Sprintf("%s/%s/%s", home, rootPath, pathMap[file]), nil
+	if dataDirOverride != "" {
+		return languagePath(dataDirOverride, file), nil
+	}
+	return languagePath(dataDir, file), nil
 }
 
 func WriteDataFile(file string, data []byte) error {
-	Path, err := GetPath(DataFile)
+	path, err := dataFilePath(file)
 	if err != nil {
 		return err
 	}
-	os.MkdirAll(filepath.Dir(Path), 0755)
-	Path = filepath.Join(Path, file)
-	return os.WriteFile(Path, data, 0644)
+	previous := previousContent(DataFile, file, path)
+	if err := writeAtomic(path, data); err != nil {
+		return err
+	}
+	changed(DataFile, file, previous, data)
+	return nil
 }
 func ReadDataFile(file string) ([]byte, error) {
-	Path, err := GetPath(DataFile)
+	path, err := dataFilePath(file)
+	if err != nil {
+		return nil, err
+	}
+	return readCached(path)
+}
+
+// RemoveDataFile deletes a file from the data directory
+func RemoveDataFile(file string) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	path, err := dataFilePath(file)
+	if err != nil {
+		return err
+	}
+	defer forget(path)
+	previous := previousContent(DataFile, file, path)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	changed(DataFile, file, previous, nil)
+	return nil
+}
+
+// ListDataFiles returns the paths of all files in the data directory, relative to it
+func ListDataFiles() ([]string, error) {
+	root, err := GetPath(DataFile)
 	if err != nil {
 		return nil, err
 	}
-	Path = filepath.Join(Path, file)
-	return os.ReadFile(Path)
+	files := []string{}
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || isStorageArtifact(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
 }
+
 func WriteFile(file int, data []byte) error {
 	path, err := GetPath(file)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	previous := previousContent(file, pathMap[file], path)
+	if err := writeAtomic(path, data); err != nil {
+		return err
+	}
+	changed(file, pathMap[file], previous, data)
+	return nil
 }
 
 func ReadFile(file int) ([]byte, error) {
@@ -70,13 +199,13 @@ func ReadFile(file int) ([]byte, error) {
 		return nil, err
 	}
 	if !exists {
-		return nil, fmt.Errorf("file does not exist: %s", pathMap[file])
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, pathMap[file])
 	}
 	path, err := GetPath(file)
 	if err != nil {
 		return nil, err
 	}
-	return os.ReadFile(path)
+	return readCached(path)
 }
 
 func ReadConversation() ([]*schema.Message, error) {
@@ -84,8 +213,8 @@ func ReadConversation() ([]*schema.Message, error) {
 	if err != nil {
 		return nil, err
 	}
-	var history []*schema.Message
-	err = json.Unmarshal(data, &history)
+	var history Conversation
+	err = decode(ConversationFile, data, &history)
 	if err != nil {
 		return nil, err
 	}
@@ -98,9 +227,13 @@ func WriteConversation(history []*schema.Message) error {
 		return err
 	}
 	if !exists {
-		os.MkdirAll(filepath.Dir(pathMap[ConversationFile]), 0755)
+		path, err := GetPath(ConversationFile)
+		if err != nil {
+			return err
+		}
+		os.MkdirAll(filepath.Dir(path), 0755)
 	}
-	data, err := json.Marshal(history)
+	data, err := json.Marshal(Conversation(history))
 	if err != nil {
 		return err
 	}
@@ -116,8 +249,61 @@ func CheckFile(file int) (bool, error) {
 	return err == nil, nil
 }
 
+// ModelUsage is what one model has used: its requests, the tokens the
+// provider counted for them and their cost
+type ModelUsage struct {
+	Requests         int     `json:"requests"`
+	Estimated        int     `json:"estimated,omitempty"` // Requests whose tokens were estimated, the provider not reporting them
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost,omitempty"`       // Dollars, at the prices when each request was made
+	ToolCalls        int     `json:"tool_calls,omitempty"` // Tools the model called
+	Timed            int     `json:"timed,omitempty"`      // Requests whose latency was measured, which earlier ones weren't
+	LatencyMS        int64   `json:"latency_ms,omitempty"` // Total latency of the timed requests
+}
+
+// AverageLatency returns how long a timed request took on average
+func (u ModelUsage) AverageLatency() time.Duration {
+	if u.Timed == 0 {
+		return 0
+	}
+	return time.Duration(u.LatencyMS/int64(u.Timed)) * time.Millisecond
+}
+
+// ModelRequest is one request made to a model and what it used
+type ModelRequest struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Estimated        bool // The tokens were estimated rather than counted by the provider
+	Cost             float64
+	Latency          time.Duration
+	ToolCalls        []string // Names of the tools the model called
+}
+
 type Stats struct {
-	TotalTokens int `json:"total_tokens"`
+	TotalTokens      int                   `json:"total_tokens"`
+	PromptTokens     int                   `json:"prompt_tokens,omitempty"`
+	CompletionTokens int                   `json:"completion_tokens,omitempty"`
+	Cost             float64               `json:"cost,omitempty"`
+	Models           map[string]ModelUsage `json:"models,omitempty"`
+	ToolCalls        map[string]int        `json:"tool_calls,omitempty"` // Calls by tool name
+}
+
+// Total adds up the usage of every model
+func (s Stats) Total() ModelUsage {
+	var total ModelUsage
+	for _, u := range s.Models {
+		total.Requests += u.Requests
+		total.Estimated += u.Estimated
+		total.PromptTokens += u.PromptTokens
+		total.CompletionTokens += u.CompletionTokens
+		total.Cost += u.Cost
+		total.ToolCalls += u.ToolCalls
+		total.Timed += u.Timed
+		total.LatencyMS += u.LatencyMS
+	}
+	return total
 }
 
 func ReadStats() (Stats, error) {
@@ -126,13 +312,51 @@ func ReadStats() (Stats, error) {
 		return Stats{TotalTokens: 0}, err
 	}
 	var stats Stats
-	err = json.Unmarshal(data, &stats)
+	err = decode(StatsFile, data, &stats)
 	if err != nil {
 		return Stats{TotalTokens: 0}, err
 	}
 	return stats, nil
 }
 
+// AddRequest adds a model request's tokens, cost, latency and tool calls to
+// the stored stats and returns the new totals
+func AddRequest(r ModelRequest) (Stats, error) {
+	defer BeginUpdate(StatsFile)()
+	stats, err := ReadStats()
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return stats, err
+	}
+	stats.TotalTokens += r.PromptTokens + r.CompletionTokens
+	stats.PromptTokens += r.PromptTokens
+	stats.CompletionTokens += r.CompletionTokens
+	stats.Cost += r.Cost
+	if stats.Models == nil {
+		stats.Models = map[string]ModelUsage{}
+	}
+	usage := stats.Models[r.Model]
+	usage.Requests++
+	if r.Estimated {
+		usage.Estimated++
+	}
+	usage.PromptTokens += r.PromptTokens
+	usage.CompletionTokens += r.CompletionTokens
+	usage.Cost += r.Cost
+	if r.Latency > 0 {
+		usage.Timed++
+		usage.LatencyMS += r.Latency.Milliseconds()
+	}
+	usage.ToolCalls += len(r.ToolCalls)
+	stats.Models[r.Model] = usage
+	if len(r.ToolCalls) > 0 && stats.ToolCalls == nil {
+		stats.ToolCalls = map[string]int{}
+	}
+	for _, name := range r.ToolCalls {
+		stats.ToolCalls[name]++
+	}
+	return stats, WriteStats(stats)
+}
+
 func WriteStats(stats Stats) error {
 	exists, err := CheckFile(StatsFile)
 	if err != nil {
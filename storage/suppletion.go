@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// SuppletiveForm is an irregular form that replaces the regular one in some
+// paradigm cells of a lexeme, like went for go
+type SuppletiveForm struct {
+	Lexeme string   `json:"lexeme" jsonschema:"required,description=Citation form of the lexeme such as go"`
+	Cell   []string `json:"cell" jsonschema:"required,description=Labels of the paradigm cells the form fills such as [PST] or [PST 3SG]; it applies to every cell carrying all of them"`
+	Form   string   `json:"form" jsonschema:"required,description=The suppletive form such as went"`
+	Stem   bool     `json:"stem,omitempty" jsonschema:"description=Whether the form is a suppletive stem that still takes the regular affixes of the other categories, rather than a complete word"`
+}
+
+func ReadSuppletion() ([]SuppletiveForm, error) {
+	forms := []SuppletiveForm{}
+	exists, err := CheckFile(SuppletionFile)
+	if err != nil || !exists {
+		return forms, err
+	}
+	data, err := ReadFile(SuppletionFile)
+	if err != nil {
+		return forms, err
+	}
+	if err := decode(SuppletionFile, data, &forms); err != nil {
+		return forms, err
+	}
+	return forms, nil
+}
+
+func WriteSuppletion(forms []SuppletiveForm) error {
+	path, err := GetPath(SuppletionFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(forms, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(SuppletionFile, data)
+}
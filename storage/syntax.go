@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// TenseLabel maps a tense named in a semantic frame to the gloss label of its affix
+type TenseLabel struct {
+	Tense string `json:"tense" jsonschema:"required,description=Tense as named in sentence frames such as past"`
+	Label string `json:"label" jsonschema:"required,description=Gloss label of the tense affix such as PST"`
+}
+
+// Syntax holds the clause-level rules sentence generation follows. Case and
+// tense are realized by the affixes in the affix store with those glosses.
+type Syntax struct {
+	WordOrder     string       `json:"word_order" jsonschema:"required,description=Basic order of subject, object and verb: SOV, SVO, VSO, VOS, OVS or OSV"`
+	AgentCase     string       `json:"agent_case,omitempty" jsonschema:"description=Case label of the agent of a transitive verb such as NOM or ERG; empty if unmarked"`
+	SubjectCase   string       `json:"subject_case,omitempty" jsonschema:"description=Case label of the only argument of an intransitive verb such as NOM or ABS (defaults to the agent case)"`
+	PatientCase   string       `json:"patient_case,omitempty" jsonschema:"description=Case label of the patient such as ACC or ABS; empty if unmarked"`
+	RecipientCase string       `json:"recipient_case,omitempty" jsonschema:"description=Case label of the recipient such as DAT; empty if unmarked"`
+	Tenses        []TenseLabel `json:"tenses,omitempty" jsonschema:"description=Gloss labels of the tenses; past, present and future default to PST, PRS and FUT"`
+}
+
+func ReadSyntax() (Syntax, error) {
+	var syntax Syntax
+	exists, err := CheckFile(SyntaxFile)
+	if err != nil || !exists {
+		return syntax, err
+	}
+	data, err := ReadFile(SyntaxFile)
+	if err != nil {
+		return syntax, err
+	}
+	if err := decode(SyntaxFile, data, &syntax); err != nil {
+		return syntax, err
+	}
+	return syntax, nil
+}
+
+func WriteSyntax(syntax Syntax) error {
+	path, err := GetPath(SyntaxFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(syntax, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(SyntaxFile, data)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Templates are the user's reusable prompt snippets, keyed by name. They
+// belong to the user, so every language shares them.
+type Templates map[string]string
+
+func ReadTemplates() (Templates, error) {
+	templates := Templates{}
+	exists, err := CheckFile(TemplatesFile)
+	if err != nil || !exists {
+		return templates, err
+	}
+	data, err := ReadFile(TemplatesFile)
+	if err != nil {
+		return templates, err
+	}
+	if err := decode(TemplatesFile, data, &templates); err != nil {
+		return templates, err
+	}
+	if templates == nil {
+		templates = Templates{}
+	}
+	return templates, nil
+}
+
+func WriteTemplates(templates Templates) error {
+	path, err := GetPath(TemplatesFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(TemplatesFile, data)
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Kinds of items that can be moved to the trash
+const (
+	TrashKindFile    = "file"
+	TrashKindLexicon = "lexicon"
+)
+
+// TrashRetention is how long trashed items are kept before being purged
+const TrashRetention = 30 * 24 * time.Hour
+
+// MaxTrashedVersions is how many earlier versions of a file the trash keeps;
+// older ones are dropped as files are overwritten
+const MaxTrashedVersions = 5
+
+// TrashItem is a deleted file or lexicon entry that can still be restored
+type TrashItem struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Payload   []byte    `json:"payload"`
+}
+
+func ReadTrash() ([]TrashItem, error) {
+	exists, err := CheckFile(TrashFile)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []TrashItem{}, nil
+	}
+	data, err := ReadFile(TrashFile)
+	if err != nil {
+		return nil, err
+	}
+	var items []TrashItem
+	if err := decode(TrashFile, data, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func WriteTrash(items []TrashItem) error {
+	path, err := GetPath(TrashFile)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return WriteFile(TrashFile, data)
+}
+
+// MoveToTrash records a deleted item so it can be restored later
+func MoveToTrash(kind, name string, payload []byte) (TrashItem, error) {
+	defer BeginUpdate(TrashFile)()
+	items, err := ReadTrash()
+	if err != nil {
+		return TrashItem{}, err
+	}
+	now := time.Now()
+	item := TrashItem{
+		ID:        fmt.Sprintf("%x", now.UnixNano()),
+		Kind:      kind,
+		Name:      name,
+		DeletedAt: now,
+		Payload:   payload,
+	}
+	items = append(items, item)
+	return item, WriteTrash(items)
+}
+
+// TrashVersion keeps the previous version of a file that is being
+// overwritten, dropping the oldest versions of it beyond MaxTrashedVersions
+func TrashVersion(name string, payload []byte) (TrashItem, error) {
+	defer BeginUpdate(TrashFile)()
+	items, err := ReadTrash()
+	if err != nil {
+		return TrashItem{}, err
+	}
+	now := time.Now()
+	item := TrashItem{
+		ID:        fmt.Sprintf("%x", now.UnixNano()),
+		Kind:      TrashKindFile,
+		Name:      name,
+		DeletedAt: now,
+		Payload:   payload,
+	}
+	items = append(items, item)
+
+	// Items are in the order they were trashed, so the newest are kept
+	versions := 0
+	kept := make([]TrashItem, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Kind == TrashKindFile && items[i].Name == name {
+			if versions++; versions > MaxTrashedVersions {
+				continue
+			}
+		}
+		kept = append(kept, items[i])
+	}
+	slices.Reverse(kept)
+	return item, WriteTrash(kept)
+}
+
+// TakeFromTrash removes an item from the trash and returns it for restoring
+func TakeFromTrash(id string) (TrashItem, error) {
+	defer BeginUpdate(TrashFile)()
+	items, err := ReadTrash()
+	if err != nil {
+		return TrashItem{}, err
+	}
+	for i, item := range items {
+		if item.ID == id {
+			items = append(items[:i], items[i+1:]...)
+			return item, WriteTrash(items)
+		}
+	}
+	return TrashItem{}, fmt.Errorf("no trash item with id %s", id)
+}
+
+// PurgeTrash permanently deletes items older than maxAge and returns how many were removed
+func PurgeTrash(maxAge time.Duration) (int, error) {
+	defer BeginUpdate(TrashFile)()
+	items, err := ReadTrash()
+	if err != nil {
+		return 0, err
+	}
+	kept := []TrashItem{}
+	for _, item := range items {
+		if time.Since(item.DeletedAt) < maxAge {
+			kept = append(kept, item)
+		}
+	}
+	purged := len(items) - len(kept)
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, WriteTrash(kept)
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"sync"
+)
+
+// pathLocks hands out a mutex per file path
+type pathLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock holds the path's mutex until the returned function is called
+func (p *pathLocks) lock(path string) func() {
+	p.mu.Lock()
+	if p.locks == nil {
+		p.locks = map[string]*sync.Mutex{}
+	}
+	l, ok := p.locks[path]
+	if !ok {
+		l = &sync.Mutex{}
+		p.locks[path] = l
+	}
+	p.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// Tools, the stream and autosave can all reach the same file at once.
+// writes keeps one write of a file, with its backup and rename, from
+// overlapping another; updates keeps read-modify-write sequences, such as
+// adding to the lexicon, from losing each other's changes.
+var (
+	writes  pathLocks
+	updates pathLocks
+)
+
+// BeginUpdate holds a stored file against other updates until the returned
+// function is called. Hold it from reading the file to writing it back.
+func BeginUpdate(file int) func() {
+	path, err := GetPath(file)
+	if err != nil {
+		return func() {} // The read that follows reports the error
+	}
+	return updates.lock(path)
+}
+
+// BeginDataUpdate is BeginUpdate for a file in the data directory
+func BeginDataUpdate(name string) func() {
+	path, err := dataFilePath(name)
+	if err != nil {
+		return func() {}
+	}
+	return updates.lock(path)
+}
@@ -0,0 +1,342 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// AffixDocumentPath is the data file the affix table is written to, so it is
+// exported with the rest of the grammar's morphology notes
+const AffixDocumentPath = "morphology/affixes.md"
+
+// AffixRequest represents a request to store affixes
+type AffixRequest struct {
+	Affixes []storage.Affix `json:"affixes" jsonschema:"required,description=The affixes to store"`
+	Merge   bool            `json:"merge" jsonschema:"description=Add or update the given affixes by form instead of replacing the whole affix store"`
+}
+
+// GetAffixesRequest represents a request to get the affix store
+type GetAffixesRequest struct {
+	AttachesTo string `json:"attaches_to" jsonschema:"description=Only return affixes attaching to this part of speech"`
+}
+
+// AffixResult represents the result of affix store operations
+type AffixResult struct {
+	Success  bool            `json:"success"`
+	Message  string          `json:"message"`
+	Affixes  []storage.Affix `json:"affixes,omitempty"`
+	Table    string          `json:"table,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// SetAffixes replaces or extends the stored affixes and rewrites the affix document
+func SetAffixes(ctx context.Context, req *AffixRequest) (*AffixResult, error) {
+	defer storage.BeginUpdate(storage.AffixFile)()
+	affixes := []storage.Affix{}
+	if req.Merge {
+		current, err := storage.ReadAffixes()
+		if err != nil {
+			return &AffixResult{
+				Success: false,
+				Message: failure("read affixes", err),
+			}, nil
+		}
+		affixes = current
+	}
+
+	for _, affix := range req.Affixes {
+		if err := normalizeAffix(&affix); err != nil {
+			return &AffixResult{
+				Success: false,
+				Message: "Invalid affix: " + err.Error(),
+			}, nil
+		}
+		replaced := false
+		for i := range affixes {
+			if affixes[i].Form == affix.Form {
+				affixes[i] = affix
+				replaced = true
+			}
+		}
+		if !replaced {
+			affixes = append(affixes, affix)
+		}
+	}
+
+	if err := storage.WriteAffixes(affixes); err != nil {
+		return &AffixResult{
+			Success: false,
+			Message: failure("save affixes", err),
+		}, nil
+	}
+	table := renderAffixTable(affixes)
+	if err := storage.WriteDataFile(AffixDocumentPath, []byte("# Affixes\n\n"+table)); err != nil {
+		return &AffixResult{
+			Success: false,
+			Message: failure("write affix document", err),
+		}, nil
+	}
+
+	return &AffixResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Saved %d affixes", len(affixes)),
+		Affixes:  affixes,
+		Table:    table,
+		Warnings: budgetWarnings(),
+	}, nil
+}
+
+// GetAffixes returns the stored affixes
+func GetAffixes(ctx context.Context, req *GetAffixesRequest) (*AffixResult, error) {
+	affixes, err := storage.ReadAffixes()
+	if err != nil {
+		return &AffixResult{
+			Success: false,
+			Message: failure("read affixes", err),
+		}, nil
+	}
+
+	if req.AttachesTo != "" {
+		matches := []storage.Affix{}
+		for _, affix := range affixes {
+			for _, pos := range affix.AttachesTo {
+				if strings.EqualFold(pos, req.AttachesTo) {
+					matches = append(matches, affix)
+					break
+				}
+			}
+		}
+		affixes = matches
+	}
+	if len(affixes) == 0 {
+		return &AffixResult{
+			Success: true,
+			Message: "No affixes stored yet",
+		}, nil
+	}
+
+	return &AffixResult{
+		Success: true,
+		Message: fmt.Sprintf("Found %d affixes", len(affixes)),
+		Affixes: affixes,
+		Table:   renderAffixTable(affixes),
+	}, nil
+}
+
+// normalizeAffix checks an affix's form, kind, position, productivity and
+// parts of speech and fills in defaults
+func normalizeAffix(affix *storage.Affix) error {
+	affix.Kind = strings.ToLower(affix.Kind)
+	if affix.Kind == "" {
+		affix.Kind = storage.AffixKind
+	}
+	side := attachment(affix.Form)
+	switch {
+	case affix.Kind == storage.AffixKind && (side == "prefix" || side == "suffix"):
+	case affix.Kind == storage.CliticKind && (side == "proclitic" || side == "enclitic"):
+	case affix.Kind == storage.ParticleKind && side == "free":
+	case affix.Kind == storage.ProcessKind:
+		if _, ok := derivationProcesses[affix.Form]; !ok {
+			return fmt.Errorf("unknown process %q, use reduplication, initial-reduplication or conversion", affix.Form)
+		}
+		affix.Derivational = true
+	case affix.Kind != storage.AffixKind && affix.Kind != storage.CliticKind && affix.Kind != storage.ParticleKind:
+		return fmt.Errorf("kind of %s must be affix, clitic, particle or process", affix.Form)
+	default:
+		return fmt.Errorf("%q is not a well-formed %s: write -ni or ta- for affixes, =la or la= for clitics and a bare form for particles", affix.Form, affix.Kind)
+	}
+	if affix.Gloss == "" {
+		return fmt.Errorf("%s needs a gloss", affix.Form)
+	}
+	for _, allomorph := range affix.Allomorphs {
+		if attachment(allomorph.Form) != side {
+			return fmt.Errorf("allomorph %s of %s must attach on the same side", allomorph.Form, affix.Form)
+		}
+		if len(allomorph.Adjacent) == 0 {
+			return fmt.Errorf("allomorph %s of %s needs the stem segments that select it", allomorph.Form, affix.Form)
+		}
+	}
+
+	if affix.Derivational && affix.Kind != storage.AffixKind && affix.Kind != storage.ProcessKind {
+		return fmt.Errorf("%s is a %s; only affixes and processes can be derivational", affix.Form, affix.Kind)
+	}
+	if affix.Yields != "" {
+		yields, err := normalizePartOfSpeech(affix.Yields)
+		if err != nil {
+			return fmt.Errorf("%s: %w", affix.Form, err)
+		}
+		affix.Yields = yields
+	}
+
+	affix.Position = strings.ToLower(affix.Position)
+	if affix.Kind == storage.AffixKind || affix.Kind == storage.ProcessKind {
+		if affix.Position != "" {
+			return fmt.Errorf("position of %s only applies to clitics and particles", affix.Form)
+		}
+	} else {
+		switch affix.Position {
+		case "":
+			affix.Position = storage.AnyPosition
+		case storage.AnyPosition, storage.FirstPosition, storage.LastPosition:
+		default:
+			return fmt.Errorf("position of %s must be any, first or last", affix.Form)
+		}
+	}
+
+	switch strings.ToLower(affix.Productivity) {
+	case "":
+		affix.Productivity = storage.Productive
+	case storage.Productive, storage.SemiProductive, storage.Unproductive:
+		affix.Productivity = strings.ToLower(affix.Productivity)
+	default:
+		return fmt.Errorf("productivity of %s must be productive, semi-productive or unproductive", affix.Form)
+	}
+
+	for i, pos := range affix.AttachesTo {
+		normalized, err := normalizePartOfSpeech(pos)
+		if err != nil {
+			return fmt.Errorf("%s: %w", affix.Form, err)
+		}
+		affix.AttachesTo[i] = normalized
+	}
+	return nil
+}
+
+// attachment tells how a written morpheme attaches from its boundary
+// markers: prefix (ta-), suffix (-ni), proclitic (la=), enclitic (=la) or
+// free for a bare particle. Malformed forms return "".
+func attachment(form string) string {
+	trimmed := strings.TrimLeft(form, "-=")
+	left := form[:len(form)-len(trimmed)]
+	body := strings.TrimRight(trimmed, "-=")
+	right := trimmed[len(body):]
+	if body == "" || strings.ContainsAny(body, "-= ") {
+		return ""
+	}
+	switch {
+	case left == "" && right == "":
+		return "free"
+	case left == "-" && right == "":
+		return "suffix"
+	case left == "" && right == "-":
+		return "prefix"
+	case left == "=" && right == "":
+		return "enclitic"
+	case left == "" && right == "=":
+		return "proclitic"
+	}
+	return ""
+}
+
+func renderAffixTable(affixes []storage.Affix) string {
+	var out strings.Builder
+	out.WriteString("| Form | Kind | Gloss | Attaches to | Yields | Position | Productivity | Allomorphs |\n|---|---|---|---|---|---|---|---|\n")
+	for _, affix := range affixes {
+		allomorphs := []string{}
+		for _, allomorph := range affix.Allomorphs {
+			allomorphs = append(allomorphs, fmt.Sprintf("%s after %s", allomorph.Form, strings.Join(allomorph.Adjacent, "/")))
+		}
+		kind := affix.Kind
+		if affix.Derivational && kind == storage.AffixKind {
+			kind = "derivational affix"
+		}
+		out.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s | %s |\n", affix.Form, kind, affix.Gloss,
+			strings.Join(affix.AttachesTo, ", "), affix.Yields, affix.Position, affix.Productivity, strings.Join(allomorphs, "; ")))
+	}
+	return out.String()
+}
+
+// affixStore gives the morphology tools access to the stored affixes
+type affixStore struct {
+	affixes  []storage.Affix
+	byForm   map[string]*storage.Affix // Affix and allomorph forms to their affix
+	phonemes *phonemeSet
+}
+
+func loadAffixStore() (*affixStore, error) {
+	affixes, err := storage.ReadAffixes()
+	if err != nil {
+		return nil, err
+	}
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return nil, err
+	}
+	s := &affixStore{affixes: affixes, byForm: map[string]*storage.Affix{}, phonemes: set}
+	for i := range affixes {
+		s.byForm[strings.ToLower(affixes[i].Form)] = &affixes[i]
+		for _, allomorph := range affixes[i].Allomorphs {
+			s.byForm[strings.ToLower(allomorph.Form)] = &affixes[i]
+		}
+	}
+	return s, nil
+}
+
+// glossed returns the stored affix with the given gloss
+func (s *affixStore) glossed(gloss string) (storage.Affix, bool) {
+	for _, affix := range s.affixes {
+		if strings.EqualFold(affix.Gloss, gloss) {
+			return affix, true
+		}
+	}
+	return storage.Affix{}, false
+}
+
+// attach attaches an affix to a stem, choosing the allomorph selected by the
+// stem segment next to it when the affix is in the store
+func (s *affixStore) attach(stem, form string) string {
+	affix, ok := s.byForm[strings.ToLower(form)]
+	if !ok {
+		return applyAffix(stem, form)
+	}
+
+	segs := s.phonemes.segment(strings.ToLower(stem))
+	edge := ""
+	if len(segs) > 0 {
+		edge = segs[len(segs)-1]
+		if side := attachment(affix.Form); side == "prefix" || side == "proclitic" {
+			edge = segs[0]
+		}
+	}
+	for _, allomorph := range affix.Allomorphs {
+		for _, adjacent := range allomorph.Adjacent {
+			if s.matches(edge, adjacent) {
+				return applyAffix(stem, allomorph.Form)
+			}
+		}
+	}
+	return applyAffix(stem, affix.Form)
+}
+
+func (s *affixStore) matches(segment, pattern string) bool {
+	switch pattern {
+	case "V":
+		return segment != "" && s.phonemes.vowel(segment)
+	case "C":
+		return segment != "" && !s.phonemes.vowel(segment)
+	}
+	return strings.EqualFold(segment, pattern)
+}
+
+// createSetAffixesTool creates the tool that stores affixes
+func createSetAffixesTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_affixes",
+		"Store the language's affixes, clitics and discourse particles with their gloss, the parts of speech they attach to, their sentence position, productivity and conditioned allomorphs. They are kept apart from the lexicon and used when segmenting, glossing, spellchecking and generating paradigms.",
+		SetAffixes,
+	)
+}
+
+// createGetAffixesTool creates the tool that lists stored affixes
+func createGetAffixesTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"get_affixes",
+		"List the stored affixes, optionally only those attaching to one part of speech.",
+		GetAffixes,
+	)
+}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// defaultAnkiDeck is the deck name used when none is given
+const defaultAnkiDeck = "Conlang Vocabulary"
+
+// AnkiExportRequest represents a request to export the lexicon as an Anki deck
+type AnkiExportRequest struct {
+	Path       string `json:"path" jsonschema:"description=Data file path to write (defaults to anki.txt)"`
+	Deck       string `json:"deck" jsonschema:"description=Name of the Anki deck to import into"`
+	IncludeIPA bool   `json:"include_ipa" jsonschema:"description=Show the IPA pronunciation on the front of each card"`
+}
+
+// ExportAnki writes the lexicon as an Anki-importable tab separated file
+func ExportAnki(ctx context.Context, req *AnkiExportRequest) (*ExportResult, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAnkiDeck(&buf, entries, req.Deck, req.IncludeIPA); err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("export deck", err),
+		}, nil
+	}
+
+	path := req.Path
+	if path == "" {
+		path = "anki.txt"
+	}
+	if err := storage.WriteDataFile(path, buf.Bytes()); err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("write deck", err),
+		}, nil
+	}
+
+	return &ExportResult{
+		Success: true,
+		Message: fmt.Sprintf("Exported %d cards to %s. Import it in Anki with File > Import.", len(entries), path),
+		Path:    path,
+	}, nil
+}
+
+// WriteAnkiDeck writes entries in Anki's plain text import format. File
+// headers tell Anki the separator, deck and which column holds the tags.
+func WriteAnkiDeck(w io.Writer, entries []LexiconEntry, deck string, includeIPA bool) error {
+	if deck == "" {
+		deck = defaultAnkiDeck
+	}
+
+	var out strings.Builder
+	out.WriteString("#separator:tab\n")
+	out.WriteString("#html:true\n")
+	out.WriteString("#notetype:Basic\n")
+	out.WriteString("#deck:" + deck + "\n")
+	out.WriteString("#columns:Front\tBack\tTags\n")
+	out.WriteString("#tags column:3\n")
+
+	for _, entry := range entries {
+		front := ankiField(entry.Word)
+		if includeIPA && entry.IPA != "" {
+			front += "<br>/" + ankiField(entry.IPA) + "/"
+		}
+
+		back := ankiField(entry.Definition)
+		if entry.PartOfSpeech != "" {
+			back = "<i>" + ankiField(entry.PartOfSpeech) + "</i> " + back
+		}
+		if etymology := EtymologyText(entry); etymology != "" {
+			back += "<br><small>" + ankiField(etymology) + "</small>"
+		}
+
+		tags := make([]string, len(entry.Tags))
+		for i, tag := range entry.Tags {
+			tags[i] = strings.ReplaceAll(tag, " ", "_")
+		}
+
+		out.WriteString(front + "\t" + back + "\t" + strings.Join(tags, " ") + "\n")
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// ankiField escapes a value for an HTML-enabled Anki field
+func ankiField(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "\t", " ")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// createAnkiTool creates the Anki export tool
+func createAnkiTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"export_anki",
+		"Export the conlang lexicon as an Anki-importable flashcard file with word, definition, part of speech, etymology and optional IPA, for spaced repetition drilling.",
+		ExportAnki,
+	)
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"l2/storage"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// approvalKey is the context key an ApproveFunc is stored under
+type approvalKey struct{}
+
+// ApproveFunc decides whether a tool call may run, given the tool's name and
+// its arguments as JSON
+type ApproveFunc func(ctx context.Context, name, arguments string) bool
+
+// WithApproval returns a context whose tool calls must be approved first
+func WithApproval(ctx context.Context, approve ApproveFunc) context.Context {
+	return context.WithValue(ctx, approvalKey{}, approve)
+}
+
+// approvedTool asks the context's ApproveFunc, if any, before running a tool
+type approvedTool struct {
+	tool.InvokableTool
+	name string
+}
+
+// InvokableRun runs the tool unless the call is declined, in which case the
+// model is told so through an unsuccessful result
+func (t *approvedTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	if approve, ok := ctx.Value(approvalKey{}).(ApproveFunc); ok && !approve(ctx, t.name, arguments) {
+		data, err := json.Marshal(Result{
+			Success: false,
+			Message: "The user declined to run " + t.name + "; ask what they would like instead",
+		})
+		return string(data), err
+	}
+	return t.InvokableTool.InvokableRun(ctx, arguments, opts...)
+}
+
+// ToolCallWarning points out what a tool call would destroy, such as the
+// file an add_file call overwrites
+func ToolCallWarning(name, arguments string) string {
+	if name != "add_file" {
+		return ""
+	}
+	var file File
+	if err := json.Unmarshal([]byte(arguments), &file); err != nil || file.Path == "" {
+		return ""
+	}
+	if _, err := storage.ReadDataFile(file.Path); err != nil {
+		return ""
+	}
+	return "Overwrites the existing " + file.Path + " (the old version goes to the trash)"
+}
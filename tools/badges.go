@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"fmt"
+	"l2/storage"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LanguageStats summarizes the size of the conlang
+type LanguageStats struct {
+	Words             int            `json:"words"`
+	Phonemes          int            `json:"phonemes"`
+	GrammarCompletion int            `json:"grammar_completion"` // Percentage of designFiles written
+	PartsOfSpeech     map[string]int `json:"parts_of_speech"`    // Words per part of speech, "untagged" for none
+}
+
+// CollectLanguageStats gathers word, phoneme and grammar coverage counts from stored data
+func CollectLanguageStats() (LanguageStats, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return LanguageStats{}, err
+	}
+
+	// The declared inventory is authoritative; otherwise count the letters in use
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return LanguageStats{}, err
+	}
+	phonemes := map[string]bool{}
+	for _, p := range append(inventory.Consonants, inventory.Vowels...) {
+		phonemes[p.Symbol] = true
+	}
+	if len(phonemes) == 0 {
+		for _, entry := range entries {
+			for _, p := range extractPhonemes(strings.ToLower(entry.Word)) {
+				phonemes[p] = true
+			}
+		}
+	}
+
+	written, err := writtenDesignFiles()
+	if err != nil {
+		return LanguageStats{}, err
+	}
+
+	partsOfSpeech := map[string]int{}
+	for _, entry := range entries {
+		pos := strings.ToLower(strings.TrimSpace(entry.PartOfSpeech))
+		if pos == "" {
+			pos = "untagged"
+		}
+		partsOfSpeech[pos]++
+	}
+
+	return LanguageStats{
+		Words:             len(entries),
+		PartsOfSpeech:     partsOfSpeech,
+		Phonemes:          len(phonemes),
+		GrammarCompletion: written * 100 / len(designFiles),
+	}, nil
+}
+
+// writtenDesignFiles counts the design files that have been written. One
+// also counts when a data file is named after it, such as syntax-notes.md
+// for syntax.json, for grammar kept as prose.
+func writtenDesignFiles() (int, error) {
+	files, err := storage.ListDataFiles()
+	if err != nil {
+		return 0, err
+	}
+	written := 0
+	for _, file := range designFiles {
+		exists, err := storage.CheckFile(file)
+		if err != nil {
+			return 0, err
+		}
+		path, err := storage.GetPath(file)
+		if err != nil {
+			return 0, err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if exists || slices.ContainsFunc(files, func(f string) bool {
+			return strings.Contains(strings.ToLower(f), name)
+		}) {
+			written++
+		}
+	}
+	return written, nil
+}
+
+// GenerateBadges writes SVG badges for the language stats into data/badges
+// and returns their paths. Only SVG is written; it scales and embeds in a
+// README where a PNG would.
+func GenerateBadges() ([]string, error) {
+	stats, err := CollectLanguageStats()
+	if err != nil {
+		return nil, err
+	}
+
+	badges := []struct {
+		file  string
+		label string
+		value string
+		color string
+	}{
+		{"words.svg", "words", fmt.Sprintf("%d", stats.Words), "#4c1"},
+		{"phonemes.svg", "phonemes", fmt.Sprintf("%d", stats.Phonemes), "#007ec6"},
+		{"grammar.svg", "grammar", fmt.Sprintf("%d%%", stats.GrammarCompletion), completionColor(stats.GrammarCompletion)},
+	}
+
+	paths := []string{}
+	for _, b := range badges {
+		path := filepath.Join("badges", b.file)
+		if err := storage.WriteDataFile(path, []byte(renderBadge(b.label, b.value, b.color))); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func completionColor(percent int) string {
+	switch {
+	case percent >= 75:
+		return "#4c1"
+	case percent >= 50:
+		return "#dfb317"
+	default:
+		return "#e05d44"
+	}
+}
+
+// renderBadge draws a flat two-part badge in the style of shields.io
+func renderBadge(label, value, color string) string {
+	// Approximate Verdana 11px text width; good enough for short labels
+	labelWidth := len(label)*7 + 10
+	valueWidth := len(value)*7 + 10
+	width := labelWidth + valueWidth
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">
+  <title>%[2]s: %[3]s</title>
+  <clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[4]d" height="20" fill="#555"/>
+    <rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[7]d" y="14">%[2]s</text>
+    <text x="%[8]d" y="14">%[3]s</text>
+  </g>
+</svg>
+`, width, label, value, labelWidth, valueWidth, color, labelWidth/2, labelWidth+valueWidth/2)
+}
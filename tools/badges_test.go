@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"l2/storage"
+	"testing"
+)
+
+func TestGrammarCompletion(t *testing.T) {
+	storage.SetRoot(t.TempDir())
+	defer storage.SetRoot("")
+
+	completion := func() int {
+		t.Helper()
+		stats, err := CollectLanguageStats()
+		if err != nil {
+			t.Fatalf("CollectLanguageStats: %v", err)
+		}
+		return stats.GrammarCompletion
+	}
+	if got := completion(); got != 0 {
+		t.Errorf("completion with nothing written = %d%%, want 0%%", got)
+	}
+
+	// Design files outside data/ count, as do data files named after one
+	if err := storage.WriteFile(storage.SyntaxFile, []byte(`{"word_order":"SOV"}`)); err != nil {
+		t.Fatalf("write syntax: %v", err)
+	}
+	if err := storage.WriteDataFile("orthography-notes.md", []byte("# Spelling")); err != nil {
+		t.Fatalf("write notes: %v", err)
+	}
+	if got, want := completion(), 2*100/len(designFiles); got != want {
+		t.Errorf("completion = %d%%, want %d%%", got, want)
+	}
+}
@@ -0,0 +1,340 @@
+package tools
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"l2/storage"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// cldfTerms is the namespace of the CLDF ontology
+const cldfTerms = "http://cldf.clld.org/v1.0/terms.rdf#"
+
+// CLDFMetadataFile is the metadata file that describes a CLDF Wordlist
+const CLDFMetadataFile = "Wordlist-metadata.json"
+
+// cldfColumn is a column of a CLDF table. Term is the CLDF property it holds,
+// empty for columns of L2's own.
+type cldfColumn struct {
+	Name      string
+	Term      string
+	Separator string // For list valued columns such as Segments
+}
+
+// cldfTable is one CSV file of the dataset and the component it conforms to
+type cldfTable struct {
+	URL       string
+	Component string
+	Columns   []cldfColumn
+	Foreign   map[string]string // Column to the table it references
+	Rows      [][]string
+}
+
+// cldfDataset is the wordlist of the language and its descendants
+type cldfDataset struct {
+	languages, parameters, forms, cognates, cognatesets *cldfTable
+	concepts                                            map[string]string // Definition to parameter ID
+}
+
+// WriteCLDF writes the lexicon, and every descendant language evolved from
+// it, as a CLDF Wordlist dataset in dir: a language table, a parameter table
+// of meanings, a form table and cognate sets linking each word with its
+// reflexes in the descendants. It returns the files written.
+func WriteCLDF(dir string) ([]string, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return nil, err
+	}
+	descendants, err := loadDescendants()
+	if err != nil {
+		return nil, err
+	}
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return nil, err
+	}
+
+	d := newCLDFDataset()
+	language := storage.Language()
+	languageIDs := map[string]string{"": cldfID(language)}
+	d.languages.Rows = append(d.languages.Rows, []string{cldfID(language), language, ""})
+	for _, descendant := range descendants {
+		languageIDs[strings.ToLower(descendant.Name)] = cldfID(descendant.Name)
+	}
+	for _, descendant := range descendants {
+		parent := languageIDs[strings.ToLower(descendant.Parent)]
+		d.languages.Rows = append(d.languages.Rows, []string{cldfID(descendant.Name), descendant.Name, parent})
+	}
+
+	// Each word of the proto-language starts a cognate set its reflexes join
+	// through their recorded ancestral form
+	lexicons := map[string][]LexiconEntry{"": entries}
+	for _, descendant := range descendants {
+		lexicons[strings.ToLower(descendant.Name)] = descendant.Entries
+	}
+	parents := map[string]string{}
+	for _, descendant := range descendants {
+		parents[strings.ToLower(descendant.Name)] = strings.ToLower(descendant.Parent)
+	}
+	protoSets := map[string]string{} // Proto-language word to its cognate set
+	var cognateset func(language, word string, depth int) string
+	cognateset = func(language, word string, depth int) string {
+		if language == "" {
+			return protoSets[strings.ToLower(word)]
+		}
+		// The depth guards against descendants listed as each other's parent
+		for _, entry := range lexicons[language] {
+			if strings.EqualFold(entry.Word, word) && strings.HasPrefix(entry.ProtoForm, "*") && depth < len(descendants) {
+				return cognateset(parents[language], strings.TrimPrefix(entry.ProtoForm, "*"), depth+1)
+			}
+		}
+		return ""
+	}
+
+	members := map[string]int{}
+	var forms [][]string
+	var cognates [][2]string // Form and cognate set
+	for _, language := range append([]string{""}, descendantKeys(descendants)...) {
+		languageID := languageIDs[language]
+		for i, entry := range lexicons[language] {
+			formID := fmt.Sprintf("%s-%d", languageID, i+1)
+			forms = append(forms, []string{
+				formID, languageID, d.concept(entry.Definition), entry.Word, entry.Word,
+				cldfSegments(set, entry), entry.PartOfSpeech, entry.Definition, EtymologyText(entry),
+			})
+			setID := ""
+			if language == "" {
+				setID = formID
+				protoSets[strings.ToLower(entry.Word)] = setID
+			} else if strings.HasPrefix(entry.ProtoForm, "*") {
+				setID = cognateset(parents[language], strings.TrimPrefix(entry.ProtoForm, "*"), 0)
+			}
+			if setID == "" {
+				continue
+			}
+			cognates = append(cognates, [2]string{formID, setID})
+			members[setID]++
+		}
+	}
+	d.forms.Rows = forms
+
+	// A set only the proto-language word belongs to says nothing
+	for i, entry := range entries {
+		setID := fmt.Sprintf("%s-%d", languageIDs[""], i+1)
+		if members[setID] > 1 {
+			d.cognatesets.Rows = append(d.cognatesets.Rows, []string{setID, "*" + entry.Word, entry.Definition})
+		}
+	}
+	for _, c := range cognates {
+		if members[c[1]] > 1 {
+			d.cognates.Rows = append(d.cognates.Rows, []string{strconv.Itoa(len(d.cognates.Rows) + 1), c[0], c[1]})
+		}
+	}
+	return d.write(dir, language)
+}
+
+func newCLDFDataset() *cldfDataset {
+	return &cldfDataset{
+		languages: &cldfTable{
+			URL:       "languages.csv",
+			Component: "LanguageTable",
+			Columns: []cldfColumn{
+				{Name: "ID", Term: "id"},
+				{Name: "Name", Term: "name"},
+				{Name: "Parent_ID"}, // The language it evolved from
+			},
+		},
+		parameters: &cldfTable{
+			URL:       "parameters.csv",
+			Component: "ParameterTable",
+			Columns:   []cldfColumn{{Name: "ID", Term: "id"}, {Name: "Name", Term: "name"}},
+		},
+		forms: &cldfTable{
+			URL:       "forms.csv",
+			Component: "FormTable",
+			Columns: []cldfColumn{
+				{Name: "ID", Term: "id"},
+				{Name: "Language_ID", Term: "languageReference"},
+				{Name: "Parameter_ID", Term: "parameterReference"},
+				{Name: "Value", Term: "value"},
+				{Name: "Form", Term: "form"},
+				{Name: "Segments", Term: "segments", Separator: " "},
+				{Name: "Part_Of_Speech"},
+				{Name: "Definition"},
+				{Name: "Comment", Term: "comment"},
+			},
+			Foreign: map[string]string{"Language_ID": "languages.csv", "Parameter_ID": "parameters.csv"},
+		},
+		cognates: &cldfTable{
+			URL:       "cognates.csv",
+			Component: "CognateTable",
+			Columns: []cldfColumn{
+				{Name: "ID", Term: "id"},
+				{Name: "Form_ID", Term: "formReference"},
+				{Name: "Cognateset_ID", Term: "cognatesetReference"},
+			},
+			Foreign: map[string]string{"Form_ID": "forms.csv", "Cognateset_ID": "cognatesets.csv"},
+		},
+		cognatesets: &cldfTable{
+			URL:       "cognatesets.csv",
+			Component: "CognatesetTable",
+			Columns: []cldfColumn{
+				{Name: "ID", Term: "id"},
+				{Name: "Name", Term: "name"},
+				{Name: "Description", Term: "description"},
+			},
+		},
+		concepts: map[string]string{},
+	}
+}
+
+// concept returns the parameter for a meaning, adding it on first use.
+// Descendants keep the meanings of their ancestors, so a reflex and its
+// source share a parameter.
+func (d *cldfDataset) concept(definition string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(definition), " "))
+	if id, ok := d.concepts[key]; ok {
+		return id
+	}
+	id := strconv.Itoa(len(d.concepts) + 1)
+	d.concepts[key] = id
+	d.parameters.Rows = append(d.parameters.Rows, []string{id, definition})
+	return id
+}
+
+// write saves the tables as CSV and describes them in the metadata file
+func (d *cldfDataset) write(dir, language string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	tables := []*cldfTable{d.languages, d.parameters, d.forms, d.cognatesets, d.cognates}
+	files := []string{}
+	described := []map[string]any{}
+	for _, table := range tables {
+		if err := table.writeCSV(filepath.Join(dir, table.URL)); err != nil {
+			return nil, err
+		}
+		files = append(files, table.URL)
+		described = append(described, table.schema())
+	}
+
+	metadata := map[string]any{
+		"@context":       []any{"http://www.w3.org/ns/csvw", map[string]string{"@language": "en"}},
+		"dc:conformsTo":  cldfTerms + "Wordlist",
+		"dc:title":       "The lexicon of " + language,
+		"dc:description": "A constructed language and the descendants evolved from it by sound change, exported from L2",
+		"dialect":        map[string]any{"commentPrefix": nil},
+		"rdf:ID":         cldfID(language),
+		"rdf:type":       "http://www.w3.org/ns/dcat#Distribution",
+		"tables":         described,
+	}
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, CLDFMetadataFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return append([]string{CLDFMetadataFile}, files...), nil
+}
+
+func (t *cldfTable) writeCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	header := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// schema describes the table in CSVW terms for the metadata file
+func (t *cldfTable) schema() map[string]any {
+	columns := []map[string]any{}
+	for _, column := range t.Columns {
+		c := map[string]any{"name": column.Name, "datatype": "string"}
+		if column.Term != "" {
+			c["propertyUrl"] = cldfTerms + column.Term
+		}
+		if column.Term == "id" {
+			c["required"] = true
+		}
+		if column.Separator != "" {
+			c["separator"] = column.Separator
+		}
+		columns = append(columns, c)
+	}
+	foreignKeys := []map[string]any{}
+	for _, column := range t.Columns {
+		if table, ok := t.Foreign[column.Name]; ok {
+			foreignKeys = append(foreignKeys, map[string]any{
+				"columnReference": []string{column.Name},
+				"reference":       map[string]any{"resource": table, "columnReference": []string{"ID"}},
+			})
+		}
+	}
+	tableSchema := map[string]any{"columns": columns, "primaryKey": []string{"ID"}}
+	if len(foreignKeys) > 0 {
+		tableSchema["foreignKeys"] = foreignKeys
+	}
+	return map[string]any{
+		"url":           t.URL,
+		"dc:conformsTo": cldfTerms + t.Component,
+		"tableSchema":   tableSchema,
+	}
+}
+
+// cldfSegments splits the pronunciation of an entry, or its spelling when it
+// has no IPA, into segments, with + between the words of a phrase
+func cldfSegments(set *phonemeSet, entry LexiconEntry) string {
+	form := entry.IPA
+	if form == "" {
+		form = strings.ToLower(entry.Word)
+	}
+	// Stress and syllable breaks aren't segments
+	form = strings.NewReplacer("ˈ", "", "ˌ", "", ".", "").Replace(strings.Trim(form, "/[]"))
+	words := []string{}
+	for _, word := range strings.Fields(form) {
+		words = append(words, strings.Join(set.segment(word), " "))
+	}
+	return strings.Join(words, " + ")
+}
+
+// cldfID turns a name into an identifier CLDF accepts: letters, digits,
+// underscores and hyphens
+func cldfID(name string) string {
+	id := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-') {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+	if id == "" {
+		return "language"
+	}
+	return id
+}
+
+// descendantKeys lists the descendants by lowercase name, as they're looked up
+func descendantKeys(descendants []DescendantLexicon) []string {
+	keys := []string{}
+	for _, descendant := range descendants {
+		keys = append(keys, strings.ToLower(descendant.Name))
+	}
+	return keys
+}
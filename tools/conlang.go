@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"l2/storage"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/cloudwego/eino/components/tool"
@@ -16,40 +17,53 @@ import (
 
 // PhonologyAnalysis represents a phonology analysis request
 type PhonologyAnalysis struct {
-	Text string `json:"text" jsonschema:"required,description=The text to analyze for phonology"`
+	Text     string   `json:"text" jsonschema:"required,description=The text to analyze for phonology"`
+	Template string   `json:"template" jsonschema:"description=Phonotactic syllable template using C, V and parentheses for optional segments, e.g. (C)(C)V(C); defaults to the declared phonotactics or (C)V(C)"`
+	Onsets   []string `json:"onsets" jsonschema:"description=Permitted onset clusters such as pr or st; when given, other multi-consonant onsets are split"`
 }
 
 // PhonologyResult represents the result of phonology analysis
 type PhonologyResult struct {
-	Success    bool     `json:"success"`
-	Message    string   `json:"message"`
-	Phonemes   []string `json:"phonemes,omitempty"`
-	Allophones []string `json:"allophones,omitempty"`
-	Syllables  []string `json:"syllables,omitempty"`
-	Analysis   string   `json:"analysis,omitempty"`
+	Success    bool              `json:"success"`
+	Message    string            `json:"message"`
+	Phonemes   []string          `json:"phonemes,omitempty"`
+	Allophones []string          `json:"allophones,omitempty"`
+	Syllables  []string          `json:"syllables,omitempty"`
+	Words      []SyllabifiedWord `json:"words,omitempty"`
+	Prosody    []ProsodicWord    `json:"prosody,omitempty"`
+	Warnings   []string          `json:"warnings,omitempty"`
+	Analysis   string            `json:"analysis,omitempty"`
 }
 
 // GrammarValidation represents a grammar validation request
 type GrammarValidation struct {
 	Text        string `json:"text" jsonschema:"required,description=The text to validate"`
-	GrammarFile string `json:"grammar_file" jsonschema:"description=Path to grammar rules file"`
+	GrammarFile string `json:"grammar_file" jsonschema:"description=Path to the PEG grammar rules file (defaults to grammar.peg)"`
 }
 
 // GrammarResult represents the result of grammar validation
 type GrammarResult struct {
-	Success     bool     `json:"success"`
-	Message     string   `json:"message"`
-	Valid       bool     `json:"valid"`
-	Errors      []string `json:"errors,omitempty"`
-	Suggestions []string `json:"suggestions,omitempty"`
+	Success     bool             `json:"success"`
+	Message     string           `json:"message"`
+	Valid       bool             `json:"valid"`
+	Errors      []string         `json:"errors,omitempty"`
+	Suggestions []string         `json:"suggestions,omitempty"`
+	Failures    []GrammarFailure `json:"failures,omitempty"`
 }
 
 // LexiconEntry represents a lexicon entry
 type LexiconEntry struct {
-	Word         string `json:"word" jsonschema:"required,description=The word to add to lexicon"`
-	Definition   string `json:"definition" jsonschema:"required,description=The definition of the word"`
-	PartOfSpeech string `json:"part_of_speech" jsonschema:"description=Part of speech"`
-	Etymology    string `json:"etymology" jsonschema:"description=Etymology of the word"`
+	Word         string   `json:"word" jsonschema:"required,description=The word to add to lexicon"`
+	Definition   string   `json:"definition" jsonschema:"required,description=The definition of the word"`
+	PartOfSpeech string   `json:"part_of_speech" jsonschema:"description=Part of speech"`
+	Etymology    string   `json:"etymology" jsonschema:"description=Free-text notes on the word's history"`
+	DerivedFrom  []string `json:"derived_from,omitempty" jsonschema:"description=Lexicon words this word was derived or compounded from"`
+	ProtoForm    string   `json:"proto_form,omitempty" jsonschema:"description=Reconstructed ancestral form such as *kana"`
+	BorrowedFrom string   `json:"borrowed_from,omitempty" jsonschema:"description=Language and form the word was borrowed from such as Vathi tesu"`
+	Tags         []string `json:"tags,omitempty" jsonschema:"description=Topic tags for the word such as body or nature"`
+	IPA          string   `json:"ipa,omitempty" jsonschema:"description=Pronunciation of the word in IPA"`
+	Root         string   `json:"root,omitempty" jsonschema:"description=The underived root of a derived word"`
+	Derivation   []string `json:"derivation,omitempty" jsonschema:"description=Derivational affixes and processes applied to the root in order"`
 }
 
 // LexiconResult represents the result of lexicon operations
@@ -68,14 +82,76 @@ func AnalyzePhonology(ctx context.Context, req *PhonologyAnalysis) (*PhonologyRe
 		}, nil
 	}
 
+	// Fall back to the declared phonotactics for anything not given
+	rules, err := storage.ReadPhonotactics()
+	if err != nil {
+		return &PhonologyResult{
+			Success: false,
+			Message: failure("read phonotactics", err),
+		}, nil
+	}
+	if req.Template == "" {
+		req.Template = rules.Template
+	}
+	if len(req.Onsets) == 0 {
+		req.Onsets = rules.Onsets
+	}
+
+	template, err := parseSyllableTemplate(req.Template, req.Onsets)
+	if err != nil {
+		return &PhonologyResult{
+			Success: false,
+			Message: "Invalid syllable template: " + err.Error(),
+		}, nil
+	}
+
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return &PhonologyResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	set := newPhonemeSet(inventory)
+	template.phonemes = set
+	supra := inventory.Suprasegmentals
+	tones := newToneSet(supra)
+
+	// Tone and stress marks are analyzed per syllable and stripped before
+	// the segments are identified
+	syllables := []string{}
+	words := []SyllabifiedWord{}
+	prosody := []ProsodicWord{}
+	warnings := []string{}
+	skeletons := []string{}
+	bare := []string{}
+	for _, word := range prosodicTokens(req.Text) {
+		w, prosodic, problems := prosodify(word, template, supra, tones)
+		words = append(words, w)
+		prosody = append(prosody, prosodic)
+		warnings = append(warnings, problems...)
+		bare = append(bare, w.Word)
+		syllables = append(syllables, w.Syllables...)
+		skeletons = append(skeletons, strings.Join(w.Syllables, ".")+" "+w.Skeleton)
+	}
+
 	// Basic phoneme extraction (this would be enhanced with actual IPA processing)
-	text := strings.ToLower(req.Text)
+	text := strings.Join(bare, " ")
 	phonemes := extractPhonemes(text)
+	if set != nil {
+		phonemes = set.phonemesOf(text)
+	}
 	allophones := extractAllophones(text)
-	syllables := extractSyllables(text)
 
-	analysis := fmt.Sprintf("Analyzed text: %s\nPhonemes: %v\nAllophones: %v\nSyllables: %v",
-		req.Text, phonemes, allophones, syllables)
+	analysis := fmt.Sprintf("Analyzed text: %s\nPhonemes: %v\nAllophones: %v\nSyllables (%s): %s",
+		req.Text, phonemes, allophones, template.source, strings.Join(skeletons, ", "))
+	if !supra.Empty() || hasProsody(prosody) {
+		rendered := make([]string, len(prosody))
+		for i, word := range prosody {
+			rendered[i] = renderProsody(word)
+		}
+		analysis += "\nProsody: " + strings.Join(rendered, " ")
+	}
 
 	return &PhonologyResult{
 		Success:    true,
@@ -83,11 +159,14 @@ func AnalyzePhonology(ctx context.Context, req *PhonologyAnalysis) (*PhonologyRe
 		Phonemes:   phonemes,
 		Allophones: allophones,
 		Syllables:  syllables,
+		Words:      words,
+		Prosody:    prosody,
+		Warnings:   warnings,
 		Analysis:   analysis,
 	}, nil
 }
 
-// ValidateGrammar validates text against grammar rules
+// ValidateGrammar parses text against the PEG grammar rules stored in a data file
 func ValidateGrammar(ctx context.Context, req *GrammarValidation) (*GrammarResult, error) {
 	if req.Text == "" {
 		return &GrammarResult{
@@ -96,41 +175,91 @@ func ValidateGrammar(ctx context.Context, req *GrammarValidation) (*GrammarResul
 		}, nil
 	}
 
-	// Load grammar rules if specified
-	if req.GrammarFile != "" {
-		_, err := storage.ReadDataFile(req.GrammarFile)
-		if err != nil {
-			return &GrammarResult{
-				Success: false,
-				Message: "Failed to load grammar rules: " + err.Error(),
-			}, nil
-		}
+	grammarFile := req.GrammarFile
+	if grammarFile == "" {
+		grammarFile = defaultGrammarFile
+	}
+	source, err := storage.ReadDataFile(grammarFile)
+	if err != nil {
+		return &GrammarResult{
+			Success: false,
+			Message: failure("load grammar rules from "+grammarFile+" (write rules there with add_file first)", err),
+		}, nil
 	}
 
-	// Basic validation (this would be enhanced with actual grammar checking)
-	errors := []string{}
-	suggestions := []string{}
-
-	// Simple checks
-	if len(strings.Split(req.Text, " ")) < 2 {
-		errors = append(errors, "Text appears to be too short for meaningful grammar validation")
+	grammar, err := parseGrammar(string(source))
+	if err != nil {
+		return &GrammarResult{
+			Success: false,
+			Message: "Invalid grammar in " + grammarFile + ": " + err.Error(),
+		}, nil
 	}
 
-	if !strings.HasSuffix(req.Text, ".") && !strings.HasSuffix(req.Text, "!") && !strings.HasSuffix(req.Text, "?") {
-		suggestions = append(suggestions, "Consider adding proper sentence termination")
+	entries, err := loadLexicon()
+	if err != nil {
+		return &GrammarResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
 	}
 
-	valid := len(errors) == 0
+	tokens := tokenizeSentence(req.Text)
+	failure := grammar.parse(tokens, entries)
+	if failure == nil {
+		return &GrammarResult{
+			Success: true,
+			Message: "Text is grammatical according to " + grammarFile,
+			Valid:   true,
+		}, nil
+	}
 
 	return &GrammarResult{
 		Success:     true,
 		Message:     "Grammar validation completed",
-		Valid:       valid,
-		Errors:      errors,
-		Suggestions: suggestions,
+		Valid:       false,
+		Errors:      []string{describeGrammarFailure(failure)},
+		Suggestions: grammarSuggestions(failure, entries),
+		Failures:    []GrammarFailure{*failure},
 	}, nil
 }
 
+// describeGrammarFailure explains a parse failure in one sentence
+func describeGrammarFailure(f *GrammarFailure) string {
+	expected := strings.Join(f.Expected, " or ")
+	if f.Position < 0 {
+		return "The grammar could not be applied; check for left-recursive rules"
+	}
+	if f.Token == "" {
+		return fmt.Sprintf("Rule %s: the sentence ends early at word %d, expected %s", f.Rule, f.Position+1, expected)
+	}
+	return fmt.Sprintf("Rule %s: unexpected %q at word %d, expected %s", f.Rule, f.Token, f.Position+1, expected)
+}
+
+// grammarSuggestions proposes fixes for a parse failure
+func grammarSuggestions(f *GrammarFailure, entries []LexiconEntry) []string {
+	suggestions := []string{}
+	if f.Token == "" {
+		return suggestions
+	}
+
+	for _, e := range f.Expected {
+		if literal := strings.Trim(e, `"`); literal != e && editDistance(f.Token, literal) <= maxSuggestionDistance {
+			suggestions = append(suggestions, fmt.Sprintf("Did you mean %q instead of %q?", literal, f.Token))
+		}
+	}
+
+	checker := newSpellchecker(entries)
+	if strings.ContainsAny(f.Token, ".,!?;:") || checker.known(f.Token) {
+		return suggestions
+	}
+	if nearest := checker.suggest(f.Token); len(nearest) > 0 {
+		suggestions = append(suggestions, fmt.Sprintf("%q is not in the lexicon; did you mean %s?", f.Token, strings.Join(nearest, ", ")))
+	} else {
+		suggestions = append(suggestions, fmt.Sprintf("%q is not in the lexicon, so part-of-speech rules cannot match it", f.Token))
+	}
+	return suggestions
+}
+
 // AddLexiconEntry adds a word to the lexicon
 func AddLexiconEntry(ctx context.Context, entry *LexiconEntry) (*LexiconResult, error) {
 	if entry.Word == "" {
@@ -147,16 +276,20 @@ func AddLexiconEntry(ctx context.Context, entry *LexiconEntry) (*LexiconResult,
 		}, nil
 	}
 
-	// Load existing lexicon
-	lexiconPath := "lexicon.json"
-	data, err := storage.ReadDataFile(lexiconPath)
-	entries := []LexiconEntry{}
+	pos, err := normalizePartOfSpeech(entry.PartOfSpeech)
+	if err != nil {
+		return &LexiconResult{
+			Success: false,
+			Message: "Invalid part of speech: " + err.Error(),
+		}, nil
+	}
+	entry.PartOfSpeech = pos
 
-	if err == nil {
-		// Parse existing entries
-		if err := json.Unmarshal(data, &entries); err != nil {
-			log.Printf("Failed to parse existing lexicon: %v", err)
-		}
+	defer storage.BeginDataUpdate(lexiconFile)()
+	entries, err := loadLexicon()
+	if err != nil {
+		log.Printf("Failed to parse existing lexicon: %v", err)
+		entries = []LexiconEntry{}
 	}
 
 	// Check for duplicates
@@ -168,23 +301,22 @@ func AddLexiconEntry(ctx context.Context, entry *LexiconEntry) (*LexiconResult,
 			}, nil
 		}
 	}
+	for _, source := range entry.DerivedFrom {
+		if _, ok := findEntry(entries, source); !ok {
+			return &LexiconResult{
+				Success: false,
+				Message: fmt.Sprintf("%s derives from %s, which is not in the lexicon", entry.Word, source),
+			}, nil
+		}
+	}
 
 	// Add new entry
 	entries = append(entries, *entry)
 
-	// Save updated lexicon
-	lexiconData, err := json.MarshalIndent(entries, "", "  ")
-	if err != nil {
+	if err := saveLexicon(entries); err != nil {
 		return &LexiconResult{
 			Success: false,
-			Message: "Failed to serialize lexicon: " + err.Error(),
-		}, nil
-	}
-
-	if err := storage.WriteDataFile(lexiconPath, lexiconData); err != nil {
-		return &LexiconResult{
-			Success: false,
-			Message: "Failed to save lexicon: " + err.Error(),
+			Message: failure("save lexicon", err),
 		}, nil
 	}
 
@@ -202,20 +334,19 @@ type GetLexiconRequest struct {
 
 // GetLexicon retrieves all lexicon entries
 func GetLexicon(ctx context.Context, req *GetLexiconRequest) (*LexiconResult, error) {
-	lexiconPath := "lexicon.json"
-	data, err := storage.ReadDataFile(lexiconPath)
+	data, err := storage.ReadDataFile(lexiconFile)
 	if err != nil {
 		return &LexiconResult{
 			Success: false,
-			Message: "Failed to read lexicon: " + err.Error(),
+			Message: failure("read lexicon", err),
 		}, nil
 	}
 
 	var entries []LexiconEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
+	if err := storage.DecodeDataFile(lexiconFile, data, &entries); err != nil {
 		return &LexiconResult{
 			Success: false,
-			Message: "Failed to parse lexicon: " + err.Error(),
+			Message: failure("parse lexicon", err),
 		}, nil
 	}
 
@@ -226,6 +357,39 @@ func GetLexicon(ctx context.Context, req *GetLexiconRequest) (*LexiconResult, er
 	}, nil
 }
 
+// lexiconFile is the data file holding the lexicon
+const lexiconFile = "lexicon.json"
+
+// loadLexicon reads the lexicon, returning an empty lexicon if none has been saved yet
+func loadLexicon() ([]LexiconEntry, error) {
+	entries := []LexiconEntry{}
+	data, err := storage.ReadDataFile(lexiconFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	if err := storage.DecodeDataFile(lexiconFile, data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// LoadLexicon returns every lexicon entry, for callers outside the tool layer
+func LoadLexicon() ([]LexiconEntry, error) {
+	return loadLexicon()
+}
+
+// saveLexicon writes the lexicon back to the data directory
+func saveLexicon(entries []LexiconEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize lexicon: %w", err)
+	}
+	return storage.WriteDataFile(lexiconFile, data)
+}
+
 // Helper functions for phonology analysis
 func extractPhonemes(text string) []string {
 	// Simplified phoneme extraction - in practice, this would use IPA analysis
@@ -249,23 +413,11 @@ func extractAllophones(text string) []string {
 	return allophones
 }
 
-func extractSyllables(text string) []string {
-	// Simplified syllable extraction
-	words := strings.Fields(text)
-	syllables := []string{}
-	for _, word := range words {
-		if len(word) > 0 {
-			syllables = append(syllables, word)
-		}
-	}
-	return syllables
-}
-
 // createPhonologyTool creates the phonology analysis tool
 func createPhonologyTool() (tool.InvokableTool, error) {
 	return utils.InferTool(
 		"analyze_phonology",
-		"Analyze the phonology of text using IPA notation. Extract phonemes, allophones, and syllable structure for conlang development.",
+		"Analyze the phonology of text using IPA notation. Extract phonemes and allophones, and syllabify each word by onset maximization against a phonotactic template such as (C)(C)V(C), returning syllable boundaries, CV skeletons and template violations. Tone diacritics, Chao tone letters, stress marks (ˈ ˌ) and length (ː) are analyzed per syllable against the declared suprasegmentals.",
 		AnalyzePhonology,
 	)
 }
@@ -274,17 +426,36 @@ func createPhonologyTool() (tool.InvokableTool, error) {
 func createGrammarTool() (tool.InvokableTool, error) {
 	return utils.InferTool(
 		"validate_grammar",
-		"Validate text against grammar rules. Check syntax, morphology, and provide suggestions for conlang grammar development.",
+		"Parse text against PEG grammar rules stored in a data file (grammar.peg by default) and report which rule failed at which word, with suggestions. "+
+			"Rules are written one per line as Name <- expression, the first rule is the start rule; \"word\" matches a literal, @noun matches any lexicon word with that part of speech, . matches any word, / separates alternatives, and ? * + ( ) work as usual.",
 		ValidateGrammar,
 	)
 }
 
 // createAddLexiconTool creates the add lexicon entry tool
 func createAddLexiconTool() (tool.InvokableTool, error) {
-	return utils.InferTool(
+	return createLexiconEntryTool(
 		"add_lexicon_entry",
 		"Add a word to the conlang lexicon with definition, part of speech, and etymology information.",
 		AddLexiconEntry,
+		map[string]*schema.ParameterInfo{
+			"word":       {Type: schema.String, Desc: "The word to add to lexicon", Required: true},
+			"definition": {Type: schema.String, Desc: "The definition of the word", Required: true},
+			"etymology":  {Type: schema.String, Desc: "Free-text notes on the word's history"},
+			"derived_from": {
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Desc:     "Lexicon words this word was derived or compounded from",
+			},
+			"proto_form":    {Type: schema.String, Desc: "Reconstructed ancestral form such as *kana"},
+			"borrowed_from": {Type: schema.String, Desc: "Language and form the word was borrowed from such as Vathi tesu"},
+			"tags": {
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Desc:     "Topic tags for the word such as body or nature",
+			},
+			"ipa": {Type: schema.String, Desc: "Pronunciation of the word in IPA"},
+		},
 	)
 }
 
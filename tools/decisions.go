@@ -0,0 +1,534 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// DefaultSketchFile is the data file the grammar sketch is exported to
+const DefaultSketchFile = "grammar_sketch.md"
+
+// sketchSections are the grammar sketch's sections in order. Decisions
+// filed under other sections follow them.
+var sketchSections = []string{"phonology", "morphology", "syntax", "lexicon", "writing"}
+
+// CitationRequest cites a source for a decision or grammar section
+type CitationRequest struct {
+	Source  string `json:"source" jsonschema:"required,description=The source: its ID from fetch_url or extract_document, a URL, a data file such as web/… or documents/…, or a title"`
+	Title   string `json:"title" jsonschema:"description=Title of a source not seen before"`
+	Locator string `json:"locator" jsonschema:"description=Page, chapter or heading cited such as Page 12"`
+	Quote   string `json:"quote" jsonschema:"description=Short passage supporting the decision"`
+}
+
+// RecordDecisionRequest represents a design decision to log
+type RecordDecisionRequest struct {
+	Section   string            `json:"section" jsonschema:"required,description=Grammar section the decision belongs to: phonology, morphology, syntax, lexicon, writing or another name"`
+	Decision  string            `json:"decision" jsonschema:"required,description=The decision in one sentence such as Nouns mark ergative case with -ka"`
+	Rationale string            `json:"rationale" jsonschema:"description=Why it was decided"`
+	Citations []CitationRequest `json:"citations" jsonschema:"description=Sources that informed the decision"`
+}
+
+// CiteSourceRequest represents a citation added to a section or an earlier decision
+type CiteSourceRequest struct {
+	CitationRequest
+	Section  string `json:"section" jsonschema:"description=Grammar section the source informs (defaults to the decision's section)"`
+	Decision int    `json:"decision" jsonschema:"description=ID of a logged decision the source supports"`
+}
+
+// ListDecisionsRequest represents a request to read the decision log
+type ListDecisionsRequest struct {
+	Section string `json:"section" jsonschema:"description=Only list decisions of this section"`
+}
+
+// ExportGrammarSketchRequest represents a request to export the grammar sketch
+type ExportGrammarSketchRequest struct {
+	Title string `json:"title" jsonschema:"description=Title of the sketch such as A Grammar of Vathi (default Grammar sketch)"`
+	Path  string `json:"path" jsonschema:"description=Data file to write (defaults to grammar_sketch.md)"`
+}
+
+// DecisionResult represents the result of decision log operations
+type DecisionResult struct {
+	Success  bool              `json:"success"`
+	Message  string            `json:"message"`
+	Decision *storage.Decision `json:"decision,omitempty"`
+	Sources  []storage.Source  `json:"sources,omitempty"`
+	Log      string            `json:"log,omitempty"`
+}
+
+// RecordDecision adds a decision and the sources behind it to the decision log
+func RecordDecision(ctx context.Context, req *RecordDecisionRequest) (*DecisionResult, error) {
+	section := normalizeSection(req.Section)
+	summary := strings.TrimSpace(req.Decision)
+	if section == "" || summary == "" {
+		return &DecisionResult{
+			Success: false,
+			Message: "A section and the decision are required",
+		}, nil
+	}
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return &DecisionResult{
+			Success: false,
+			Message: failure("read decision log", err),
+		}, nil
+	}
+
+	decision := storage.Decision{ID: 1, Section: section, Summary: summary, Rationale: strings.TrimSpace(req.Rationale), DecidedAt: time.Now()}
+	for _, d := range log.Decisions {
+		decision.ID = max(decision.ID, d.ID+1)
+	}
+	sources := []storage.Source{}
+	for _, c := range req.Citations {
+		source, err := citeSource(&log, c, section, decision.ID)
+		if err != nil {
+			return &DecisionResult{
+				Success: false,
+				Message: failure("cite source", err),
+			}, nil
+		}
+		sources = append(sources, source)
+	}
+	log.Decisions = append(log.Decisions, decision)
+	if err := storage.WriteDecisionLog(log); err != nil {
+		return &DecisionResult{
+			Success: false,
+			Message: failure("save decision log", err),
+		}, nil
+	}
+
+	return &DecisionResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Recorded %s decision #%d with %d citations", section, decision.ID, len(sources)),
+		Decision: &decision,
+		Sources:  sources,
+	}, nil
+}
+
+// CiteSource attaches a source to a grammar section or a logged decision
+func CiteSource(ctx context.Context, req *CiteSourceRequest) (*DecisionResult, error) {
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return &DecisionResult{
+			Success: false,
+			Message: failure("read decision log", err),
+		}, nil
+	}
+	section := normalizeSection(req.Section)
+	var decision *storage.Decision
+	if req.Decision != 0 {
+		i := slices.IndexFunc(log.Decisions, func(d storage.Decision) bool { return d.ID == req.Decision })
+		if i < 0 {
+			return &DecisionResult{
+				Success: false,
+				Message: fmt.Sprintf("No decision #%d in the decision log", req.Decision),
+			}, nil
+		}
+		decision = &log.Decisions[i]
+		section = decision.Section
+	}
+	if section == "" {
+		return &DecisionResult{
+			Success: false,
+			Message: "A section or a decision ID is required",
+		}, nil
+	}
+
+	source, err := citeSource(&log, req.CitationRequest, section, req.Decision)
+	if err != nil {
+		return &DecisionResult{
+			Success: false,
+			Message: failure("cite source", err),
+		}, nil
+	}
+	if err := storage.WriteDecisionLog(log); err != nil {
+		return &DecisionResult{
+			Success: false,
+			Message: failure("save decision log", err),
+		}, nil
+	}
+
+	message := fmt.Sprintf("Cited %s in %s", source.Title, section)
+	if decision != nil {
+		message = fmt.Sprintf("Cited %s for decision #%d", source.Title, decision.ID)
+	}
+	return &DecisionResult{
+		Success:  true,
+		Message:  message,
+		Decision: decision,
+		Sources:  []storage.Source{source},
+	}, nil
+}
+
+// ListDecisions renders the decision log with its citations
+func ListDecisions(ctx context.Context, req *ListDecisionsRequest) (*DecisionResult, error) {
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return &DecisionResult{
+			Success: false,
+			Message: failure("read decision log", err),
+		}, nil
+	}
+	section := normalizeSection(req.Section)
+	sections := decisionSections(log)
+	if section != "" {
+		sections = []string{section}
+	}
+
+	var out strings.Builder
+	count := 0
+	for _, s := range sections {
+		notes := renderDesignNotes(log, s)
+		if notes == "" {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("### %s\n\n%s\n", sectionTitle(s), notes))
+		count += len(sectionDecisions(log, s))
+	}
+	if out.Len() == 0 {
+		return &DecisionResult{
+			Success: true,
+			Message: "No decisions have been recorded yet",
+		}, nil
+	}
+	if references := renderReferences(log, sections); references != "" {
+		out.WriteString("### References\n\n" + references)
+	}
+	return &DecisionResult{
+		Success: true,
+		Message: fmt.Sprintf("%d decisions and %d sources", count, len(log.Sources)),
+		Log:     out.String(),
+	}, nil
+}
+
+// ExportGrammarSketch writes the stored design as a Markdown grammar sketch,
+// with the decisions of each section and a references section for the
+// sources cited
+func ExportGrammarSketch(ctx context.Context, req *ExportGrammarSketchRequest) (*ExportResult, error) {
+	sketch, err := renderGrammarSketch(orDefault(strings.TrimSpace(req.Title), "Grammar sketch"))
+	if err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("build grammar sketch", err),
+		}, nil
+	}
+	path := orDefault(req.Path, DefaultSketchFile)
+	if err := storage.WriteDataFile(path, []byte(sketch)); err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("write grammar sketch", err),
+		}, nil
+	}
+	return &ExportResult{
+		Success: true,
+		Message: fmt.Sprintf("Exported the grammar sketch to %s", path),
+		Path:    path,
+	}, nil
+}
+
+func renderGrammarSketch(title string) (string, error) {
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return "", err
+	}
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return "", err
+	}
+	phonotactics, err := storage.ReadPhonotactics()
+	if err != nil {
+		return "", err
+	}
+	tags, err := storage.ReadPartsOfSpeech()
+	if err != nil {
+		return "", err
+	}
+	affixes, err := storage.ReadAffixes()
+	if err != nil {
+		return "", err
+	}
+	syntax, err := storage.ReadSyntax()
+	if err != nil {
+		return "", err
+	}
+	orthography, err := storage.ReadOrthography()
+	if err != nil {
+		return "", err
+	}
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return "", err
+	}
+	entries, err := loadLexicon()
+	if err != nil {
+		return "", err
+	}
+
+	body := map[string]string{}
+	var phonology strings.Builder
+	if !inventory.Empty() || !inventory.Suprasegmentals.Empty() {
+		phonology.WriteString(strings.ReplaceAll(renderInventoryTable(inventory), "### ", "#### "))
+	}
+	if phonotactics.Template != "" {
+		phonology.WriteString(fmt.Sprintf("Syllables follow the template %s.", phonotactics.Template))
+		if len(phonotactics.Onsets) > 0 {
+			phonology.WriteString(fmt.Sprintf(" Permitted onset clusters: %s.", strings.Join(phonotactics.Onsets, ", ")))
+		}
+		if len(phonotactics.Codas) > 0 {
+			phonology.WriteString(fmt.Sprintf(" Permitted codas: %s.", strings.Join(phonotactics.Codas, ", ")))
+		}
+		phonology.WriteString("\n\n")
+	}
+	body["phonology"] = phonology.String()
+
+	var morphology strings.Builder
+	if len(tags) > 0 {
+		morphology.WriteString("#### Parts of speech\n\n" + renderPartsOfSpeech(tags) + "\n")
+	}
+	if len(affixes) > 0 {
+		morphology.WriteString("#### Affixes\n\n" + renderAffixTable(affixes) + "\n")
+	}
+	body["morphology"] = morphology.String()
+
+	if syntax.WordOrder != "" {
+		cases := []string{}
+		for _, c := range []struct{ role, label string }{
+			{"agent", syntax.AgentCase}, {"intransitive subject", syntax.SubjectCase},
+			{"patient", syntax.PatientCase}, {"recipient", syntax.RecipientCase},
+		} {
+			if c.label != "" {
+				cases = append(cases, fmt.Sprintf("%s %s", c.role, c.label))
+			}
+		}
+		body["syntax"] = fmt.Sprintf("The basic word order is %s.", syntax.WordOrder)
+		if len(cases) > 0 {
+			body["syntax"] += fmt.Sprintf(" Core arguments are marked as %s.", strings.Join(cases, ", "))
+		}
+		body["syntax"] += "\n\n"
+	}
+	if len(entries) > 0 {
+		body["lexicon"] = fmt.Sprintf("The lexicon has %d entries.\n\n", len(entries))
+	}
+	var writing strings.Builder
+	if len(orthography) > 0 {
+		writing.WriteString("#### Romanization\n\n| Grapheme | Phoneme |\n|---|---|\n")
+		for _, m := range orthography {
+			writing.WriteString(fmt.Sprintf("| %s | /%s/ |\n", m.Grapheme, m.Phoneme))
+		}
+		writing.WriteString("\n")
+	}
+	if len(glyphs) > 0 {
+		writing.WriteString("#### Native script\n\n" + renderGlyphTable(glyphs) + "\n")
+	}
+	body["writing"] = writing.String()
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("# %s\n\nExported %s\n\n", title, time.Now().Format("2006-01-02")))
+	sections := decisionSections(log)
+	for _, section := range sections {
+		notes := renderDesignNotes(log, section)
+		if body[section] == "" && notes == "" {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("## %s\n\n%s", sectionTitle(section), body[section]))
+		if notes != "" {
+			out.WriteString("### Design notes\n\n" + notes + "\n")
+		}
+	}
+	if references := renderReferences(log, sections); references != "" {
+		out.WriteString("## References\n\n" + references)
+	}
+	return out.String(), nil
+}
+
+// citeSource resolves the cited source, registering it if it is new, and
+// adds the citation to the log
+func citeSource(log *storage.DecisionLog, c CitationRequest, section string, decision int) (storage.Source, error) {
+	source, err := resolveSource(log, c.Source, c.Title)
+	if err != nil {
+		return source, err
+	}
+	log.Citations = append(log.Citations, storage.Citation{
+		Source:   source.ID,
+		Section:  section,
+		Decision: decision,
+		Locator:  strings.TrimSpace(c.Locator),
+		Quote:    strings.TrimSpace(c.Quote),
+	})
+	return source, nil
+}
+
+// resolveSource finds a cited source in the log. Data files written by
+// fetch_url and extract_document are registered with the title and origin
+// from their header; other URLs and paths are registered as given.
+func resolveSource(log *storage.DecisionLog, ref, title string) (storage.Source, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return storage.Source{}, fmt.Errorf("a source is required")
+	}
+	if source, ok := log.FindSource(ref); ok {
+		return source, nil
+	}
+	if data, err := storage.ReadDataFile(ref); err == nil {
+		header, origin := sourceHeader(string(data))
+		return log.AddSource(storage.Source{Title: orDefault(title, orDefault(header, ref)), Location: orDefault(origin, ref), Path: ref}), nil
+	}
+	if u, err := url.Parse(ref); err == nil && (u.Scheme == "http" || u.Scheme == "https") || strings.ContainsAny(ref, "/\\") {
+		return log.AddSource(storage.Source{Title: orDefault(strings.TrimSpace(title), ref), Location: ref}), nil
+	}
+	return storage.Source{}, fmt.Errorf("unknown source %q; cite a source ID, a URL or a data file", ref)
+}
+
+// sourceHeader reads the title and origin from the header of a fetched or
+// extracted data file
+func sourceHeader(text string) (title, origin string) {
+	for i, line := range strings.SplitN(text, "\n", 6) {
+		if i == 0 && strings.HasPrefix(line, "# ") {
+			title = strings.TrimPrefix(line, "# ")
+		}
+		if strings.HasPrefix(line, "Source: ") {
+			origin = strings.TrimPrefix(line, "Source: ")
+		}
+	}
+	return title, origin
+}
+
+// renderDesignNotes lists a section's decisions with their citations,
+// followed by the sources cited for the section as a whole
+func renderDesignNotes(log storage.DecisionLog, section string) string {
+	var out strings.Builder
+	for _, d := range sectionDecisions(log, section) {
+		out.WriteString(fmt.Sprintf("- **#%d** %s", d.ID, d.Summary))
+		if d.Rationale != "" {
+			out.WriteString(" — " + d.Rationale)
+		}
+		out.WriteString(renderCitationMarks(log, section, d.ID) + "\n")
+	}
+	if marks := renderCitationMarks(log, section, 0); marks != "" {
+		out.WriteString("- Sources for this section:" + marks + "\n")
+	}
+	return out.String()
+}
+
+// renderCitationMarks writes citations as reference numbers with their
+// locators, such as [2, Page 12]
+func renderCitationMarks(log storage.DecisionLog, section string, decision int) string {
+	var out strings.Builder
+	for _, c := range log.Citations {
+		if c.Section != section || c.Decision != decision {
+			continue
+		}
+		mark := fmt.Sprint(c.Source)
+		if c.Locator != "" {
+			mark += ", " + c.Locator
+		}
+		out.WriteString(" [" + mark + "]")
+		if c.Quote != "" {
+			out.WriteString(fmt.Sprintf(" (“%s”)", c.Quote))
+		}
+	}
+	return out.String()
+}
+
+// renderReferences lists the sources cited in the given sections by their
+// reference numbers
+func renderReferences(log storage.DecisionLog, sections []string) string {
+	cited := map[int]bool{}
+	for _, c := range log.Citations {
+		if slices.Contains(sections, c.Section) {
+			cited[c.Source] = true
+		}
+	}
+	var out strings.Builder
+	for _, s := range log.Sources {
+		if !cited[s.ID] {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("%d. %s. %s. Accessed %s.\n", s.ID, s.Title, s.Location, s.Accessed.Format("2006-01-02")))
+	}
+	return out.String()
+}
+
+// decisionSections returns the sketch sections followed by any other
+// sections the log files decisions or citations under
+func decisionSections(log storage.DecisionLog) []string {
+	sections := append([]string{}, sketchSections...)
+	extra := []string{}
+	for _, d := range log.Decisions {
+		if !slices.Contains(sections, d.Section) && !slices.Contains(extra, d.Section) {
+			extra = append(extra, d.Section)
+		}
+	}
+	for _, c := range log.Citations {
+		if !slices.Contains(sections, c.Section) && !slices.Contains(extra, c.Section) {
+			extra = append(extra, c.Section)
+		}
+	}
+	sort.Strings(extra)
+	return append(sections, extra...)
+}
+
+func sectionDecisions(log storage.DecisionLog, section string) []storage.Decision {
+	decisions := []storage.Decision{}
+	for _, d := range log.Decisions {
+		if d.Section == section {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions
+}
+
+func normalizeSection(section string) string {
+	return strings.ToLower(strings.TrimSpace(section))
+}
+
+func sectionTitle(section string) string {
+	if section == "" {
+		return section
+	}
+	return strings.ToUpper(section[:1]) + section[1:]
+}
+
+// createRecordDecisionTool creates the decision logging tool
+func createRecordDecisionTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"record_decision",
+		"Record a design decision in the decision log under a grammar section (phonology, morphology, syntax, lexicon, writing or another), with its rationale and citations of the web pages, PDFs or other sources that informed it.",
+		RecordDecision,
+	)
+}
+
+// createCiteSourceTool creates the citation tool
+func createCiteSourceTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"cite_source",
+		"Cite a source consulted during design, such as a page fetched with fetch_url or a document extracted with extract_document, for a grammar section or a logged decision, with the page or chapter and a short quote.",
+		CiteSource,
+	)
+}
+
+// createListDecisionsTool creates the decision log reading tool
+func createListDecisionsTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"list_decisions",
+		"List the recorded design decisions by grammar section with their citations and the references they cite.",
+		ListDecisions,
+	)
+}
+
+// createExportGrammarSketchTool creates the grammar sketch export tool
+func createExportGrammarSketchTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"export_grammar_sketch",
+		"Export a Markdown grammar sketch of the language: phoneme inventory and phonotactics, parts of speech and affixes, syntax, lexicon size, orthography and native script, with the design decisions of each section and a references section listing the cited sources.",
+		ExportGrammarSketch,
+	)
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// FindContentRequest represents a request to look up stored content by hash
+type FindContentRequest struct {
+	Content string `json:"content" jsonschema:"description=Content about to be written; leave empty for a report of all duplicated files"`
+}
+
+// FindContentResult represents the files matching some content, or the dedupe report
+type FindContentResult struct {
+	Success    bool                     `json:"success"`
+	Message    string                   `json:"message"`
+	Hash       string                   `json:"hash,omitempty"`
+	Matches    []string                 `json:"matches,omitempty"`
+	Duplicates []storage.DuplicateGroup `json:"duplicates,omitempty"`
+}
+
+// FindContent reports which data files already hold equivalent content. With
+// no content it reports every group of duplicated files instead.
+func FindContent(ctx context.Context, req *FindContentRequest) (*FindContentResult, error) {
+	if req.Content == "" {
+		groups, err := storage.FindDuplicates()
+		if err != nil {
+			return &FindContentResult{
+				Success: false,
+				Message: failure("scan data files", err),
+			}, nil
+		}
+		return &FindContentResult{
+			Success:    true,
+			Message:    DescribeDuplicates(groups),
+			Duplicates: groups,
+		}, nil
+	}
+
+	matches, err := storage.FindDataFilesByContent([]byte(req.Content))
+	if err != nil {
+		return &FindContentResult{
+			Success: false,
+			Message: failure("scan data files", err),
+		}, nil
+	}
+
+	message := "No stored file has this content"
+	if len(matches) > 0 {
+		message = "This content is already stored in " + strings.Join(matches, ", ")
+	}
+	return &FindContentResult{
+		Success: true,
+		Message: message,
+		Hash:    storage.HashContent([]byte(req.Content)),
+		Matches: matches,
+	}, nil
+}
+
+// DescribeDuplicates summarizes a dedupe report, one group per line
+func DescribeDuplicates(groups []storage.DuplicateGroup) string {
+	if len(groups) == 0 {
+		return "No duplicated files found"
+	}
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Found %d groups of duplicated files:", len(groups)))
+	for _, g := range groups {
+		out.WriteString(fmt.Sprintf("\n- %s (%d bytes, %s)", strings.Join(g.Paths, ", "), g.Size, g.Hash[:12]))
+	}
+	return out.String()
+}
+
+// createFindContentTool creates the content lookup tool
+func createFindContentTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"find_content",
+		"Check whether equivalent content is already stored in a data file before writing it, by content hash (ignoring line endings and trailing whitespace). Call with no content for a report of all duplicated files.",
+		FindContent,
+	)
+}
@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// derivationProcesses are the non-affixal derivations a process in the affix
+// store can name
+var derivationProcesses = map[string]func(stem string, phonemes *phonemeSet) string{
+	// Full reduplication copies the whole stem
+	"reduplication": func(stem string, phonemes *phonemeSet) string {
+		return stem + stem
+	},
+	// Initial reduplication copies everything up to the first vowel
+	"initial-reduplication": func(stem string, phonemes *phonemeSet) string {
+		segs := phonemes.segment(stem)
+		for i, seg := range segs {
+			if phonemes.vowel(seg) {
+				return strings.Join(segs[:i+1], "") + stem
+			}
+		}
+		return stem + stem
+	},
+	// Conversion changes the part of speech without changing the form
+	"conversion": func(stem string, phonemes *phonemeSet) string {
+		return stem
+	},
+}
+
+// DeriveRequest represents a request to derive a word from a root
+type DeriveRequest struct {
+	Root         string   `json:"root" jsonschema:"required,description=The lexicon word to derive from"`
+	Steps        []string `json:"steps" jsonschema:"required,description=Derivational affixes or processes from the affix store to apply in order, by form (-ri) or gloss (AGT)"`
+	Definition   string   `json:"definition" jsonschema:"description=Definition of the derived word; defaults to the glosses applied to the root"`
+	PartOfSpeech string   `json:"part_of_speech" jsonschema:"description=Part of speech of the derived word; defaults to what the last step yields"`
+	Preview      bool     `json:"preview" jsonschema:"description=Only show the derived word without adding it to the lexicon"`
+}
+
+// DeriveResult represents the result of a derivation
+type DeriveResult struct {
+	Success  bool          `json:"success"`
+	Message  string        `json:"message"`
+	Entry    *LexiconEntry `json:"entry,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// DeriveWord applies derivational affixes and processes to a lexicon root and
+// adds the result with its derivation chain, refusing derivations that
+// already exist
+func DeriveWord(ctx context.Context, req *DeriveRequest) (*DeriveResult, error) {
+	if req.Root == "" || len(req.Steps) == 0 {
+		return &DeriveResult{
+			Success: false,
+			Message: "A root and at least one derivational step are required",
+		}, nil
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return &DeriveResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	var root *LexiconEntry
+	for i := range entries {
+		if strings.EqualFold(entries[i].Word, req.Root) {
+			root = &entries[i]
+			break
+		}
+	}
+	if root == nil {
+		return &DeriveResult{
+			Success: false,
+			Message: fmt.Sprintf("%s is not in the lexicon", req.Root),
+		}, nil
+	}
+
+	store, err := loadAffixStore()
+	if err != nil {
+		return &DeriveResult{
+			Success: false,
+			Message: failure("read affixes", err),
+		}, nil
+	}
+
+	form := root.Word
+	pos := root.PartOfSpeech
+	glosses := []string{}
+	derivation := append([]string{}, root.Derivation...)
+	warnings := []string{}
+	for _, step := range req.Steps {
+		affix, ok := store.derivational(step)
+		if !ok {
+			return &DeriveResult{
+				Success: false,
+				Message: fmt.Sprintf("No derivational affix or process %s in the affix store", step),
+			}, nil
+		}
+		if len(affix.AttachesTo) > 0 && !slices.ContainsFunc(affix.AttachesTo, func(p string) bool { return strings.EqualFold(p, pos) }) {
+			warnings = append(warnings, fmt.Sprintf("%s attaches to %s, not %s", affix.Form, strings.Join(affix.AttachesTo, " or "), pos))
+		}
+		if affix.Productivity == storage.Unproductive {
+			warnings = append(warnings, fmt.Sprintf("%s is unproductive and not normally used for new words", affix.Form))
+		}
+
+		if process, ok := derivationProcesses[affix.Form]; ok && affix.Kind == storage.ProcessKind {
+			form = process(form, store.phonemes)
+		} else {
+			form = store.attach(form, affix.Form)
+		}
+		if affix.Yields != "" {
+			pos = affix.Yields
+		}
+		glosses = append(glosses, affix.Gloss)
+		derivation = append(derivation, affix.Form)
+	}
+
+	base := root.Word
+	if root.Root != "" {
+		base = root.Root
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Root, base) && slices.Equal(entry.Derivation, derivation) {
+			return &DeriveResult{
+				Success: false,
+				Message: fmt.Sprintf("%s already derives %s with %s", base, entry.Word, strings.Join(derivation, " ")),
+			}, nil
+		}
+		if strings.EqualFold(entry.Word, form) {
+			return &DeriveResult{
+				Success: false,
+				Message: fmt.Sprintf("%s is already in the lexicon meaning %q", entry.Word, entry.Definition),
+			}, nil
+		}
+	}
+
+	entry := LexiconEntry{
+		Word:         form,
+		Definition:   req.Definition,
+		PartOfSpeech: pos,
+		Etymology:    fmt.Sprintf("%s + %s", root.Word, strings.Join(derivation[len(root.Derivation):], " + ")),
+		Root:         base,
+		Derivation:   derivation,
+		DerivedFrom:  []string{root.Word},
+	}
+	if req.PartOfSpeech != "" {
+		entry.PartOfSpeech = req.PartOfSpeech
+	}
+	if entry.Definition == "" {
+		entry.Definition = fmt.Sprintf("%s of %s (%s)", strings.Join(glosses, "."), root.Word, root.Definition)
+	}
+
+	if req.Preview {
+		return &DeriveResult{
+			Success:  true,
+			Message:  fmt.Sprintf("%s would derive %s", root.Word, form),
+			Entry:    &entry,
+			Warnings: warnings,
+		}, nil
+	}
+
+	result, _ := AddLexiconEntry(ctx, &entry)
+	if !result.Success {
+		return &DeriveResult{
+			Success:  false,
+			Message:  result.Message,
+			Warnings: warnings,
+		}, nil
+	}
+	return &DeriveResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Derived %s from %s and added it to the lexicon", form, root.Word),
+		Entry:    &entry,
+		Warnings: warnings,
+	}, nil
+}
+
+// derivational returns the derivational affix or process with the given form or gloss
+func (s *affixStore) derivational(step string) (storage.Affix, bool) {
+	for _, affix := range s.affixes {
+		if affix.Derivational && (strings.EqualFold(affix.Form, step) || strings.EqualFold(affix.Gloss, step)) {
+			return affix, true
+		}
+	}
+	return storage.Affix{}, false
+}
+
+// createDeriveWordTool creates the derivation tool
+func createDeriveWordTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"derive_word",
+		"Derive a new word from a lexicon root by applying derivational affixes or processes (agentive, diminutive, nominalizer, reduplication) registered in the affix store, adding it to the lexicon with its derivation chain. Refuses derivations that already exist; use preview to check the form first.",
+		DeriveWord,
+	)
+}
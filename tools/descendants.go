@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"l2/storage"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// descendantsDir is the data directory descendant lexicons are written to
+const descendantsDir = "descendants"
+
+// DescendantLexicon is a daughter language evolved from the main lexicon or
+// from another descendant by an ordered sound change history
+type DescendantLexicon struct {
+	Name      string         `json:"name"`
+	Parent    string         `json:"parent,omitempty"` // Empty when evolved from the main lexicon
+	RulesFile string         `json:"rules_file"`
+	Entries   []LexiconEntry `json:"entries"`
+}
+
+// EvolveRequest represents a request to evolve a descendant language
+type EvolveRequest struct {
+	Name      string `json:"name" jsonschema:"required,description=Name of the descendant language such as Old Vathi"`
+	RulesFile string `json:"rules_file" jsonschema:"description=Data file with the ordered sound changes leading to it, one rule per line like k > tʃ / _i (default sound_changes.txt)"`
+	Parent    string `json:"parent" jsonschema:"description=Descendant language to evolve from instead of the main lexicon (the proto-language), for languages further down the family tree"`
+}
+
+// EvolveResult represents an evolved descendant lexicon
+type EvolveResult struct {
+	Success  bool     `json:"success"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path,omitempty"`
+	Changed  int      `json:"changed"`
+	Examples []string `json:"examples,omitempty"`
+	Mergers  []string `json:"mergers,omitempty"`
+}
+
+// LanguageFamilyRequest represents a request to show the language family
+type LanguageFamilyRequest struct {
+	// Empty struct for consistency with other tools
+}
+
+// LanguageFamilyResult represents the language family tree
+type LanguageFamilyResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Tree    string `json:"tree,omitempty"`
+}
+
+// EvolveLexicon runs a sound change history over the proto-lexicon, or over
+// a descendant's lexicon, and saves the result as a new descendant. Entries
+// keep their meanings and record their ancestral form as the proto-form.
+func EvolveLexicon(ctx context.Context, req *EvolveRequest) (*EvolveResult, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return &EvolveResult{
+			Success: false,
+			Message: "A name for the descendant language is required",
+		}, nil
+	}
+	rulesFile := req.RulesFile
+	if rulesFile == "" {
+		rulesFile = DefaultSoundChangeFile
+	}
+	if strings.EqualFold(req.Name, req.Parent) {
+		return &EvolveResult{
+			Success: false,
+			Message: "A language can't descend from itself",
+		}, nil
+	}
+
+	var ancestors []LexiconEntry
+	if req.Parent == "" {
+		entries, err := loadLexicon()
+		if err != nil {
+			return &EvolveResult{
+				Success: false,
+				Message: failure("read lexicon", err),
+			}, nil
+		}
+		ancestors = entries
+	} else {
+		parent, err := loadDescendant(req.Parent)
+		if err != nil {
+			return &EvolveResult{
+				Success: false,
+				Message: failure("read descendant "+req.Parent, err),
+			}, nil
+		}
+		ancestors = parent.Entries
+	}
+	if len(ancestors) == 0 {
+		return &EvolveResult{
+			Success: false,
+			Message: "The ancestral lexicon is empty",
+		}, nil
+	}
+
+	source, err := storage.ReadDataFile(rulesFile)
+	if err != nil {
+		return &EvolveResult{
+			Success: false,
+			Message: failure("read "+rulesFile+" (write one rule per line, e.g. k > tʃ / _i)", err),
+		}, nil
+	}
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return &EvolveResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	changer, err := parseSoundChanges(string(source), set)
+	if err != nil {
+		return &EvolveResult{
+			Success: false,
+			Message: "Invalid sound changes in " + rulesFile + ": " + err.Error(),
+		}, nil
+	}
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return &EvolveResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	spellings := symbolSpellings(inventory)
+
+	descendant := DescendantLexicon{Name: req.Name, Parent: req.Parent, RulesFile: rulesFile}
+	result := &EvolveResult{Success: true}
+	evolved := map[string]string{} // Ancestral word to its descendant
+	sources := map[string][]string{}
+	for i, ancestor := range ancestors {
+		if err := ctx.Err(); err != nil {
+			return &EvolveResult{
+				Success: false,
+				Message: "Evolution stopped: " + err.Error(),
+			}, nil
+		}
+		reportProgress(ctx, i, len(ancestors))
+		words, symbols := []string{}, []string{}
+		for _, word := range strings.Fields(ancestor.Word) {
+			changed := changer.apply(changer.symbols(word))
+			words = append(words, respell(changed, spellings))
+			symbols = append(symbols, strings.Join(changed, ""))
+		}
+
+		entry := LexiconEntry{
+			Word:         strings.Join(words, " "),
+			Definition:   ancestor.Definition,
+			PartOfSpeech: ancestor.PartOfSpeech,
+			Tags:         ancestor.Tags,
+			IPA:          strings.Join(symbols, " "),
+			ProtoForm:    "*" + ancestor.Word,
+		}
+		if entry.Word != strings.ToLower(ancestor.Word) {
+			result.Changed++
+			if len(result.Examples) < 5 {
+				result.Examples = append(result.Examples, ancestor.Word+" → "+entry.Word)
+			}
+		}
+		evolved[strings.ToLower(ancestor.Word)] = entry.Word
+		sources[entry.Word] = append(sources[entry.Word], ancestor.Word)
+		descendant.Entries = append(descendant.Entries, entry)
+	}
+	// Derivations carry over to the descendant forms of their sources
+	for i, ancestor := range ancestors {
+		for _, from := range ancestor.DerivedFrom {
+			if form, ok := evolved[strings.ToLower(from)]; ok {
+				descendant.Entries[i].DerivedFrom = append(descendant.Entries[i].DerivedFrom, form)
+			}
+		}
+	}
+	forms := make([]string, 0, len(sources))
+	for form := range sources {
+		forms = append(forms, form)
+	}
+	sort.Strings(forms)
+	for _, form := range forms {
+		if len(sources[form]) > 1 {
+			result.Mergers = append(result.Mergers, strings.Join(sources[form], ", ")+" → "+form)
+		}
+	}
+
+	data, err := json.MarshalIndent(descendant, "", "  ")
+	if err != nil {
+		return &EvolveResult{
+			Success: false,
+			Message: failure("serialize descendant lexicon", err),
+		}, nil
+	}
+	result.Path = descendantPath(req.Name)
+	if err := storage.WriteDataFile(result.Path, data); err != nil {
+		return &EvolveResult{
+			Success: false,
+			Message: failure("save descendant lexicon", err),
+		}, nil
+	}
+
+	origin := "the proto-language"
+	if req.Parent != "" {
+		origin = req.Parent
+	}
+	result.Message = fmt.Sprintf("Evolved %d words of %s into %s with %d rules; %d changed and %d pairs or groups merged",
+		len(descendant.Entries), origin, req.Name, len(changer.rules), result.Changed, len(result.Mergers))
+	return result, nil
+}
+
+// LanguageFamily renders the proto-language and its descendants as a tree
+func LanguageFamily(ctx context.Context, req *LanguageFamilyRequest) (*LanguageFamilyResult, error) {
+	descendants, err := loadDescendants()
+	if err != nil {
+		return &LanguageFamilyResult{
+			Success: false,
+			Message: failure("read descendant languages", err),
+		}, nil
+	}
+	if len(descendants) == 0 {
+		return &LanguageFamilyResult{
+			Success: true,
+			Message: "No descendant languages yet; create one with evolve_lexicon",
+		}, nil
+	}
+
+	children := map[string][]DescendantLexicon{}
+	for _, d := range descendants {
+		children[strings.ToLower(d.Parent)] = append(children[strings.ToLower(d.Parent)], d)
+	}
+	var tree strings.Builder
+	tree.WriteString("Proto-language (main lexicon)\n")
+	var write func(parent, indent string)
+	write = func(parent, indent string) {
+		for i, d := range children[strings.ToLower(parent)] {
+			connector, next := "├── ", "│   "
+			if i == len(children[strings.ToLower(parent)])-1 {
+				connector, next = "└── ", "    "
+			}
+			tree.WriteString(fmt.Sprintf("%s%s%s (%d words, %s)\n", indent, connector, d.Name, len(d.Entries), d.RulesFile))
+			write(d.Name, indent+next)
+		}
+	}
+	write("", "")
+
+	return &LanguageFamilyResult{
+		Success: true,
+		Message: fmt.Sprintf("%d descendant languages", len(descendants)),
+		Tree:    tree.String(),
+	}, nil
+}
+
+// descendantPath is the data file a descendant language is saved to
+func descendantPath(name string) string {
+	return path.Join(descendantsDir, fileSlug(name)+".json")
+}
+
+// fileSlug turns a name into a file name of lowercase letters, digits and
+// underscores
+func fileSlug(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+}
+
+func loadDescendant(name string) (DescendantLexicon, error) {
+	var d DescendantLexicon
+	data, err := storage.ReadDataFile(descendantPath(name))
+	if err != nil {
+		return d, err
+	}
+	err = storage.DecodeDataFile(descendantPath(name), data, &d)
+	return d, err
+}
+
+// loadDescendants reads every saved descendant language, sorted by name
+func loadDescendants() ([]DescendantLexicon, error) {
+	files, err := storage.ListDataFiles()
+	if err != nil {
+		return nil, err
+	}
+	descendants := []DescendantLexicon{}
+	for _, file := range files {
+		if path.Dir(filepath.ToSlash(file)) != descendantsDir || path.Ext(file) != ".json" {
+			continue
+		}
+		data, err := storage.ReadDataFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var d DescendantLexicon
+		if err := storage.DecodeDataFile(file, data, &d); err != nil {
+			return nil, err
+		}
+		descendants = append(descendants, d)
+	}
+	sort.Slice(descendants, func(i, j int) bool { return descendants[i].Name < descendants[j].Name })
+	return descendants, nil
+}
+
+// createEvolveLexiconTool creates the descendant language tool
+func createEvolveLexiconTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"evolve_lexicon",
+		"Create a descendant language by running an ordered sound change history (a data file of rules) over the main lexicon as the proto-language, or over another descendant. The daughter lexicon is saved as a separate data file under descendants/, keeping meanings and recording each word's ancestral form, and the result reports sound mergers.",
+		EvolveLexicon,
+	)
+}
+
+// createLanguageFamilyTool creates the language family tool
+func createLanguageFamilyTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"language_family",
+		"Show the proto-language and its descendant languages as a family tree.",
+		LanguageFamily,
+	)
+}
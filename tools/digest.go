@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"l2/storage"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultDigestDays is the period a digest covers unless told otherwise
+const DefaultDigestDays = 7
+
+// Digest is what happened to the language over a period, gathered from the
+// session log and the decision log
+type Digest struct {
+	Language  string
+	From      time.Time
+	To        time.Time
+	Logged    bool                     // Whether sessions are being logged, without which words and sessions are unknown
+	Sessions  []storage.SessionSummary // Sessions that ended in the period
+	NewWords  []LexiconEntry           // Words added in the period that are still in the lexicon
+	Decisions []storage.Decision       // Decisions recorded in the period
+	Files     []string                 // Files the sessions changed
+	Words     int                      // Size of the lexicon now
+}
+
+// BuildDigest gathers the sessions, new words and decisions between from and to
+func BuildDigest(from, to time.Time) (Digest, error) {
+	d := Digest{Language: storage.Language(), From: from, To: to}
+	if settings, err := storage.ReadConfig(); err == nil {
+		d.Logged = settings.LogSessions
+	}
+
+	sessions, err := storage.ReadSessions()
+	if err != nil {
+		return d, err
+	}
+	added := []string{}
+	for _, s := range sessions {
+		if s.Ended.Before(from) || s.Ended.After(to) {
+			continue
+		}
+		d.Sessions = append(d.Sessions, s)
+		for _, word := range s.WordsAdded {
+			if !slices.Contains(added, word) {
+				added = append(added, word)
+			}
+		}
+		added = slices.DeleteFunc(added, func(word string) bool { return slices.Contains(s.WordsRemoved, word) })
+		for _, file := range s.FilesChanged {
+			if !slices.Contains(d.Files, file) {
+				d.Files = append(d.Files, file)
+			}
+		}
+	}
+	slices.Sort(d.Files)
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return d, err
+	}
+	d.Words = len(entries)
+	for _, word := range added {
+		if i := slices.IndexFunc(entries, func(e LexiconEntry) bool { return e.Word == word }); i >= 0 {
+			d.NewWords = append(d.NewWords, entries[i])
+		}
+	}
+
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return d, err
+	}
+	for _, decision := range log.Decisions {
+		if !decision.DecidedAt.Before(from) && !decision.DecidedAt.After(to) {
+			d.Decisions = append(d.Decisions, decision)
+		}
+	}
+	return d, nil
+}
+
+// Title names the language and the period the digest covers
+func (d Digest) Title() string {
+	return fmt.Sprintf("L2 digest for %s, %s to %s", d.Language, d.From.Format("Jan 2"), d.To.Format("Jan 2, 2006"))
+}
+
+// Markdown renders the digest as a Markdown document
+func (d Digest) Markdown() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s\n\n", d.Title())
+
+	var duration time.Duration
+	tokens, cost := 0, 0.0
+	for _, s := range d.Sessions {
+		duration += s.Ended.Sub(s.Started)
+		tokens += s.Tokens
+		cost += s.Cost
+	}
+	fmt.Fprintf(&out, "- **%s**, %s, %d tokens", pluralize(len(d.Sessions), "session"), fmt.Sprintf("%dh%02dm", int(duration.Hours()), int(duration.Minutes())%60), tokens)
+	if cost > 0 {
+		fmt.Fprintf(&out, " (about $%.2f)", cost)
+	}
+	fmt.Fprintf(&out, "\n- **%s**; the lexicon has %d\n", pluralize(len(d.NewWords), "new word"), d.Words)
+	fmt.Fprintf(&out, "- **%s** recorded\n", pluralize(len(d.Decisions), "decision"))
+	if !d.Logged && len(d.Sessions) == 0 {
+		out.WriteString("\n> Sessions aren't being logged, so sessions and new words are missing. Run `/sessionlog on` in the chat to include them in future digests.\n")
+	}
+
+	if len(d.NewWords) > 0 {
+		out.WriteString("\n## New vocabulary\n\n| Word | Part of speech | Definition |\n|---|---|---|\n")
+		for _, e := range d.NewWords {
+			fmt.Fprintf(&out, "| %s | %s | %s |\n", tableCell(e.Word), tableCell(e.PartOfSpeech), tableCell(e.Definition))
+		}
+	}
+	if len(d.Decisions) > 0 {
+		out.WriteString("\n## Decisions\n\n")
+		for _, decision := range d.Decisions {
+			fmt.Fprintf(&out, "- **%s** (%s): %s", decision.Section, decision.DecidedAt.Format("Jan 2"), decision.Summary)
+			if decision.Rationale != "" {
+				fmt.Fprintf(&out, " *%s*", decision.Rationale)
+			}
+			out.WriteString("\n")
+		}
+	}
+	if len(d.Files) > 0 {
+		out.WriteString("\n## Files changed\n\n")
+		for _, file := range d.Files {
+			out.WriteString("- " + file + "\n")
+		}
+	}
+	return out.String()
+}
+
+// pluralize gives a number of things, such as "1 session" or "3 sessions"
+func pluralize(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// tableCell keeps a value on one line of a Markdown table
+func tableCell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+}
+
+// PostDigest posts the digest to a webhook as JSON {"text": ...}
+func PostDigest(ctx context.Context, url, markdown string) error {
+	body, err := json.Marshal(map[string]string{"text": markdown})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("the webhook returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// EmailDigest mails the digest as Markdown text through the configured mail
+// server, logging in with SMTP_USER and SMTP_PASSWORD
+func EmailDigest(settings storage.DigestConfig, to, subject, markdown string) error {
+	if settings.SMTP == "" {
+		return fmt.Errorf(`mailing the digest needs a mail server: set "digest": {"smtp": "host:port"} in config.json`)
+	}
+	host, _, err := net.SplitHostPort(settings.SMTP)
+	if err != nil {
+		return fmt.Errorf("the mail server should be host:port: %w", err)
+	}
+	user := os.Getenv("SMTP_USER")
+	from := settings.From
+	if from == "" {
+		from = user
+	}
+	if from == "" {
+		return fmt.Errorf(`mailing the digest needs a sender: set "from" under "digest" in config.json or SMTP_USER in .env`)
+	}
+
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/markdown; charset=UTF-8\r\n\r\n%s",
+		from, to, subject, strings.ReplaceAll(markdown, "\n", "\r\n"))
+	return smtp.SendMail(settings.SMTP, auth, from, []string{to}, []byte(message))
+}
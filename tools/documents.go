@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"l2/storage"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// documentsDir is the data directory extracted documents are written to
+const documentsDir = "documents"
+
+// documentPreviewChars is how much extracted text is returned as a preview
+const documentPreviewChars = 2000
+
+// ExtractDocumentRequest represents a request to extract a PDF or EPUB
+type ExtractDocumentRequest struct {
+	Path string `json:"path" jsonschema:"required,description=Local path of the PDF or EPUB file such as ~/papers/finnish-grammar.pdf"`
+	Name string `json:"name" jsonschema:"description=Data file name to save the text as (default documents/<file name>.md)"`
+}
+
+// ExtractDocumentResult represents an extracted document
+type ExtractDocumentResult struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	Path       string `json:"path,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Source     int    `json:"source,omitempty"` // Source ID for citations
+	Sections   int    `json:"sections"`         // Pages of a PDF or chapters of an EPUB
+	Characters int    `json:"characters"`
+	Preview    string `json:"preview,omitempty"`
+}
+
+// documentSection is a page or chapter of extracted text
+type documentSection struct {
+	heading string
+	text    string
+}
+
+// ExtractDocument extracts the text of a local PDF or EPUB into a data file,
+// marking every page or chapter with a heading so passages can be cited
+func ExtractDocument(ctx context.Context, req *ExtractDocumentRequest) (*ExtractDocumentResult, error) {
+	source := strings.TrimSpace(req.Path)
+	if strings.HasPrefix(source, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			source = filepath.Join(home, source[2:])
+		}
+	}
+
+	var title string
+	var sections []documentSection
+	var err error
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".pdf":
+		sections, err = extractPDF(ctx, source)
+	case ".epub":
+		title, sections, err = extractEPUB(source)
+	default:
+		return &ExtractDocumentResult{
+			Success: false,
+			Message: "Only .pdf and .epub files can be extracted",
+		}, nil
+	}
+	if err != nil {
+		return &ExtractDocumentResult{
+			Success: false,
+			Message: failure("extract "+source, err),
+		}, nil
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+
+	var text strings.Builder
+	characters := 0
+	for _, section := range sections {
+		text.WriteString(fmt.Sprintf("## %s\n\n%s\n\n", section.heading, section.text))
+		characters += len([]rune(section.text))
+	}
+	if characters == 0 {
+		return &ExtractDocumentResult{
+			Success: false,
+			Message: source + " has no extractable text; scanned documents need OCR first",
+		}, nil
+	}
+
+	name := req.Name
+	if name == "" {
+		name = path.Join(documentsDir, documentSlug(filepath.Base(source))+".md")
+	}
+	document := fmt.Sprintf("# %s\n\nSource: %s\nExtracted: %s\n\n%s", title, filepath.Base(source), time.Now().Format("2006-01-02"), text.String())
+	if err := storage.WriteDataFile(name, []byte(document)); err != nil {
+		return &ExtractDocumentResult{
+			Success: false,
+			Message: failure("save extracted text", err),
+		}, nil
+	}
+
+	message := fmt.Sprintf("Extracted %d sections of %s to %s; read it with read_file and cite passages by their section heading", len(sections), title, name)
+	sourceID := 0
+	if registered, err := storage.RegisterSource(storage.Source{Title: title, Location: source, Path: name}); err == nil {
+		sourceID = registered.ID
+		message += fmt.Sprintf(" as source %d", registered.ID)
+	}
+
+	preview := []rune(text.String())
+	if len(preview) > documentPreviewChars {
+		preview = preview[:documentPreviewChars]
+	}
+	return &ExtractDocumentResult{
+		Success:    true,
+		Message:    message,
+		Path:       name,
+		Title:      title,
+		Source:     sourceID,
+		Sections:   len(sections),
+		Characters: characters,
+		Preview:    string(preview),
+	}, nil
+}
+
+// extractPDF extracts the pages of a PDF with pdftotext from poppler, which
+// separates pages with form feeds
+func extractPDF(ctx context.Context, source string) ([]documentSection, error) {
+	if _, err := os.Stat(source); err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("pdftotext"); err != nil {
+		return nil, fmt.Errorf("PDF extraction needs pdftotext; install poppler (poppler-utils on Linux)")
+	}
+	out, err := exec.CommandContext(ctx, "pdftotext", "-enc", "UTF-8", "-layout", source, "-").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pdftotext failed: %w", err)
+	}
+
+	sections := []documentSection{}
+	for i, page := range strings.Split(strings.TrimRight(string(out), "\f"), "\f") {
+		lines := strings.Split(page, "\n")
+		for j, line := range lines {
+			lines[j] = strings.TrimRightFunc(line, unicode.IsSpace)
+		}
+		text := strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+		if text != "" {
+			sections = append(sections, documentSection{heading: fmt.Sprintf("Page %d", i+1), text: text})
+		}
+	}
+	return sections, nil
+}
+
+// epubPackage is the part of an EPUB's OPF package file needed to read its
+// chapters in order
+type epubPackage struct {
+	Title    string `xml:"metadata>title"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// extractEPUB extracts the chapters of an EPUB in reading order
+func extractEPUB(source string) (string, []documentSection, error) {
+	archive, err := zip.OpenReader(source)
+	if err != nil {
+		return "", nil, err
+	}
+	defer archive.Close()
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	read := func(name string) ([]byte, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is missing from the EPUB", name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+
+	data, err := read("META-INF/container.xml")
+	if err != nil {
+		return "", nil, err
+	}
+	var container struct {
+		Rootfiles []struct {
+			Path string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil || len(container.Rootfiles) == 0 {
+		return "", nil, fmt.Errorf("the EPUB has no package file")
+	}
+	opf := container.Rootfiles[0].Path
+	if data, err = read(opf); err != nil {
+		return "", nil, err
+	}
+	var pkg epubPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return "", nil, fmt.Errorf("invalid package file: %w", err)
+	}
+
+	hrefs := map[string]string{}
+	for _, item := range pkg.Manifest {
+		if strings.Contains(item.MediaType, "html") {
+			hrefs[item.ID] = item.Href
+		}
+	}
+	sections := []documentSection{}
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		// Manifest paths are relative to the package file
+		href, _, _ = strings.Cut(href, "#")
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		data, err := read(path.Join(path.Dir(opf), href))
+		if err != nil {
+			return "", nil, err
+		}
+		title, text := extractReadableText(string(data))
+		if text == "" {
+			continue
+		}
+		heading := fmt.Sprintf("Chapter %d", len(sections)+1)
+		if title != "" {
+			heading += ": " + title
+		}
+		sections = append(sections, documentSection{heading: heading, text: text})
+	}
+	return strings.TrimSpace(pkg.Title), sections, nil
+}
+
+// documentSlug turns a file name into a data file name
+func documentSlug(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, name), "_")
+}
+
+// createExtractDocumentTool creates the PDF and EPUB text extraction tool
+func createExtractDocumentTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"extract_document",
+		"Extract the text of a local PDF or EPUB (linguistics papers, natural language grammars) into a data file under documents/, with a heading for every page or chapter so the model can read it with read_file and cite passages by page or chapter.",
+		ExtractDocument,
+	)
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"errors"
+
+	"l2/storage"
+)
+
+// Remediation suggests how to get past a storage error, so the model can
+// retry differently and the user knows what to do. It returns "" for errors
+// without a known remedy.
+func Remediation(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrSandboxViolation):
+		return "Use a path relative to the data directory, without .. or a leading /."
+	case errors.Is(err, storage.ErrNotFound):
+		return "It hasn't been created yet or has another name; list the stored files to check."
+	case errors.Is(err, storage.ErrInvalidSchema):
+		return "The file was edited into a shape l2 can't read; fix it by hand, or restore the .bak copy next to it."
+	case errors.Is(err, storage.ErrReadOnly):
+		return "Changes can't be saved until the other l2 instance quits."
+	}
+	return ""
+}
+
+// failure describes a failed action with the error and its remedy, for the
+// message of a tool result
+func failure(action string, err error) string {
+	message := "Failed to " + action + ": " + err.Error()
+	if hint := Remediation(err); hint != "" {
+		message += ". " + hint
+	}
+	return message
+}
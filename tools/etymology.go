@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// EtymologyTreeRequest represents a request to render a word's etymology
+type EtymologyTreeRequest struct {
+	Word string `json:"word" jsonschema:"required,description=The lexicon word to trace"`
+}
+
+// EtymologyTreeResult represents a rendered etymology
+type EtymologyTreeResult struct {
+	Success     bool     `json:"success"`
+	Message     string   `json:"message"`
+	Tree        string   `json:"tree,omitempty"`
+	Descendants []string `json:"descendants,omitempty"`
+}
+
+// EtymologyTree renders the words a lexicon entry derives from, back to
+// their proto-forms and borrowing sources, and lists the words derived from it
+func EtymologyTree(ctx context.Context, req *EtymologyTreeRequest) (*EtymologyTreeResult, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return &EtymologyTreeResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	entry, ok := findEntry(entries, req.Word)
+	if !ok {
+		return &EtymologyTreeResult{
+			Success: false,
+			Message: fmt.Sprintf("%s is not in the lexicon", req.Word),
+		}, nil
+	}
+
+	var tree strings.Builder
+	tree.WriteString(etymologyLabel(entry) + "\n")
+	writeEtymologyBranches(&tree, entries, entry, "", map[string]bool{strings.ToLower(entry.Word): true})
+
+	descendants := []string{}
+	for _, e := range entries {
+		for _, source := range e.DerivedFrom {
+			if strings.EqualFold(source, entry.Word) {
+				descendants = append(descendants, e.Word)
+				break
+			}
+		}
+	}
+
+	return &EtymologyTreeResult{
+		Success:     true,
+		Message:     fmt.Sprintf("Etymology of %s, with %d words derived from it", entry.Word, len(descendants)),
+		Tree:        tree.String(),
+		Descendants: descendants,
+	}, nil
+}
+
+// writeEtymologyBranches writes the sources of an entry as indented branches.
+// Words already on the path are not followed again, so cyclic references
+// can't loop.
+func writeEtymologyBranches(out *strings.Builder, entries []LexiconEntry, entry LexiconEntry, indent string, path map[string]bool) {
+	branches := []string{}
+	sources := []*LexiconEntry{}
+	for _, source := range entry.DerivedFrom {
+		if e, ok := findEntry(entries, source); ok {
+			branches = append(branches, etymologyLabel(e))
+			sources = append(sources, &e)
+		} else {
+			branches = append(branches, source+" (not in lexicon)")
+			sources = append(sources, nil)
+		}
+	}
+	if entry.ProtoForm != "" {
+		branches = append(branches, "from "+entry.ProtoForm)
+		sources = append(sources, nil)
+	}
+	if entry.BorrowedFrom != "" {
+		branches = append(branches, "borrowed from "+entry.BorrowedFrom)
+		sources = append(sources, nil)
+	}
+
+	for i, branch := range branches {
+		connector, next := "├── ", "│   "
+		if i == len(branches)-1 {
+			connector, next = "└── ", "    "
+		}
+		source := sources[i]
+		if source != nil && path[strings.ToLower(source.Word)] {
+			branch += " (cycle)"
+			source = nil
+		}
+		out.WriteString(indent + connector + branch + "\n")
+		if source != nil {
+			path[strings.ToLower(source.Word)] = true
+			writeEtymologyBranches(out, entries, *source, indent+next, path)
+			delete(path, strings.ToLower(source.Word))
+		}
+	}
+}
+
+// etymologyLabel describes an entry in an etymology tree
+func etymologyLabel(entry LexiconEntry) string {
+	label := fmt.Sprintf("%s '%s'", entry.Word, entry.Definition)
+	if entry.PartOfSpeech != "" {
+		label += " (" + entry.PartOfSpeech + ")"
+	}
+	if len(entry.DerivedFrom) > 0 && len(entry.Derivation) > 0 {
+		label += " + " + entry.Derivation[len(entry.Derivation)-1]
+	}
+	return label
+}
+
+// EtymologyText describes where an entry comes from in one line, from its
+// sources, proto-form and borrowing followed by any free-text notes, for
+// outputs that only have room for a single etymology field
+func EtymologyText(entry LexiconEntry) string {
+	parts := []string{}
+	if len(entry.DerivedFrom) > 0 {
+		parts = append(parts, "from "+strings.Join(entry.DerivedFrom, " + "))
+	}
+	if entry.ProtoForm != "" {
+		parts = append(parts, "from "+entry.ProtoForm)
+	}
+	if entry.BorrowedFrom != "" {
+		parts = append(parts, "borrowed from "+entry.BorrowedFrom)
+	}
+	if notes := strings.TrimSpace(entry.Etymology); notes != "" {
+		parts = append(parts, notes)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// findEntry finds a lexicon entry by word, ignoring case
+func findEntry(entries []LexiconEntry, word string) (LexiconEntry, bool) {
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Word, word) {
+			return entry, true
+		}
+	}
+	return LexiconEntry{}, false
+}
+
+// createEtymologyTreeTool creates the etymology tree tool
+func createEtymologyTreeTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"etymology_tree",
+		"Render the etymology of a lexicon word as a tree: the words it was derived or compounded from, recursively, down to proto-forms and borrowing sources, plus the words derived from it.",
+		EtymologyTree,
+	)
+}
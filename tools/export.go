@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"l2/storage"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// DefaultExportColumns is the column order used when none is requested
+var DefaultExportColumns = []string{"word", "part_of_speech", "definition", "etymology", "tags"}
+
+// lexiconColumns maps export column names to the entry field they hold
+var lexiconColumns = map[string]func(LexiconEntry) string{
+	"word":           func(e LexiconEntry) string { return e.Word },
+	"definition":     func(e LexiconEntry) string { return e.Definition },
+	"part_of_speech": func(e LexiconEntry) string { return e.PartOfSpeech },
+	"etymology":      EtymologyText,
+	"tags":           func(e LexiconEntry) string { return strings.Join(e.Tags, ";") },
+	"ipa":            func(e LexiconEntry) string { return e.IPA },
+	"respelling": func(e LexiconEntry) string {
+		if e.IPA == "" {
+			return ""
+		}
+		return respellIPA(e.IPA)
+	},
+	// Filled in by WriteLexiconTable, since it needs the native script
+	"script": nil,
+}
+
+// ExportLexiconRequest represents a request to export the lexicon as a table
+type ExportLexiconRequest struct {
+	Path    string   `json:"path" jsonschema:"description=Data file path to write (defaults to lexicon.csv or lexicon.tsv)"`
+	Format  string   `json:"format" jsonschema:"description=Either csv or tsv (defaults to csv)"`
+	Columns []string `json:"columns" jsonschema:"description=Column order using word, ipa, respelling (English-friendly pronunciation from the IPA), script (the word in the native script), part_of_speech, definition, etymology and tags"`
+}
+
+// ExportResult represents the result of an export
+type ExportResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+// ExportLexicon writes the lexicon to a CSV or TSV data file
+func ExportLexicon(ctx context.Context, req *ExportLexiconRequest) (*ExportResult, error) {
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = "csv"
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLexiconTable(&buf, entries, format, req.Columns); err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("export lexicon", err),
+		}, nil
+	}
+
+	path := req.Path
+	if path == "" {
+		path = "lexicon." + format
+	}
+	if err := storage.WriteDataFile(path, buf.Bytes()); err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("write export", err),
+		}, nil
+	}
+
+	return &ExportResult{
+		Success: true,
+		Message: fmt.Sprintf("Exported %d lexicon entries to %s", len(entries), path),
+		Path:    path,
+	}, nil
+}
+
+// WriteLexiconTable writes entries as a CSV or TSV table with a header row.
+// An empty column list uses DefaultExportColumns.
+func WriteLexiconTable(w io.Writer, entries []LexiconEntry, format string, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultExportColumns
+	}
+	for _, column := range columns {
+		if _, ok := lexiconColumns[column]; !ok {
+			return fmt.Errorf("unknown column %q", column)
+		}
+	}
+	values := lexiconColumns
+	if slices.Contains(columns, "script") {
+		o, err := loadOrthography()
+		if err != nil {
+			return err
+		}
+		values = maps.Clone(lexiconColumns)
+		values["script"] = o.nativeSpelling
+	}
+
+	writer := csv.NewWriter(w)
+	switch format {
+	case "csv":
+	case "tsv":
+		writer.Comma = '\t'
+	default:
+		return fmt.Errorf("unknown format %q (expected csv or tsv)", format)
+	}
+
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = values[column](entry)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// createExportLexiconTool creates the export lexicon tool
+func createExportLexiconTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"export_lexicon",
+		"Export the conlang lexicon to a CSV or TSV file with a configurable column order, for use in spreadsheets.",
+		ExportLexicon,
+	)
+}
@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"l2/storage"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// webCacheDir is the data directory fetched pages are cached in
+const webCacheDir = "web"
+
+const (
+	// maxFetchBytes is the largest page downloaded
+	maxFetchBytes = 5 << 20
+	// defaultFetchChars is how much extracted text is returned by default
+	defaultFetchChars = 20000
+)
+
+var (
+	// Elements that never hold the page's readable text
+	boilerplateElements = elementPatterns("head", "script", "style", "noscript", "svg", "nav", "header", "footer", "aside", "form", "iframe", "template")
+	htmlComments        = regexp.MustCompile(`(?s)<!--.*?-->`)
+	mainContent         = regexp.MustCompile(`(?is)<(article|main)\b[^>]*>(.*)</(article|main)>`)
+	pageTitle           = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	headingTags         = regexp.MustCompile(`(?is)<h([1-6])[^>]*>(.*?)</h[1-6]>`)
+	listItemTags        = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	cellTags            = regexp.MustCompile(`(?i)</t[dh]>`)
+	blockTags           = regexp.MustCompile(`(?i)</?(p|div|br|tr|table|ul|ol|dl|dt|dd|blockquote|pre|section|figure|figcaption|hr)\b[^>]*>`)
+	anyTag              = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines          = regexp.MustCompile(`\n{3,}`)
+)
+
+// FetchURLRequest represents a request to fetch a web page
+type FetchURLRequest struct {
+	URL      string `json:"url" jsonschema:"required,description=Address of the web page such as a natural language grammar reference"`
+	Refresh  bool   `json:"refresh" jsonschema:"description=Download the page again instead of using the cached copy"`
+	MaxChars int    `json:"max_chars" jsonschema:"description=Maximum characters of text to return (default 20000); the full text stays in the cache"`
+}
+
+// FetchURLResult represents the readable text of a web page
+type FetchURLResult struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Title     string `json:"title,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Source    int    `json:"source,omitempty"` // Source ID for citations
+	Cached    bool   `json:"cached,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+// FetchURL downloads a web page, extracts its readable text and caches it as
+// a data file so the page is only downloaded once
+func FetchURL(ctx context.Context, req *FetchURLRequest) (*FetchURLResult, error) {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &FetchURLResult{
+			Success: false,
+			Message: "A full http or https URL is required",
+		}, nil
+	}
+	cachePath := webCachePath(u)
+
+	result := &FetchURLResult{Success: true, Path: cachePath}
+	text := ""
+	if !req.Refresh {
+		if data, err := storage.ReadDataFile(cachePath); err == nil {
+			text, result.Cached = string(data), true
+		}
+	}
+	if !result.Cached {
+		page, err := downloadPage(ctx, u.String())
+		if err != nil {
+			return &FetchURLResult{
+				Success: false,
+				Message: failure("fetch "+u.String(), err),
+			}, nil
+		}
+		title, body := extractReadableText(page)
+		if title == "" {
+			title = "Untitled page"
+		}
+		if body == "" {
+			return &FetchURLResult{
+				Success: false,
+				Message: u.String() + " has no readable text",
+			}, nil
+		}
+		// The cached copy starts with a header recording its source
+		text = fmt.Sprintf("# %s\n\nSource: %s\nFetched: %s\n\n%s\n", title, u.String(), time.Now().Format("2006-01-02"), body)
+		if err := storage.WriteDataFile(cachePath, []byte(text)); err != nil {
+			return &FetchURLResult{
+				Success: false,
+				Message: failure("cache page", err),
+			}, nil
+		}
+	}
+	if heading, _, ok := strings.Cut(text, "\n"); ok {
+		result.Title = strings.TrimPrefix(heading, "# ")
+	}
+
+	limit := req.MaxChars
+	if limit <= 0 {
+		limit = defaultFetchChars
+	}
+	if runes := []rune(text); len(runes) > limit {
+		text, result.Truncated = string(runes[:limit]), true
+	}
+	result.Text = text
+
+	result.Message = fmt.Sprintf("Fetched %s and cached it as %s", u.String(), cachePath)
+	if result.Cached {
+		result.Message = fmt.Sprintf("Read %s from the cache at %s", u.String(), cachePath)
+	}
+	if source, err := storage.RegisterSource(storage.Source{Title: result.Title, Location: u.String(), Path: cachePath}); err == nil {
+		result.Source = source.ID
+		result.Message += fmt.Sprintf("; cite it as source %d", source.ID)
+	}
+	if result.Truncated {
+		result.Message += fmt.Sprintf("; showing the first %d characters, read the rest with read_file", limit)
+	}
+	return result, nil
+}
+
+// downloadPage fetches a page as text, refusing anything that isn't HTML or plain text
+func downloadPage(ctx context.Context, address string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "l2-conlang-assistant")
+	req.Header.Set("Accept", "text/html,text/plain;q=0.9")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned %s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "text/") && !strings.Contains(contentType, "html") {
+		return "", fmt.Errorf("page is %s, not text", contentType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes))
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(contentType, "text/plain") {
+		return "<pre>" + html.EscapeString(string(data)) + "</pre>", nil
+	}
+	return string(data), nil
+}
+
+// extractReadableText returns a page's title and its main text with
+// headings and list items kept as Markdown. Navigation, scripts and other
+// boilerplate are dropped, and an article or main element is preferred over
+// the whole body.
+func extractReadableText(page string) (title, text string) {
+	if m := pageTitle.FindStringSubmatch(page); m != nil {
+		title = strings.Join(strings.Fields(html.UnescapeString(anyTag.ReplaceAllString(m[1], ""))), " ")
+	}
+	page = htmlComments.ReplaceAllString(page, "")
+	for _, element := range boilerplateElements {
+		page = element.ReplaceAllString(page, "")
+	}
+	if m := mainContent.FindStringSubmatch(page); m != nil {
+		page = m[2]
+	}
+
+	page = headingTags.ReplaceAllStringFunc(page, func(h string) string {
+		m := headingTags.FindStringSubmatch(h)
+		return "\n\n" + strings.Repeat("#", int(m[1][0]-'0')) + " " + strings.Join(strings.Fields(anyTag.ReplaceAllString(m[2], "")), " ") + "\n\n"
+	})
+	page = listItemTags.ReplaceAllString(page, "\n- ")
+	page = cellTags.ReplaceAllString(page, " | ")
+	page = blockTags.ReplaceAllString(page, "\n")
+	page = html.UnescapeString(anyTag.ReplaceAllString(page, ""))
+
+	// Collapse the source's indentation and runs of blank lines
+	lines := strings.Split(page, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.FieldsFunc(line, unicode.IsSpace), " ")
+	}
+	text = strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+	return title, text
+}
+
+// elementPatterns match whole elements with the given tag names
+func elementPatterns(tags ...string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(tags))
+	for i, tag := range tags {
+		patterns[i] = regexp.MustCompile(`(?is)<` + tag + `\b.*?</` + tag + `>`)
+	}
+	return patterns
+}
+
+// webCachePath is the data file a page is cached in, named after its address
+func webCachePath(u *url.URL) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, strings.Trim(u.Host+u.EscapedPath()+"_"+u.RawQuery, "/_"))
+	if len(name) > 100 {
+		name = name[:80] + "_" + storage.HashContent([]byte(u.String()))[:12]
+	}
+	return path.Join(webCacheDir, name+".md")
+}
+
+// createFetchURLTool creates the web page fetching tool
+func createFetchURLTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"fetch_url",
+		"Download a web page such as a natural language grammar reference, extract its readable text (dropping navigation and scripts) and cache it under web/ in the data files. Repeated fetches of the same URL use the cache unless refresh is set.",
+		FetchURL,
+	)
+}
@@ -2,13 +2,11 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"l2/storage"
-	"log"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
-	"github.com/cloudwego/eino/compose"
-	"github.com/cloudwego/eino/schema"
 )
 
 // File represents a file operation request
@@ -40,11 +38,29 @@ func AddFile(ctx context.Context, file *File) (*Result, error) {
 		}, nil
 	}
 
-	err := storage.WriteDataFile(file.Path, []byte(file.Content))
+	// Keep the previous version restorable when overwriting an existing file;
+	// the file is left as it is if that fails
+	previous, err := storage.ReadDataFile(file.Path)
+	switch {
+	case err == nil:
+		if string(previous) != file.Content {
+			_, err = storage.TrashVersion(file.Path, previous)
+		}
+	case errors.Is(err, storage.ErrNotFound):
+		err = nil
+	}
+	if err != nil {
+		return &Result{
+			Success: false,
+			Message: failure("keep the previous version", err),
+		}, nil
+	}
+
+	err = storage.WriteDataFile(file.Path, []byte(file.Content))
 	if err != nil {
 		return &Result{
 			Success: false,
-			Message: "Failed to write file: " + err.Error(),
+			Message: failure("write file", err),
 		}, nil
 	}
 
@@ -67,7 +83,7 @@ func ReadFile(ctx context.Context, file *File) (*Result, error) {
 	if err != nil {
 		return &Result{
 			Success: false,
-			Message: "Failed to read file: " + err.Error(),
+			Message: failure("read file", err),
 		}, nil
 	}
 
@@ -95,146 +111,3 @@ func createReadFileTool() (tool.InvokableTool, error) {
 		ReadFile,
 	)
 }
-
-// Tools creates and returns a ToolsNode with all available tools
-func Tools() *compose.ToolsNode {
-	// Create file management tools
-	addFileTool, err := createAddFileTool()
-	if err != nil {
-		log.Printf("Failed to create add file tool: %v", err)
-	}
-
-	readFileTool, err := createReadFileTool()
-	if err != nil {
-		log.Printf("Failed to create read file tool: %v", err)
-	}
-
-	// Create conlang-specific tools
-	phonologyTool, err := createPhonologyTool()
-	if err != nil {
-		log.Printf("Failed to create phonology tool: %v", err)
-	}
-
-	grammarTool, err := createGrammarTool()
-	if err != nil {
-		log.Printf("Failed to create grammar tool: %v", err)
-	}
-
-	addLexiconTool, err := createAddLexiconTool()
-	if err != nil {
-		log.Printf("Failed to create add lexicon tool: %v", err)
-	}
-
-	getLexiconTool, err := createGetLexiconTool()
-	if err != nil {
-		log.Printf("Failed to create get lexicon tool: %v", err)
-	}
-
-	// Collect all tools
-	tools := []tool.BaseTool{}
-	if addFileTool != nil {
-		tools = append(tools, addFileTool)
-	}
-	if readFileTool != nil {
-		tools = append(tools, readFileTool)
-	}
-	if phonologyTool != nil {
-		tools = append(tools, phonologyTool)
-	}
-	if grammarTool != nil {
-		tools = append(tools, grammarTool)
-	}
-	if addLexiconTool != nil {
-		tools = append(tools, addLexiconTool)
-	}
-	if getLexiconTool != nil {
-		tools = append(tools, getLexiconTool)
-	}
-
-	if len(tools) == 0 {
-		log.Printf("No tools could be created")
-		return nil
-	}
-
-	conf := &compose.ToolsNodeConfig{
-		Tools: tools,
-	}
-
-	toolsNode, err := compose.NewToolNode(context.Background(), conf)
-	if err != nil {
-		log.Printf("Failed to create tools node: %v", err)
-		return nil
-	}
-
-	return toolsNode
-}
-
-// ToolsInfo returns information about all available tools
-func ToolsInfo() []*schema.ToolInfo {
-	// Get file management tool info
-	addFileTool, err := createAddFileTool()
-	if err != nil {
-		log.Printf("Failed to create add file tool for info: %v", err)
-	}
-
-	readFileTool, err := createReadFileTool()
-	if err != nil {
-		log.Printf("Failed to create read file tool for info: %v", err)
-	}
-
-	// Get conlang tool info
-	phonologyTool, err := createPhonologyTool()
-	if err != nil {
-		log.Printf("Failed to create phonology tool for info: %v", err)
-	}
-
-	grammarTool, err := createGrammarTool()
-	if err != nil {
-		log.Printf("Failed to create grammar tool for info: %v", err)
-	}
-
-	addLexiconTool, err := createAddLexiconTool()
-	if err != nil {
-		log.Printf("Failed to create add lexicon tool for info: %v", err)
-	}
-
-	getLexiconTool, err := createGetLexiconTool()
-	if err != nil {
-		log.Printf("Failed to create get lexicon tool for info: %v", err)
-	}
-
-	// Collect all tools
-	tools := []tool.BaseTool{}
-	if addFileTool != nil {
-		tools = append(tools, addFileTool)
-	}
-	if readFileTool != nil {
-		tools = append(tools, readFileTool)
-	}
-	if phonologyTool != nil {
-		tools = append(tools, phonologyTool)
-	}
-	if grammarTool != nil {
-		tools = append(tools, grammarTool)
-	}
-	if addLexiconTool != nil {
-		tools = append(tools, addLexiconTool)
-	}
-	if getLexiconTool != nil {
-		tools = append(tools, getLexiconTool)
-	}
-
-	ctx := context.Background()
-	toolInfos := make([]*schema.ToolInfo, 0, len(tools))
-
-	for _, t := range tools {
-		info, err := t.Info(ctx)
-		if err != nil {
-			log.Printf("Failed to get tool info: %v", err)
-			continue
-		}
-		toolInfos = append(toolInfos, info)
-	}
-
-	return toolInfos
-}
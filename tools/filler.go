@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// maxFillerRepeats bounds how often * and + repeat when generating text
+const maxFillerRepeats = 3
+
+// FillerTextRequest represents a request for generated filler text
+type FillerTextRequest struct {
+	Paragraphs  int      `json:"paragraphs" jsonschema:"description=Number of paragraphs (default 3)"`
+	Sentences   int      `json:"sentences" jsonschema:"description=Sentences per paragraph (default 5)"`
+	GrammarFile string   `json:"grammar_file" jsonschema:"description=Data file holding the grammar rules (default grammar.peg)"`
+	Files       []string `json:"files" jsonschema:"description=Stored corpus files whose word frequencies weight the choice of words; without them every word is equally likely"`
+	Seed        int64    `json:"seed" jsonschema:"description=Random seed, to get the same text again"`
+	Path        string   `json:"path" jsonschema:"description=Data file path to save the text to"`
+}
+
+// FillerTextResult represents generated filler text
+type FillerTextResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Text    string `json:"text,omitempty"`
+	Seed    int64  `json:"seed"`
+}
+
+// GenerateFillerText produces paragraphs of random sentences from the
+// grammar's rules, filling part-of-speech slots with lexicon words weighted
+// by their corpus frequency
+func GenerateFillerText(ctx context.Context, req *FillerTextRequest) (*FillerTextResult, error) {
+	grammarFile := req.GrammarFile
+	if grammarFile == "" {
+		grammarFile = defaultGrammarFile
+	}
+	source, err := storage.ReadDataFile(grammarFile)
+	if err != nil {
+		return &FillerTextResult{
+			Success: false,
+			Message: failure("load grammar rules from "+grammarFile+" (write rules there with add_file first)", err),
+		}, nil
+	}
+	grammar, err := parseGrammar(string(source))
+	if err != nil {
+		return &FillerTextResult{
+			Success: false,
+			Message: "Invalid grammar in " + grammarFile + ": " + err.Error(),
+		}, nil
+	}
+	entries, err := loadLexicon()
+	if err != nil {
+		return &FillerTextResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	if len(entries) == 0 {
+		return &FillerTextResult{
+			Success: false,
+			Message: "The lexicon is empty; add words before generating text",
+		}, nil
+	}
+
+	counts := map[string]int{}
+	for _, file := range req.Files {
+		data, err := storage.ReadDataFile(file)
+		if err != nil {
+			return &FillerTextResult{
+				Success: false,
+				Message: failure("read "+file, err),
+			}, nil
+		}
+		for _, word := range TokenizeWords(string(data)) {
+			counts[strings.ToLower(word)]++
+		}
+	}
+
+	seed := req.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	g := &fillerGenerator{grammar: grammar, rng: rand.New(rand.NewSource(seed)), words: map[string][]weightedWord{}}
+	for _, entry := range entries {
+		// Affixes and clitics written with boundary markers aren't words
+		if strings.ContainsAny(entry.Word, "-=") {
+			continue
+		}
+		// Unseen words keep a weight of one so they can still appear
+		w := weightedWord{word: entry.Word, weight: 1 + counts[strings.ToLower(entry.Word)]}
+		g.words[strings.ToLower(entry.PartOfSpeech)] = append(g.words[strings.ToLower(entry.PartOfSpeech)], w)
+		g.all = append(g.all, w)
+	}
+
+	paragraphs, sentences := req.Paragraphs, req.Sentences
+	if paragraphs <= 0 {
+		paragraphs = 3
+	}
+	if sentences <= 0 {
+		sentences = 5
+	}
+	text := make([]string, paragraphs)
+	for p := range text {
+		paragraph := make([]string, sentences)
+		for s := range paragraph {
+			words, err := g.sentence()
+			if err != nil {
+				return &FillerTextResult{
+					Success: false,
+					Message: failure("generate text from "+grammarFile, err),
+				}, nil
+			}
+			paragraph[s] = words
+		}
+		text[p] = strings.Join(paragraph, " ")
+	}
+	result := strings.Join(text, "\n\n")
+
+	message := fmt.Sprintf("Generated %d paragraphs of %d sentences", paragraphs, sentences)
+	if req.Path != "" {
+		if err := storage.WriteDataFile(req.Path, []byte(result+"\n")); err != nil {
+			return &FillerTextResult{
+				Success: false,
+				Message: failure("save text", err),
+			}, nil
+		}
+		message += " and saved them to " + req.Path
+	}
+	return &FillerTextResult{
+		Success: true,
+		Message: message,
+		Text:    result,
+		Seed:    seed,
+	}, nil
+}
+
+type weightedWord struct {
+	word   string
+	weight int
+}
+
+// fillerGenerator expands grammar rules at random
+type fillerGenerator struct {
+	grammar *pegGrammar
+	rng     *rand.Rand
+	words   map[string][]weightedWord // Lowercase part of speech to its words
+	all     []weightedWord
+	depth   int
+}
+
+// sentence generates one sentence from the start rule, capitalized and
+// ending in a full stop unless the grammar supplies punctuation
+func (g *fillerGenerator) sentence() (string, error) {
+	tokens, err := g.expand(&pegNode{kind: pegRule, value: g.grammar.start})
+	if err != nil {
+		return "", err
+	}
+	text := ""
+	for _, token := range tokens {
+		if text != "" && !(len(token) == 1 && unicode.IsPunct(rune(token[0]))) {
+			text += " "
+		}
+		text += token
+	}
+	if text == "" {
+		return "", fmt.Errorf("the start rule %s produced no words", g.grammar.start)
+	}
+	if last := rune(text[len(text)-1]); !unicode.IsPunct(last) {
+		text += "."
+	}
+	runes := []rune(text)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes), nil
+}
+
+func (g *fillerGenerator) expand(n *pegNode) ([]string, error) {
+	g.depth++
+	defer func() { g.depth-- }()
+	if g.depth > maxGrammarDepth {
+		return nil, fmt.Errorf("rules nest too deeply; check for rules that always recurse")
+	}
+	// Deep in the tree optional parts are left out so recursive rules end
+	deep := g.depth > maxGrammarDepth/10
+
+	switch n.kind {
+	case pegSequence:
+		tokens := []string{}
+		for _, c := range n.children {
+			t, err := g.expand(c)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t...)
+		}
+		return tokens, nil
+	case pegChoice:
+		choice := n.children[0]
+		if !deep {
+			choice = n.children[g.rng.Intn(len(n.children))]
+		}
+		return g.expand(choice)
+	case pegOptional, pegZeroOrMore, pegOneOrMore:
+		repeats := 0
+		switch {
+		case deep:
+		case n.kind == pegOptional:
+			repeats = g.rng.Intn(2)
+		default:
+			repeats = g.rng.Intn(maxFillerRepeats)
+		}
+		if n.kind == pegOneOrMore {
+			repeats++
+		}
+		tokens := []string{}
+		for ; repeats > 0; repeats-- {
+			t, err := g.expand(n.children[0])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t...)
+		}
+		return tokens, nil
+	case pegRule:
+		rule, ok := g.grammar.rules[n.value]
+		if !ok {
+			return nil, fmt.Errorf("rule %s is not defined", n.value)
+		}
+		return g.expand(rule)
+	case pegLiteral:
+		return []string{n.value}, nil
+	case pegPartOfSpeech:
+		words := g.words[n.value]
+		if len(words) == 0 {
+			return nil, fmt.Errorf("no lexicon words with part of speech %s", n.value)
+		}
+		return []string{g.pick(words)}, nil
+	}
+	return []string{g.pick(g.all)}, nil
+}
+
+// pick chooses a word with probability proportional to its weight
+func (g *fillerGenerator) pick(words []weightedWord) string {
+	total := 0
+	for _, w := range words {
+		total += w.weight
+	}
+	n := g.rng.Intn(total)
+	for _, w := range words {
+		if n < w.weight {
+			return w.word
+		}
+		n -= w.weight
+	}
+	return words[len(words)-1].word
+}
+
+// createFillerTextTool creates the filler text generation tool
+func createFillerTextTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"generate_filler_text",
+		"Generate paragraphs of grammatical lorem-ipsum style filler text in the conlang by expanding the PEG grammar rules (grammar.peg by default) with random lexicon words, weighted by their frequency in optional corpus files. Use it for typesetting tests and font proofs, not for meaningful sentences.",
+		GenerateFillerText,
+	)
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// defaultFrequencyRows is how many rows each frequency table shows by default
+const defaultFrequencyRows = 20
+
+// FrequencyRequest represents a request to analyze frequencies
+type FrequencyRequest struct {
+	Files   []string `json:"files" jsonschema:"description=Paths of stored corpus files to analyze"`
+	Lexicon bool     `json:"lexicon" jsonschema:"description=Also count the lexicon's words; used on its own when no files are given"`
+	Limit   int      `json:"limit" jsonschema:"description=Rows per table (default 20)"`
+}
+
+// FrequencyCount is how often one item occurs
+type FrequencyCount struct {
+	Item    string  `json:"item"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// FrequencyResult represents phoneme, bigram and word frequency distributions
+type FrequencyResult struct {
+	Success  bool             `json:"success"`
+	Message  string           `json:"message"`
+	Phonemes []FrequencyCount `json:"phonemes,omitempty"`
+	Bigrams  []FrequencyCount `json:"bigrams,omitempty"`
+	Words    []FrequencyCount `json:"words,omitempty"`
+	Tables   string           `json:"tables,omitempty"`
+}
+
+// AnalyzeFrequency counts phonemes, phoneme bigrams within words and words
+// over corpus files and the lexicon
+func AnalyzeFrequency(ctx context.Context, req *FrequencyRequest) (*FrequencyResult, error) {
+	words := []string{}
+	for _, file := range req.Files {
+		data, err := storage.ReadDataFile(file)
+		if err != nil {
+			return &FrequencyResult{
+				Success: false,
+				Message: failure("read "+file, err),
+			}, nil
+		}
+		words = append(words, TokenizeWords(string(data))...)
+	}
+	if req.Lexicon || len(req.Files) == 0 {
+		entries, err := loadLexicon()
+		if err != nil {
+			return &FrequencyResult{
+				Success: false,
+				Message: failure("read lexicon", err),
+			}, nil
+		}
+		for _, entry := range entries {
+			words = append(words, TokenizeWords(entry.Word)...)
+		}
+	}
+	if len(words) == 0 {
+		return &FrequencyResult{
+			Success: false,
+			Message: "No words to analyze; add lexicon entries or give corpus files",
+		}, nil
+	}
+
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return &FrequencyResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	// Segmenting is the slow part, so it is spread over the worker pool
+	segmented, err := parallelMap(ctx, words, func(word string) []string {
+		segs := set.segment(strings.ToLower(word))
+		for i, seg := range segs {
+			if p, ok := set.phoneme(seg); ok {
+				segs[i] = p.Symbol
+			}
+		}
+		return segs
+	})
+	if err != nil {
+		return &FrequencyResult{
+			Success: false,
+			Message: "Frequency analysis stopped: " + err.Error(),
+		}, nil
+	}
+	phonemes, bigrams, wordCounts := map[string]int{}, map[string]int{}, map[string]int{}
+	for i, segs := range segmented {
+		wordCounts[strings.ToLower(words[i])]++
+		for j, seg := range segs {
+			phonemes[seg]++
+			if j > 0 {
+				bigrams[segs[j-1]+" "+seg]++
+			}
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultFrequencyRows
+	}
+	result := &FrequencyResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Analyzed %d words (%d distinct)", len(words), len(wordCounts)),
+		Phonemes: rankFrequencies(phonemes, limit),
+		Bigrams:  rankFrequencies(bigrams, limit),
+	}
+	var tables strings.Builder
+	tables.WriteString(renderFrequencyTable("Phonemes", result.Phonemes))
+	tables.WriteString("\n" + renderFrequencyTable("Bigrams", result.Bigrams))
+	// Every lexicon word occurs once, so word frequencies only mean something for a corpus
+	if len(req.Files) > 0 {
+		result.Words = rankFrequencies(wordCounts, limit)
+		tables.WriteString("\n" + renderFrequencyTable("Words", result.Words))
+	}
+	result.Tables = tables.String()
+	return result, nil
+}
+
+// rankFrequencies sorts counts from most to least frequent and keeps the top rows
+func rankFrequencies(counts map[string]int, limit int) []FrequencyCount {
+	total := 0
+	ranked := []FrequencyCount{}
+	for item, count := range counts {
+		total += count
+		ranked = append(ranked, FrequencyCount{Item: item, Count: count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Item < ranked[j].Item
+	})
+	for i := range ranked {
+		ranked[i].Percent = float64(ranked[i].Count) / float64(total) * 100
+	}
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+func renderFrequencyTable(title string, counts []FrequencyCount) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("### %s\n\n| Rank | Item | Count | %% |\n|---|---|---|---|\n", title))
+	for i, c := range counts {
+		out.WriteString(fmt.Sprintf("| %d | %s | %d | %.1f |\n", i+1, c.Item, c.Count, c.Percent))
+	}
+	return out.String()
+}
+
+// createAnalyzeFrequencyTool creates the frequency analysis tool
+func createAnalyzeFrequencyTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"analyze_frequency",
+		"Compute phoneme, phoneme bigram and word frequency distributions over stored corpus files and/or the lexicon, returning ranked tables. Use it to check the language stays statistically naturalistic, e.g. that no phoneme is over- or underused.",
+		AnalyzeFrequency,
+	)
+}
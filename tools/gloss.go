@@ -0,0 +1,471 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"l2/storage"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// Interlinear layouts supported by FormatInterlinear
+const (
+	GlossFormatAligned = "aligned" // Plain text with space padded columns
+	GlossFormatTabs    = "tabs"    // Tab separated columns for spreadsheets and word processors
+	GlossFormatExpex   = "expex"   // LaTeX using the expex package
+	GlossFormatRuby    = "ruby"    // HTML ruby annotations
+)
+
+// GlossWord is one aligned column of an interlinear gloss
+type GlossWord struct {
+	Morphemes string `json:"morphemes"` // Morpheme breakdown such as kala-ni
+	Gloss     string `json:"gloss"`     // Leipzig gloss such as house-PL
+}
+
+// Interlinear is a glossed sentence
+type Interlinear struct {
+	Surface     string      `json:"surface"`
+	Words       []GlossWord `json:"words"`
+	Translation string      `json:"translation"`
+}
+
+// GlossExportRequest represents a request to export an interlinear gloss
+type GlossExportRequest struct {
+	Sentence    string `json:"sentence" jsonschema:"required,description=The conlang sentence as written"`
+	Morphemes   string `json:"morphemes" jsonschema:"required,description=Space separated words with morphemes separated by hyphens such as kala-ni tu-ma"`
+	Glosses     string `json:"glosses" jsonschema:"required,description=Space separated glosses aligned with the morphemes such as house-PL go-PST"`
+	Translation string `json:"translation" jsonschema:"description=Free translation of the sentence"`
+	Format      string `json:"format" jsonschema:"description=Layout: aligned, tabs, expex or ruby (defaults to aligned)"`
+	Path        string `json:"path" jsonschema:"description=Data file path to write the gloss to"`
+}
+
+// GlossTextRequest represents a request to gloss a conlang sentence
+type GlossTextRequest struct {
+	Sentence    string `json:"sentence" jsonschema:"required,description=The conlang sentence as written"`
+	Morphemes   string `json:"morphemes" jsonschema:"description=Space separated words with morphemes separated by hyphens (and clitics by =) such as kala-ni tu-ma. When omitted the sentence is segmented using the lexicon and affix store"`
+	Glosses     string `json:"glosses" jsonschema:"description=Space separated Leipzig glosses aligned with the morphemes such as house-PL go-PST. Missing glosses are looked up in the lexicon and affix store"`
+	Translation string `json:"translation" jsonschema:"description=Free translation of the sentence"`
+}
+
+// GlossResult represents the result of glossing a sentence
+type GlossResult struct {
+	Success     bool        `json:"success"`
+	Message     string      `json:"message"`
+	Interlinear Interlinear `json:"interlinear"`
+	Text        string      `json:"text,omitempty"`
+	Warnings    []string    `json:"warnings,omitempty"`
+}
+
+// NewInterlinear pairs up morpheme and gloss lines word by word
+func NewInterlinear(sentence, morphemes, glosses, translation string) (Interlinear, error) {
+	morphemeWords := strings.Fields(morphemes)
+	glossWords := strings.Fields(glosses)
+	if len(morphemeWords) != len(glossWords) {
+		return Interlinear{}, fmt.Errorf("morpheme line has %d words but gloss line has %d", len(morphemeWords), len(glossWords))
+	}
+
+	words := make([]GlossWord, len(morphemeWords))
+	for i := range morphemeWords {
+		words[i] = GlossWord{Morphemes: morphemeWords[i], Gloss: glossWords[i]}
+	}
+	return Interlinear{
+		Surface:     sentence,
+		Words:       words,
+		Translation: translation,
+	}, nil
+}
+
+// FormatInterlinear renders a gloss in the requested layout
+func FormatInterlinear(gloss Interlinear, format string) (string, error) {
+	switch format {
+	case "", GlossFormatAligned:
+		return formatAligned(gloss), nil
+	case GlossFormatTabs:
+		return formatTabs(gloss), nil
+	case GlossFormatExpex:
+		return formatExpex(gloss), nil
+	case GlossFormatRuby:
+		return formatRuby(gloss), nil
+	}
+	return "", fmt.Errorf("unknown gloss format %q (expected aligned, tabs, expex or ruby)", format)
+}
+
+func formatAligned(gloss Interlinear) string {
+	var morphemes, glosses strings.Builder
+	for _, w := range gloss.Words {
+		width := max(utf8.RuneCountInString(w.Morphemes), utf8.RuneCountInString(w.Gloss)) + 2
+		morphemes.WriteString(padRight(w.Morphemes, width))
+		glosses.WriteString(padRight(w.Gloss, width))
+	}
+
+	lines := []string{gloss.Surface, strings.TrimRight(morphemes.String(), " "), strings.TrimRight(glosses.String(), " ")}
+	if gloss.Translation != "" {
+		lines = append(lines, "'"+gloss.Translation+"'")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func formatTabs(gloss Interlinear) string {
+	morphemes := make([]string, len(gloss.Words))
+	glosses := make([]string, len(gloss.Words))
+	for i, w := range gloss.Words {
+		morphemes[i] = w.Morphemes
+		glosses[i] = w.Gloss
+	}
+
+	lines := []string{gloss.Surface, strings.Join(morphemes, "\t"), strings.Join(glosses, "\t")}
+	if gloss.Translation != "" {
+		lines = append(lines, "'"+gloss.Translation+"'")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func formatExpex(gloss Interlinear) string {
+	morphemes := make([]string, len(gloss.Words))
+	glosses := make([]string, len(gloss.Words))
+	for i, w := range gloss.Words {
+		morphemes[i] = latexEscape(w.Morphemes)
+		glosses[i] = expexGloss(w.Gloss)
+	}
+
+	var out strings.Builder
+	out.WriteString("\\ex\n\\begingl\n")
+	if gloss.Surface != "" {
+		out.WriteString("\\glpreamble " + latexEscape(gloss.Surface) + " //\n")
+	}
+	out.WriteString("\\gla " + strings.Join(morphemes, " ") + " //\n")
+	out.WriteString("\\glb " + strings.Join(glosses, " ") + " //\n")
+	if gloss.Translation != "" {
+		out.WriteString("\\glft `" + latexEscape(gloss.Translation) + "' //\n")
+	}
+	out.WriteString("\\endgl\n\\xe\n")
+	return out.String()
+}
+
+func formatRuby(gloss Interlinear) string {
+	var out strings.Builder
+	out.WriteString("<div class=\"interlinear\">\n")
+	if gloss.Surface != "" {
+		out.WriteString("  <p class=\"surface\">" + html.EscapeString(gloss.Surface) + "</p>\n")
+	}
+	out.WriteString("  <p class=\"gloss\">")
+	for i, w := range gloss.Words {
+		if i > 0 {
+			out.WriteString(" ")
+		}
+		out.WriteString("<ruby>" + html.EscapeString(w.Morphemes) + "<rt>" + html.EscapeString(w.Gloss) + "</rt></ruby>")
+	}
+	out.WriteString("</p>\n")
+	if gloss.Translation != "" {
+		out.WriteString("  <p class=\"translation\">‘" + html.EscapeString(gloss.Translation) + "’</p>\n")
+	}
+	out.WriteString("</div>\n")
+	return out.String()
+}
+
+// expexGloss escapes a gloss and sets Leipzig category labels (runs of
+// capitals such as PL or 3SG) in small caps. Capitalized words like proper
+// names are left alone.
+func expexGloss(gloss string) string {
+	var out, category strings.Builder
+	flush := func(smallCaps bool) {
+		if category.Len() == 0 {
+			return
+		}
+		if smallCaps {
+			out.WriteString("\\textsc{" + strings.ToLower(category.String()) + "}")
+		} else {
+			out.WriteString(category.String())
+		}
+		category.Reset()
+	}
+	for _, r := range latexEscape(gloss) {
+		if r >= 'A' && r <= 'Z' {
+			category.WriteRune(r)
+			continue
+		}
+		flush(!unicode.IsLower(r))
+		out.WriteRune(r)
+	}
+	flush(true)
+	return out.String()
+}
+
+func latexEscape(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+		"{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+	)
+	return replacer.Replace(s)
+}
+
+func padRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// ExportGloss renders an interlinear gloss in a publication layout and optionally saves it
+func ExportGloss(ctx context.Context, req *GlossExportRequest) (*Result, error) {
+	gloss, err := NewInterlinear(req.Sentence, req.Morphemes, req.Glosses, req.Translation)
+	if err != nil {
+		return &Result{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	content, err := FormatInterlinear(gloss, req.Format)
+	if err != nil {
+		return &Result{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	message := "Gloss formatted"
+	if req.Path != "" {
+		if err := storage.WriteDataFile(req.Path, []byte(content)); err != nil {
+			return &Result{
+				Success: false,
+				Message: failure("write gloss", err),
+			}, nil
+		}
+		message = "Gloss written to " + req.Path
+	}
+
+	return &Result{
+		Success: true,
+		Message: message,
+		Content: content,
+	}, nil
+}
+
+// GlossText builds a Leipzig-style interlinear gloss, filling in glosses from
+// the lexicon when they are not given and checking morpheme/gloss alignment
+func GlossText(ctx context.Context, req *GlossTextRequest) (*GlossResult, error) {
+	if strings.TrimSpace(req.Morphemes) == "" && strings.TrimSpace(req.Sentence) == "" {
+		return &GlossResult{
+			Success: false,
+			Message: "A sentence or morpheme breakdown is required for glossing",
+		}, nil
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return &GlossResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	affixes, err := storage.ReadAffixes()
+	if err != nil {
+		return &GlossResult{
+			Success: false,
+			Message: failure("read affixes", err),
+		}, nil
+	}
+
+	warnings := []string{}
+	morphemes := req.Morphemes
+	if strings.TrimSpace(morphemes) == "" {
+		checker := newSpellchecker(entries)
+		words := []string{}
+		for _, word := range TokenizeWords(req.Sentence) {
+			segmented, ok := checker.segment(word)
+			if !ok {
+				segmented = word
+				warnings = append(warnings, fmt.Sprintf("Could not segment %s", word))
+			}
+			words = append(words, segmented)
+		}
+		morphemes = strings.Join(words, " ")
+	}
+
+	glosses := req.Glosses
+	if strings.TrimSpace(glosses) == "" {
+		glosses = autoGloss(morphemes, entries, affixes)
+	}
+
+	gloss, err := NewInterlinear(req.Sentence, morphemes, glosses, req.Translation)
+	if err != nil {
+		return &GlossResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	warnings = append(warnings, positionWarnings(gloss.Words, affixes)...)
+	for _, w := range gloss.Words {
+		if glossSeparators(w.Morphemes) != glossSeparators(w.Gloss) {
+			warnings = append(warnings, fmt.Sprintf("%s and %s do not have matching morpheme boundaries (Leipzig rule 2)", w.Morphemes, w.Gloss))
+		}
+		if strings.Contains(w.Gloss, "?") {
+			warnings = append(warnings, fmt.Sprintf("No lexicon entry found for part of %s", w.Morphemes))
+		}
+	}
+
+	return &GlossResult{
+		Success:     true,
+		Message:     "Sentence glossed",
+		Interlinear: gloss,
+		Text:        formatAligned(gloss),
+		Warnings:    warnings,
+	}, nil
+}
+
+// glossSeparators returns the sequence of morpheme boundary markers in a
+// word, so that aligned morpheme and gloss words can be compared
+func glossSeparators(word string) string {
+	var separators strings.Builder
+	for _, r := range word {
+		if r == '-' || r == '=' {
+			separators.WriteRune(r)
+		}
+	}
+	return separators.String()
+}
+
+// headGloss reduces a definition to the head word used to gloss a root,
+// so "to walk slowly" glosses as walk
+func headGloss(definition string) string {
+	fields := strings.Fields(definition)
+	if len(fields) > 1 && (fields[0] == "to" || fields[0] == "a" || fields[0] == "an" || fields[0] == "the") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.Trim(fields[0], ",;.")
+}
+
+// autoGloss glosses each morpheme from the lexicon. Roots use the first
+// word of their definition (skipping "to" and articles) and affixes (entries like -ni or ta-) use their
+// definition as written, which is expected to be a category label like PL.
+// Affixes and allomorphs in the affix store use their stored gloss.
+func autoGloss(morphemes string, entries []LexiconEntry, affixes []storage.Affix) string {
+	lookup := map[string]string{}
+	for _, affix := range affixes {
+		if affix.Kind == storage.ProcessKind {
+			continue
+		}
+		lookup[strings.ToLower(affix.Form)] = affix.Gloss
+		for _, allomorph := range affix.Allomorphs {
+			lookup[strings.ToLower(allomorph.Form)] = affix.Gloss
+		}
+	}
+	for _, entry := range entries {
+		definition := strings.TrimSpace(entry.Definition)
+		if _, ok := lookup[strings.ToLower(entry.Word)]; ok {
+			continue
+		}
+		if strings.HasPrefix(entry.Word, "-") || strings.HasSuffix(entry.Word, "-") || strings.HasPrefix(entry.Word, "=") || strings.HasSuffix(entry.Word, "=") {
+			lookup[strings.ToLower(entry.Word)] = definition
+			continue
+		}
+		if gloss := headGloss(definition); gloss != "" {
+			lookup[strings.ToLower(entry.Word)] = gloss
+		}
+	}
+
+	words := strings.Fields(morphemes)
+	glossed := make([]string, len(words))
+	for i, word := range words {
+		var out strings.Builder
+		parts := strings.FieldsFunc(word, func(r rune) bool { return r == '-' || r == '=' })
+		separators := glossSeparators(word)
+		for j, part := range parts {
+			// Bound forms are looked up by the boundary they sit on first, so
+			// a clitic =la is not glossed as a homophonous root la
+			key := strings.ToLower(part)
+			gloss, ok := "", false
+			if j > 0 {
+				gloss, ok = lookup[string(separators[j-1])+key]
+			}
+			if !ok && j < len(separators) {
+				gloss, ok = lookup[key+string(separators[j])]
+			}
+			if !ok {
+				gloss, ok = lookup[key]
+			}
+			if !ok || gloss == "" {
+				gloss = "?"
+			}
+			out.WriteString(strings.ReplaceAll(gloss, " ", "."))
+			if j < len(separators) {
+				out.WriteByte(separators[j])
+			}
+		}
+		glossed[i] = out.String()
+	}
+	return strings.Join(glossed, " ")
+}
+
+// positionWarnings reports clitics and particles from the affix store that
+// sit somewhere their position rule doesn't allow
+func positionWarnings(words []GlossWord, affixes []storage.Affix) []string {
+	positions := map[string]string{}
+	for _, affix := range affixes {
+		if affix.Position == "" || affix.Position == storage.AnyPosition {
+			continue
+		}
+		positions[strings.ToLower(affix.Form)] = affix.Position
+		for _, allomorph := range affix.Allomorphs {
+			positions[strings.ToLower(allomorph.Form)] = affix.Position
+		}
+	}
+
+	warnings := []string{}
+	for i, w := range words {
+		word := strings.ToLower(w.Morphemes)
+		forms := []string{word}
+		parts := strings.Split(word, "=")
+		for j, part := range parts {
+			if j > 0 {
+				forms = append(forms, "="+strings.Split(part, "-")[0])
+			}
+			if j < len(parts)-1 {
+				pieces := strings.Split(part, "-")
+				forms = append(forms, pieces[len(pieces)-1]+"=")
+			}
+		}
+		for _, form := range forms {
+			switch positions[form] {
+			case storage.FirstPosition:
+				if i != 0 {
+					warnings = append(warnings, fmt.Sprintf("%s belongs on the first word of the sentence but attaches to word %d", form, i+1))
+				}
+			case storage.LastPosition:
+				if i != len(words)-1 {
+					warnings = append(warnings, fmt.Sprintf("%s belongs on the last word of the sentence but attaches to word %d", form, i+1))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// createGlossTextTool creates the gloss text tool
+func createGlossTextTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"gloss_text",
+		"Produce an aligned Leipzig-style interlinear gloss (surface, morphemes, gloss and translation lines) for a conlang sentence. Without a morpheme breakdown the sentence is segmented into roots, affixes, clitics and particles using the lexicon and affix store; glosses are looked up there when not given, and clitics or particles out of their declared position are flagged.",
+		GlossText,
+	)
+}
+
+// createExportGlossTool creates the gloss export tool
+func createExportGlossTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"export_gloss",
+		"Format an interlinear gloss for publication as aligned plain text, tab separated columns, LaTeX expex markup or HTML ruby text, optionally saving it to a file.",
+		ExportGloss,
+	)
+}
@@ -0,0 +1,493 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultGrammarFile is the grammar used by validate_grammar when no file is given
+const defaultGrammarFile = "grammar.peg"
+
+// maxGrammarDepth bounds rule nesting so left-recursive grammars fail instead of hanging
+const maxGrammarDepth = 500
+
+// Grammar files use a PEG-like notation, one rule per line (continuation
+// lines are indented). The first rule is the start rule.
+//
+//	# comments start with a hash
+//	Sentence   <- NounPhrase VerbPhrase "."?
+//	NounPhrase <- Det? @noun @adjective*
+//	Det        <- "ta" / "ka"
+//	VerbPhrase <- @verb NounPhrase*
+//
+// "word" matches a literal word, @pos matches any lexicon word with that part
+// of speech, . matches any word, and / (or |) separates ordered alternatives.
+// BNF style "::=" is accepted in place of "<-".
+
+type pegKind int
+
+const (
+	pegSequence pegKind = iota
+	pegChoice
+	pegLiteral
+	pegPartOfSpeech
+	pegAny
+	pegRule
+	pegOptional
+	pegZeroOrMore
+	pegOneOrMore
+)
+
+type pegNode struct {
+	kind     pegKind
+	value    string
+	children []*pegNode
+}
+
+// describe renders a terminal the way it is written in the grammar file
+func (n *pegNode) describe() string {
+	switch n.kind {
+	case pegLiteral:
+		return fmt.Sprintf("%q", n.value)
+	case pegPartOfSpeech:
+		return "@" + n.value
+	case pegAny:
+		return "any word"
+	}
+	return n.value
+}
+
+type pegGrammar struct {
+	rules map[string]*pegNode
+	start string
+}
+
+// parseGrammar reads grammar rules from the PEG-like notation described above
+func parseGrammar(src string) (*pegGrammar, error) {
+	g := &pegGrammar{rules: map[string]*pegNode{}}
+
+	// Join continuation lines onto the rule they belong to
+	type ruleSource struct {
+		name string
+		body string
+		line int
+	}
+	sources := []ruleSource{}
+	for i, line := range strings.Split(src, "\n") {
+		line = stripGrammarComment(line)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(sources) == 0 {
+				return nil, fmt.Errorf("line %d: continuation line before any rule", i+1)
+			}
+			sources[len(sources)-1].body += " " + strings.TrimSpace(line)
+			continue
+		}
+		name, body, ok := strings.Cut(line, "<-")
+		if !ok {
+			name, body, ok = strings.Cut(line, "::=")
+		}
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a rule like Name <- expression", i+1)
+		}
+		sources = append(sources, ruleSource{strings.TrimSpace(name), body, i + 1})
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("grammar has no rules")
+	}
+
+	for _, rs := range sources {
+		p := &pegParser{tokens: lexGrammar(rs.body)}
+		node, err := p.parseChoice()
+		if err == nil && p.pos < len(p.tokens) {
+			err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d (%s): %w", rs.line, rs.name, err)
+		}
+		if _, exists := g.rules[rs.name]; exists {
+			return nil, fmt.Errorf("line %d: rule %s is defined twice", rs.line, rs.name)
+		}
+		g.rules[rs.name] = node
+	}
+	g.start = sources[0].name
+
+	// Every referenced rule must exist
+	var check func(n *pegNode) error
+	check = func(n *pegNode) error {
+		if n.kind == pegRule {
+			if _, ok := g.rules[n.value]; !ok {
+				return fmt.Errorf("rule %s is used but never defined", n.value)
+			}
+		}
+		for _, c := range n.children {
+			if err := check(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, rule := range g.rules {
+		if err := check(rule); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// stripGrammarComment cuts a line at the first # outside a quoted literal
+func stripGrammarComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// lexGrammar splits a rule body into tokens
+func lexGrammar(body string) []string {
+	tokens := []string{}
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			tokens = append(tokens, string(runes[i:min(end+1, len(runes))]))
+			i = end
+		case strings.ContainsRune("()/|?*+.", r):
+			tokens = append(tokens, string(r))
+		default:
+			start := i
+			for i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && !strings.ContainsRune(`()/|?*+."'`, runes[i+1]) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i+1]))
+		}
+	}
+	return tokens
+}
+
+type pegParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *pegParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *pegParser) parseChoice() (*pegNode, error) {
+	first, err := p.parseSequence()
+	if err != nil {
+		return nil, err
+	}
+	alternatives := []*pegNode{first}
+	for p.peek() == "/" || p.peek() == "|" {
+		p.pos++
+		next, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, next)
+	}
+	if len(alternatives) == 1 {
+		return first, nil
+	}
+	return &pegNode{kind: pegChoice, children: alternatives}, nil
+}
+
+func (p *pegParser) parseSequence() (*pegNode, error) {
+	items := []*pegNode{}
+	for {
+		next := p.peek()
+		if next == "" || next == "/" || next == "|" || next == ")" {
+			break
+		}
+		item, err := p.parseSuffix()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return &pegNode{kind: pegSequence, children: items}, nil
+}
+
+func (p *pegParser) parseSuffix() (*pegNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	switch p.peek() {
+	case "?":
+		p.pos++
+		return &pegNode{kind: pegOptional, children: []*pegNode{node}}, nil
+	case "*":
+		p.pos++
+		return &pegNode{kind: pegZeroOrMore, children: []*pegNode{node}}, nil
+	case "+":
+		p.pos++
+		return &pegNode{kind: pegOneOrMore, children: []*pegNode{node}}, nil
+	}
+	return node, nil
+}
+
+func (p *pegParser) parsePrimary() (*pegNode, error) {
+	token := p.peek()
+	p.pos++
+	switch {
+	case token == "(":
+		node, err := p.parseChoice()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case token == ".":
+		return &pegNode{kind: pegAny}, nil
+	case strings.HasPrefix(token, `"`) || strings.HasPrefix(token, "'"):
+		if len(token) < 2 || token[len(token)-1] != token[0] {
+			return nil, fmt.Errorf("unterminated string %s", token)
+		}
+		return &pegNode{kind: pegLiteral, value: strings.ToLower(token[1 : len(token)-1])}, nil
+	case strings.HasPrefix(token, "@") && len(token) > 1:
+		return &pegNode{kind: pegPartOfSpeech, value: strings.ToLower(token[1:])}, nil
+	case token != "" && !strings.ContainsAny(token, "?*+)"):
+		return &pegNode{kind: pegRule, value: token}, nil
+	}
+	if token == "" {
+		return nil, fmt.Errorf("unexpected end of rule")
+	}
+	return nil, fmt.Errorf("unexpected %q", token)
+}
+
+// GrammarFailure describes where and why a sentence failed to parse
+type GrammarFailure struct {
+	Rule     string   `json:"rule"`
+	Position int      `json:"position"` // Index of the offending word, starting at 0
+	Token    string   `json:"token"`    // The offending word, empty at the end of the sentence
+	Expected []string `json:"expected"`
+}
+
+// pegMatcher runs a grammar over a tokenized sentence, remembering the
+// furthest point any terminal failed so errors point at the real problem
+type pegMatcher struct {
+	grammar   *pegGrammar
+	tokens    []string
+	pos       map[string]string // Lexicon word to lowercase part of speech
+	ruleStack []string
+	depth     int
+
+	furthest int
+	rules    []string // Rule stack shared by every failure at the furthest position
+	expected map[string]bool
+}
+
+func (m *pegMatcher) fail(n *pegNode, at int) {
+	if at > m.furthest {
+		m.furthest = at
+		m.rules = append([]string{}, m.ruleStack...)
+		m.expected = map[string]bool{}
+	}
+	if at == m.furthest {
+		m.expected[n.describe()] = true
+		// Attribute the failure to the innermost rule shared by every expectation
+		common := 0
+		for common < len(m.rules) && common < len(m.ruleStack) && m.rules[common] == m.ruleStack[common] {
+			common++
+		}
+		m.rules = m.rules[:common]
+	}
+}
+
+func (m *pegMatcher) match(n *pegNode, at int) (int, bool) {
+	m.depth++
+	defer func() { m.depth-- }()
+	if m.depth > maxGrammarDepth {
+		return at, false
+	}
+
+	switch n.kind {
+	case pegSequence:
+		pos := at
+		for _, c := range n.children {
+			next, ok := m.match(c, pos)
+			if !ok {
+				return at, false
+			}
+			pos = next
+		}
+		return pos, true
+	case pegChoice:
+		for _, c := range n.children {
+			if next, ok := m.match(c, at); ok {
+				return next, true
+			}
+		}
+		return at, false
+	case pegOptional:
+		if next, ok := m.match(n.children[0], at); ok {
+			return next, true
+		}
+		return at, true
+	case pegZeroOrMore, pegOneOrMore:
+		pos, count := at, 0
+		for {
+			next, ok := m.match(n.children[0], pos)
+			if !ok || next == pos {
+				break
+			}
+			pos = next
+			count++
+		}
+		if n.kind == pegOneOrMore && count == 0 {
+			return at, false
+		}
+		return pos, true
+	case pegRule:
+		m.ruleStack = append(m.ruleStack, n.value)
+		next, ok := m.match(m.grammar.rules[n.value], at)
+		m.ruleStack = m.ruleStack[:len(m.ruleStack)-1]
+		return next, ok
+	}
+
+	// Terminals consume exactly one word
+	if at >= len(m.tokens) {
+		m.fail(n, at)
+		return at, false
+	}
+	token := m.tokens[at]
+	ok := false
+	switch n.kind {
+	case pegAny:
+		ok = true
+	case pegLiteral:
+		ok = token == n.value
+	case pegPartOfSpeech:
+		ok = m.pos[token] == n.value
+	}
+	if !ok {
+		m.fail(n, at)
+		return at, false
+	}
+	return at + 1, true
+}
+
+// parse matches the whole sentence against the start rule and returns nil on success
+func (g *pegGrammar) parse(tokens []string, entries []LexiconEntry) *GrammarFailure {
+	m := &pegMatcher{
+		grammar:  g,
+		tokens:   tokens,
+		pos:      map[string]string{},
+		furthest: -1,
+		expected: map[string]bool{},
+	}
+	for _, entry := range entries {
+		m.pos[strings.ToLower(entry.Word)] = strings.ToLower(entry.PartOfSpeech)
+	}
+	// Inflected words take the part of speech of the root they segment to
+	var checker *spellchecker
+	for _, token := range tokens {
+		if _, ok := m.pos[token]; ok {
+			continue
+		}
+		if checker == nil {
+			checker = newSpellchecker(entries)
+		}
+		if segmented, ok := checker.segment(token); ok {
+			for _, part := range strings.FieldsFunc(segmented, func(r rune) bool { return r == '-' || r == '=' }) {
+				if pos, ok := m.pos[part]; ok {
+					m.pos[token] = pos
+					break
+				}
+			}
+		}
+	}
+
+	end, ok := m.match(&pegNode{kind: pegRule, value: g.start}, 0)
+	if ok && end == len(tokens) {
+		return nil
+	}
+	if ok && end > m.furthest {
+		// The start rule matched a prefix and nothing tried to go further
+		m.furthest = end
+		m.rules = []string{g.start}
+		m.expected = map[string]bool{"end of sentence": true}
+	}
+
+	if m.furthest < 0 {
+		// Nothing was matched or tried, as when a left-recursive rule hits
+		// the depth bound
+		m.furthest = 0
+	}
+	rule := g.start
+	if len(m.rules) > 0 {
+		rule = m.rules[len(m.rules)-1]
+	}
+	failure := &GrammarFailure{
+		Rule:     rule,
+		Position: m.furthest,
+		Expected: []string{},
+	}
+	if m.furthest < len(tokens) {
+		failure.Token = tokens[m.furthest]
+	}
+	for e := range m.expected {
+		failure.Expected = append(failure.Expected, e)
+	}
+	sort.Strings(failure.Expected)
+	return failure
+}
+
+// tokenizeSentence splits a sentence into lowercase words, with punctuation
+// as separate tokens so grammars can match it
+func tokenizeSentence(text string) []string {
+	tokens := []string{}
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		start, end := 0, len(field)
+		for start < end && strings.ContainsRune(`"'(`, rune(field[start])) {
+			start++
+		}
+		trailing := []string{}
+		for end > start && strings.ContainsRune(`.,!?;:)"'`, rune(field[end-1])) {
+			if strings.ContainsRune(".,!?;:", rune(field[end-1])) {
+				trailing = append([]string{field[end-1 : end]}, trailing...)
+			}
+			end--
+		}
+		if start < end {
+			tokens = append(tokens, field[start:end])
+		}
+		tokens = append(tokens, trailing...)
+	}
+	return tokens
+}
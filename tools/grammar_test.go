@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"l2/storage"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testGrammar is the example grammar from the notation's description
+const testGrammar = `# A small verb-second grammar
+Sentence   <- NounPhrase VerbPhrase "."?
+NounPhrase <- Det? @noun @adjective*
+Det        <- "ta" / "ka"
+VerbPhrase <- @verb
+              NounPhrase*
+`
+
+var testGrammarLexicon = []LexiconEntry{
+	{Word: "kani", PartOfSpeech: "noun"},
+	{Word: "Miru", PartOfSpeech: "Verb"},
+	{Word: "sola", PartOfSpeech: "adjective"},
+	{Word: "-ra", PartOfSpeech: "suffix"},
+}
+
+func TestParseGrammarErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"empty", "", "grammar has no rules"},
+		{"only comments", "# nothing here\n\n", "grammar has no rules"},
+		{"continuation first", "  \"ta\"\nS <- \"ka\"", "line 1: continuation line before any rule"},
+		{"no arrow", "Sentence \"ta\"", "line 1: expected a rule"},
+		{"empty body", "S <-", "empty expression"},
+		{"empty alternative", `S <- / "a"`, "empty expression"},
+		{"unclosed group", `S <- ("a" "b"`, "missing closing parenthesis"},
+		{"unterminated string", `S <- "ta`, "unterminated string"},
+		{"stray parenthesis", `S <- "a" )`, `unexpected ")"`},
+		{"dangling operator", "S <- ?", `unexpected "?"`},
+		{"undefined rule", "S <- NP", "rule NP is used but never defined"},
+		{"duplicate rule", "S <- \"a\"\nS <- \"b\"", "line 2: rule S is defined twice"},
+		{"error names the rule", "S <- A\nA <- (", "line 2 (A)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseGrammar(tt.src)
+			if err == nil {
+				t.Fatalf("parseGrammar(%q) succeeded, want error containing %q", tt.src, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseGrammar(%q) error = %q, want it to contain %q", tt.src, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseGrammarNotation(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		start string
+		rules int
+	}{
+		{"example", testGrammar, "Sentence", 4},
+		{"bnf arrows", "S ::= A | B\nA ::= \"a\"\nB ::= 'b'", "S", 3},
+		{"hash inside a literal", `S <- "#" . # a trailing comment`, "S", 1},
+		{"comment after a literal", "S <- D . # a trailing comment\nD <- \"ta\" / 'ka' # determiners", "S", 2},
+		{"nested groups", `S <- (("a" / "b")+ .)?`, "S", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := parseGrammar(tt.src)
+			if err != nil {
+				t.Fatalf("parseGrammar: %v", err)
+			}
+			if g.start != tt.start {
+				t.Errorf("start = %q, want %q", g.start, tt.start)
+			}
+			if len(g.rules) != tt.rules {
+				t.Errorf("got %d rules, want %d", len(g.rules), tt.rules)
+			}
+		})
+	}
+}
+
+func TestGrammarParse(t *testing.T) {
+	storage.SetRoot(t.TempDir()) // No affix store: only the lexicon's affixes segment words
+	defer storage.SetRoot("")
+
+	g, err := parseGrammar(testGrammar)
+	if err != nil {
+		t.Fatalf("parseGrammar: %v", err)
+	}
+	tests := []struct {
+		sentence string
+		want     *GrammarFailure // nil when the sentence parses
+	}{
+		{"Ta kani miru.", nil},
+		{"kani sola sola miru ka kani", nil},
+		{"kanira miru", nil}, // Inflected noun
+		{"ta kani miru (kani).", nil},
+		{"", &GrammarFailure{Rule: "NounPhrase", Position: 0, Expected: []string{`"ka"`, `"ta"`, "@noun"}}},
+		{"miru kani", &GrammarFailure{Rule: "NounPhrase", Position: 0, Token: "miru", Expected: []string{`"ka"`, `"ta"`, "@noun"}}},
+		{"ta miru", &GrammarFailure{Rule: "NounPhrase", Position: 1, Token: "miru", Expected: []string{"@noun"}}},
+		{"ta kani", &GrammarFailure{Rule: "Sentence", Position: 2, Expected: []string{"@adjective", "@verb"}}},
+		{"ta kani miru miru", &GrammarFailure{Rule: "Sentence", Position: 3, Token: "miru", Expected: []string{`"."`, `"ka"`, `"ta"`, "@noun"}}},
+		{"kani miru . kani", &GrammarFailure{Rule: "Sentence", Position: 3, Token: "kani", Expected: []string{"end of sentence"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sentence, func(t *testing.T) {
+			got := g.parse(tokenizeSentence(tt.sentence), testGrammarLexicon)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("parse failed: %+v", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("parse succeeded, want failure %+v", *tt.want)
+			}
+			if got.Rule != tt.want.Rule || got.Position != tt.want.Position || got.Token != tt.want.Token || !slices.Equal(got.Expected, tt.want.Expected) {
+				t.Errorf("failure = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestGrammarParseLeftRecursion(t *testing.T) {
+	g, err := parseGrammar(`S <- S "a"`)
+	if err != nil {
+		t.Fatalf("parseGrammar: %v", err)
+	}
+	// The depth bound stops the recursion instead of hanging, before any
+	// word is tried
+	failure := g.parse([]string{"a", "a"}, nil)
+	if failure == nil {
+		t.Fatal("left-recursive grammar parsed")
+	}
+	if failure.Position != 0 || failure.Token != "a" {
+		t.Errorf("failure = %+v, want it at the first word", *failure)
+	}
+}
+
+func TestTokenizeSentence(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"Ta KANI miru.", []string{"ta", "kani", "miru", "."}},
+		{"kani, miru!", []string{"kani", ",", "miru", "!"}},
+		{`("kani")`, []string{"kani"}},
+		{"'miru?'", []string{"miru", "?"}},
+		{"...", []string{".", ".", "."}},
+	}
+	for _, tt := range tests {
+		got := tokenizeSentence(tt.text)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("tokenizeSentence(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
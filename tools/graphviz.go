@@ -0,0 +1,263 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+
+	"l2/storage"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// graphsDir is the data directory graphs are written to
+const graphsDir = "graphs"
+
+// Graphs that can be exported
+const (
+	GraphFamily      = "family"
+	GraphDerivations = "derivations"
+)
+
+// ExportGraphRequest represents a request to export a graph as DOT
+type ExportGraphRequest struct {
+	Graph  string `json:"graph" jsonschema:"required,enum=family,enum=derivations,description=family for the proto-language and its descendants or derivations for how lexicon words derive from each other"`
+	Word   string `json:"word" jsonschema:"description=For derivations only the words connected to this one instead of the whole lexicon"`
+	Render bool   `json:"render" jsonschema:"description=Also render the graph as SVG with Graphviz's dot"`
+}
+
+// ExportGraphResult represents an exported graph
+type ExportGraphResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	SVG     string `json:"svg,omitempty"` // Path of the rendered SVG
+}
+
+// ExportGraph writes the language family tree or the derivation graph as a
+// Graphviz DOT data file under graphs/, and renders it as SVG next to it
+// when asked
+func ExportGraph(ctx context.Context, req *ExportGraphRequest) (*ExportGraphResult, error) {
+	var dot string
+	var err error
+	name := req.Graph
+	switch req.Graph {
+	case GraphFamily:
+		dot, err = FamilyDOT()
+	case GraphDerivations:
+		dot, err = DerivationDOT(req.Word)
+		if req.Word != "" {
+			name += "_" + fileSlug(req.Word)
+		}
+	default:
+		return &ExportGraphResult{
+			Success: false,
+			Message: fmt.Sprintf("Unknown graph %q; use family or derivations", req.Graph),
+		}, nil
+	}
+	if err != nil {
+		return &ExportGraphResult{
+			Success: false,
+			Message: failure("build the "+req.Graph+" graph", err),
+		}, nil
+	}
+
+	result := &ExportGraphResult{Success: true, Path: path.Join(graphsDir, name+".dot")}
+	if err := storage.WriteDataFile(result.Path, []byte(dot)); err != nil {
+		return &ExportGraphResult{
+			Success: false,
+			Message: failure("save graph", err),
+		}, nil
+	}
+	result.Message = "Graph written to " + result.Path
+	if !req.Render {
+		return result, nil
+	}
+
+	svg, err := RenderDOT(ctx, dot)
+	if err != nil {
+		result.Message += "; rendering failed: " + err.Error()
+		return result, nil
+	}
+	result.SVG = path.Join(graphsDir, name+".svg")
+	if err := storage.WriteDataFile(result.SVG, svg); err != nil {
+		return &ExportGraphResult{
+			Success: false,
+			Message: failure("save rendered graph", err),
+		}, nil
+	}
+	result.Message += " and rendered to " + result.SVG
+	return result, nil
+}
+
+// RenderDOT renders a DOT graph as SVG with Graphviz's dot
+func RenderDOT(ctx context.Context, dot string) ([]byte, error) {
+	if _, err := exec.LookPath("dot"); err != nil {
+		return nil, fmt.Errorf("rendering needs Graphviz's dot; install graphviz")
+	}
+	cmd := exec.CommandContext(ctx, "dot", "-Tsvg")
+	cmd.Stdin = strings.NewReader(dot)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("dot failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// FamilyDOT draws the proto-language and its descendants as a DOT graph,
+// each edge labeled with the sound changes that led to the daughter
+func FamilyDOT() (string, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return "", fmt.Errorf("failed to read lexicon: %w", err)
+	}
+	descendants, err := loadDescendants()
+	if err != nil {
+		return "", fmt.Errorf("failed to read descendant languages: %w", err)
+	}
+	if len(descendants) == 0 {
+		return "", fmt.Errorf("no descendant languages yet; create one with evolve_lexicon")
+	}
+
+	var out strings.Builder
+	out.WriteString("digraph family {\n\trankdir=TB;\n\tnode [shape=box, style=rounded];\n")
+	fmt.Fprintf(&out, "\t%s [label=%s];\n", dotID(""), dotString(fmt.Sprintf("%s\n(proto-language, %d words)", storage.Language(), len(entries))))
+	names := map[string]bool{"": true}
+	for _, d := range descendants {
+		names[strings.ToLower(d.Name)] = true
+		fmt.Fprintf(&out, "\t%s [label=%s];\n", dotID(d.Name), dotString(fmt.Sprintf("%s\n(%d words)", d.Name, len(d.Entries))))
+	}
+	for _, d := range descendants {
+		parent := d.Parent
+		if !names[strings.ToLower(parent)] {
+			// A parent since removed leaves its daughter hanging from the proto-language
+			parent = ""
+		}
+		fmt.Fprintf(&out, "\t%s -> %s [label=%s];\n", dotID(parent), dotID(d.Name), dotString(d.RulesFile))
+	}
+	out.WriteString("}\n")
+	return out.String(), nil
+}
+
+// DerivationDOT draws how lexicon words derive from each other as a DOT
+// graph, each edge labeled with the derivation that made the word, with
+// proto-forms and loan sources as dashed nodes. Given a word, only the words
+// connected to it are drawn.
+func DerivationDOT(word string) (string, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return "", fmt.Errorf("failed to read lexicon: %w", err)
+	}
+	if word != "" {
+		if _, ok := findEntry(entries, word); !ok {
+			return "", fmt.Errorf("%s is not in the lexicon", word)
+		}
+		entries = derivationFamily(entries, word)
+	}
+
+	var out strings.Builder
+	out.WriteString("digraph derivations {\n\trankdir=LR;\n\tnode [shape=box, style=rounded];\n")
+	origins := map[string]bool{}
+	drawn := 0
+	for _, entry := range entries {
+		if word == "" && len(entry.DerivedFrom) == 0 && !derivesAny(entries, entry.Word) {
+			// Underived words nothing derives from would only clutter the graph
+			continue
+		}
+		drawn++
+		label := fmt.Sprintf("%s\n'%s'", entry.Word, entry.Definition)
+		if entry.PartOfSpeech != "" {
+			label += " (" + entry.PartOfSpeech + ")"
+		}
+		attributes := ""
+		if strings.EqualFold(entry.Word, word) {
+			attributes = ", penwidth=2"
+		}
+		fmt.Fprintf(&out, "\t%s [label=%s%s];\n", dotID(entry.Word), dotString(label), attributes)
+
+		for _, source := range entry.DerivedFrom {
+			if _, ok := findEntry(entries, source); !ok {
+				fmt.Fprintf(&out, "\t%s [label=%s, style=dashed];\n", dotID(source), dotString(source+"\n(not in lexicon)"))
+			}
+			edge := ""
+			if len(entry.Derivation) > 0 {
+				edge = fmt.Sprintf(" [label=%s]", dotString(entry.Derivation[len(entry.Derivation)-1]))
+			}
+			fmt.Fprintf(&out, "\t%s -> %s%s;\n", dotID(source), dotID(entry.Word), edge)
+		}
+		for _, origin := range []struct{ form, label string }{{entry.ProtoForm, "proto-form"}, {entry.BorrowedFrom, "borrowed"}} {
+			if origin.form == "" {
+				continue
+			}
+			id := dotID(origin.label + ":" + origin.form)
+			if !origins[id] {
+				origins[id] = true
+				fmt.Fprintf(&out, "\t%s [label=%s, style=dashed];\n", id, dotString(origin.form))
+			}
+			fmt.Fprintf(&out, "\t%s -> %s [label=%s, style=dashed];\n", id, dotID(entry.Word), dotString(origin.label))
+		}
+	}
+	out.WriteString("}\n")
+	if drawn == 0 {
+		return "", fmt.Errorf("no word derives from another yet")
+	}
+	return out.String(), nil
+}
+
+// derivationFamily returns the entries connected to a word through
+// derivation, in either direction
+func derivationFamily(entries []LexiconEntry, word string) []LexiconEntry {
+	connected := map[string]bool{strings.ToLower(word): true}
+	for grew := true; grew; {
+		grew = false
+		for _, entry := range entries {
+			key := strings.ToLower(entry.Word)
+			for _, source := range entry.DerivedFrom {
+				source = strings.ToLower(source)
+				if connected[key] != connected[source] {
+					connected[key], connected[source] = true, true
+					grew = true
+				}
+			}
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(entries), func(e LexiconEntry) bool { return !connected[strings.ToLower(e.Word)] })
+}
+
+// derivesAny tells whether any entry derives from a word
+func derivesAny(entries []LexiconEntry, word string) bool {
+	return slices.ContainsFunc(entries, func(e LexiconEntry) bool {
+		return slices.ContainsFunc(e.DerivedFrom, func(source string) bool { return strings.EqualFold(source, word) })
+	})
+}
+
+// dotID names a node after a word or language, case being ignored as in
+// lexicon lookups; the proto-language is the empty name
+func dotID(name string) string {
+	if name == "" {
+		return `"proto"`
+	}
+	return dotString("n:" + strings.ToLower(name))
+}
+
+// dotString quotes a DOT string, keeping line breaks
+func dotString(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// createExportGraphTool creates the graph export tool
+func createExportGraphTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"export_graph",
+		"Export the language family tree (family) or the word derivation graph (derivations, optionally only around one word) as a Graphviz DOT data file under graphs/, and optionally render it to SVG with Graphviz for viewing.",
+		ExportGraph,
+	)
+}
@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// HarmonyRequest represents a request to declare the vowel harmony systems
+type HarmonyRequest struct {
+	Systems []storage.HarmonySystem `json:"systems" jsonschema:"required,description=Harmony dimensions such as backness or ATR with their vowel classes; replaces any previously declared systems"`
+}
+
+// HarmonyCheckRequest represents a request to check words for harmony
+type HarmonyCheckRequest struct {
+	Words    []string `json:"words" jsonschema:"required,description=Words or stems to check"`
+	Suffixes []string `json:"suffixes" jsonschema:"description=Suffixes such as -ler to attach to every word; the suffix vowels should harmonize with the stem"`
+}
+
+// HarmonyCheck is the harmony verdict for one word or suffixed form
+type HarmonyCheck struct {
+	Form       string   `json:"form"`
+	Valid      bool     `json:"valid"`
+	Violations []string `json:"violations,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"` // Harmonized alternative for invalid forms
+}
+
+// HarmonyResult represents the result of vowel harmony operations
+type HarmonyResult struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Systems []storage.HarmonySystem `json:"systems,omitempty"`
+	Checks  []HarmonyCheck          `json:"checks,omitempty"`
+}
+
+// SetVowelHarmony stores the language's vowel harmony systems
+func SetVowelHarmony(ctx context.Context, req *HarmonyRequest) (*HarmonyResult, error) {
+	for _, system := range req.Systems {
+		if len(system.Classes) < 2 {
+			return &HarmonyResult{
+				Success: false,
+				Message: fmt.Sprintf("Harmony system %s needs at least two classes", system.Name),
+			}, nil
+		}
+		seen := map[string]string{}
+		for _, class := range system.Classes {
+			for _, vowel := range class.Vowels {
+				if other, ok := seen[vowel]; ok {
+					return &HarmonyResult{
+						Success: false,
+						Message: fmt.Sprintf("Vowel %s is in both %s and %s of %s", vowel, other, class.Name, system.Name),
+					}, nil
+				}
+				seen[vowel] = class.Name
+			}
+		}
+	}
+
+	if err := storage.WriteHarmony(req.Systems); err != nil {
+		return &HarmonyResult{
+			Success: false,
+			Message: failure("save vowel harmony", err),
+		}, nil
+	}
+	return &HarmonyResult{
+		Success: true,
+		Message: fmt.Sprintf("Saved %d harmony systems", len(req.Systems)),
+		Systems: req.Systems,
+	}, nil
+}
+
+// CheckHarmony checks words, and each word with each suffix attached, for
+// vowel harmony violations and proposes harmonized alternatives
+func CheckHarmony(ctx context.Context, req *HarmonyCheckRequest) (*HarmonyResult, error) {
+	if len(req.Words) == 0 {
+		return &HarmonyResult{
+			Success: false,
+			Message: "At least one word is required",
+		}, nil
+	}
+	h, err := loadHarmonizer()
+	if err != nil {
+		return &HarmonyResult{
+			Success: false,
+			Message: failure("load vowel harmony", err),
+		}, nil
+	}
+	if len(h.systems) == 0 {
+		return &HarmonyResult{
+			Success: false,
+			Message: "No vowel harmony declared yet; use set_vowel_harmony first",
+		}, nil
+	}
+
+	suffixes := req.Suffixes
+	if len(suffixes) == 0 {
+		suffixes = []string{""}
+	}
+	perWord, err := parallelMap(ctx, req.Words, func(word string) []HarmonyCheck {
+		word = strings.ToLower(strings.TrimSpace(word))
+		checks := make([]HarmonyCheck, 0, len(suffixes))
+		for _, suffix := range suffixes {
+			suffix = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(suffix)), "-")
+			check := HarmonyCheck{Form: word + suffix, Violations: h.violations(word + suffix)}
+			check.Valid = len(check.Violations) == 0
+			if !check.Valid {
+				if suggestion := h.harmonize(word, suffix); suggestion != check.Form {
+					check.Suggestion = suggestion
+				}
+			}
+			checks = append(checks, check)
+		}
+		return checks
+	})
+	if err != nil {
+		return &HarmonyResult{
+			Success: false,
+			Message: "Harmony check stopped: " + err.Error(),
+		}, nil
+	}
+	checks := []HarmonyCheck{}
+	invalid := 0
+	for _, wordChecks := range perWord {
+		for _, check := range wordChecks {
+			if !check.Valid {
+				invalid++
+			}
+			checks = append(checks, check)
+		}
+	}
+
+	return &HarmonyResult{
+		Success: true,
+		Message: fmt.Sprintf("Checked %d forms, %d break vowel harmony", len(checks), invalid),
+		Checks:  checks,
+	}, nil
+}
+
+// harmonizer checks and repairs vowel harmony using the declared systems
+type harmonizer struct {
+	systems  []storage.HarmonySystem
+	phonemes *phonemeSet
+	classOf  []map[string]int // Per system, vowel symbol to class index
+}
+
+func loadHarmonizer() (*harmonizer, error) {
+	systems, err := storage.ReadHarmony()
+	if err != nil {
+		return nil, err
+	}
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return nil, err
+	}
+	h := &harmonizer{systems: systems, phonemes: set}
+	for _, system := range systems {
+		classOf := map[string]int{}
+		for i, class := range system.Classes {
+			for _, vowel := range class.Vowels {
+				classOf[vowel] = i
+			}
+		}
+		h.classOf = append(h.classOf, classOf)
+	}
+	return h, nil
+}
+
+// symbol returns the inventory symbol for a segment, or the segment itself
+func (h *harmonizer) symbol(segment string) string {
+	if p, ok := h.phonemes.phoneme(segment); ok {
+		return p.Symbol
+	}
+	return segment
+}
+
+// spell returns how a phoneme symbol is written in the romanization
+func (h *harmonizer) spell(symbol string) string {
+	if p, ok := h.phonemes.phoneme(symbol); ok {
+		return romanization(p)
+	}
+	return symbol
+}
+
+// violations describes every harmony system a word mixes classes of
+func (h *harmonizer) violations(word string) []string {
+	violations := []string{}
+	segs := h.phonemes.segment(word)
+	for i, system := range h.systems {
+		first := map[int]string{}
+		order := []int{}
+		for _, seg := range segs {
+			class, ok := h.classOf[i][h.symbol(seg)]
+			if !ok {
+				continue
+			}
+			if _, seen := first[class]; !seen {
+				first[class] = seg
+				order = append(order, class)
+			}
+		}
+		if len(order) > 1 {
+			parts := []string{}
+			for _, class := range order {
+				parts = append(parts, fmt.Sprintf("%s (%s)", first[class], system.Classes[class].Name))
+			}
+			violations = append(violations, fmt.Sprintf("%s harmony: mixes %s", system.Name, strings.Join(parts, " and ")))
+		}
+	}
+	return violations
+}
+
+// harmonize rewrites disharmonic vowels to their counterparts in the
+// controlling class. For a bare word the first harmonic vowel controls the
+// rest of the word; for a suffixed form the last harmonic vowel of the stem
+// controls the suffix and the stem is left untouched.
+func (h *harmonizer) harmonize(stem, suffix string) string {
+	stemSegs := h.phonemes.segment(stem)
+	segs := append(append([]string{}, stemSegs...), h.phonemes.segment(suffix)...)
+
+	for i, system := range h.systems {
+		control := -1
+		start := 0
+		if suffix != "" {
+			start = len(stemSegs)
+			for j := len(stemSegs) - 1; j >= 0 && control < 0; j-- {
+				if class, ok := h.classOf[i][h.symbol(stemSegs[j])]; ok {
+					control = class
+				}
+			}
+		} else {
+			for j := 0; j < len(stemSegs) && control < 0; j++ {
+				if class, ok := h.classOf[i][h.symbol(stemSegs[j])]; ok {
+					control = class
+				}
+			}
+		}
+		if control < 0 {
+			continue
+		}
+
+		target := system.Classes[control].Vowels
+		for j := start; j < len(segs); j++ {
+			symbol := h.symbol(segs[j])
+			class, ok := h.classOf[i][symbol]
+			if !ok || class == control {
+				continue
+			}
+			for k, vowel := range system.Classes[class].Vowels {
+				if vowel == symbol && k < len(target) {
+					segs[j] = h.spell(target[k])
+					break
+				}
+			}
+		}
+	}
+	return strings.Join(segs, "")
+}
+
+// createSetVowelHarmonyTool creates the tool that declares vowel harmony
+func createSetVowelHarmonyTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_vowel_harmony",
+		"Declare the language's vowel harmony systems, such as front/back or ±ATR, each with its vowel classes. List the vowels of each class in corresponding order (e.g. front [i, e, y] and back [ɯ, a, u]) so disharmonic vowels can be mapped to their counterparts.",
+		SetVowelHarmony,
+	)
+}
+
+// createCheckHarmonyTool creates the vowel harmony checker tool
+func createCheckHarmonyTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"check_harmony",
+		"Check words, and words with suffixes attached, for vowel harmony violations against the declared harmony systems, proposing harmonized alternatives for disharmonic forms.",
+		CheckHarmony,
+	)
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// defaultHyphenationFile is where hyphenation patterns are written when no path is given
+const defaultHyphenationFile = "hyphenation.tex"
+
+// HyphenationRequest represents a request to export TeX hyphenation patterns
+type HyphenationRequest struct {
+	Path string `json:"path" jsonschema:"description=Data file path to write the patterns to (defaults to hyphenation.tex)"`
+}
+
+// HyphenationResult represents the result of a hyphenation export
+type HyphenationResult struct {
+	Success    bool     `json:"success"`
+	Message    string   `json:"message"`
+	Path       string   `json:"path,omitempty"`
+	Patterns   int      `json:"patterns"`
+	Exceptions int      `json:"exceptions"`
+	Examples   []string `json:"examples,omitempty"`
+}
+
+// ExportHyphenation derives hyphenation points from the syllable structure of
+// every lexicon word and writes them as a TeX hyphenation file
+func ExportHyphenation(ctx context.Context, req *HyphenationRequest) (*HyphenationResult, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return &HyphenationResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+
+	words := []string{}
+	for _, entry := range entries {
+		word := strings.ToLower(entry.Word)
+		if strings.ContainsAny(word, " -") {
+			continue // Skip phrases and affixes
+		}
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return &HyphenationResult{
+			Success: false,
+			Message: "The lexicon has no words to derive hyphenation from",
+		}, nil
+	}
+
+	// Words break where the language's own syllable template splits them
+	template, _, err := loadProsody()
+	if err != nil {
+		return &HyphenationResult{
+			Success: false,
+			Message: failure("load the syllable template", err),
+		}, nil
+	}
+	patterns, exceptions := hyphenationPatterns(template, words)
+
+	path := req.Path
+	if path == "" {
+		path = defaultHyphenationFile
+	}
+	if err := storage.WriteDataFile(path, []byte(renderHyphenationFile(patterns, exceptions))); err != nil {
+		return &HyphenationResult{
+			Success: false,
+			Message: failure("write hyphenation file", err),
+		}, nil
+	}
+
+	examples := exceptions
+	if len(examples) > 10 {
+		examples = examples[:10]
+	}
+
+	return &HyphenationResult{
+		Success:    true,
+		Message:    fmt.Sprintf("Wrote %d patterns and %d exceptions to %s", len(patterns), len(exceptions), path),
+		Path:       path,
+		Patterns:   len(patterns),
+		Exceptions: len(exceptions),
+		Examples:   examples,
+	}, nil
+}
+
+// hyphenationPatterns returns Liang-style patterns for letter pairs that the
+// template splits by a syllable boundary in most of the words they occur in,
+// plus the hyphenated form of every multi-syllable word as an exception list
+// so that known words always break correctly.
+func hyphenationPatterns(template *syllableTemplate, words []string) ([]string, []string) {
+	type pairCount struct{ split, total int }
+	pairs := map[string]*pairCount{}
+	exceptions := []string{}
+
+	for _, word := range words {
+		syllables := template.syllabify(word).Syllables
+		if len(syllables) > 1 {
+			exceptions = append(exceptions, strings.Join(syllables, "-"))
+		}
+
+		runes := []rune(word)
+		boundaries := map[int]bool{}
+		offset := 0
+		for _, syllable := range syllables[:len(syllables)-1] {
+			offset += len([]rune(syllable))
+			boundaries[offset] = true
+		}
+		for i := 1; i < len(runes); i++ {
+			key := string(runes[i-1 : i+1])
+			if pairs[key] == nil {
+				pairs[key] = &pairCount{}
+			}
+			pairs[key].total++
+			if boundaries[i] {
+				pairs[key].split++
+			}
+		}
+	}
+
+	patterns := []string{}
+	for key, count := range pairs {
+		if count.split*2 > count.total {
+			runes := []rune(key)
+			patterns = append(patterns, string(runes[0])+"1"+string(runes[1]))
+		}
+	}
+	sort.Strings(patterns)
+	sort.Strings(exceptions)
+	return patterns, exceptions
+}
+
+func renderHyphenationFile(patterns, exceptions []string) string {
+	var out strings.Builder
+	out.WriteString("% Hyphenation patterns generated by L2 from the lexicon's syllable structure,\n")
+	out.WriteString("% laid out like the hyph-*.tex files of hyph-utf8.\n")
+	out.WriteString("%\n")
+	out.WriteString("% pdfTeX and XeTeX only read \\patterns while a format is being built, so\n")
+	out.WriteString("% \\input in a document stops with \"Patterns can be loaded only by INITEX\".\n")
+	out.WriteString("% Instead add a line naming a new language and this file to language.dat,\n")
+	out.WriteString("% rebuild the formats with fmtutil, and select the language with babel or\n")
+	out.WriteString("% polyglossia. LuaLaTeX can load the patterns while typesetting: declare the\n")
+	out.WriteString("% language with \\babelprovide and pass the lines below to\n")
+	out.WriteString("% \\babelpatterns[<language>]{...} and \\babelhyphenation[<language>]{...}.\n")
+	out.WriteString("% Non-ASCII letters need \\lccode assignments, or a Unicode engine such as LuaTeX or XeTeX.\n\n")
+
+	out.WriteString("\\patterns{\n")
+	for _, p := range patterns {
+		out.WriteString(p + "\n")
+	}
+	out.WriteString("}\n\n")
+
+	out.WriteString("\\hyphenation{\n")
+	for _, e := range exceptions {
+		out.WriteString(e + "\n")
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// createHyphenationTool creates the hyphenation export tool
+func createHyphenationTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"export_hyphenation",
+		"Derive hyphenation points from the syllable structure of lexicon words and export a TeX-compatible hyphenation pattern file for typesetting conlang documents.",
+		ExportHyphenation,
+	)
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHyphenationPatterns(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		onsets     []string
+		words      []string
+		patterns   []string
+		exceptions []string
+	}{
+		{"default template", "", nil, []string{"patra", "kanta"}, []string{"n1t", "t1r"}, []string{"kan-ta", "pat-ra"}},
+		{"declared onsets", "(C)(C)V(C)", []string{"tr"}, []string{"patra", "kanta"}, []string{"a1t", "n1t"}, []string{"kan-ta", "pa-tra"}},
+		{"one syllable", "", nil, []string{"ka"}, []string{}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := parseSyllableTemplate(tt.template, tt.onsets)
+			if err != nil {
+				t.Fatalf("parseSyllableTemplate: %v", err)
+			}
+			patterns, exceptions := hyphenationPatterns(template, tt.words)
+			if !slices.Equal(patterns, tt.patterns) {
+				t.Errorf("patterns = %q, want %q", patterns, tt.patterns)
+			}
+			if !slices.Equal(exceptions, tt.exceptions) {
+				t.Errorf("exceptions = %q, want %q", exceptions, tt.exceptions)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// DefaultInflectionFile is the data file the inflection rules are read from
+const DefaultInflectionFile = "inflection.txt"
+
+// Inflection files group rules into classes headed by a part of speech,
+// optionally only for stems with a given ending. The most specific class
+// matching a word is used.
+//
+//	# comments start with a hash
+//	[noun]
+//	case: NOM, ACC -ka, DAT -ni
+//	number: SG, PL ri-
+//
+//	[verb -u]
+//	tense: PRS -, PST -ta
+//	mutation PST: u > o / _#
+//	irregular tesu PST: tesa
+//
+// A category line lists its values with an optional affix; a value without
+// one uses the affix store's affix with that gloss, and - or Ø marks zero.
+// Mutations are sound change rules applied to the stem in every cell
+// carrying all the listed labels, before affixation. Irregular lines
+// override a whole cell of one word.
+
+// inflectionClass is one section of an inflection file
+type inflectionClass struct {
+	pos        string
+	ending     string
+	dimensions []ParadigmDimension
+	mutations  []inflectionMutation
+	irregular  []storage.SuppletiveForm
+}
+
+type inflectionMutation struct {
+	labels []string
+	rule   soundChange
+}
+
+// inflectionRules are the parsed classes of an inflection file
+type inflectionRules struct {
+	classes []*inflectionClass
+	changer *soundChanger // Applies the mutation rules
+}
+
+// InflectRequest represents a request to inflect a lexicon word
+type InflectRequest struct {
+	Word  string `json:"word" jsonschema:"required,description=The lexicon noun, verb or other word to inflect"`
+	Rules string `json:"rules" jsonschema:"description=Data file holding the inflection rules (default inflection.txt)"`
+	Path  string `json:"path" jsonschema:"description=Data file path to save the table to"`
+}
+
+// parseInflectionRules parses an inflection file
+func parseInflectionRules(source string, set *phonemeSet) (*inflectionRules, error) {
+	rules := &inflectionRules{changer: &soundChanger{phonemes: set}}
+	var class *inflectionClass
+	for n, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("line %d: %s", n+1, fmt.Sprintf(format, args...))
+		}
+
+		if strings.HasPrefix(line, "[") {
+			header := strings.Fields(strings.Trim(line, "[]"))
+			if !strings.HasSuffix(line, "]") || len(header) == 0 || len(header) > 2 {
+				return nil, fail("class headers look like [noun] or [verb -u]")
+			}
+			class = &inflectionClass{pos: strings.ToLower(header[0])}
+			if len(header) == 2 {
+				class.ending = strings.ToLower(strings.TrimPrefix(header[1], "-"))
+			}
+			rules.classes = append(rules.classes, class)
+			continue
+		}
+		if class == nil {
+			return nil, fail("rules must follow a class header such as [noun]")
+		}
+
+		head, body, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fail("expected a category, mutation or irregular rule followed by a colon")
+		}
+		head, body = strings.TrimSpace(head), strings.TrimSpace(body)
+		fields := strings.Fields(head)
+		switch {
+		case len(fields) == 2 && fields[0] == "mutation":
+			rule, err := rules.changer.parseRule(body)
+			if err != nil {
+				return nil, fail("%s", err.Error())
+			}
+			class.mutations = append(class.mutations, inflectionMutation{labels: strings.Split(fields[1], "."), rule: rule})
+		case len(fields) == 3 && fields[0] == "irregular":
+			if body == "" {
+				return nil, fail("irregular %s %s needs a form", fields[1], fields[2])
+			}
+			class.irregular = append(class.irregular, storage.SuppletiveForm{Lexeme: fields[1], Cell: strings.Split(fields[2], "."), Form: body})
+		case len(fields) == 1:
+			dimension := ParadigmDimension{Name: head}
+			for _, value := range strings.Split(body, ",") {
+				parts := strings.Fields(value)
+				if len(parts) == 0 || len(parts) > 2 {
+					return nil, fail("values of %s look like LABEL or LABEL affix", head)
+				}
+				v := ParadigmValue{Label: parts[0]}
+				if len(parts) == 2 {
+					v.Affix = parts[1]
+				}
+				dimension.Values = append(dimension.Values, v)
+			}
+			class.dimensions = append(class.dimensions, dimension)
+		default:
+			return nil, fail("unrecognized rule %q", head)
+		}
+	}
+	for _, class := range rules.classes {
+		if len(class.dimensions) == 0 {
+			return nil, fmt.Errorf("class [%s] has no categories", strings.TrimSpace(class.pos+" -"+class.ending))
+		}
+	}
+	return rules, nil
+}
+
+// classFor returns the most specific class for a word and part of speech
+func (r *inflectionRules) classFor(word, pos string) *inflectionClass {
+	var best *inflectionClass
+	for _, class := range r.classes {
+		if !strings.EqualFold(class.pos, pos) || !strings.HasSuffix(strings.ToLower(word), class.ending) {
+			continue
+		}
+		if best == nil || len(class.ending) > len(best.ending) {
+			best = class
+		}
+	}
+	return best
+}
+
+// mutate applies the class's stem mutations for a cell
+func (r *inflectionRules) mutate(class *inflectionClass, stem string, cell []ParadigmValue, spellings map[string]string) string {
+	has := map[string]bool{}
+	for _, v := range cell {
+		has[strings.ToLower(v.Label)] = true
+	}
+	var symbols []string
+	for _, m := range class.mutations {
+		applies := true
+		for _, label := range m.labels {
+			if !has[strings.ToLower(label)] {
+				applies = false
+				break
+			}
+		}
+		if !applies {
+			continue
+		}
+		if symbols == nil {
+			symbols = r.changer.symbols(stem)
+		}
+		symbols = r.changer.applyRule(m.rule, symbols)
+	}
+	if symbols == nil {
+		return stem
+	}
+	return respell(symbols, spellings)
+}
+
+// Inflect produces the full inflection table of a lexicon word from the
+// rules of its class in the inflection file
+func Inflect(ctx context.Context, req *InflectRequest) (*ParadigmResult, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	entry, ok := findEntry(entries, req.Word)
+	if !ok {
+		return &ParadigmResult{
+			Success: false,
+			Message: fmt.Sprintf("%s is not in the lexicon", req.Word),
+		}, nil
+	}
+
+	rulesFile := req.Rules
+	if rulesFile == "" {
+		rulesFile = DefaultInflectionFile
+	}
+	source, err := storage.ReadDataFile(rulesFile)
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read "+rulesFile+" (write classes like [noun] followed by lines like case: NOM, ACC -ka)", err),
+		}, nil
+	}
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	rules, err := parseInflectionRules(string(source), set)
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: "Invalid inflection rules in " + rulesFile + ": " + err.Error(),
+		}, nil
+	}
+	class := rules.classFor(entry.Word, entry.PartOfSpeech)
+	if class == nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: fmt.Sprintf("No class in %s inflects %s (%s)", rulesFile, entry.Word, entry.PartOfSpeech),
+		}, nil
+	}
+
+	store, err := loadAffixStore()
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read affixes", err),
+		}, nil
+	}
+	suppletion, err := storage.ReadSuppletion()
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read suppletive forms", err),
+		}, nil
+	}
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	spellings := symbolSpellings(inventory)
+	// Irregular cells in the rules count as suppletive forms of the word
+	suppletion = append(suppletion, class.irregular...)
+
+	cells := paradigmCells(class.dimensions)
+	forms := make([]ParadigmForm, 0, len(cells))
+	irregular := 0
+	for _, c := range cells {
+		stem := rules.mutate(class, entry.Word, c, spellings)
+		cell := realizeCell(stem, entry.Word, headGloss(entry.Definition), c, store, suppletion)
+		f := ParadigmForm{Labels: []string{}, Form: cell.form(), Suppletive: cell.suppletive}
+		for _, v := range c {
+			f.Labels = append(f.Labels, v.Label)
+		}
+		if f.Suppletive {
+			irregular++
+		}
+		forms = append(forms, f)
+	}
+	table := renderParadigmTable(entry.Word, class.dimensions, forms)
+
+	message := fmt.Sprintf("Inflected %s with %d forms", entry.Word, len(forms))
+	if irregular > 0 {
+		message += fmt.Sprintf(", %d of them irregular", irregular)
+	}
+	if req.Path != "" {
+		if err := storage.WriteDataFile(req.Path, []byte(table)); err != nil {
+			return &ParadigmResult{
+				Success: false,
+				Message: failure("save table", err),
+			}, nil
+		}
+		message += " and saved the table to " + req.Path
+	}
+	return &ParadigmResult{
+		Success: true,
+		Message: message,
+		Forms:   forms,
+		Table:   table,
+	}, nil
+}
+
+// createInflectTool creates the inflection table tool
+func createInflectTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"inflect",
+		"Produce the full conjugation or declension table of a lexicon word from the inflection rules in a data file (inflection.txt by default): per part-of-speech classes with suffix tables, stem mutations and irregular overrides. "+
+			"Rules look like [verb -u] headers followed by lines such as tense: PRS -, PST -ta; mutation PST: u > o / _#; irregular tesu PST: tesa. Use this instead of deriving tables yourself.",
+		Inflect,
+	)
+}
@@ -0,0 +1,315 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// PhonemeInventoryRequest represents a request to declare the phoneme inventory
+type PhonemeInventoryRequest struct {
+	Consonants []storage.Phoneme `json:"consonants" jsonschema:"description=The consonant phonemes"`
+	Vowels     []storage.Phoneme `json:"vowels" jsonschema:"description=The vowel phonemes"`
+	Merge      bool              `json:"merge" jsonschema:"description=Add or update the given phonemes instead of replacing the whole inventory"`
+}
+
+// GetPhonemeInventoryRequest represents a request to get the phoneme inventory
+type GetPhonemeInventoryRequest struct {
+	// Empty struct for consistency with other tools
+}
+
+// InventoryResult represents the result of phoneme inventory operations
+type InventoryResult struct {
+	Success   bool                      `json:"success"`
+	Message   string                    `json:"message"`
+	Inventory *storage.PhonemeInventory `json:"inventory,omitempty"`
+	Table     string                    `json:"table,omitempty"`
+}
+
+// SetPhonemeInventory replaces or extends the stored phoneme inventory
+func SetPhonemeInventory(ctx context.Context, req *PhonemeInventoryRequest) (*InventoryResult, error) {
+	current, err := storage.ReadInventory()
+	if err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	inventory := current
+	if !req.Merge {
+		// Replacing the segments keeps the declared suprasegmentals
+		inventory = storage.PhonemeInventory{Consonants: []storage.Phoneme{}, Vowels: []storage.Phoneme{}, Suprasegmentals: current.Suprasegmentals}
+	}
+	inventory.Consonants = mergePhonemes(inventory.Consonants, req.Consonants)
+	inventory.Vowels = mergePhonemes(inventory.Vowels, req.Vowels)
+
+	if err := validateInventory(inventory); err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: "Invalid phoneme inventory: " + err.Error(),
+		}, nil
+	}
+
+	if err := storage.WriteInventory(inventory); err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: failure("save phoneme inventory", err),
+		}, nil
+	}
+
+	return &InventoryResult{
+		Success:   true,
+		Message:   fmt.Sprintf("Saved %d consonants and %d vowels", len(inventory.Consonants), len(inventory.Vowels)),
+		Inventory: &inventory,
+		Table:     renderInventoryTable(inventory),
+	}, nil
+}
+
+// GetPhonemeInventory returns the stored phoneme inventory
+func GetPhonemeInventory(ctx context.Context, req *GetPhonemeInventoryRequest) (*InventoryResult, error) {
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	if inventory.Empty() {
+		return &InventoryResult{
+			Success: true,
+			Message: "No phoneme inventory has been declared yet",
+		}, nil
+	}
+
+	return &InventoryResult{
+		Success:   true,
+		Message:   fmt.Sprintf("%d consonants and %d vowels", len(inventory.Consonants), len(inventory.Vowels)),
+		Inventory: &inventory,
+		Table:     renderInventoryTable(inventory),
+	}, nil
+}
+
+// mergePhonemes adds phonemes to a list, replacing any with the same symbol
+func mergePhonemes(current, updates []storage.Phoneme) []storage.Phoneme {
+	for _, p := range updates {
+		p.Symbol = strings.TrimSpace(p.Symbol)
+		p.Romanization = strings.TrimSpace(p.Romanization)
+		replaced := false
+		for i := range current {
+			if current[i].Symbol == p.Symbol {
+				current[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			current = append(current, p)
+		}
+	}
+	return current
+}
+
+// validateInventory rejects empty or repeated symbols and romanizations that
+// two phonemes share, since text could then not be mapped back unambiguously
+func validateInventory(inventory storage.PhonemeInventory) error {
+	symbols := map[string]bool{}
+	spellings := map[string]string{}
+	for _, p := range append(append([]storage.Phoneme{}, inventory.Consonants...), inventory.Vowels...) {
+		if p.Symbol == "" {
+			return fmt.Errorf("phoneme symbol is required")
+		}
+		if symbols[p.Symbol] {
+			return fmt.Errorf("/%s/ is listed more than once", p.Symbol)
+		}
+		symbols[p.Symbol] = true
+
+		spelling := romanization(p)
+		if other, ok := spellings[spelling]; ok {
+			return fmt.Errorf("/%s/ and /%s/ are both romanized as %q", other, p.Symbol, spelling)
+		}
+		spellings[spelling] = p.Symbol
+	}
+	return nil
+}
+
+// romanization returns how a phoneme is spelled, defaulting to its symbol
+func romanization(p storage.Phoneme) string {
+	if p.Romanization != "" {
+		return p.Romanization
+	}
+	return p.Symbol
+}
+
+// symbolSpellings maps each phoneme symbol of an inventory to its romanization
+func symbolSpellings(inventory storage.PhonemeInventory) map[string]string {
+	spellings := map[string]string{}
+	for _, p := range append(append([]storage.Phoneme{}, inventory.Consonants...), inventory.Vowels...) {
+		spellings[p.Symbol] = romanization(p)
+	}
+	return spellings
+}
+
+// respell writes phoneme symbols in the romanization, keeping symbols the
+// inventory doesn't know as they are
+func respell(symbols []string, spellings map[string]string) string {
+	var out strings.Builder
+	for _, symbol := range symbols {
+		if spelling, ok := spellings[symbol]; ok {
+			out.WriteString(spelling)
+		} else {
+			out.WriteString(symbol)
+		}
+	}
+	return out.String()
+}
+
+// RenderInventory renders the inventory as markdown tables for display
+func RenderInventory(inventory storage.PhonemeInventory) string {
+	return renderInventoryTable(inventory)
+}
+
+// renderInventoryTable renders the inventory as markdown tables
+func renderInventoryTable(inventory storage.PhonemeInventory) string {
+	var out strings.Builder
+	for _, group := range []struct {
+		name     string
+		phonemes []storage.Phoneme
+	}{{"Consonants", inventory.Consonants}, {"Vowels", inventory.Vowels}} {
+		if len(group.phonemes) == 0 {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("### %s\n\n| Phoneme | Romanization | Features |\n|---|---|---|\n", group.name))
+		for _, p := range group.phonemes {
+			out.WriteString(fmt.Sprintf("| /%s/ | %s | %s |\n", p.Symbol, romanization(p), strings.Join(p.Features, ", ")))
+		}
+		out.WriteString("\n")
+	}
+	if supra := inventory.Suprasegmentals; !supra.Empty() {
+		out.WriteString(renderSuprasegmentals(supra))
+	}
+	return out.String()
+}
+
+// phonemeSet segments text into the phonemes of the declared inventory.
+// Both IPA symbols and romanizations are recognized; where a romanization
+// collides with another phoneme's symbol the romanization wins, since the
+// lexicon is written in romanization.
+type phonemeSet struct {
+	phonemes map[string]storage.Phoneme // Spelling or symbol to phoneme
+	vowels   map[string]bool            // Symbols of vowel phonemes
+	longest  int                        // Length in runes of the longest spelling
+}
+
+// newPhonemeSet indexes an inventory, returning nil for an empty inventory
+func newPhonemeSet(inventory storage.PhonemeInventory) *phonemeSet {
+	if inventory.Empty() {
+		return nil
+	}
+	set := &phonemeSet{phonemes: map[string]storage.Phoneme{}, vowels: map[string]bool{}}
+	for _, p := range inventory.Vowels {
+		set.vowels[p.Symbol] = true
+	}
+	all := append(append([]storage.Phoneme{}, inventory.Consonants...), inventory.Vowels...)
+	for _, p := range all {
+		set.add(strings.ToLower(p.Symbol), p)
+	}
+	for _, p := range all {
+		set.add(strings.ToLower(romanization(p)), p)
+	}
+	return set
+}
+
+func (s *phonemeSet) add(spelling string, p storage.Phoneme) {
+	s.phonemes[spelling] = p
+	s.longest = max(s.longest, len([]rune(spelling)))
+}
+
+// loadPhonemeSet reads the stored inventory, returning nil if none is declared
+func loadPhonemeSet() (*phonemeSet, error) {
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return nil, err
+	}
+	return newPhonemeSet(inventory), nil
+}
+
+// segment splits a word into segments by longest match against the
+// inventory. Without an inventory it falls back to single letters.
+func (s *phonemeSet) segment(word string) []string {
+	if s == nil {
+		return segments(word)
+	}
+	runes := []rune(word)
+	segs := []string{}
+	for i := 0; i < len(runes); {
+		n := 0
+		for l := min(s.longest, len(runes)-i); l > 0; l-- {
+			if _, ok := s.phonemes[strings.ToLower(string(runes[i:i+l]))]; ok {
+				n = l
+				break
+			}
+		}
+		if n == 0 {
+			n = 1
+		}
+		// Keep trailing diacritics on the segment
+		for i+n < len(runes) && isSegmentModifier(runes[i+n]) {
+			n++
+		}
+		segs = append(segs, string(runes[i:i+n]))
+		i += n
+	}
+	return segs
+}
+
+// phoneme returns the inventory phoneme a segment spells
+func (s *phonemeSet) phoneme(segment string) (storage.Phoneme, bool) {
+	if s == nil {
+		return storage.Phoneme{}, false
+	}
+	p, ok := s.phonemes[strings.ToLower(segment)]
+	return p, ok
+}
+
+// phonemesOf returns the inventory symbols of the phonemes in text, in order
+func (s *phonemeSet) phonemesOf(text string) []string {
+	symbols := []string{}
+	for _, word := range TokenizeWords(text) {
+		for _, seg := range s.segment(word) {
+			if p, ok := s.phoneme(seg); ok {
+				symbols = append(symbols, p.Symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// vowel reports whether a segment is a syllable nucleus
+func (s *phonemeSet) vowel(segment string) bool {
+	if p, ok := s.phoneme(segment); ok {
+		return s.vowels[p.Symbol]
+	}
+	return isVowel([]rune(segment)[0])
+}
+
+// createSetPhonemeInventoryTool creates the tool that declares the phoneme inventory
+func createSetPhonemeInventoryTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_phoneme_inventory",
+		"Declare the language's consonant and vowel phonemes with their features and romanizations. Syllabification, phonotactics checks and stats read this inventory as the single source of truth. Set merge to add or update phonemes without replacing the rest.",
+		SetPhonemeInventory,
+	)
+}
+
+// createGetPhonemeInventoryTool creates the tool that retrieves the phoneme inventory
+func createGetPhonemeInventoryTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"get_phoneme_inventory",
+		"Retrieve the language's declared consonant and vowel phonemes with their features and romanizations.",
+		GetPhonemeInventory,
+	)
+}
@@ -0,0 +1,361 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"l2/storage"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// DefaultKinshipFile is the data file the kinship chart is written to
+const DefaultKinshipFile = "kinship.json"
+
+// kinTypes are the relatives a kinship chart names, in the usual notation:
+// F father, M mother, B brother, Z sister, S son, D daughter. Ego is male
+// where the systems depend on it.
+var kinTypes = []string{
+	"F", "M", "FB", "FZ", "MB", "MZ", "B", "Z",
+	"FBS", "FBD", "FZS", "FZD", "MBS", "MBD", "MZS", "MZD",
+	"S", "D", "BS", "BD", "ZS", "ZD",
+}
+
+var kinLetters = map[rune]string{'F': "father", 'M': "mother", 'B': "brother", 'Z': "sister", 'S': "son", 'D': "daughter"}
+
+// kinshipSystems map every kin type to the category it is named by in each
+// of Murdock's six systems
+var kinshipSystems = map[string]map[string]string{
+	"eskimo": kinCategories(map[string][]string{
+		"father": {"F"}, "mother": {"M"}, "uncle": {"FB", "MB"}, "aunt": {"FZ", "MZ"}, "brother": {"B"}, "sister": {"Z"},
+		"cousin": {"FBS", "FBD", "FZS", "FZD", "MBS", "MBD", "MZS", "MZD"},
+		"son":    {"S"}, "daughter": {"D"}, "nephew": {"BS", "ZS"}, "niece": {"BD", "ZD"},
+	}),
+	"hawaiian": kinCategories(map[string][]string{
+		"father": {"F", "FB", "MB"}, "mother": {"M", "MZ", "FZ"},
+		"brother": {"B", "FBS", "FZS", "MBS", "MZS"}, "sister": {"Z", "FBD", "FZD", "MBD", "MZD"},
+		"son": {"S", "BS", "ZS"}, "daughter": {"D", "BD", "ZD"},
+	}),
+	"iroquois": kinCategories(map[string][]string{
+		"father": {"F", "FB"}, "mother": {"M", "MZ"}, "uncle": {"MB"}, "aunt": {"FZ"},
+		"brother": {"B", "FBS", "MZS"}, "sister": {"Z", "FBD", "MZD"},
+		"male cross-cousin": {"FZS", "MBS"}, "female cross-cousin": {"FZD", "MBD"},
+		"son": {"S", "BS"}, "daughter": {"D", "BD"}, "nephew": {"ZS"}, "niece": {"ZD"},
+	}),
+	"omaha": kinCategories(map[string][]string{
+		"father": {"F", "FB"}, "mother": {"M", "MZ", "MBD"}, "uncle": {"MB", "MBS"}, "aunt": {"FZ"},
+		"brother": {"B", "FBS", "MZS"}, "sister": {"Z", "FBD", "MZD"},
+		"son": {"S", "BS"}, "daughter": {"D", "BD"}, "nephew": {"ZS", "FZS"}, "niece": {"ZD", "FZD"},
+	}),
+	"crow": kinCategories(map[string][]string{
+		"father": {"F", "FB", "FZS"}, "mother": {"M", "MZ"}, "uncle": {"MB"}, "aunt": {"FZ", "FZD"},
+		"brother": {"B", "FBS", "MZS"}, "sister": {"Z", "FBD", "MZD"},
+		"son": {"S", "BS", "MBS"}, "daughter": {"D", "BD", "MBD"}, "nephew": {"ZS"}, "niece": {"ZD"},
+	}),
+	"sudanese": kinCategories(nil),
+}
+
+// kinCategories inverts category lists into a kin type map; kin types not
+// listed are their own category, named by their description
+func kinCategories(categories map[string][]string) map[string]string {
+	byType := map[string]string{}
+	for category, types := range categories {
+		for _, t := range types {
+			byType[t] = category
+		}
+	}
+	for _, t := range kinTypes {
+		if byType[t] == "" {
+			byType[t] = describeKinType(t)
+		}
+	}
+	return byType
+}
+
+// describeKinType spells out a kin type such as FZD as father's sister's daughter
+func describeKinType(kinType string) string {
+	words := []string{}
+	for _, r := range kinType {
+		words = append(words, kinLetters[r])
+	}
+	return strings.Join(words, "'s ")
+}
+
+// KinTerm is the term for one category of relatives
+type KinTerm struct {
+	Category string   `json:"category"`
+	KinTypes []string `json:"kin_types"`
+	Term     string   `json:"term,omitempty"` // Empty while no root covers the category
+	Composed bool     `json:"composed,omitempty"`
+}
+
+// KinshipChart is a language's kinship terminology
+type KinshipChart struct {
+	System string    `json:"system"`
+	Terms  []KinTerm `json:"terms"`
+}
+
+// KinshipRequest represents a request to design the kinship terminology
+type KinshipRequest struct {
+	System string            `json:"system" jsonschema:"required,description=Kinship system: eskimo, hawaiian, iroquois, omaha, crow or sudanese"`
+	Roots  map[string]string `json:"roots" jsonschema:"required,description=Existing roots by category such as father: aba or cousin: tel; father, mother, brother, sister, son and daughter are enough to compose the rest"`
+	Joiner string            `json:"joiner" jsonschema:"description=Text joining roots in composed terms such as father's brother (default none)"`
+}
+
+// KinTermRequest represents a request to check a new kinship term
+type KinTermRequest struct {
+	Term     string   `json:"term" jsonschema:"required,description=The proposed kinship term"`
+	KinTypes []string `json:"kin_types" jsonschema:"required,description=Relatives it should name, in F M B Z S D notation such as FB and MB"`
+	Save     bool     `json:"save" jsonschema:"description=Store the term in the chart when it is consistent"`
+}
+
+// KinshipResult represents a kinship chart or a term check
+type KinshipResult struct {
+	Success  bool      `json:"success"`
+	Message  string    `json:"message"`
+	System   string    `json:"system,omitempty"`
+	Terms    []KinTerm `json:"terms,omitempty"`
+	Problems []string  `json:"problems,omitempty"`
+	Table    string    `json:"table,omitempty"`
+}
+
+// DesignKinship generates the full kinship chart of a system from the given
+// roots, composing terms for categories without one, and stores it
+func DesignKinship(ctx context.Context, req *KinshipRequest) (*KinshipResult, error) {
+	system := strings.ToLower(strings.TrimSpace(req.System))
+	categories, ok := kinshipSystems[system]
+	if !ok {
+		return &KinshipResult{
+			Success: false,
+			Message: fmt.Sprintf("Unknown kinship system %s; choose eskimo, hawaiian, iroquois, omaha, crow or sudanese", req.System),
+		}, nil
+	}
+	roots := map[string]string{}
+	for category, root := range req.Roots {
+		roots[strings.ToLower(strings.TrimSpace(category))] = strings.TrimSpace(root)
+	}
+
+	chart := KinshipChart{System: system}
+	for _, t := range kinTypes {
+		i := slices.IndexFunc(chart.Terms, func(k KinTerm) bool { return k.Category == categories[t] })
+		if i < 0 {
+			chart.Terms = append(chart.Terms, KinTerm{Category: categories[t]})
+			i = len(chart.Terms) - 1
+		}
+		chart.Terms[i].KinTypes = append(chart.Terms[i].KinTypes, t)
+	}
+
+	result := &KinshipResult{Success: true, System: system}
+	for i, term := range chart.Terms {
+		if root, ok := roots[term.Category]; ok {
+			chart.Terms[i].Term = root
+			continue
+		}
+		// Compose from the roots along the category's first kin type
+		parts := []string{}
+		for _, r := range term.KinTypes[0] {
+			root, ok := roots[kinLetters[r]]
+			if !ok {
+				parts = nil
+				break
+			}
+			parts = append(parts, root)
+		}
+		if parts == nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("No root for %s and none to compose it from", term.Category))
+			continue
+		}
+		chart.Terms[i].Term = strings.Join(parts, req.Joiner)
+		chart.Terms[i].Composed = len(parts) > 1
+	}
+	for category := range roots {
+		if !slices.ContainsFunc(chart.Terms, func(k KinTerm) bool { return k.Category == category }) && !isKinLetterWord(category) {
+			result.Problems = append(result.Problems, fmt.Sprintf("The %s system has no %s category", system, category))
+		}
+	}
+	result.Problems = append(result.Problems, kinHomonyms(chart.Terms)...)
+
+	if entries, err := loadLexicon(); err == nil {
+		for _, root := range roots {
+			if _, ok := findEntry(entries, root); !ok {
+				result.Problems = append(result.Problems, fmt.Sprintf("Root %s is not in the lexicon", root))
+			}
+		}
+	}
+	sort.Strings(result.Problems)
+
+	if err := saveKinshipChart(chart); err != nil {
+		return &KinshipResult{
+			Success: false,
+			Message: failure("save kinship chart", err),
+		}, nil
+	}
+	result.Terms = chart.Terms
+	result.Table = renderKinshipChart(chart)
+	result.Message = fmt.Sprintf("Generated a kinship chart for the %s system with %d terms and saved it to %s", system, len(chart.Terms), DefaultKinshipFile)
+	return result, nil
+}
+
+// CheckKinTerm checks that a new term names exactly one category of the
+// chosen system, and neither merges categories the system keeps apart nor
+// splits one it merges
+func CheckKinTerm(ctx context.Context, req *KinTermRequest) (*KinshipResult, error) {
+	chart, err := loadKinshipChart()
+	if err != nil {
+		return &KinshipResult{
+			Success: false,
+			Message: failure("read kinship chart (design one with design_kinship first)", err),
+		}, nil
+	}
+	categories := kinshipSystems[chart.System]
+	if len(req.KinTypes) == 0 || strings.TrimSpace(req.Term) == "" {
+		return &KinshipResult{
+			Success: false,
+			Message: "A term and the kin types it names are required",
+		}, nil
+	}
+
+	result := &KinshipResult{Success: true, System: chart.System}
+	named := map[string][]string{} // Category to the requested kin types in it
+	for _, t := range req.KinTypes {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		category, ok := categories[t]
+		if !ok {
+			result.Problems = append(result.Problems, fmt.Sprintf("Unknown kin type %s; use one of %s", t, strings.Join(kinTypes, ", ")))
+			continue
+		}
+		named[category] = appendUnique(named[category], t)
+	}
+	if len(named) > 1 {
+		names := make([]string, 0, len(named))
+		for category := range named {
+			names = append(names, category)
+		}
+		sort.Strings(names)
+		result.Problems = append(result.Problems, fmt.Sprintf("The %s system names these relatives with different terms: %s", chart.System, strings.Join(names, ", ")))
+	}
+	var target *KinTerm
+	for i, term := range chart.Terms {
+		types, ok := named[term.Category]
+		if !ok {
+			if strings.EqualFold(term.Term, req.Term) {
+				result.Problems = append(result.Problems, fmt.Sprintf("%s already names %s", req.Term, term.Category))
+			}
+			continue
+		}
+		target = &chart.Terms[i]
+		for _, t := range term.KinTypes {
+			if !slices.Contains(types, t) {
+				result.Problems = append(result.Problems, fmt.Sprintf("In the %s system %s (%s) has the same term as %s", chart.System, t, describeKinType(t), strings.Join(types, ", ")))
+			}
+		}
+	}
+
+	result.Message = fmt.Sprintf("%s is consistent with the %s system", req.Term, chart.System)
+	if len(result.Problems) > 0 {
+		result.Message = fmt.Sprintf("%s is inconsistent with the %s system", req.Term, chart.System)
+	} else if req.Save && target != nil {
+		target.Term, target.Composed = req.Term, false
+		if err := saveKinshipChart(chart); err != nil {
+			return &KinshipResult{
+				Success: false,
+				Message: failure("save kinship chart", err),
+			}, nil
+		}
+		result.Message += fmt.Sprintf(" and now names %s", target.Category)
+		result.Table = renderKinshipChart(chart)
+	}
+	if target != nil {
+		result.Terms = []KinTerm{*target}
+	}
+	return result, nil
+}
+
+func isKinLetterWord(category string) bool {
+	for _, word := range kinLetters {
+		if word == category {
+			return true
+		}
+	}
+	return false
+}
+
+// kinHomonyms reports terms shared by several categories
+func kinHomonyms(terms []KinTerm) []string {
+	byTerm := map[string][]string{}
+	for _, term := range terms {
+		if term.Term != "" {
+			byTerm[strings.ToLower(term.Term)] = append(byTerm[strings.ToLower(term.Term)], term.Category)
+		}
+	}
+	problems := []string{}
+	for term, categories := range byTerm {
+		if len(categories) > 1 {
+			problems = append(problems, fmt.Sprintf("%s names %s", term, strings.Join(categories, " and ")))
+		}
+	}
+	return problems
+}
+
+func renderKinshipChart(chart KinshipChart) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("**%s kinship**\n\n| Term | Category | Relatives |\n|---|---|---|\n", strings.ToUpper(chart.System[:1])+chart.System[1:]))
+	for _, term := range chart.Terms {
+		word := term.Term
+		switch {
+		case word == "":
+			word = "—"
+		case term.Composed:
+			word += " *"
+		}
+		out.WriteString(fmt.Sprintf("| %s | %s | %s |\n", word, term.Category, strings.Join(term.KinTypes, ", ")))
+	}
+	out.WriteString("\n\\* composed from roots\n")
+	return out.String()
+}
+
+func saveKinshipChart(chart KinshipChart) error {
+	data, err := json.MarshalIndent(chart, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteDataFile(DefaultKinshipFile, data)
+}
+
+func loadKinshipChart() (KinshipChart, error) {
+	var chart KinshipChart
+	data, err := storage.ReadDataFile(DefaultKinshipFile)
+	if err != nil {
+		return chart, err
+	}
+	if err := storage.DecodeDataFile(DefaultKinshipFile, data, &chart); err != nil {
+		return chart, err
+	}
+	if _, ok := kinshipSystems[chart.System]; !ok {
+		return chart, fmt.Errorf("unknown kinship system %s", chart.System)
+	}
+	return chart, nil
+}
+
+// createDesignKinshipTool creates the kinship chart tool
+func createDesignKinshipTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"design_kinship",
+		"Generate the full kinship term chart for a kinship system (eskimo, hawaiian, iroquois, omaha, crow or sudanese) from existing roots: parents, siblings, parents' siblings, cousins, children and siblings' children. "+
+			"Categories without a root are composed from the roots for father, mother, brother, sister, son and daughter. The chart is saved to kinship.json.",
+		DesignKinship,
+	)
+}
+
+// createCheckKinTermTool creates the kinship term consistency tool
+func createCheckKinTermTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"check_kin_term",
+		"Check a new kinship term against the chosen kinship system: it must name exactly the relatives the system groups together (e.g. FB and MB for an Eskimo uncle term) and not clash with other terms. Optionally store it in the chart.",
+		CheckKinTerm,
+	)
+}
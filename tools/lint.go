@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"fmt"
+	"l2/storage"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// phonemicTranscription matches a single word in slashes such as /ka.ta/,
+// preceded by the start of a line, a space or an opening bracket or quote
+var phonemicTranscription = regexp.MustCompile(`(?:^|[\s(“"'])/([^/\s]{1,40})/`)
+
+// wordOrderCode matches the six basic word orders
+var wordOrderCode = regexp.MustCompile(`\b(SOV|SVO|VSO|VOS|OVS|OSV)\b`)
+
+// glossLabel matches uppercase words that may be Leipzig gloss labels
+var glossLabel = regexp.MustCompile(`\b[A-Z]{2,6}\b`)
+
+// transcriptionMarks are written in transcriptions without being phonemes
+const transcriptionMarks = "ˈˌ.-‿"
+
+// LintResponse checks a response against the stored design and returns the
+// contradictions it finds: phonemes outside the inventory or forbidden
+// clusters in transcriptions, a basic word order other than the declared
+// one, and more cases than the language profile allows
+func LintResponse(text string) ([]string, error) {
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return nil, err
+	}
+	phonotactics, err := storage.ReadPhonotactics()
+	if err != nil {
+		return nil, err
+	}
+	syntax, err := storage.ReadSyntax()
+	if err != nil {
+		return nil, err
+	}
+	profile, err := storage.ReadProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := lintTranscriptions(text, newPhonemeSet(inventory), phonotactics.ForbiddenClusters)
+	if syntax.WordOrder != "" {
+		issues = append(issues, lintWordOrder(text, strings.ToUpper(syntax.WordOrder))...)
+	}
+	if profile.MaxCases > 0 {
+		current, err := measureComplexity()
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, lintCases(text, current.cases, profile.MaxCases)...)
+	}
+	return issues, nil
+}
+
+// lintTranscriptions flags phonemic transcriptions using sounds missing from
+// the inventory or containing forbidden clusters
+func lintTranscriptions(text string, set *phonemeSet, forbidden []string) []string {
+	var issues []string
+	flagged := map[string]bool{}
+	flag := func(issue string) {
+		if !flagged[issue] {
+			flagged[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+	for _, match := range phonemicTranscription.FindAllStringSubmatch(text, -1) {
+		transcription := match[1]
+		bare := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(transcriptionMarks, r) {
+				return -1
+			}
+			return r
+		}, transcription)
+		if set != nil {
+			for _, seg := range set.segment(bare) {
+				if _, ok := set.phoneme(seg); ok {
+					continue
+				}
+				// Diacritics may mark allophones; only the base sound must be a phoneme
+				if _, ok := set.phoneme(string([]rune(seg)[0])); ok {
+					continue
+				}
+				flag(fmt.Sprintf("/%s/ uses /%s/, which is not in the phoneme inventory", transcription, seg))
+			}
+		}
+		for _, cluster := range forbidden {
+			if cluster != "" && strings.Contains(strings.ToLower(bare), strings.ToLower(cluster)) {
+				flag(fmt.Sprintf("/%s/ contains the forbidden cluster %s", transcription, cluster))
+			}
+		}
+	}
+	return issues
+}
+
+// lintWordOrder flags sentences about word order that name an order other
+// than the declared one
+func lintWordOrder(text, declared string) []string {
+	var issues []string
+	for _, sentence := range strings.FieldsFunc(text, func(r rune) bool { return r == '.' || r == '\n' }) {
+		if !strings.Contains(strings.ToLower(sentence), "order") {
+			continue
+		}
+		for _, order := range wordOrderCode.FindAllString(sentence, -1) {
+			if order != declared && !strings.Contains(sentence, declared) {
+				issues = append(issues, fmt.Sprintf("Describes the word order as %s, but it is declared as %s", order, declared))
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// lintCases flags case labels that would take the language past the number
+// of cases the profile allows
+func lintCases(text string, current []string, maxCases int) []string {
+	cases := slices.Clone(current)
+	var introduced []string
+	for _, label := range glossLabel.FindAllString(text, -1) {
+		if caseLabels[label] && !slices.Contains(cases, label) {
+			cases = append(cases, label)
+			introduced = append(introduced, label)
+		}
+	}
+	if len(introduced) == 0 || len(cases) <= maxCases {
+		return nil
+	}
+	return []string{fmt.Sprintf("Uses the cases %s, making %d cases where the language profile allows %d", strings.Join(introduced, ", "), len(cases), maxCases)}
+}
+
+// LintPrompt asks the model to find contradictions between a response and
+// the decision log, language profile and declared grammar
+func LintPrompt(response string) (string, error) {
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return "", err
+	}
+	profile, err := storage.ReadProfile()
+	if err != nil {
+		return "", err
+	}
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return "", err
+	}
+	syntax, err := storage.ReadSyntax()
+	if err != nil {
+		return "", err
+	}
+
+	var design strings.Builder
+	for _, section := range decisionSections(log) {
+		for _, d := range sectionDecisions(log, section) {
+			design.WriteString(fmt.Sprintf("- %s decision #%d: %s\n", section, d.ID, d.Summary))
+		}
+	}
+	if !inventory.Empty() {
+		symbols := []string{}
+		for _, p := range append(append([]storage.Phoneme{}, inventory.Consonants...), inventory.Vowels...) {
+			symbols = append(symbols, p.Symbol)
+		}
+		design.WriteString("- Phoneme inventory: " + strings.Join(symbols, " ") + "\n")
+	}
+	if syntax.WordOrder != "" {
+		design.WriteString("- Basic word order: " + syntax.WordOrder + "\n")
+	}
+	if profile.MaxCases > 0 {
+		design.WriteString(fmt.Sprintf("- At most %d grammatical cases\n", profile.MaxCases))
+	}
+	if profile.MaxFusion > 0 {
+		design.WriteString(fmt.Sprintf("- Fusion index at most %.2f\n", profile.MaxFusion))
+	}
+	if profile.MaxIrregularity > 0 {
+		design.WriteString(fmt.Sprintf("- At most %.1f%% of words irregular\n", profile.MaxIrregularity))
+	}
+	if design.Len() == 0 {
+		return "", nil
+	}
+
+	return "Check this response from a conlang design assistant against the language's recorded design.\n\n" +
+		"DESIGN:\n" + design.String() + "\nRESPONSE:\n" + response + "\n\n" +
+		"List every statement in the response that contradicts the design, one per line starting with \"- \" and naming what it contradicts. " +
+		"Deliberate, explicit changes to the design are not contradictions. Reply with NONE if there are none. Don't call tools.", nil
+}
+
+// ParseLintReply reads the contradictions listed in the model's reply to LintPrompt
+func ParseLintReply(reply string) []string {
+	var issues []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		if issue, ok := strings.CutPrefix(line, "- "); ok && issue != "" {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,257 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"l2/storage"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// LogogramRequest represents a request to set a glyph's logographic metadata
+type LogogramRequest struct {
+	Glyph      string   `json:"glyph" jsonschema:"required,description=Name of an existing glyph"`
+	Morphemes  []string `json:"morphemes" jsonschema:"description=Morphemes the glyph writes, by form (kano or -ka) or gloss (dog or PL); replaces the previous list"`
+	Radical    string   `json:"radical" jsonschema:"description=Name of the glyph that is this glyph's radical"`
+	Components []string `json:"components" jsonschema:"description=Names of the glyphs this glyph is composed of"`
+}
+
+// LogographicRequest represents a request to write a glossed sentence in logograms
+type LogographicRequest struct {
+	Morphemes string `json:"morphemes" jsonschema:"required,description=Morpheme-segmented sentence such as kano mir-ka ri-tesu"`
+	Gloss     string `json:"gloss" jsonschema:"description=Gloss line aligned with the morphemes such as dog cat-ACC PST-see; glossed automatically when empty"`
+}
+
+// LogographicResult represents a sentence written in logograms
+type LogographicResult struct {
+	Success     bool     `json:"success"`
+	Message     string   `json:"message"`
+	Text        string   `json:"text,omitempty"`
+	Glyphs      string   `json:"glyphs,omitempty"` // Glyph names, aligned with the morphemes
+	Ambiguities []string `json:"ambiguities,omitempty"`
+	Phonetic    []string `json:"phonetic,omitempty"`
+	Unknown     []string `json:"unknown,omitempty"`
+}
+
+// SetLogogram records which morphemes a glyph writes and what it is built from
+func SetLogogram(ctx context.Context, req *LogogramRequest) (*GlyphResult, error) {
+	defer storage.BeginDataUpdate(scriptFile)()
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("read script", err),
+		}, nil
+	}
+	i := slices.IndexFunc(glyphs, func(g Glyph) bool { return g.Name == req.Glyph })
+	if i < 0 {
+		return &GlyphResult{
+			Success: false,
+			Message: fmt.Sprintf("No glyph named %s; add it with add_glyph first", req.Glyph),
+		}, nil
+	}
+
+	glyph := glyphs[i]
+	glyph.Morphemes, glyph.Radical, glyph.Components = req.Morphemes, req.Radical, req.Components
+	if err := checkGlyphParts(glyphs, glyph); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+	glyphs[i] = glyph
+	if err := saveGlyphs(glyphs); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("save script", err),
+		}, nil
+	}
+
+	return &GlyphResult{
+		Success: true,
+		Message: fmt.Sprintf("%s writes %d morphemes", glyph.Name, len(glyph.Morphemes)),
+		Glyphs:  []Glyph{glyph},
+	}, nil
+}
+
+// checkGlyphParts checks that a glyph's radical and components are other glyphs of the script
+func checkGlyphParts(glyphs []Glyph, glyph Glyph) error {
+	names := map[string]bool{}
+	for _, g := range glyphs {
+		names[g.Name] = true
+	}
+	for _, part := range append([]string{glyph.Radical}, glyph.Components...) {
+		if part == "" {
+			continue
+		}
+		if part == glyph.Name {
+			return fmt.Errorf("glyph %s can't be a part of itself", glyph.Name)
+		}
+		if !names[part] {
+			return fmt.Errorf("radical or component %s is not a glyph of the script", part)
+		}
+	}
+	return nil
+}
+
+// WriteLogographic writes each morpheme of a glossed sentence with the glyph
+// mapped to its form or gloss. Morphemes without a logogram are spelled
+// with the script's phonographic glyphs where it has them.
+func WriteLogographic(ctx context.Context, req *LogographicRequest) (*LogographicResult, error) {
+	if strings.TrimSpace(req.Morphemes) == "" {
+		return &LogographicResult{
+			Success: false,
+			Message: "A morpheme-segmented sentence is required",
+		}, nil
+	}
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return &LogographicResult{
+			Success: false,
+			Message: failure("read script", err),
+		}, nil
+	}
+	logograms := map[string][]Glyph{} // Lowercase morpheme form or gloss to the glyphs writing it
+	for _, g := range glyphs {
+		for _, morpheme := range g.Morphemes {
+			key := strings.ToLower(morpheme)
+			logograms[key] = append(logograms[key], g)
+		}
+	}
+	if len(logograms) == 0 {
+		return &LogographicResult{
+			Success: false,
+			Message: "No glyphs write morphemes yet; map them with set_logogram",
+		}, nil
+	}
+	o, err := loadOrthography()
+	if err != nil {
+		return &LogographicResult{
+			Success: false,
+			Message: failure("read orthography", err),
+		}, nil
+	}
+
+	gloss := req.Gloss
+	if gloss == "" {
+		entries, err := loadLexicon()
+		if err != nil {
+			return &LogographicResult{
+				Success: false,
+				Message: failure("read lexicon", err),
+			}, nil
+		}
+		store, err := loadAffixStore()
+		if err != nil {
+			return &LogographicResult{
+				Success: false,
+				Message: failure("read affixes", err),
+			}, nil
+		}
+		gloss = autoGloss(req.Morphemes, entries, store.affixes)
+	}
+	words, glossWords := strings.Fields(req.Morphemes), strings.Fields(gloss)
+	if len(words) != len(glossWords) {
+		return &LogographicResult{
+			Success: false,
+			Message: fmt.Sprintf("Morpheme line has %d words but gloss line has %d", len(words), len(glossWords)),
+		}, nil
+	}
+
+	result := &LogographicResult{Success: true}
+	text, names := make([]string, len(words)), make([]string, len(words))
+	isSeparator := func(r rune) bool { return r == '-' || r == '=' }
+	for i, word := range words {
+		parts := strings.FieldsFunc(word, isSeparator)
+		glosses := strings.FieldsFunc(glossWords[i], isSeparator)
+		separators := glossSeparators(word)
+		var written, named strings.Builder
+		for j, part := range parts {
+			// Bound morphemes are looked up with their boundary first, then
+			// bare, then by gloss
+			keys := []string{}
+			if j > 0 {
+				keys = append(keys, string(separators[j-1])+part)
+			}
+			if j < len(separators) {
+				keys = append(keys, part+string(separators[j]))
+			}
+			keys = append(keys, part)
+			if len(glosses) == len(parts) {
+				keys = append(keys, glosses[j])
+			}
+
+			var found []Glyph
+			for _, key := range keys {
+				if found = logograms[strings.ToLower(key)]; len(found) > 0 {
+					break
+				}
+			}
+			switch {
+			case len(found) > 0:
+				r, err := parseCodepoint(found[0].Codepoint)
+				if err != nil {
+					return &LogographicResult{
+						Success: false,
+						Message: fmt.Sprintf("Glyph %s has an invalid codepoint: %s", found[0].Name, err.Error()),
+					}, nil
+				}
+				written.WriteRune(r)
+				named.WriteString(found[0].Name)
+				if len(found) > 1 {
+					alternatives := []string{}
+					for _, g := range found {
+						alternatives = append(alternatives, g.Name)
+					}
+					result.Ambiguities = appendUnique(result.Ambiguities, fmt.Sprintf("%s: %s", part, strings.Join(alternatives, ", ")))
+				}
+			case len(o.glyphs) > 0:
+				spelled, _, unknown := convert(strings.ToLower(part), o.glyphs)
+				written.WriteString(spelled)
+				named.WriteString("[" + part + "]")
+				result.Phonetic = appendUnique(result.Phonetic, part)
+				for _, u := range unknown {
+					result.Unknown = appendUnique(result.Unknown, u)
+				}
+			default:
+				written.WriteString(part)
+				named.WriteString("[" + part + "]")
+				result.Unknown = appendUnique(result.Unknown, part)
+			}
+			if j < len(separators) {
+				named.WriteByte(separators[j])
+			}
+		}
+		text[i], names[i] = written.String(), named.String()
+	}
+
+	result.Text = strings.Join(text, " ")
+	result.Glyphs = strings.Join(names, " ")
+	result.Message = "Wrote the sentence in logograms"
+	if len(result.Phonetic) > 0 || len(result.Unknown) > 0 {
+		result.Message = fmt.Sprintf("Wrote the sentence; %d morphemes have no logogram", len(result.Phonetic)+len(result.Unknown))
+	}
+	return result, nil
+}
+
+// createSetLogogramTool creates the tool that maps glyphs to morphemes
+func createSetLogogramTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_logogram",
+		"Make a glyph of the native script a logogram: set the morphemes it writes (many-to-many, by form or gloss) and its radical and component glyphs.",
+		SetLogogram,
+	)
+}
+
+// createWriteLogographicTool creates the tool that writes glossed sentences in logograms
+func createWriteLogographicTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"write_logographic",
+		"Convert a glossed sentence (morpheme line and optional gloss line) into a sequence of logographic glyphs. Morphemes without a logogram are spelled phonetically with the script's sound glyphs; homographic choices are reported.",
+		WriteLogographic,
+	)
+}
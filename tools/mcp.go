@@ -0,0 +1,483 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"l2/storage"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// mcpProtocolVersion is the MCP revision the client speaks
+	mcpProtocolVersion = "2024-11-05"
+	// mcpStartTimeout bounds connecting to a server and listing its tools
+	mcpStartTimeout = 10 * time.Second
+	// mcpCallTimeout bounds a single tool call
+	mcpCallTimeout = 60 * time.Second
+	// maxMCPMessageBytes is the largest message read from a server
+	maxMCPMessageBytes = 16 << 20
+)
+
+// MCPServerStatus describes a configured MCP server and the tools it offers
+type MCPServerStatus struct {
+	Name  string   `json:"name"`
+	Tools []string `json:"tools,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// mcpServers holds the servers connected for this process. Tools are built
+// more than once, so every server is only started the first time.
+var mcpServers struct {
+	once    sync.Once
+	clients []*mcpClient
+	tools   []tool.InvokableTool
+	status  []MCPServerStatus
+}
+
+// mcpMessage is a JSON-RPC message from a server: a response to one of our
+// requests, a request of its own or a notification
+type mcpMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// mcpClient talks JSON-RPC to an MCP server over its stdin and stdout
+type mcpClient struct {
+	name    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	mu      sync.Mutex // Guards writes, nextID and pending
+	nextID  int
+	pending map[int]chan mcpMessage
+	exited  chan struct{} // Closed once the server's output ends
+}
+
+// mcpToolInfo is a tool as listed by a server
+type mcpToolInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	InputSchema *jsonSchema `json:"inputSchema"`
+}
+
+// jsonSchema is the part of a JSON Schema needed to describe tool parameters
+type jsonSchema struct {
+	Type        any                    `json:"type"` // A type name, or a list of them
+	Description string                 `json:"description"`
+	Enum        []any                  `json:"enum"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Required    []string               `json:"required"`
+	Items       *jsonSchema            `json:"items"`
+}
+
+// connectMCPServers starts the MCP servers declared in the config and
+// returns their tools. Servers that fail to start are logged and skipped.
+func connectMCPServers() []tool.InvokableTool {
+	mcpServers.once.Do(func() {
+		config, err := storage.ReadConfig()
+		if err != nil {
+			log.Printf("Failed to read MCP servers from config: %v", err)
+			return
+		}
+		// Tool names must be unique among the built-in tools and every
+		// server's, or no tools can be offered at all
+		taken := map[string]bool{}
+		for _, name := range builtinToolNames() {
+			taken[name] = true
+		}
+		for _, server := range config.MCPServers {
+			status := MCPServerStatus{Name: server.Name}
+			client, tools, err := startMCPServer(server)
+			if err != nil {
+				log.Printf("Failed to connect to MCP server %s: %v", server.Name, err)
+				status.Error = err.Error()
+				mcpServers.status = append(mcpServers.status, status)
+				continue
+			}
+			for _, t := range tools {
+				if taken[t.name] {
+					name := uniqueToolName(t.name, taken)
+					log.Printf("MCP server %s's tool %s clashes with the tool %s; offering it as %s", server.Name, t.info.Name, t.name, name)
+					t.name = name
+				}
+				taken[t.name] = true
+				status.Tools = append(status.Tools, t.name)
+				mcpServers.tools = append(mcpServers.tools, t)
+			}
+			mcpServers.clients = append(mcpServers.clients, client)
+			mcpServers.status = append(mcpServers.status, status)
+		}
+	})
+	return mcpServers.tools
+}
+
+// MCPStatus reports the configured MCP servers and their tools
+func MCPStatus() []MCPServerStatus {
+	connectMCPServers()
+	return mcpServers.status
+}
+
+// CloseMCPServers stops every connected MCP server
+func CloseMCPServers() {
+	for _, client := range mcpServers.clients {
+		client.close()
+	}
+	mcpServers.clients = nil
+}
+
+// startMCPServer runs a server, performs the MCP handshake and lists its tools
+func startMCPServer(server storage.MCPServer) (*mcpClient, []*mcpTool, error) {
+	if mcpToolPrefix(server.Name) == "" || server.Command == "" {
+		return nil, nil, errors.New("MCP servers need a name and a command")
+	}
+	cmd := exec.Command(server.Command, server.Args...)
+	cmd.Env = os.Environ()
+	for key, value := range server.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	client := &mcpClient{
+		name:    server.Name,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: map[int]chan mcpMessage{},
+		exited:  make(chan struct{}),
+	}
+	go client.readLoop(stdout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), mcpStartTimeout)
+	defer cancel()
+	tools, err := client.handshake(ctx)
+	if err != nil {
+		client.close()
+		return nil, nil, err
+	}
+	return client, tools, nil
+}
+
+// handshake initializes the session and lists every page of the server's tools
+func (c *mcpClient) handshake(ctx context.Context) ([]*mcpTool, error) {
+	_, err := c.call(ctx, "initialize", map[string]any{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]string{"name": "l2", "version": "1.0"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+	c.mu.Lock()
+	err = c.send(map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"})
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	tools := []*mcpTool{}
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, err := c.call(ctx, "tools/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("listing tools failed: %w", err)
+		}
+		var page struct {
+			Tools      []mcpToolInfo `json:"tools"`
+			NextCursor string        `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("invalid tool list: %w", err)
+		}
+		for _, info := range page.Tools {
+			tools = append(tools, &mcpTool{client: c, info: info, name: mcpToolName(c.name, info.Name)})
+		}
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// readLoop reads newline-delimited messages from the server, handing
+// responses to the requests waiting for them
+func (c *mcpClient) readLoop(stdout io.Reader) {
+	defer close(c.exited)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64<<10), maxMCPMessageBytes)
+	for scanner.Scan() {
+		var msg mcpMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Method != "" {
+			// Server requests are answered so the server isn't left waiting;
+			// only pings are supported
+			if len(msg.ID) > 0 {
+				reply := map[string]any{"jsonrpc": "2.0", "id": msg.ID}
+				if msg.Method == "ping" {
+					reply["result"] = map[string]any{}
+				} else {
+					reply["error"] = map[string]any{"code": -32601, "message": "method not supported by l2"}
+				}
+				c.mu.Lock()
+				c.send(reply)
+				c.mu.Unlock()
+			}
+			continue
+		}
+		id, err := strconv.Atoi(string(msg.ID))
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		waiting, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ok {
+			waiting <- msg
+		}
+	}
+}
+
+// send writes one message to the server; callers hold c.mu
+func (c *mcpClient) send(message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// call sends a request and waits for its response
+func (c *mcpClient) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	response := make(chan mcpMessage, 1)
+	c.pending[id] = response
+	err := c.send(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-response:
+		if msg.Error != nil {
+			return nil, fmt.Errorf("%s (code %d)", msg.Error.Message, msg.Error.Code)
+		}
+		return msg.Result, nil
+	case <-c.exited:
+		return nil, fmt.Errorf("MCP server %s exited", c.name)
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// close ends the session and stops the server
+func (c *mcpClient) close() {
+	c.stdin.Close()
+	select {
+	case <-c.exited:
+	case <-time.After(time.Second):
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+}
+
+// mcpTool is a tool offered by an MCP server
+type mcpTool struct {
+	client *mcpClient
+	info   mcpToolInfo
+	name   string // Name shown to the model, prefixed with the server's name
+}
+
+// Info describes the tool to the model
+func (t *mcpTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	params := map[string]*schema.ParameterInfo{}
+	if t.info.InputSchema != nil {
+		required := map[string]bool{}
+		for _, name := range t.info.InputSchema.Required {
+			required[name] = true
+		}
+		for name, property := range t.info.InputSchema.Properties {
+			params[name] = property.parameter(required[name])
+		}
+	}
+	return &schema.ToolInfo{
+		Name:        t.name,
+		Desc:        strings.TrimSpace(t.info.Description + " (from the " + t.client.name + " MCP server)"),
+		ParamsOneOf: schema.NewParamsOneOfByParams(params),
+	}, nil
+}
+
+// InvokableRun calls the tool on its server and returns its text output
+func (t *mcpTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	ctx, cancel := context.WithTimeout(ctx, mcpCallTimeout)
+	defer cancel()
+
+	result := Result{Success: true}
+	raw, err := t.client.call(ctx, "tools/call", map[string]any{"name": t.info.Name, "arguments": json.RawMessage(arguments)})
+	if err == nil {
+		var output struct {
+			Content []struct {
+				Type     string `json:"type"`
+				Text     string `json:"text"`
+				Resource struct {
+					URI  string `json:"uri"`
+					Text string `json:"text"`
+				} `json:"resource"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		}
+		if err = json.Unmarshal(raw, &output); err == nil {
+			parts := []string{}
+			for _, content := range output.Content {
+				switch {
+				case content.Type == "text":
+					parts = append(parts, content.Text)
+				case content.Type == "resource" && content.Resource.Text != "":
+					parts = append(parts, content.Resource.Text)
+				case content.Type == "resource":
+					parts = append(parts, "["+content.Resource.URI+"]")
+				default:
+					parts = append(parts, "["+content.Type+" content]")
+				}
+			}
+			result.Message = strings.Join(parts, "\n")
+			if output.IsError {
+				result.Success = false
+				result.Message = "Failed to run " + t.name + ": " + result.Message
+			}
+		}
+	}
+	if err != nil {
+		result = Result{Success: false, Message: failure("run "+t.name, err)}
+	}
+	data, err := json.Marshal(result)
+	return string(data), err
+}
+
+// parameter converts a JSON Schema property to a tool parameter
+func (s *jsonSchema) parameter(required bool) *schema.ParameterInfo {
+	param := &schema.ParameterInfo{Type: s.dataType(), Desc: s.Description, Required: required}
+	for _, value := range s.Enum {
+		param.Enum = append(param.Enum, fmt.Sprint(value))
+	}
+	switch param.Type {
+	case schema.Array:
+		if s.Items != nil {
+			param.ElemInfo = s.Items.parameter(false)
+		} else {
+			param.ElemInfo = &schema.ParameterInfo{Type: schema.String}
+		}
+	case schema.Object:
+		requiredFields := map[string]bool{}
+		for _, name := range s.Required {
+			requiredFields[name] = true
+		}
+		param.SubParams = map[string]*schema.ParameterInfo{}
+		for name, property := range s.Properties {
+			param.SubParams[name] = property.parameter(requiredFields[name])
+		}
+	}
+	return param
+}
+
+// dataType is the schema's type, taking the first non-null one from a list
+func (s *jsonSchema) dataType() schema.DataType {
+	types := []string{}
+	switch t := s.Type.(type) {
+	case string:
+		types = append(types, t)
+	case []any:
+		for _, name := range t {
+			types = append(types, fmt.Sprint(name))
+		}
+	}
+	for _, name := range types {
+		switch name {
+		case "object", "array", "string", "number", "integer", "boolean":
+			return schema.DataType(name)
+		}
+	}
+	if s.Properties != nil {
+		return schema.Object
+	}
+	return schema.String
+}
+
+// maxToolNameLength is the longest function name models accept
+const maxToolNameLength = 64
+
+// mcpToolName prefixes a server's tool name with the server's name, keeping
+// to the characters and length function names may use
+func mcpToolName(server, name string) string {
+	full := mcpToolPrefix(server) + "_" + mcpToolPrefix(name)
+	if len(full) > maxToolNameLength {
+		full = full[:maxToolNameLength]
+	}
+	return full
+}
+
+// uniqueToolName numbers a tool name that is already taken, as in name_2,
+// shortening it so the number still fits
+func uniqueToolName(name string, taken map[string]bool) string {
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		suffix := "_" + strconv.Itoa(n)
+		unique = name[:min(len(name), maxToolNameLength-len(suffix))] + suffix
+	}
+	return unique
+}
+
+// mcpToolPrefix reduces a name to letters, digits, underscores and hyphens
+func mcpToolPrefix(name string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			return r
+		}
+		return '_'
+	}, name), "_")
+}
@@ -0,0 +1,374 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// mcpRequest is a message the client sent to the fake server
+type mcpRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// startFakeMCPServer connects a client to a server that answers every message
+// the client sends with the lines respond returns, written as they are.
+// Closing the returned function ends the server's output.
+func startFakeMCPServer(t *testing.T, respond func(req mcpRequest) []string) (*mcpClient, func()) {
+	t.Helper()
+	clientOut, serverIn := io.Pipe()
+	serverOut, clientIn := io.Pipe()
+	client := &mcpClient{
+		name:    "notes",
+		stdin:   serverIn,
+		pending: map[int]chan mcpMessage{},
+		exited:  make(chan struct{}),
+	}
+	go client.readLoop(serverOut)
+
+	// Output goes through a queue like an OS pipe's buffer, so the server
+	// keeps reading while the client is busy answering one of its requests
+	lines := make(chan string, 64)
+	go func() {
+		for line := range lines {
+			if _, err := io.WriteString(clientIn, line+"\n"); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(clientOut)
+		for scanner.Scan() {
+			var req mcpRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				t.Errorf("client sent a malformed message %q: %v", scanner.Text(), err)
+				continue
+			}
+			for _, line := range respond(req) {
+				lines <- line
+			}
+		}
+	}()
+	stop := func() {
+		clientIn.Close()
+		serverIn.Close()
+	}
+	t.Cleanup(stop)
+	return client, stop
+}
+
+// reply is a response to req with the given result
+func reply(req mcpRequest, result string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+}
+
+func TestMCPCallSkipsOtherMessages(t *testing.T) {
+	answered := make(chan mcpRequest, 1)
+	client, _ := startFakeMCPServer(t, func(req mcpRequest) []string {
+		if req.Method == "" {
+			answered <- req // The client's answer to the server's ping
+			return nil
+		}
+		return []string{
+			"not json at all",
+			"",
+			`{"jsonrpc":"2.0","method":"notifications/progress","params":{}}`,
+			`{"jsonrpc":"2.0","id":"ping-1","method":"ping"}`,
+			`{"jsonrpc":"2.0","id":"abc","result":{"wrong":true}}`,
+			`{"jsonrpc":"2.0","id":9999,"result":{"wrong":true}}`,
+			reply(req, `{"ok":true}`),
+		}
+	})
+
+	result, err := client.call(context.Background(), "tools/list", map[string]any{})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if string(result) != `{"ok":true}` {
+		t.Errorf("result = %s, want {\"ok\":true}", result)
+	}
+
+	select {
+	case req := <-answered:
+		if string(req.ID) != `"ping-1"` || string(req.Result) != "{}" {
+			t.Errorf("ping answered with id %s and result %s", req.ID, req.Result)
+		}
+	case <-time.After(time.Second):
+		t.Error("the server's ping was not answered")
+	}
+}
+
+func TestMCPCallRejectsServerRequests(t *testing.T) {
+	answered := make(chan mcpRequest, 1)
+	client, _ := startFakeMCPServer(t, func(req mcpRequest) []string {
+		if req.Method == "" {
+			answered <- req
+			return nil
+		}
+		return []string{
+			`{"jsonrpc":"2.0","id":7,"method":"sampling/createMessage","params":{}}`,
+			reply(req, "{}"),
+		}
+	})
+	if _, err := client.call(context.Background(), "tools/list", nil); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	select {
+	case req := <-answered:
+		if string(req.ID) != "7" || !strings.Contains(string(req.Error), "-32601") {
+			t.Errorf("request answered with id %s and error %s, want a method not found error", req.ID, req.Error)
+		}
+	case <-time.After(time.Second):
+		t.Error("the server's request was not answered")
+	}
+}
+
+func TestMCPCallErrors(t *testing.T) {
+	t.Run("error response", func(t *testing.T) {
+		client, _ := startFakeMCPServer(t, func(req mcpRequest) []string {
+			return []string{fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32602,"message":"unknown tool"}}`, req.ID)}
+		})
+		_, err := client.call(context.Background(), "tools/call", nil)
+		if err == nil || err.Error() != "unknown tool (code -32602)" {
+			t.Errorf("error = %v, want unknown tool (code -32602)", err)
+		}
+	})
+
+	t.Run("server exits", func(t *testing.T) {
+		var stop func()
+		client, stop := startFakeMCPServer(t, func(req mcpRequest) []string {
+			go stop()
+			return nil
+		})
+		_, err := client.call(context.Background(), "tools/list", nil)
+		if err == nil || !strings.Contains(err.Error(), "MCP server notes exited") {
+			t.Errorf("error = %v, want the server to have exited", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		client, _ := startFakeMCPServer(t, func(req mcpRequest) []string { return nil })
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := client.call(ctx, "tools/list", nil); err != context.DeadlineExceeded {
+			t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		if len(client.pending) != 0 {
+			t.Errorf("%d requests still pending after the timeout", len(client.pending))
+		}
+	})
+
+	t.Run("message too long", func(t *testing.T) {
+		client, _ := startFakeMCPServer(t, func(req mcpRequest) []string {
+			return []string{reply(req, `"`+strings.Repeat("x", maxMCPMessageBytes)+`"`)}
+		})
+		_, err := client.call(context.Background(), "tools/list", nil)
+		if err == nil || !strings.Contains(err.Error(), "exited") {
+			t.Errorf("error = %v, want the oversized message to end the connection", err)
+		}
+	})
+}
+
+func TestMCPHandshake(t *testing.T) {
+	initialized := make(chan bool, 1)
+	client, _ := startFakeMCPServer(t, func(req mcpRequest) []string {
+		switch req.Method {
+		case "initialize":
+			return []string{reply(req, `{"protocolVersion":"2024-11-05","capabilities":{}}`)}
+		case "notifications/initialized":
+			initialized <- len(req.ID) == 0
+		case "tools/list":
+			var params struct {
+				Cursor string `json:"cursor"`
+			}
+			json.Unmarshal(req.Params, &params)
+			if params.Cursor == "" {
+				return []string{reply(req, `{"tools":[{"name":"search","description":"Search notes"}],"nextCursor":"page2"}`)}
+			}
+			return []string{reply(req, `{"tools":[{"name":"get note","inputSchema":{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}}]}`)}
+		}
+		return nil
+	})
+
+	tools, err := client.handshake(context.Background())
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if ok := <-initialized; !ok {
+		t.Error("notifications/initialized was sent with an id")
+	}
+	names := []string{}
+	for _, tool := range tools {
+		names = append(names, tool.name)
+	}
+	if want := []string{"notes_search", "notes_get_note"}; !slices.Equal(names, want) {
+		t.Fatalf("tools = %q, want %q", names, want)
+	}
+
+	info, err := tools[1].Info(context.Background())
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if info.Desc != "(from the notes MCP server)" {
+		t.Errorf("description = %q", info.Desc)
+	}
+}
+
+func TestMCPHandshakeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		wantErr string
+	}{
+		{"invalid tool list", `{"tools":"search"}`, "invalid tool list"},
+		{"list error", "", "listing tools failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := startFakeMCPServer(t, func(req mcpRequest) []string {
+				switch req.Method {
+				case "initialize":
+					return []string{reply(req, "{}")}
+				case "tools/list":
+					if tt.list == "" {
+						return []string{fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"no tools"}}`, req.ID)}
+					}
+					return []string{reply(req, tt.list)}
+				}
+				return nil
+			})
+			_, err := client.handshake(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("handshake error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMCPToolRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		success bool
+		message string
+	}{
+		{"text", `{"content":[{"type":"text","text":"first"},{"type":"text","text":"second"}]}`, true, "first\nsecond"},
+		{"resources", `{"content":[{"type":"resource","resource":{"uri":"notes://1","text":"body"}},{"type":"resource","resource":{"uri":"notes://2"}}]}`, true, "body\n[notes://2]"},
+		{"other content", `{"content":[{"type":"image","data":"..."}]}`, true, "[image content]"},
+		{"tool error", `{"content":[{"type":"text","text":"no such note"}],"isError":true}`, false, "Failed to run notes_get: no such note"},
+		{"malformed result", `{"content":"text"}`, false, "Failed to run notes_get: json: cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arguments json.RawMessage
+			client, _ := startFakeMCPServer(t, func(req mcpRequest) []string {
+				var params struct {
+					Arguments json.RawMessage `json:"arguments"`
+				}
+				json.Unmarshal(req.Params, &params)
+				arguments = params.Arguments
+				return []string{reply(req, tt.result)}
+			})
+			tool := &mcpTool{client: client, info: mcpToolInfo{Name: "get"}, name: "notes_get"}
+			output, err := tool.InvokableRun(context.Background(), "  ")
+			if err != nil {
+				t.Fatalf("InvokableRun: %v", err)
+			}
+			if string(arguments) != "{}" {
+				t.Errorf("arguments sent = %s, want {}", arguments)
+			}
+			var result Result
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("output %q is not a result: %v", output, err)
+			}
+			if result.Success != tt.success || !strings.HasPrefix(result.Message, tt.message) {
+				t.Errorf("result = %+v, want success %v and message %q", result, tt.success, tt.message)
+			}
+		})
+	}
+}
+
+func TestMCPToolName(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	tests := []struct {
+		server, name string
+		want         string
+	}{
+		{"notes", "search", "notes_search"},
+		{"My Notes", "get note", "My_Notes_get_note"},
+		{"notes", "wörter.suchen", "notes_w_rter_suchen"},
+		{"_notes_", "__search", "notes_search"},
+		{"notes", long, ("notes_" + long)[:64]},
+	}
+	for _, tt := range tests {
+		if got := mcpToolName(tt.server, tt.name); got != tt.want {
+			t.Errorf("mcpToolName(%q, %q) = %q, want %q", tt.server, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueToolName(t *testing.T) {
+	long := strings.Repeat("a", maxToolNameLength)
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{"notes_search", nil, "notes_search"},
+		{"notes_search", []string{"notes_search"}, "notes_search_2"},
+		{"notes_search", []string{"notes_search", "notes_search_2"}, "notes_search_3"},
+		{long, []string{long}, long[:maxToolNameLength-2] + "_2"},
+	}
+	for _, tt := range tests {
+		taken := map[string]bool{}
+		for _, name := range tt.taken {
+			taken[name] = true
+		}
+		got := uniqueToolName(tt.name, taken)
+		if got != tt.want {
+			t.Errorf("uniqueToolName(%q, %q) = %q, want %q", tt.name, tt.taken, got, tt.want)
+		}
+		if len(got) > maxToolNameLength {
+			t.Errorf("uniqueToolName(%q) is %d characters long", tt.name, len(got))
+		}
+	}
+}
+
+func TestJSONSchemaDataType(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   schema.DataType
+	}{
+		{`{"type":"integer"}`, schema.Integer},
+		{`{"type":["null","boolean"]}`, schema.Boolean},
+		{`{"type":"null"}`, schema.String},
+		{`{"type":"date"}`, schema.String},
+		{`{}`, schema.String},
+		{`{"properties":{}}`, schema.Object},
+		{`{"type":42}`, schema.String},
+	}
+	for _, tt := range tests {
+		var s jsonSchema
+		if err := json.Unmarshal([]byte(tt.schema), &s); err != nil {
+			t.Fatalf("schema %s: %v", tt.schema, err)
+		}
+		if got := s.dataType(); got != tt.want {
+			t.Errorf("dataType(%s) = %q, want %q", tt.schema, got, tt.want)
+		}
+	}
+}
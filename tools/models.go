@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"log"
+	"strings"
+
+	"l2/storage"
+)
+
+// DefaultModel is the OpenRouter model used unless config.json names another
+const DefaultModel = "google/gemini-2.5-flash"
+
+// unknownModel is assumed for models missing from the registry: tools,
+// which l2 depends on, no images and a modest context window
+var unknownModel = storage.ModelCapabilities{Tools: true, ContextTokens: 32_768}
+
+// modelRegistry lists the capabilities of known models by OpenRouter name
+// prefix; the longest prefix of a model's name applies
+var modelRegistry = map[string]storage.ModelCapabilities{
+	"google/gemini-2.5":                  {Tools: true, Vision: true, ContextTokens: 1_048_576},
+	"google/gemini-2.0-flash":            {Tools: true, Vision: true, ContextTokens: 1_048_576},
+	"openai/gpt-4o":                      {Tools: true, Vision: true, ContextTokens: 128_000},
+	"openai/gpt-4.1":                     {Tools: true, Vision: true, ContextTokens: 1_047_576},
+	"anthropic/claude":                   {Tools: true, Vision: true, ContextTokens: 200_000},
+	"deepseek/deepseek-chat":             {Tools: true, Vision: false, ContextTokens: 64_000},
+	"deepseek/deepseek-r1":               {Tools: false, Vision: false, ContextTokens: 64_000},
+	"deepseek/deepseek-r1-0528-qwen3-8b": {Tools: false, Vision: false, ContextTokens: 32_768},
+	"meta-llama/llama-3.1":               {Tools: true, Vision: false, ContextTokens: 131_072},
+	"mistralai/mistral-7b-instruct":      {Tools: false, Vision: false, ContextTokens: 32_768},
+}
+
+// CapabilitiesOf returns what a model supports: as declared for it under
+// model_capabilities in config.json, from the registry, or else assumed. A
+// declaration without a context size keeps the registry's.
+func CapabilitiesOf(model string, declared map[string]storage.ModelCapabilities) storage.ModelCapabilities {
+	known := registeredCapabilities(model)
+	if capabilities, ok := declared[model]; ok {
+		if capabilities.ContextTokens <= 0 {
+			capabilities.ContextTokens = known.ContextTokens
+		}
+		return capabilities
+	}
+	return known
+}
+
+// registeredCapabilities looks a model up in the registry
+func registeredCapabilities(model string) storage.ModelCapabilities {
+	if capabilities, ok := lookupModel(modelRegistry, model); ok {
+		return capabilities
+	}
+	return unknownModel
+}
+
+// lookupModel finds a model in a table keyed by OpenRouter name prefix,
+// where the longest matching prefix applies
+func lookupModel[T any](table map[string]T, model string) (T, bool) {
+	// Variants such as :free share their base model's entry
+	name, _, _ := strings.Cut(model, ":")
+	best := ""
+	for prefix := range table {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	entry, ok := table[best]
+	return entry, ok && best != ""
+}
+
+// ActiveModel returns the model chosen in config.json and its capabilities
+func ActiveModel() (string, storage.ModelCapabilities) {
+	config, err := storage.ReadConfig()
+	if err != nil {
+		log.Printf("Failed to read config: %v", err)
+	}
+	model := config.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	return model, CapabilitiesOf(model, config.ModelCapabilities)
+}
+
+// HistoryBudget returns how many tokens of conversation history fit a
+// model's context window, leaving the rest for the system prompt, the tool
+// definitions and the answer
+func HistoryBudget(capabilities storage.ModelCapabilities) int {
+	return capabilities.ContextTokens / 2
+}
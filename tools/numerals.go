@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"l2/storage"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// DefaultNumeralFile is the data file the numeral system is written to
+const DefaultNumeralFile = "numerals.json"
+
+// maxNumeralSearch bounds the numbers tried when identifying a number name
+const maxNumeralSearch = 100000
+
+// NumeralSystem describes how number names are built. Names are composed
+// from templates with {multiplier} and {power}, {big} and {small} slots.
+type NumeralSystem struct {
+	Base             int           `json:"base" jsonschema:"required,description=Numeral base such as 10, 12 or 20"`
+	Digits           []string      `json:"digits" jsonschema:"required,description=Words for 0 up to base-1 in order; the word for zero may be empty"`
+	Powers           []string      `json:"powers" jsonschema:"required,description=Words for base, base², base³ and so on in order"`
+	OmitOne          bool          `json:"omit_one,omitempty" jsonschema:"description=Say the power alone for one of it, as in hundred rather than one hundred"`
+	MultiplyTemplate string        `json:"multiply_template,omitempty" jsonschema:"description=How a multiplier combines with a power (default {multiplier} {power})"`
+	AddTemplate      string        `json:"add_template,omitempty" jsonschema:"description=How a smaller number is added to a larger one (default {big} {small})"`
+	Subtractive      []int         `json:"subtractive,omitempty" jsonschema:"description=Final digits expressed by subtraction from the next multiple of the base, such as 8 and 9 for Latin duodeviginti"`
+	SubtractTemplate string        `json:"subtract_template,omitempty" jsonschema:"description=How a number is subtracted from the next multiple of the base (default {small} from {big})"`
+	Exceptions       []NumeralForm `json:"exceptions,omitempty" jsonschema:"description=Irregular names that override the rules for specific numbers"`
+	Notes            string        `json:"notes,omitempty" jsonschema:"description=Free-text notes on the system"`
+}
+
+// NumeralForm is the name of one number
+type NumeralForm struct {
+	Number int64  `json:"number" jsonschema:"required,description=The number"`
+	Name   string `json:"name" jsonschema:"required,description=Its name in the conlang"`
+}
+
+// NumeralSystemResult represents the result of declaring a numeral system
+type NumeralSystemResult struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Path    string        `json:"path,omitempty"`
+	Samples []NumeralForm `json:"samples,omitempty"`
+}
+
+// NumberNamesRequest represents a request to name or check numbers
+type NumberNamesRequest struct {
+	Numbers []int64  `json:"numbers" jsonschema:"description=Numbers to name"`
+	Names   []string `json:"names" jsonschema:"description=Number names to validate; aligned with numbers to check each one, or on their own to find which number each names"`
+}
+
+// NumberNamesResult represents generated and validated number names
+type NumberNamesResult struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Forms   []NumeralForm `json:"forms,omitempty"`
+	Errors  []string      `json:"errors,omitempty"`
+}
+
+// SetNumeralSystem checks a numeral system and writes it to the numeral data file
+func SetNumeralSystem(ctx context.Context, req *NumeralSystem) (*NumeralSystemResult, error) {
+	if err := req.check(); err != nil {
+		return &NumeralSystemResult{
+			Success: false,
+			Message: "Invalid numeral system: " + err.Error(),
+		}, nil
+	}
+
+	samples := []NumeralForm{}
+	for _, n := range numeralSamples(int64(req.Base)) {
+		name, err := req.name(n)
+		if err != nil {
+			return &NumeralSystemResult{
+				Success: false,
+				Message: fmt.Sprintf("Invalid numeral system: %d: %s", n, err.Error()),
+			}, nil
+		}
+		samples = append(samples, NumeralForm{Number: n, Name: name})
+	}
+
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return &NumeralSystemResult{
+			Success: false,
+			Message: failure("serialize numeral system", err),
+		}, nil
+	}
+	if err := storage.WriteDataFile(DefaultNumeralFile, data); err != nil {
+		return &NumeralSystemResult{
+			Success: false,
+			Message: failure("save numeral system", err),
+		}, nil
+	}
+
+	return &NumeralSystemResult{
+		Success: true,
+		Message: fmt.Sprintf("Saved a base-%d numeral system with %d powers to %s", req.Base, len(req.Powers), DefaultNumeralFile),
+		Path:    DefaultNumeralFile,
+		Samples: samples,
+	}, nil
+}
+
+// NumberNames names numbers with the declared numeral system and validates
+// proposed names against it
+func NumberNames(ctx context.Context, req *NumberNamesRequest) (*NumberNamesResult, error) {
+	system, err := loadNumeralSystem()
+	if err != nil {
+		return &NumberNamesResult{
+			Success: false,
+			Message: failure("read numeral system (declare one with set_numeral_system first)", err),
+		}, nil
+	}
+	if len(req.Numbers) == 0 && len(req.Names) == 0 {
+		return &NumberNamesResult{
+			Success: false,
+			Message: "Numbers to name or names to validate are required",
+		}, nil
+	}
+	if len(req.Names) > 0 && len(req.Numbers) > 0 && len(req.Names) != len(req.Numbers) {
+		return &NumberNamesResult{
+			Success: false,
+			Message: fmt.Sprintf("Got %d numbers but %d names; align them to validate, or give names alone", len(req.Numbers), len(req.Names)),
+		}, nil
+	}
+
+	result := &NumberNamesResult{Success: true}
+	for i, n := range req.Numbers {
+		name, err := system.name(n)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%d: %s", n, err.Error()))
+			continue
+		}
+		result.Forms = append(result.Forms, NumeralForm{Number: n, Name: name})
+		if len(req.Names) > 0 && normalizeNumeral(req.Names[i]) != normalizeNumeral(name) {
+			result.Errors = append(result.Errors, fmt.Sprintf("%q is not %d by the rules; it is %q", req.Names[i], n, name))
+		}
+	}
+	if len(req.Numbers) == 0 {
+		for _, name := range req.Names {
+			n, ok := system.identify(name)
+			if !ok {
+				result.Errors = append(result.Errors, fmt.Sprintf("%q is not a well-formed number name below %d", name, maxNumeralSearch))
+				continue
+			}
+			result.Forms = append(result.Forms, NumeralForm{Number: n, Name: name})
+		}
+	}
+
+	result.Message = fmt.Sprintf("Named %d numbers", len(result.Forms))
+	if len(req.Names) > 0 {
+		result.Message = fmt.Sprintf("Validated %d names; %d are well-formed", len(req.Names), len(req.Names)-len(result.Errors))
+	}
+	return result, nil
+}
+
+func loadNumeralSystem() (*NumeralSystem, error) {
+	data, err := storage.ReadDataFile(DefaultNumeralFile)
+	if err != nil {
+		return nil, err
+	}
+	var system NumeralSystem
+	if err := storage.DecodeDataFile(DefaultNumeralFile, data, &system); err != nil {
+		return nil, err
+	}
+	return &system, system.check()
+}
+
+// check reports rules that can't name numbers
+func (s *NumeralSystem) check() error {
+	if s.Base < 2 || s.Base > 64 {
+		return fmt.Errorf("base must be between 2 and 64")
+	}
+	if len(s.Digits) != s.Base {
+		return fmt.Errorf("base %d needs %d digit words, from zero to %d, but got %d", s.Base, s.Base, s.Base-1, len(s.Digits))
+	}
+	for i, digit := range s.Digits[1:] {
+		if strings.TrimSpace(digit) == "" {
+			return fmt.Errorf("the word for %d is empty", i+1)
+		}
+	}
+	if len(s.Powers) == 0 {
+		return fmt.Errorf("at least a word for the base itself is needed")
+	}
+	for _, d := range s.Subtractive {
+		if d < 1 || d >= s.Base {
+			return fmt.Errorf("subtractive digit %d is not a digit of base %d", d, s.Base)
+		}
+	}
+	templates := []struct{ template, slots string }{
+		{s.MultiplyTemplate, "{multiplier} {power}"},
+		{s.AddTemplate, "{big} {small}"},
+		{s.SubtractTemplate, "{small} {big}"},
+	}
+	for _, t := range templates {
+		if t.template == "" {
+			continue
+		}
+		for _, slot := range strings.Fields(t.slots) {
+			if !strings.Contains(t.template, slot) {
+				return fmt.Errorf("template %q has no %s slot", t.template, slot)
+			}
+		}
+	}
+	return nil
+}
+
+// name builds the name of a number from the rules
+func (s *NumeralSystem) name(n int64) (string, error) {
+	for _, e := range s.Exceptions {
+		if e.Number == n {
+			return e.Name, nil
+		}
+	}
+	if n < 0 {
+		return "", fmt.Errorf("negative numbers have no names")
+	}
+	base := int64(s.Base)
+	if n < base {
+		if s.Digits[n] == "" {
+			return "", fmt.Errorf("no word for zero")
+		}
+		return s.Digits[n], nil
+	}
+
+	// Numbers ending in a subtractive digit count down from the next multiple
+	if d := int(n % base); slices.Contains(s.Subtractive, d) {
+		small, err := s.name(base - int64(d))
+		if err != nil {
+			return "", err
+		}
+		big, err := s.name(n + base - int64(d))
+		if err != nil {
+			return "", err
+		}
+		return fillNumeral(orDefault(s.SubtractTemplate, "{small} from {big}"), "{small}", small, "{big}", big), nil
+	}
+
+	// The largest named power not above n leads, counted by a multiplier
+	power, value := 0, base
+	for power+1 < len(s.Powers) && value <= n/base {
+		power++
+		value *= base
+	}
+	multiplier, rest := n/value, n%value
+	head := s.Powers[power]
+	if multiplier > 1 || !s.OmitOne {
+		m, err := s.name(multiplier)
+		if err != nil {
+			return "", err
+		}
+		head = fillNumeral(orDefault(s.MultiplyTemplate, "{multiplier} {power}"), "{multiplier}", m, "{power}", head)
+	}
+	if rest == 0 {
+		return head, nil
+	}
+	small, err := s.name(rest)
+	if err != nil {
+		return "", err
+	}
+	return fillNumeral(orDefault(s.AddTemplate, "{big} {small}"), "{big}", head, "{small}", small), nil
+}
+
+// identify finds the number a name denotes by naming numbers in turn
+func (s *NumeralSystem) identify(name string) (int64, bool) {
+	target := normalizeNumeral(name)
+	for n := int64(0); n < maxNumeralSearch; n++ {
+		if generated, err := s.name(n); err == nil && normalizeNumeral(generated) == target {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func fillNumeral(template string, pairs ...string) string {
+	return strings.TrimSpace(strings.NewReplacer(pairs...).Replace(template))
+}
+
+// normalizeNumeral compares number names ignoring case and spacing
+func normalizeNumeral(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// numeralSamples are the numbers shown after declaring a system: the
+// digits, the first round numbers and a few compounds around the base
+func numeralSamples(base int64) []int64 {
+	samples := []int64{}
+	for n := int64(1); n <= base+3; n++ {
+		samples = append(samples, n)
+	}
+	return append(samples, 2*base, 2*base-1, 3*base+5, base*base, base*base+base+1, 2*base*base*base+7)
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// createSetNumeralSystemTool creates the numeral system declaration tool
+func createSetNumeralSystemTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_numeral_system",
+		"Declare the conlang's numeral system: base, digit and power words, templates for multiplying, adding and subtracting, subtractive digits and irregular names. The rules are written to numerals.json and sample number names are returned.",
+		SetNumeralSystem,
+	)
+}
+
+// createNumberNamesTool creates the number naming and validation tool
+func createNumberNamesTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"number_names",
+		"Generate number names for arbitrary integers from the declared numeral system, check proposed names against the rules, or find which number a name denotes. Use this instead of composing numerals yourself.",
+		NumberNames,
+	)
+}
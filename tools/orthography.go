@@ -0,0 +1,500 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// OrthographyRequest represents a request to define the grapheme-phoneme mapping
+type OrthographyRequest struct {
+	Mappings []storage.GraphemeMapping `json:"mappings" jsonschema:"required,description=Every grapheme of the romanization with the phoneme it writes; list a grapheme twice if it writes two phonemes. Replaces any previous mapping"`
+}
+
+// RomanizeRequest represents a request to convert text into the romanization
+type RomanizeRequest struct {
+	Text string `json:"text" jsonschema:"required,description=The text to romanize"`
+	From string `json:"from" jsonschema:"description=ipa for a phonemic transcription or native for native script text (defaults to ipa)"`
+}
+
+// NativeScriptRequest represents a request to convert text into the native script
+type NativeScriptRequest struct {
+	Text string `json:"text" jsonschema:"required,description=The text to write in the native script"`
+	From string `json:"from" jsonschema:"description=romanized or ipa (defaults to romanized)"`
+}
+
+// TranscribeRequest represents a request to convert romanized text into phonemes
+type TranscribeRequest struct {
+	Text string `json:"text" jsonschema:"required,description=Romanized text to transcribe"`
+}
+
+// OrthographyResult represents the result of orthography operations
+type OrthographyResult struct {
+	Success     bool     `json:"success"`
+	Message     string   `json:"message"`
+	Text        string   `json:"text,omitempty"`
+	Ambiguities []string `json:"ambiguities,omitempty"`
+	Unknown     []string `json:"unknown,omitempty"`    // Letters with no mapping, copied through unchanged
+	Mismatches  []string `json:"mismatches,omitempty"` // Lexicon entries whose spelling and IPA disagree
+	Table       string   `json:"table,omitempty"`
+}
+
+// SetOrthography stores the grapheme-phoneme mapping and reports its
+// ambiguities and the lexicon entries it contradicts
+func SetOrthography(ctx context.Context, req *OrthographyRequest) (*OrthographyResult, error) {
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return &OrthographyResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	symbols := map[string]bool{}
+	for _, p := range append(append([]storage.Phoneme{}, inventory.Consonants...), inventory.Vowels...) {
+		symbols[p.Symbol] = true
+	}
+	for _, m := range req.Mappings {
+		if m.Grapheme == "" || m.Phoneme == "" {
+			return &OrthographyResult{
+				Success: false,
+				Message: "Every mapping needs a grapheme and a phoneme",
+			}, nil
+		}
+		if len(symbols) > 0 && !symbols[m.Phoneme] {
+			return &OrthographyResult{
+				Success: false,
+				Message: fmt.Sprintf("/%s/ is not in the phoneme inventory", m.Phoneme),
+			}, nil
+		}
+	}
+
+	if err := storage.WriteOrthography(req.Mappings); err != nil {
+		return &OrthographyResult{
+			Success: false,
+			Message: failure("save orthography", err),
+		}, nil
+	}
+
+	o, err := loadOrthography()
+	if err != nil {
+		return &OrthographyResult{
+			Success: false,
+			Message: failure("load orthography", err),
+		}, nil
+	}
+	graphemes := make([]string, 0, len(o.readings))
+	for g := range o.readings {
+		graphemes = append(graphemes, g)
+	}
+	sort.Strings(graphemes)
+
+	message := fmt.Sprintf("Saved %d grapheme mappings", len(req.Mappings))
+	mismatches := []string{}
+	if entries, err := loadLexicon(); err == nil {
+		mismatches = o.mismatches(entries)
+		if len(mismatches) > 0 {
+			message += fmt.Sprintf("; %d lexicon entries are spelled inconsistently with their IPA", len(mismatches))
+		}
+	}
+
+	return &OrthographyResult{
+		Success:     true,
+		Message:     message,
+		Ambiguities: o.ambiguities(graphemes, o.phonemes()),
+		Mismatches:  mismatches,
+		Table:       o.table(req.Mappings),
+	}, nil
+}
+
+// Romanize converts a phonemic transcription or native script text into the romanization
+func Romanize(ctx context.Context, req *RomanizeRequest) (*OrthographyResult, error) {
+	o, err := loadOrthography()
+	if err != nil {
+		return &OrthographyResult{
+			Success: false,
+			Message: failure("load orthography", err),
+		}, nil
+	}
+
+	switch req.From {
+	case "", "ipa":
+		if len(o.spellings) == 0 {
+			return &OrthographyResult{
+				Success: false,
+				Message: "No orthography defined yet; use set_orthography or set_phoneme_inventory first",
+			}, nil
+		}
+		text, used, unknown := convert(stripTranscription(req.Text), o.spellings)
+		return &OrthographyResult{
+			Success:     true,
+			Message:     "Romanized the transcription",
+			Text:        text,
+			Ambiguities: o.ambiguities(nil, used),
+			Unknown:     unknown,
+		}, nil
+	case "native":
+		if len(o.graphemeOf) == 0 {
+			return &OrthographyResult{
+				Success: false,
+				Message: "The native script has no glyphs with graphemes yet; add them with add_glyph",
+			}, nil
+		}
+		text, _, unknown := convert(req.Text, o.graphemeOf)
+		return &OrthographyResult{
+			Success: true,
+			Message: "Romanized the native script text",
+			Text:    text,
+			Unknown: unknown,
+		}, nil
+	}
+	return &OrthographyResult{
+		Success: false,
+		Message: fmt.Sprintf("Unknown source %q (expected ipa or native)", req.From),
+	}, nil
+}
+
+// ToNativeScript converts romanized or phonemic text into the native script
+func ToNativeScript(ctx context.Context, req *NativeScriptRequest) (*OrthographyResult, error) {
+	o, err := loadOrthography()
+	if err != nil {
+		return &OrthographyResult{
+			Success: false,
+			Message: failure("load orthography", err),
+		}, nil
+	}
+	if len(o.glyphs) == 0 && len(o.phonemic) == 0 {
+		return &OrthographyResult{
+			Success: false,
+			Message: "The native script has no glyphs with graphemes or phonemes yet; add them with add_glyph",
+		}, nil
+	}
+
+	text := req.Text
+	ambiguities := []string{}
+	var native string
+	var unknown []string
+	switch req.From {
+	case "", "romanized":
+		if len(o.glyphs) > 0 {
+			native, _, unknown = convert(strings.ToLower(text), o.glyphs)
+			break
+		}
+		// A phonemic script is written from the romanization's phonemes
+		var used []string
+		text, used, _ = convert(strings.ToLower(text), o.readings)
+		ambiguities = o.ambiguities(used, nil)
+		native, _, unknown = convert(text, o.phonemic)
+	case "ipa":
+		if len(o.phonemic) > 0 {
+			native, _, unknown = convert(stripSyllables(text), o.phonemic)
+			break
+		}
+		var used []string
+		text, used, _ = convert(stripTranscription(text), o.spellings)
+		ambiguities = o.ambiguities(nil, used)
+		native, _, unknown = convert(strings.ToLower(text), o.glyphs)
+	default:
+		return &OrthographyResult{
+			Success: false,
+			Message: fmt.Sprintf("Unknown source %q (expected romanized or ipa)", req.From),
+		}, nil
+	}
+
+	return &OrthographyResult{
+		Success:     true,
+		Message:     "Converted to the native script",
+		Text:        native,
+		Ambiguities: ambiguities,
+		Unknown:     unknown,
+	}, nil
+}
+
+// Transcribe converts romanized text into its phonemes
+func Transcribe(ctx context.Context, req *TranscribeRequest) (*OrthographyResult, error) {
+	o, err := loadOrthography()
+	if err != nil {
+		return &OrthographyResult{
+			Success: false,
+			Message: failure("load orthography", err),
+		}, nil
+	}
+	if len(o.readings) == 0 {
+		return &OrthographyResult{
+			Success: false,
+			Message: "No orthography defined yet; use set_orthography or set_phoneme_inventory first",
+		}, nil
+	}
+
+	text, used, unknown := convert(strings.ToLower(req.Text), o.readings)
+	return &OrthographyResult{
+		Success:     true,
+		Message:     "Transcribed the romanized text",
+		Text:        "/" + text + "/",
+		Ambiguities: o.ambiguities(used, nil),
+		Unknown:     unknown,
+	}, nil
+}
+
+// orthography converts between phonemes, the romanization and the native script
+type orthography struct {
+	readings   map[string][]string // Grapheme to the phonemes it writes
+	spellings  map[string][]string // Phoneme to the graphemes writing it
+	glyphs     map[string][]string // Grapheme to its native script glyph
+	graphemeOf map[string][]string // Native script glyph to its grapheme
+	phonemic   map[string][]string // Phoneme to the glyph writing it directly
+}
+
+// loadOrthography reads the grapheme mapping, falling back to the
+// romanizations declared in the phoneme inventory, and the native script
+func loadOrthography() (*orthography, error) {
+	mappings, err := storage.ReadOrthography()
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) == 0 {
+		inventory, err := storage.ReadInventory()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range append(append([]storage.Phoneme{}, inventory.Consonants...), inventory.Vowels...) {
+			mappings = append(mappings, storage.GraphemeMapping{Grapheme: romanization(p), Phoneme: p.Symbol})
+		}
+	}
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return nil, err
+	}
+
+	o := &orthography{
+		readings:   map[string][]string{},
+		spellings:  map[string][]string{},
+		glyphs:     map[string][]string{},
+		graphemeOf: map[string][]string{},
+		phonemic:   map[string][]string{},
+	}
+	for _, m := range mappings {
+		grapheme := strings.ToLower(m.Grapheme)
+		o.readings[grapheme] = appendUnique(o.readings[grapheme], m.Phoneme)
+		o.spellings[m.Phoneme] = appendUnique(o.spellings[m.Phoneme], grapheme)
+	}
+	for _, g := range glyphs {
+		r, err := parseCodepoint(g.Codepoint)
+		if err != nil {
+			continue
+		}
+		if len(g.Phonemes) > 0 {
+			phonemes := strings.Join(g.Phonemes, "")
+			o.phonemic[phonemes] = appendUnique(o.phonemic[phonemes], string(r))
+			if g.Grapheme == "" {
+				spelling, _, _ := convert(phonemes, o.spellings)
+				o.graphemeOf[string(r)] = []string{spelling}
+			}
+		}
+		if g.Grapheme == "" {
+			continue
+		}
+		grapheme := strings.ToLower(g.Grapheme)
+		o.glyphs[grapheme] = appendUnique(o.glyphs[grapheme], string(r))
+		o.graphemeOf[string(r)] = []string{grapheme}
+	}
+	return o, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// phonemes returns every mapped phoneme in a stable order
+func (o *orthography) phonemes() []string {
+	phonemes := make([]string, 0, len(o.spellings))
+	for p := range o.spellings {
+		phonemes = append(phonemes, p)
+	}
+	sort.Strings(phonemes)
+	return phonemes
+}
+
+// ambiguities describes the given graphemes that can be read more than one
+// way and the given phonemes that can be spelled more than one way
+func (o *orthography) ambiguities(graphemes, phonemes []string) []string {
+	ambiguities := []string{}
+	for _, g := range graphemes {
+		if readings := o.readings[g]; len(readings) > 1 {
+			ambiguities = append(ambiguities, fmt.Sprintf("%s writes /%s/", g, strings.Join(readings, "/ or /")))
+		}
+		if parts := o.split(g); parts != nil {
+			ambiguities = append(ambiguities, fmt.Sprintf("%s could also be read as %s", g, strings.Join(parts, "+")))
+		}
+	}
+	for _, p := range phonemes {
+		if spellings := o.spellings[p]; len(spellings) > 1 {
+			ambiguities = append(ambiguities, fmt.Sprintf("/%s/ is written %s", p, strings.Join(spellings, " or ")))
+		}
+	}
+	return ambiguities
+}
+
+// split returns a way to read a multigraph as a sequence of shorter
+// graphemes, such as s+h for sh, or nil if there is none
+func (o *orthography) split(grapheme string) []string {
+	runes := []rune(grapheme)
+	var walk func(i int) []string
+	walk = func(i int) []string {
+		if i == len(runes) {
+			return []string{}
+		}
+		for l := len(runes) - i; l > 0; l-- {
+			part := string(runes[i : i+l])
+			if _, ok := o.readings[part]; !ok || l == len(runes) {
+				continue
+			}
+			if rest := walk(i + l); rest != nil {
+				return append([]string{part}, rest...)
+			}
+		}
+		return nil
+	}
+	return walk(0)
+}
+
+// mismatches lists lexicon entries whose spelling and IPA don't correspond.
+// An entry agrees if its IPA romanizes to its spelling or its spelling
+// transcribes to its IPA.
+func (o *orthography) mismatches(entries []LexiconEntry) []string {
+	mismatches := []string{}
+	for _, entry := range entries {
+		if entry.IPA == "" {
+			continue
+		}
+		ipa := strings.NewReplacer(" ", "", ".", "").Replace(stripTranscription(entry.IPA))
+		word := strings.ToLower(entry.Word)
+		spelled, _, _ := convert(ipa, o.spellings)
+		read, _, _ := convert(word, o.readings)
+		if spelled != word && read != ipa {
+			mismatches = append(mismatches, fmt.Sprintf("%s /%s/ would be spelled %s", entry.Word, ipa, spelled))
+		}
+	}
+	return mismatches
+}
+
+func (o *orthography) table(mappings []storage.GraphemeMapping) string {
+	var out strings.Builder
+	out.WriteString("| Grapheme | Phoneme | Glyph |\n|---|---|---|\n")
+	for _, m := range mappings {
+		glyph := strings.Join(o.glyphs[strings.ToLower(m.Grapheme)], " ")
+		out.WriteString(fmt.Sprintf("| %s | /%s/ | %s |\n", m.Grapheme, m.Phoneme, glyph))
+	}
+	return out.String()
+}
+
+// nativeSpelling writes a lexicon entry in the native script: from its
+// pronunciation when glyphs write phonemes, otherwise from its spelling
+func (o *orthography) nativeSpelling(entry LexiconEntry) string {
+	if len(o.phonemic) > 0 {
+		phonemes := stripSyllables(entry.IPA)
+		if phonemes == "" {
+			phonemes, _, _ = convert(strings.ToLower(entry.Word), o.readings)
+		}
+		native, _, _ := convert(phonemes, o.phonemic)
+		return native
+	}
+	if len(o.glyphs) == 0 {
+		return ""
+	}
+	native, _, _ := convert(strings.ToLower(entry.Word), o.glyphs)
+	return native
+}
+
+// stripTranscription removes slashes, brackets and stress marks from a
+// phonemic transcription
+func stripTranscription(text string) string {
+	return strings.NewReplacer("/", "", "[", "", "]", "", "ˈ", "", "ˌ", "").Replace(text)
+}
+
+// stripSyllables removes syllable dots along with the transcription marks
+func stripSyllables(text string) string {
+	return strings.ReplaceAll(stripTranscription(text), ".", "")
+}
+
+// convert rewrites text by longest match against the keys of table, using the
+// first value of each key. It returns the matched keys and the letters that
+// match no key, which are copied through unchanged like spaces and punctuation.
+func convert(text string, table map[string][]string) (string, []string, []string) {
+	longest := 0
+	for key := range table {
+		longest = max(longest, len([]rune(key)))
+	}
+
+	var out strings.Builder
+	used, unknown := []string{}, []string{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		n := 0
+		for l := min(longest, len(runes)-i); l > 0; l-- {
+			if _, ok := table[string(runes[i:i+l])]; ok {
+				n = l
+				break
+			}
+		}
+		if n == 0 {
+			if unicode.IsLetter(runes[i]) {
+				unknown = appendUnique(unknown, string(runes[i]))
+			}
+			out.WriteRune(runes[i])
+			i++
+			continue
+		}
+		key := string(runes[i : i+n])
+		out.WriteString(table[key][0])
+		used = appendUnique(used, key)
+		i += n
+	}
+	return out.String(), used, unknown
+}
+
+// createSetOrthographyTool creates the tool that defines the grapheme-phoneme mapping
+func createSetOrthographyTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_orthography",
+		"Define the romanization as a grapheme-to-phoneme mapping. Reports ambiguous graphemes, phonemes with several spellings, multigraphs that could be split, and lexicon entries whose spelling disagrees with their IPA.",
+		SetOrthography,
+	)
+}
+
+// createRomanizeTool creates the romanization tool
+func createRomanizeTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"romanize",
+		"Convert a phonemic (IPA) transcription or native script text into the romanization, reporting ambiguous spellings and unmapped letters.",
+		Romanize,
+	)
+}
+
+// createToNativeScriptTool creates the native script conversion tool
+func createToNativeScriptTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"to_native_script",
+		"Convert romanized or IPA text into the native script using the graphemes of the glyphs added with add_glyph.",
+		ToNativeScript,
+	)
+}
+
+// createTranscribeTool creates the romanized-to-phonemic conversion tool
+func createTranscribeTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"transcribe",
+		"Convert romanized text into a phonemic (IPA) transcription using the orthography, reporting graphemes that can be read more than one way.",
+		Transcribe,
+	)
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+)
+
+// DefaultSprintBatch is how many concepts a vocabulary sprint sends at once
+const DefaultSprintBatch = 8
+
+// Concept is a meaning to coin a word for. Frequency from 1 (rare) to 5
+// (everyday) orders sprints so the most useful words come first.
+type Concept struct {
+	Gloss     string `json:"gloss"`
+	Frequency int    `json:"frequency"`
+}
+
+// ConceptPack is a curated set of concepts from one semantic field
+type ConceptPack struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Concepts    []Concept `json:"concepts"`
+}
+
+// conceptPacks are the packs available for vocabulary sprints
+var conceptPacks = []ConceptPack{
+	{
+		Name:        "cooking",
+		Description: "Food, the kitchen and preparing meals",
+		Concepts: []Concept{
+			{"bread", 5}, {"eat", 5}, {"water", 5}, {"cook", 5}, {"meat", 4},
+			{"salt", 4}, {"fire", 4}, {"pot", 4}, {"knife", 4}, {"boil", 3},
+			{"bake", 3}, {"roast", 3}, {"flour", 3}, {"oil", 3}, {"taste", 3},
+			{"spice", 2}, {"ladle", 2}, {"ferment", 2}, {"simmer", 1}, {"marinade", 1},
+		},
+	},
+	{
+		Name:        "seafaring",
+		Description: "Ships, sailing and life at sea",
+		Concepts: []Concept{
+			{"sea", 5}, {"boat", 5}, {"fish", 5}, {"wave", 4}, {"shore", 4},
+			{"sail", 4}, {"wind", 4}, {"oar", 3}, {"harbor", 3}, {"anchor", 3},
+			{"net", 3}, {"tide", 3}, {"storm", 3}, {"mast", 2}, {"rudder", 2},
+			{"navigate", 2}, {"keel", 1}, {"shipwreck", 1}, {"starboard", 1}, {"becalmed", 1},
+		},
+	},
+	{
+		Name:        "law",
+		Description: "Rules, courts, crime and justice",
+		Concepts: []Concept{
+			{"law", 5}, {"judge", 4}, {"steal", 4}, {"guilty", 4}, {"punish", 4},
+			{"promise", 4}, {"crime", 3}, {"witness", 3}, {"court", 3}, {"debt", 3},
+			{"oath", 3}, {"accuse", 3}, {"innocent", 3}, {"contract", 2}, {"fine", 2},
+			{"inherit", 2}, {"testimony", 2}, {"appeal", 1}, {"verdict", 1}, {"exile", 1},
+		},
+	},
+}
+
+// FindPack returns the concept pack with the given name
+func FindPack(name string) (ConceptPack, error) {
+	names := []string{}
+	for _, pack := range conceptPacks {
+		if strings.EqualFold(pack.Name, name) {
+			return pack, nil
+		}
+		names = append(names, pack.Name)
+	}
+	return ConceptPack{}, fmt.Errorf("no concept pack %s, choose one of %s", name, strings.Join(names, ", "))
+}
+
+// PackStatus is how far a concept pack has been covered
+type PackStatus struct {
+	Pack      ConceptPack `json:"pack"`
+	Done      []Concept   `json:"done"`    // Concepts with a lexicon entry
+	Pending   []Concept   `json:"pending"` // Concepts with a proposal waiting for review
+	Remaining []Concept   `json:"remaining"`
+	Weighted  int         `json:"weighted"` // Frequency-weighted completion percentage
+}
+
+// PackProgress reports the coverage of every concept pack. A concept counts
+// as done when a lexicon definition mentions its gloss.
+func PackProgress() ([]PackStatus, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return nil, err
+	}
+	proposals, err := storage.ReadReview()
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := []string{}
+	for _, entry := range entries {
+		definitions = append(definitions, " "+strings.Join(TokenizeWords(entry.Definition), " ")+" ")
+	}
+
+	statuses := []PackStatus{}
+	for _, pack := range conceptPacks {
+		status := PackStatus{Pack: pack}
+		done, total := 0, 0
+		for _, concept := range pack.Concepts {
+			total += concept.Frequency
+			switch {
+			case definesConcept(definitions, concept):
+				status.Done = append(status.Done, concept)
+				done += concept.Frequency
+			case proposedFor(proposals, pack, concept):
+				status.Pending = append(status.Pending, concept)
+			default:
+				status.Remaining = append(status.Remaining, concept)
+			}
+		}
+		if total > 0 {
+			status.Weighted = done * 100 / total
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func definesConcept(definitions []string, concept Concept) bool {
+	gloss := " " + strings.Join(TokenizeWords(concept.Gloss), " ") + " "
+	for _, definition := range definitions {
+		if strings.Contains(definition, gloss) {
+			return true
+		}
+	}
+	return false
+}
+
+func proposedFor(proposals []storage.Proposal, pack ConceptPack, concept Concept) bool {
+	for _, p := range proposals {
+		if strings.EqualFold(p.Pack, pack.Name) && strings.EqualFold(p.Concept, concept.Gloss) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextSprintBatch returns up to size remaining concepts of a pack, most
+// frequent first
+func NextSprintBatch(name string, size int) ([]Concept, error) {
+	pack, err := FindPack(name)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := PackProgress()
+	if err != nil {
+		return nil, err
+	}
+	var remaining []Concept
+	for _, status := range statuses {
+		if status.Pack.Name == pack.Name {
+			remaining = append(remaining, status.Remaining...)
+		}
+	}
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Frequency > remaining[j].Frequency
+	})
+	if len(remaining) > size {
+		remaining = remaining[:size]
+	}
+	return remaining, nil
+}
+
+// SprintPrompt asks the model to coin words for a batch of concepts and stage
+// them for review
+func SprintPrompt(pack string, batch []Concept) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Vocabulary sprint for the %s pack. Coin a word for each of these concepts, ", pack))
+	out.WriteString("checking it against the phonology where one is declared, and stage each one with propose_lexicon_entry ")
+	out.WriteString(fmt.Sprintf("using pack %q and the concept exactly as written. Do not add them to the lexicon directly.\n\n", pack))
+	for _, concept := range batch {
+		out.WriteString(fmt.Sprintf("- %s (frequency %d/5)\n", concept.Gloss, concept.Frequency))
+	}
+	return out.String()
+}
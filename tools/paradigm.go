@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// ParadigmValue is one value of an inflectional category and the affix that marks it
+type ParadigmValue struct {
+	Label string `json:"label" jsonschema:"required,description=Gloss label for the value such as NOM or PL"`
+	Affix string `json:"affix" jsonschema:"description=Affix marking the value: -x for a suffix, x- for a prefix, empty for the stored affix with this gloss or none"`
+}
+
+// ParadigmDimension is an inflectional category such as case or number
+type ParadigmDimension struct {
+	Name   string          `json:"name" jsonschema:"required,description=Name of the category such as case, number, tense or person"`
+	Values []ParadigmValue `json:"values" jsonschema:"required,description=The values of the category in order"`
+}
+
+// ParadigmRequest represents a request to generate a paradigm
+type ParadigmRequest struct {
+	Stem       string              `json:"stem" jsonschema:"required,description=The stem to inflect"`
+	Lexeme     string              `json:"lexeme" jsonschema:"description=Citation form the suppletive forms are registered under, if different from the stem"`
+	Dimensions []ParadigmDimension `json:"dimensions" jsonschema:"required,description=Inflectional categories; affixes are applied in this order from the stem outwards"`
+	Path       string              `json:"path" jsonschema:"description=Data file path to save the table to"`
+}
+
+// ParadigmForm is a single cell of a paradigm
+type ParadigmForm struct {
+	Labels     []string `json:"labels"`
+	Form       string   `json:"form"`
+	Suppletive bool     `json:"suppletive,omitempty"`
+}
+
+// ParadigmResult represents a generated paradigm
+type ParadigmResult struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Forms   []ParadigmForm `json:"forms,omitempty"`
+	Table   string         `json:"table,omitempty"`
+}
+
+// applyAffix attaches an affix to a stem. A leading hyphen marks a suffix
+// (-ni), a trailing hyphen a prefix (ta-) and a bare affix is treated as a
+// suffix. An empty affix leaves the stem unchanged.
+func applyAffix(stem, affix string) string {
+	switch {
+	case affix == "" || affix == "-" || affix == "Ø" || affix == "∅":
+		return stem
+	case strings.HasSuffix(affix, "-") && !strings.HasPrefix(affix, "-"):
+		return strings.TrimSuffix(affix, "-") + stem
+	default:
+		return stem + strings.TrimPrefix(affix, "-")
+	}
+}
+
+// GenerateParadigm mechanically applies every combination of affixes to a
+// stem, using registered suppletive forms where a cell has one
+func GenerateParadigm(ctx context.Context, req *ParadigmRequest) (*ParadigmResult, error) {
+	if req.Stem == "" {
+		return &ParadigmResult{
+			Success: false,
+			Message: "Stem is required",
+		}, nil
+	}
+	if len(req.Dimensions) == 0 {
+		return &ParadigmResult{
+			Success: false,
+			Message: "At least one inflectional category is required",
+		}, nil
+	}
+	for _, d := range req.Dimensions {
+		if len(d.Values) == 0 {
+			return &ParadigmResult{
+				Success: false,
+				Message: fmt.Sprintf("Category %s has no values", d.Name),
+			}, nil
+		}
+	}
+
+	store, err := loadAffixStore()
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read affixes", err),
+		}, nil
+	}
+
+	suppletion, err := storage.ReadSuppletion()
+	if err != nil {
+		return &ParadigmResult{
+			Success: false,
+			Message: failure("read suppletive forms", err),
+		}, nil
+	}
+	lexeme := req.Lexeme
+	if lexeme == "" {
+		lexeme = req.Stem
+	}
+
+	cells := paradigmCells(req.Dimensions)
+	forms := make([]ParadigmForm, 0, len(cells))
+	suppletive := 0
+	for _, c := range cells {
+		cell := realizeCell(req.Stem, lexeme, req.Stem, c, store, suppletion)
+		f := ParadigmForm{Labels: []string{}, Form: cell.form(), Suppletive: cell.suppletive}
+		for _, v := range c {
+			f.Labels = append(f.Labels, v.Label)
+		}
+		if f.Suppletive {
+			suppletive++
+		}
+		forms = append(forms, f)
+	}
+
+	table := renderParadigmTable(req.Stem, req.Dimensions, forms)
+
+	message := fmt.Sprintf("Generated %d forms of %s", len(forms), req.Stem)
+	if suppletive > 0 {
+		message += fmt.Sprintf(", %d of them suppletive", suppletive)
+	}
+	if req.Path != "" {
+		if err := storage.WriteDataFile(req.Path, []byte(table)); err != nil {
+			return &ParadigmResult{
+				Success: false,
+				Message: failure("save paradigm", err),
+			}, nil
+		}
+		message += " and saved the table to " + req.Path
+	}
+
+	return &ParadigmResult{
+		Success: true,
+		Message: message,
+		Forms:   forms,
+		Table:   table,
+	}, nil
+}
+
+// paradigmCells lists every combination of the dimensions' values, the last
+// dimension varying fastest
+func paradigmCells(dimensions []ParadigmDimension) [][]ParadigmValue {
+	cells := [][]ParadigmValue{{}}
+	for _, d := range dimensions {
+		next := make([][]ParadigmValue, 0, len(cells)*len(d.Values))
+		for _, c := range cells {
+			for _, v := range d.Values {
+				next = append(next, append(append([]ParadigmValue{}, c...), v))
+			}
+		}
+		cells = next
+	}
+	return cells
+}
+
+// inflectedForm is a realized paradigm cell split into morphemes, with the
+// gloss of each morpheme
+type inflectedForm struct {
+	morphemes  []string
+	glosses    []string
+	suppletive bool
+}
+
+func (f inflectedForm) form() string {
+	return strings.Join(f.morphemes, "")
+}
+
+// realizeCell builds the form of one paradigm cell. Suppletive forms of the
+// lexeme are consulted before the regular affixes are applied; a suppletive
+// word fills the cell on its own and a suppletive stem still takes the
+// affixes of the categories it doesn't express.
+func realizeCell(stem, lexeme, gloss string, values []ParadigmValue, store *affixStore, suppletion []storage.SuppletiveForm) inflectedForm {
+	labels := []string{}
+	for _, v := range values {
+		labels = append(labels, v.Label)
+	}
+
+	full, suppletiveStem := suppletiveFor(suppletion, lexeme, labels)
+	if full != nil && (suppletiveStem == nil || len(full.Cell) >= len(suppletiveStem.Cell)) {
+		return inflectedForm{
+			morphemes:  []string{full.Form},
+			glosses:    []string{strings.Join(append([]string{gloss}, labels...), ".")},
+			suppletive: true,
+		}
+	}
+
+	f := inflectedForm{morphemes: []string{stem}, glosses: []string{gloss}}
+	expressed := map[string]bool{}
+	if suppletiveStem != nil {
+		f.morphemes[0] = suppletiveStem.Form
+		f.glosses[0] = strings.Join(append([]string{gloss}, suppletiveStem.Cell...), ".")
+		f.suppletive = true
+		for _, label := range suppletiveStem.Cell {
+			expressed[strings.ToLower(label)] = true
+		}
+	}
+	for _, v := range values {
+		if expressed[strings.ToLower(v.Label)] {
+			continue
+		}
+		affix := v.Affix
+		if affix == "" {
+			if stored, ok := store.glossed(v.Label); ok {
+				affix = stored.Form
+			}
+		}
+		before := f.form()
+		after := store.attach(before, affix)
+		switch {
+		case after == before:
+		case strings.HasPrefix(after, before):
+			f.morphemes = append(f.morphemes, after[len(before):])
+			f.glosses = append(f.glosses, v.Label)
+		case strings.HasSuffix(after, before):
+			f.morphemes = append([]string{after[:len(after)-len(before)]}, f.morphemes...)
+			f.glosses = append([]string{v.Label}, f.glosses...)
+		default:
+			f.morphemes, f.glosses = []string{after}, []string{strings.Join(append(f.glosses, v.Label), ".")}
+		}
+	}
+	return f
+}
+
+// renderParadigmTable renders forms as a markdown table. The last category
+// forms the columns and every combination of the others forms a row.
+func renderParadigmTable(stem string, dimensions []ParadigmDimension, forms []ParadigmForm) string {
+	columns := dimensions[len(dimensions)-1]
+	rowNames := []string{}
+	for _, d := range dimensions[:len(dimensions)-1] {
+		rowNames = append(rowNames, d.Name)
+	}
+
+	var out strings.Builder
+	suppletive := false
+	out.WriteString(fmt.Sprintf("### Paradigm of %s\n\n", stem))
+
+	header := strings.Join(rowNames, " / ")
+	if header == "" {
+		header = stem
+	}
+	out.WriteString("| " + header + " |")
+	for _, v := range columns.Values {
+		out.WriteString(" " + v.Label + " |")
+	}
+	out.WriteString("\n|---|" + strings.Repeat("---|", len(columns.Values)) + "\n")
+
+	for i := 0; i < len(forms); i += len(columns.Values) {
+		row := forms[i].Labels[:len(forms[i].Labels)-1]
+		label := strings.Join(row, ".")
+		if label == "" {
+			label = columns.Name
+		}
+		out.WriteString("| " + label + " |")
+		for _, f := range forms[i : i+len(columns.Values)] {
+			if f.Suppletive {
+				out.WriteString(" **" + f.Form + "** |")
+				suppletive = true
+			} else {
+				out.WriteString(" " + f.Form + " |")
+			}
+		}
+		out.WriteString("\n")
+	}
+	if suppletive {
+		out.WriteString("\nSuppletive forms are in bold.\n")
+	}
+	return out.String()
+}
+
+// createParadigmTool creates the paradigm generator tool
+func createParadigmTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"generate_paradigm",
+		"Generate a complete inflectional paradigm table for a stem from categories (case, number, tense, person) and their affixes, using registered suppletive forms where they exist, optionally saving it to a file. Use this instead of writing conjugation or declension tables by hand.",
+		GenerateParadigm,
+	)
+}
@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// PhonotacticsCheckRequest represents a request to check candidate words
+type PhonotacticsCheckRequest struct {
+	Words []string `json:"words" jsonschema:"required,description=Candidate words to check against the declared phonotactics"`
+}
+
+// WordCheck is the phonotactic verdict for one word
+type WordCheck struct {
+	Word       string                 `json:"word"`
+	Valid      bool                   `json:"valid"`
+	Syllables  string                 `json:"syllables"` // Syllables separated by dots
+	Violations []PhonotacticViolation `json:"violations,omitempty"`
+}
+
+// PhonotacticsResult represents the result of phonotactics operations
+type PhonotacticsResult struct {
+	Success      bool                  `json:"success"`
+	Message      string                `json:"message"`
+	Phonotactics *storage.Phonotactics `json:"phonotactics,omitempty"`
+	Words        []WordCheck           `json:"words,omitempty"`
+}
+
+// SetPhonotactics stores the language's phonotactic constraints
+func SetPhonotactics(ctx context.Context, req *storage.Phonotactics) (*PhonotacticsResult, error) {
+	if _, err := parseSyllableTemplate(req.Template, req.Onsets); err != nil {
+		return &PhonotacticsResult{
+			Success: false,
+			Message: "Invalid syllable template: " + err.Error(),
+		}, nil
+	}
+	if err := storage.WritePhonotactics(*req); err != nil {
+		return &PhonotacticsResult{
+			Success: false,
+			Message: failure("save phonotactics", err),
+		}, nil
+	}
+	return &PhonotacticsResult{
+		Success:      true,
+		Message:      "Phonotactic constraints saved",
+		Phonotactics: req,
+	}, nil
+}
+
+// CheckPhonotactics validates candidate words against the declared phoneme
+// inventory and phonotactic constraints
+func CheckPhonotactics(ctx context.Context, req *PhonotacticsCheckRequest) (*PhonotacticsResult, error) {
+	if len(req.Words) == 0 {
+		return &PhonotacticsResult{
+			Success: false,
+			Message: "At least one word is required",
+		}, nil
+	}
+
+	checker, err := loadPhonotacticsChecker()
+	if err != nil {
+		return &PhonotacticsResult{
+			Success: false,
+			Message: failure("load phonotactics", err),
+		}, nil
+	}
+
+	checks, err := parallelMap(ctx, req.Words, func(word string) WordCheck {
+		return checker.check(strings.ToLower(strings.TrimSpace(word)))
+	})
+	if err != nil {
+		return &PhonotacticsResult{
+			Success: false,
+			Message: "Phonotactics check stopped: " + err.Error(),
+		}, nil
+	}
+	invalid := 0
+	for _, check := range checks {
+		if !check.Valid {
+			invalid++
+		}
+	}
+
+	return &PhonotacticsResult{
+		Success: true,
+		Message: fmt.Sprintf("Checked %d words, %d break the phonotactics", len(checks), invalid),
+		Words:   checks,
+	}, nil
+}
+
+// phonotacticsChecker checks words against the stored inventory and constraints
+type phonotacticsChecker struct {
+	rules    storage.Phonotactics
+	template *syllableTemplate
+	phonemes *phonemeSet
+	codas    map[string]bool
+	harmony  *harmonizer
+}
+
+func loadPhonotacticsChecker() (*phonotacticsChecker, error) {
+	rules, err := storage.ReadPhonotactics()
+	if err != nil {
+		return nil, err
+	}
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return nil, err
+	}
+	template, err := parseSyllableTemplate(rules.Template, rules.Onsets)
+	if err != nil {
+		return nil, err
+	}
+	template.phonemes = set
+
+	harmony, err := loadHarmonizer()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &phonotacticsChecker{rules: rules, template: template, phonemes: set, harmony: harmony}
+	if len(rules.Codas) > 0 {
+		c.codas = map[string]bool{}
+		for _, coda := range rules.Codas {
+			c.codas[strings.ToLower(coda)] = true
+		}
+	}
+	return c, nil
+}
+
+// symbol returns the inventory symbol for a segment, or the segment itself
+func (c *phonotacticsChecker) symbol(segment string) string {
+	if p, ok := c.phonemes.phoneme(segment); ok {
+		return p.Symbol
+	}
+	return segment
+}
+
+func (c *phonotacticsChecker) check(word string) WordCheck {
+	syllabified := c.template.syllabify(word)
+	violations := append([]PhonotacticViolation{}, syllabified.Violations...)
+
+	if c.phonemes != nil {
+		for _, seg := range c.phonemes.segment(word) {
+			if _, ok := c.phonemes.phoneme(seg); !ok {
+				violations = append(violations, PhonotacticViolation{
+					Constraint: "inventory",
+					Detail:     fmt.Sprintf("%s is not in the phoneme inventory", seg),
+				})
+			}
+		}
+	}
+
+	if c.codas != nil {
+		for _, syllable := range syllabified.Syllables {
+			segs := c.phonemes.segment(syllable)
+			last := len(segs)
+			for last > 0 && !c.phonemes.vowel(segs[last-1]) {
+				last--
+			}
+			coda := strings.Join(segs[last:], "")
+			if coda != "" && !c.codas[coda] {
+				violations = append(violations, PhonotacticViolation{
+					Constraint: "coda",
+					Syllable:   syllable,
+					Detail:     fmt.Sprintf("coda %s is not permitted", coda),
+				})
+			}
+		}
+	}
+
+	for _, cluster := range c.rules.ForbiddenClusters {
+		if cluster != "" && strings.Contains(word, strings.ToLower(cluster)) {
+			violations = append(violations, PhonotacticViolation{
+				Constraint: "cluster",
+				Detail:     fmt.Sprintf("contains forbidden sequence %s", cluster),
+			})
+		}
+	}
+
+	for _, violation := range c.harmony.violations(word) {
+		violations = append(violations, PhonotacticViolation{
+			Constraint: "harmony",
+			Detail:     violation,
+		})
+	}
+
+	return WordCheck{
+		Word:       word,
+		Valid:      len(violations) == 0,
+		Syllables:  strings.Join(syllabified.Syllables, "."),
+		Violations: violations,
+	}
+}
+
+// createSetPhonotacticsTool creates the tool that declares phonotactic constraints
+func createSetPhonotacticsTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_phonotactics",
+		"Declare the language's phonotactic constraints: syllable template, permitted onset clusters and codas and forbidden sequences. Replaces the previous constraints.",
+		SetPhonotactics,
+	)
+}
+
+// createCheckPhonotacticsTool creates the phonotactics checker tool
+func createCheckPhonotacticsTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"check_phonotactics",
+		"Check candidate words against the phoneme inventory and declared phonotactic and vowel harmony constraints, reporting for each violation which constraint it breaks (inventory, template, onset, coda, cluster or harmony).",
+		CheckPhonotactics,
+	)
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// maxPlanSteps is the most steps a plan may have
+const maxPlanSteps = 12
+
+// PlanStep is one step of a plan, carried out as its own turn
+type PlanStep struct {
+	Description string   `json:"description" jsonschema:"required,description=What the step does such as 'Apply the Old to Middle sound changes to the lexicon'"`
+	Tools       []string `json:"tools,omitempty" jsonschema:"description=Names of the tools the step is expected to call such as evolve_lexicon"`
+}
+
+// PlanStepsRequest represents a plan for a request that needs several tool steps
+type PlanStepsRequest struct {
+	Goal  string     `json:"goal" jsonschema:"required,description=The user's request the plan carries out such as 'Evolve the language 500 years'"`
+	Steps []PlanStep `json:"steps" jsonschema:"required,description=Steps in the order they run; each is sent back to you as its own turn"`
+}
+
+// PlanResult represents a plan accepted for execution
+type PlanResult struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message"`
+	Goal    string     `json:"goal,omitempty"`
+	Steps   []PlanStep `json:"steps,omitempty"`
+}
+
+// PlanSteps checks a plan of tool steps. The plan is only recorded here; the
+// interface shows it as a checklist and sends each step back as its own turn.
+func PlanSteps(ctx context.Context, req *PlanStepsRequest) (*PlanResult, error) {
+	goal := strings.TrimSpace(req.Goal)
+	if goal == "" {
+		return &PlanResult{
+			Success: false,
+			Message: "A plan needs a goal",
+		}, nil
+	}
+	if len(req.Steps) < 2 || len(req.Steps) > maxPlanSteps {
+		return &PlanResult{
+			Success: false,
+			Message: fmt.Sprintf("A plan needs between 2 and %d steps; carry out simpler requests directly", maxPlanSteps),
+		}, nil
+	}
+
+	steps := make([]PlanStep, len(req.Steps))
+	for i, step := range req.Steps {
+		step.Description = strings.TrimSpace(step.Description)
+		if step.Description == "" {
+			return &PlanResult{
+				Success: false,
+				Message: fmt.Sprintf("Step %d has no description", i+1),
+			}, nil
+		}
+		if slices.Contains(step.Tools, "plan_steps") {
+			return &PlanResult{
+				Success: false,
+				Message: fmt.Sprintf("Step %d plans again; plans can't be nested", i+1),
+			}, nil
+		}
+		steps[i] = step
+	}
+
+	return &PlanResult{
+		Success: true,
+		Message: fmt.Sprintf("Planned %d steps for %q; stop here, each step will be sent to you in turn", len(steps), goal),
+		Goal:    goal,
+		Steps:   steps,
+	}, nil
+}
+
+// PlanStepPrompt is the request that has the model carry out one step of a plan
+func PlanStepPrompt(plan *PlanResult, step int) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Plan step %d of %d for %q: %s\n\n", step+1, len(plan.Steps), plan.Goal, plan.Steps[step].Description))
+	if tools := plan.Steps[step].Tools; len(tools) > 0 {
+		out.WriteString(fmt.Sprintf("Expected tools: %s. ", strings.Join(tools, ", ")))
+	}
+	out.WriteString("Carry out only this step, then briefly report what changed. Do not start the next step or make a new plan.")
+	return out.String()
+}
+
+// createPlanStepsTool creates the tool planning multi-step requests
+func createPlanStepsTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"plan_steps",
+		"Lay out a plan of tool steps before carrying out a request that needs several of them, such as evolving the language 500 years or building a paradigm from scratch. Call it on its own; the user sees the plan as a checklist and each step is sent back to you as its own turn. The user can abort the plan between steps.",
+		PlanSteps,
+	)
+}
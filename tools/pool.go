@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// minItemsPerWorker keeps small batches from starting workers they don't need
+const minItemsPerWorker = 64
+
+// parallelMap runs work on every item on a bounded pool of workers, one per
+// CPU, and returns the results in the items' order. Workers stop taking items
+// once ctx is cancelled, in which case the context's error is returned.
+// Progress is reported through the context's ProgressFunc, so work must only
+// read shared state.
+func parallelMap[T, R any](ctx context.Context, items []T, work func(T) R) ([]R, error) {
+	results := make([]R, len(items))
+	workers := min(runtime.GOMAXPROCS(0), (len(items)+minItemsPerWorker-1)/minItemsPerWorker)
+	if workers <= 1 {
+		for i, item := range items {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			results[i] = work(item)
+			reportProgress(ctx, i+1, len(items))
+		}
+		return results, nil
+	}
+
+	var next, done atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1)) - 1
+				if i >= len(items) {
+					return
+				}
+				results[i] = work(items[i])
+				reportProgress(ctx, int(done.Add(1)), len(items))
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
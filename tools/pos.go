@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+)
+
+// PartsOfSpeechRequest represents a request to define the part-of-speech tag set
+type PartsOfSpeechRequest struct {
+	Tags []storage.PartOfSpeech `json:"tags" jsonschema:"required,description=The complete tag set; replaces any previous one"`
+}
+
+// PartsOfSpeechResult represents the result of part-of-speech tag set operations
+type PartsOfSpeechResult struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Tags    []storage.PartOfSpeech `json:"tags,omitempty"`
+	Table   string                 `json:"table,omitempty"`
+}
+
+// SetPartsOfSpeech stores the language's part-of-speech tag set and reports
+// lexicon entries that don't fit it
+func SetPartsOfSpeech(ctx context.Context, req *PartsOfSpeechRequest) (*PartsOfSpeechResult, error) {
+	seen := map[string]bool{}
+	for _, t := range req.Tags {
+		tag := strings.ToLower(strings.TrimSpace(t.Tag))
+		if tag == "" {
+			return &PartsOfSpeechResult{
+				Success: false,
+				Message: "Every part of speech needs a tag",
+			}, nil
+		}
+		if seen[tag] {
+			return &PartsOfSpeechResult{
+				Success: false,
+				Message: fmt.Sprintf("Part of speech %s is listed more than once", t.Tag),
+			}, nil
+		}
+		seen[tag] = true
+	}
+
+	if err := storage.WritePartsOfSpeech(req.Tags); err != nil {
+		return &PartsOfSpeechResult{
+			Success: false,
+			Message: failure("save parts of speech", err),
+		}, nil
+	}
+
+	message := fmt.Sprintf("Saved %d parts of speech", len(req.Tags))
+	entries, err := loadLexicon()
+	if err == nil {
+		misfits := []string{}
+		for _, entry := range entries {
+			if entry.PartOfSpeech != "" && !seen[strings.ToLower(entry.PartOfSpeech)] {
+				misfits = append(misfits, fmt.Sprintf("%s (%s)", entry.Word, entry.PartOfSpeech))
+			}
+		}
+		if len(misfits) > 0 {
+			message += fmt.Sprintf("; %d lexicon entries use other tags: %s", len(misfits), strings.Join(misfits, ", "))
+		}
+	}
+
+	return &PartsOfSpeechResult{
+		Success: true,
+		Message: message,
+		Tags:    req.Tags,
+		Table:   renderPartsOfSpeech(req.Tags),
+	}, nil
+}
+
+func renderPartsOfSpeech(tags []storage.PartOfSpeech) string {
+	var out strings.Builder
+	out.WriteString("| Tag | Description |\n|---|---|\n")
+	for _, t := range tags {
+		out.WriteString(fmt.Sprintf("| %s | %s |\n", t.Tag, t.Description))
+	}
+	return out.String()
+}
+
+// normalizePartOfSpeech checks a part of speech against the declared tag set
+// and returns it in the declared spelling. Without a tag set any value passes.
+func normalizePartOfSpeech(pos string) (string, error) {
+	pos = strings.TrimSpace(pos)
+	if pos == "" {
+		return "", nil
+	}
+	tags, err := storage.ReadPartsOfSpeech()
+	if err != nil || len(tags) == 0 {
+		return pos, err
+	}
+	names := []string{}
+	for _, t := range tags {
+		if strings.EqualFold(t.Tag, pos) {
+			return t.Tag, nil
+		}
+		names = append(names, t.Tag)
+	}
+	return "", fmt.Errorf("unknown part of speech %q, use one of %s", pos, strings.Join(names, ", "))
+}
+
+// partOfSpeechParam describes the part_of_speech parameter of a tool, offering
+// the declared tag set as an enum
+func partOfSpeechParam(tags []storage.PartOfSpeech) *schema.ParameterInfo {
+	param := &schema.ParameterInfo{Type: schema.String, Desc: "Part of speech:"}
+	for _, t := range tags {
+		param.Enum = append(param.Enum, t.Tag)
+		if t.Description != "" {
+			param.Desc += fmt.Sprintf(" %s (%s);", t.Tag, t.Description)
+		} else {
+			param.Desc += " " + t.Tag + ";"
+		}
+	}
+	param.Desc = strings.TrimSuffix(param.Desc, ";")
+	return param
+}
+
+// createLexiconEntryTool creates a tool taking a lexicon entry. Once a tag set
+// is declared the part of speech is offered as an enum; the schema is built
+// when the tools are created, so a changed tag set shows up after a restart.
+func createLexiconEntryTool[T, D any](name, desc string, fn utils.InvokeFunc[T, D], params map[string]*schema.ParameterInfo) (tool.InvokableTool, error) {
+	tags, err := storage.ReadPartsOfSpeech()
+	if err != nil || len(tags) == 0 {
+		return utils.InferTool(name, desc, fn)
+	}
+	params["part_of_speech"] = partOfSpeechParam(tags)
+	return utils.NewTool(&schema.ToolInfo{
+		Name:        name,
+		Desc:        desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(params),
+	}, fn), nil
+}
+
+// createSetPartsOfSpeechTool creates the tool that defines the tag set
+func createSetPartsOfSpeechTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_parts_of_speech",
+		"Define the language's part-of-speech tag set with a description of each tag. New lexicon entries must then use one of these tags.",
+		SetPartsOfSpeech,
+	)
+}
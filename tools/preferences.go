@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const (
+	// maxPreferences keeps the preferences injected into every prompt small
+	maxPreferences = 30
+	// maxPreferenceRunes is the longest a single preference may be
+	maxPreferenceRunes = 300
+)
+
+// RememberPreferenceRequest represents a preference to remember or forget
+type RememberPreferenceRequest struct {
+	Key   string `json:"key" jsonschema:"required,description=Short name of the preference such as notation, gloss_format or verbosity"`
+	Value string `json:"value" jsonschema:"description=The preference in the user's terms such as 'IPA in slashes, never X-SAMPA'; empty to forget it"`
+}
+
+// GetPreferencesRequest represents a request for the remembered preferences
+type GetPreferencesRequest struct {
+	// Empty struct for consistency with other tools
+}
+
+// PreferencesResult represents the remembered preferences
+type PreferencesResult struct {
+	Success     bool                `json:"success"`
+	Message     string              `json:"message"`
+	Preferences storage.Preferences `json:"preferences,omitempty"`
+}
+
+// RememberPreference stores one of the user's standing preferences, or
+// forgets it when the value is empty
+func RememberPreference(ctx context.Context, req *RememberPreferenceRequest) (*PreferencesResult, error) {
+	key := preferenceKey(req.Key)
+	value := strings.Join(strings.Fields(req.Value), " ")
+	if key == "" {
+		return &PreferencesResult{
+			Success: false,
+			Message: "A preference key is required",
+		}, nil
+	}
+	if len([]rune(value)) > maxPreferenceRunes {
+		return &PreferencesResult{
+			Success: false,
+			Message: fmt.Sprintf("Preferences are at most %d characters; state it more briefly", maxPreferenceRunes),
+		}, nil
+	}
+
+	defer storage.BeginUpdate(storage.PreferencesFile)()
+	preferences, err := storage.ReadPreferences()
+	if err != nil {
+		return &PreferencesResult{
+			Success: false,
+			Message: failure("read preferences", err),
+		}, nil
+	}
+	message := fmt.Sprintf("Remembered %s: %s", key, value)
+	if value == "" {
+		if _, ok := preferences[key]; !ok {
+			return &PreferencesResult{
+				Success: false,
+				Message: "No preference named " + key,
+			}, nil
+		}
+		delete(preferences, key)
+		message = "Forgot " + key
+	} else {
+		if _, ok := preferences[key]; !ok && len(preferences) >= maxPreferences {
+			return &PreferencesResult{
+				Success: false,
+				Message: fmt.Sprintf("Already remembering %d preferences; forget or merge some first", maxPreferences),
+			}, nil
+		}
+		preferences[key] = value
+	}
+	if err := storage.WritePreferences(preferences); err != nil {
+		return &PreferencesResult{
+			Success: false,
+			Message: failure("save preferences", err),
+		}, nil
+	}
+	return &PreferencesResult{
+		Success:     true,
+		Message:     message,
+		Preferences: preferences,
+	}, nil
+}
+
+// GetPreferences returns the remembered preferences
+func GetPreferences(ctx context.Context, req *GetPreferencesRequest) (*PreferencesResult, error) {
+	preferences, err := storage.ReadPreferences()
+	if err != nil {
+		return &PreferencesResult{
+			Success: false,
+			Message: failure("read preferences", err),
+		}, nil
+	}
+	return &PreferencesResult{
+		Success:     true,
+		Message:     fmt.Sprintf("%d remembered preferences", len(preferences)),
+		Preferences: preferences,
+	}, nil
+}
+
+// preferenceKey normalizes a preference name to lowercase words joined by underscores
+func preferenceKey(key string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(key), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "_")
+}
+
+// FormatPreferences lists preferences as markdown bullets in key order
+func FormatPreferences(preferences storage.Preferences) string {
+	keys := make([]string, 0, len(preferences))
+	for key := range preferences {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	var out strings.Builder
+	for _, key := range keys {
+		out.WriteString(fmt.Sprintf("- %s: %s\n", key, preferences[key]))
+	}
+	return out.String()
+}
+
+// PreferencesPrompt is the system message carrying the remembered
+// preferences into every request, or empty when there are none
+func PreferencesPrompt() string {
+	preferences, err := storage.ReadPreferences()
+	if err != nil || len(preferences) == 0 {
+		return ""
+	}
+	return "USER PREFERENCES (remembered across sessions; follow them unless the user says otherwise, and update them with remember_preference when they change):\n" + FormatPreferences(preferences)
+}
+
+// createRememberPreferenceTool creates the tool that remembers user preferences
+func createRememberPreferenceTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"remember_preference",
+		"Remember one of the user's standing preferences across sessions, such as their notation style, preferred gloss format or how verbose answers should be. Remembered preferences are included in every request. Give an empty value to forget one.",
+		RememberPreference,
+	)
+}
+
+// createGetPreferencesTool creates the tool that lists remembered preferences
+func createGetPreferencesTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"get_preferences",
+		"List the user's remembered preferences.",
+		GetPreferences,
+	)
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"strings"
+
+	"l2/storage"
+)
+
+// modelPrices lists OpenRouter's prices of known models in dollars per
+// million tokens, by name prefix as in the capabilities registry. Prices
+// change; model_prices in config.json overrides them.
+var modelPrices = map[string]storage.ModelPrice{
+	"google/gemini-2.5-flash":      {Prompt: 0.30, Completion: 2.50},
+	"google/gemini-2.5-flash-lite": {Prompt: 0.10, Completion: 0.40},
+	"google/gemini-2.5-pro":        {Prompt: 1.25, Completion: 10},
+	"google/gemini-2.0-flash":      {Prompt: 0.10, Completion: 0.40},
+	"openai/gpt-4o":                {Prompt: 2.50, Completion: 10},
+	"openai/gpt-4o-mini":           {Prompt: 0.15, Completion: 0.60},
+	"openai/gpt-4.1":               {Prompt: 2, Completion: 8},
+	"openai/gpt-4.1-mini":          {Prompt: 0.40, Completion: 1.60},
+	"openai/gpt-4.1-nano":          {Prompt: 0.10, Completion: 0.40},
+	"anthropic/claude-3.5-haiku":   {Prompt: 0.80, Completion: 4},
+	"anthropic/claude-3.5-sonnet":  {Prompt: 3, Completion: 15},
+	"anthropic/claude-3.7-sonnet":  {Prompt: 3, Completion: 15},
+	"anthropic/claude-sonnet-4":    {Prompt: 3, Completion: 15},
+	"anthropic/claude-opus-4":      {Prompt: 15, Completion: 75},
+}
+
+// PriceOf returns a model's price: as declared under model_prices in
+// config.json, from the built-in list, or else token_price for every token.
+// Free variants cost nothing. ok is false when no price is known.
+func PriceOf(model string, settings storage.Config) (price storage.ModelPrice, ok bool) {
+	if price, ok := settings.ModelPrices[model]; ok {
+		return price, true
+	}
+	if strings.HasSuffix(model, ":free") {
+		return storage.ModelPrice{}, true
+	}
+	if price, ok := lookupModel(modelPrices, model); ok {
+		return price, true
+	}
+	if settings.TokenPrice > 0 {
+		return storage.ModelPrice{Prompt: settings.TokenPrice, Completion: settings.TokenPrice}, true
+	}
+	return storage.ModelPrice{}, false
+}
+
+// CostOf returns what a number of tokens cost at a price
+func CostOf(price storage.ModelPrice, promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6
+}
@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// caseLabels are the Leipzig glosses counted as grammatical cases
+var caseLabels = map[string]bool{
+	"NOM": true, "ACC": true, "ERG": true, "ABS": true, "DAT": true, "GEN": true,
+	"LOC": true, "ABL": true, "ALL": true, "INS": true, "COM": true, "VOC": true,
+	"ESS": true, "PRT": true, "OBL": true, "BEN": true, "TRANSL": true, "PERL": true,
+}
+
+// ComplexityReportRequest represents a request to measure the grammar against its budget
+type ComplexityReportRequest struct {
+	// Empty struct for consistency with other tools
+}
+
+// ProfileResult represents the result of language profile operations
+type ProfileResult struct {
+	Success  bool            `json:"success"`
+	Message  string          `json:"message"`
+	Profile  storage.Profile `json:"profile"`
+	Report   string          `json:"report,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// complexity is how complex the grammar currently is
+type complexity struct {
+	cases        []string
+	fusion       float64 // Average categories per inflectional affix
+	irregularity float64 // Percentage of lexicon words with suppletive forms
+}
+
+// SetLanguageProfile stores the complexity budget of the language
+func SetLanguageProfile(ctx context.Context, req *storage.Profile) (*ProfileResult, error) {
+	if req.MaxCases < 0 || req.MaxFusion < 0 || req.MaxIrregularity < 0 || req.MaxIrregularity > 100 {
+		return &ProfileResult{
+			Success: false,
+			Message: "Limits can't be negative and irregularity is a percentage from 0 to 100",
+		}, nil
+	}
+	if err := storage.WriteProfile(*req); err != nil {
+		return &ProfileResult{
+			Success: false,
+			Message: failure("save language profile", err),
+		}, nil
+	}
+
+	result, _ := ComplexityReport(ctx, &ComplexityReportRequest{})
+	if !result.Success {
+		return result, nil
+	}
+	result.Message = "Saved complexity budget"
+	return result, nil
+}
+
+// ComplexityReport measures the grammar against the complexity budget
+func ComplexityReport(ctx context.Context, req *ComplexityReportRequest) (*ProfileResult, error) {
+	profile, err := storage.ReadProfile()
+	if err != nil {
+		return &ProfileResult{
+			Success: false,
+			Message: failure("read language profile", err),
+		}, nil
+	}
+	current, err := measureComplexity()
+	if err != nil {
+		return &ProfileResult{
+			Success: false,
+			Message: failure("measure complexity", err),
+		}, nil
+	}
+
+	limit := func(v float64, format string) string {
+		if v == 0 {
+			return "none"
+		}
+		return fmt.Sprintf(format, v)
+	}
+	var report strings.Builder
+	report.WriteString("| Measure | Current | Budget |\n|---|---|---|\n")
+	report.WriteString(fmt.Sprintf("| Cases | %d (%s) | %s |\n", len(current.cases), strings.Join(current.cases, ", "), limit(float64(profile.MaxCases), "%.0f")))
+	report.WriteString(fmt.Sprintf("| Fusion index | %.2f | %s |\n", current.fusion, limit(profile.MaxFusion, "%.2f")))
+	report.WriteString(fmt.Sprintf("| Irregularity | %.1f%% | %s |\n", current.irregularity, limit(profile.MaxIrregularity, "%.1f%%")))
+
+	warnings := current.overBudget(profile)
+	message := "The grammar is within its complexity budget"
+	if len(warnings) > 0 {
+		message = fmt.Sprintf("The grammar exceeds %d budget limits", len(warnings))
+	}
+	return &ProfileResult{
+		Success:  true,
+		Message:  message,
+		Profile:  profile,
+		Report:   report.String(),
+		Warnings: warnings,
+	}, nil
+}
+
+// measureComplexity counts the cases, fusion and irregularity of the stored grammar
+func measureComplexity() (complexity, error) {
+	var c complexity
+	syntax, err := storage.ReadSyntax()
+	if err != nil {
+		return c, err
+	}
+	affixes, err := storage.ReadAffixes()
+	if err != nil {
+		return c, err
+	}
+	suppletion, err := storage.ReadSuppletion()
+	if err != nil {
+		return c, err
+	}
+	entries, err := loadLexicon()
+	if err != nil {
+		return c, err
+	}
+
+	cases := map[string]bool{}
+	for _, label := range []string{syntax.AgentCase, syntax.SubjectCase, syntax.PatientCase, syntax.RecipientCase} {
+		if label != "" {
+			cases[strings.ToUpper(label)] = true
+		}
+	}
+	categories, inflectional := 0, 0
+	for _, affix := range affixes {
+		if affix.Derivational || affix.Kind == storage.ParticleKind {
+			continue
+		}
+		labels := strings.Split(affix.Gloss, ".")
+		for _, label := range labels {
+			if caseLabels[strings.ToUpper(label)] {
+				cases[strings.ToUpper(label)] = true
+			}
+		}
+		categories += len(labels)
+		inflectional++
+	}
+	for label := range cases {
+		c.cases = append(c.cases, label)
+	}
+	sort.Strings(c.cases)
+	if inflectional > 0 {
+		c.fusion = float64(categories) / float64(inflectional)
+	}
+
+	if len(entries) > 0 {
+		irregular := map[string]bool{}
+		for _, f := range suppletion {
+			irregular[strings.ToLower(f.Lexeme)] = true
+		}
+		c.irregularity = float64(len(irregular)) / float64(len(entries)) * 100
+	}
+	return c, nil
+}
+
+// overBudget describes each limit of the profile the grammar exceeds
+func (c complexity) overBudget(profile storage.Profile) []string {
+	var warnings []string
+	if profile.MaxCases > 0 && len(c.cases) > profile.MaxCases {
+		warnings = append(warnings, fmt.Sprintf("%d cases (%s) exceed the budget of %d", len(c.cases), strings.Join(c.cases, ", "), profile.MaxCases))
+	}
+	if profile.MaxFusion > 0 && c.fusion > profile.MaxFusion {
+		warnings = append(warnings, fmt.Sprintf("Fusion index %.2f exceeds the budget of %.2f", c.fusion, profile.MaxFusion))
+	}
+	if profile.MaxIrregularity > 0 && c.irregularity > profile.MaxIrregularity {
+		warnings = append(warnings, fmt.Sprintf("%.1f%% of words are irregular, over the budget of %.1f%%", c.irregularity, profile.MaxIrregularity))
+	}
+	return warnings
+}
+
+// budgetWarnings reports the complexity budget limits the grammar exceeds, for
+// design tools to return after a change. Problems reading the grammar are
+// left to the tool that made the change.
+func budgetWarnings() []string {
+	profile, err := storage.ReadProfile()
+	if err != nil || profile == (storage.Profile{}) {
+		return nil
+	}
+	current, err := measureComplexity()
+	if err != nil {
+		return nil
+	}
+	return current.overBudget(profile)
+}
+
+// createSetLanguageProfileTool creates the tool that stores the complexity budget
+func createSetLanguageProfileTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_language_profile",
+		"Set the language's complexity budget: the most cases, the highest fusion index (categories per inflectional affix) and the highest percentage of irregular words. Design tools warn when a change takes the grammar over budget.",
+		SetLanguageProfile,
+	)
+}
+
+// createComplexityReportTool creates the complexity report tool
+func createComplexityReportTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"complexity_report",
+		"Measure the grammar's case count, fusion index and irregularity against the complexity budget in the language profile.",
+		ComplexityReport,
+	)
+}
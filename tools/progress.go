@@ -0,0 +1,23 @@
+package tools
+
+import "context"
+
+// progressKey is the context key a ProgressFunc is stored under
+type progressKey struct{}
+
+// ProgressFunc receives how many of total items a long-running operation has processed
+type ProgressFunc func(done, total int)
+
+// WithProgress returns a context that long-running operations report their
+// progress through
+func WithProgress(ctx context.Context, progress ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// reportProgress tells the context's ProgressFunc, if any, how far an
+// operation has got
+func reportProgress(ctx context.Context, done, total int) {
+	if progress, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		progress(done, total)
+	}
+}
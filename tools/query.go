@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+)
+
+// MatchesQuery reports whether a lexicon entry satisfies every set field of the query
+func MatchesQuery(entry LexiconEntry, q storage.LexiconQuery) bool {
+	word := strings.ToLower(entry.Word)
+	if q.Prefix != "" && !strings.HasPrefix(word, strings.ToLower(q.Prefix)) {
+		return false
+	}
+	if q.Contains != "" && !strings.Contains(word, strings.ToLower(q.Contains)) {
+		return false
+	}
+	if q.PartOfSpeech != "" && !strings.EqualFold(entry.PartOfSpeech, q.PartOfSpeech) {
+		return false
+	}
+	if q.Keyword != "" && !strings.Contains(strings.ToLower(entry.Definition), strings.ToLower(q.Keyword)) {
+		return false
+	}
+	if q.Tag != "" {
+		tagged := false
+		for _, tag := range entry.Tags {
+			if strings.EqualFold(tag, q.Tag) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+	if q.MissingEtymology && EtymologyText(entry) != "" {
+		return false
+	}
+	return true
+}
+
+// FilterLexicon returns the lexicon entries matching the query
+func FilterLexicon(q storage.LexiconQuery) ([]LexiconEntry, error) {
+	pos, err := normalizePartOfSpeech(q.PartOfSpeech)
+	if err != nil {
+		return nil, err
+	}
+	q.PartOfSpeech = pos
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return nil, err
+	}
+	matches := []LexiconEntry{}
+	for _, entry := range entries {
+		if MatchesQuery(entry, q) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches, nil
+}
+
+// ParseLexiconQuery builds a query from key=value arguments such as
+// "pos=verb no-etymology tag=body"
+func ParseLexiconQuery(args []string) (storage.LexiconQuery, error) {
+	q := storage.LexiconQuery{}
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		switch key {
+		case "prefix":
+			q.Prefix = value
+		case "contains":
+			q.Contains = value
+		case "pos":
+			q.PartOfSpeech = value
+		case "keyword":
+			q.Keyword = value
+		case "tag":
+			q.Tag = value
+		case "no-etymology":
+			q.MissingEtymology = value == "" || value == "true"
+		default:
+			return q, fmt.Errorf("unknown filter %q (expected prefix, contains, pos, keyword, tag or no-etymology)", key)
+		}
+	}
+	return q, nil
+}
+
+// DescribeQuery renders a query back into the key=value form accepted by ParseLexiconQuery
+func DescribeQuery(q storage.LexiconQuery) string {
+	parts := []string{}
+	if q.Prefix != "" {
+		parts = append(parts, "prefix="+q.Prefix)
+	}
+	if q.Contains != "" {
+		parts = append(parts, "contains="+q.Contains)
+	}
+	if q.PartOfSpeech != "" {
+		parts = append(parts, "pos="+q.PartOfSpeech)
+	}
+	if q.Keyword != "" {
+		parts = append(parts, "keyword="+q.Keyword)
+	}
+	if q.Tag != "" {
+		parts = append(parts, "tag="+q.Tag)
+	}
+	if q.MissingEtymology {
+		parts = append(parts, "no-etymology")
+	}
+	if len(parts) == 0 {
+		return "(all entries)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// SaveQuery stores a named query in the config
+func SaveQuery(name string, q storage.LexiconQuery) error {
+	return storage.UpdateConfig(func(config *storage.Config) error {
+		config.SavedQueries[name] = q
+		return nil
+	})
+}
+
+// DeleteQuery removes a named query from the config
+func DeleteQuery(name string) error {
+	return storage.UpdateConfig(func(config *storage.Config) error {
+		if _, ok := config.SavedQueries[name]; !ok {
+			return fmt.Errorf("no saved query named %q", name)
+		}
+		delete(config.SavedQueries, name)
+		return nil
+	})
+}
+
+// SavedQueries returns all saved queries along with their names in sorted order
+func SavedQueries() ([]string, map[string]storage.LexiconQuery, error) {
+	config, err := storage.ReadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(config.SavedQueries))
+	for name := range config.SavedQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, config.SavedQueries, nil
+}
+
+// RunSavedQuery looks up a saved query by name and returns its matches
+func RunSavedQuery(name string) ([]LexiconEntry, error) {
+	config, err := storage.ReadConfig()
+	if err != nil {
+		return nil, err
+	}
+	q, ok := config.SavedQueries[name]
+	if !ok {
+		return nil, fmt.Errorf("no saved query named %q", name)
+	}
+	return FilterLexicon(q)
+}
+
+// QueryLexicon runs either a saved query (a single bare name) or an ad hoc
+// query given as key=value filters
+func QueryLexicon(args []string) ([]LexiconEntry, error) {
+	if len(args) == 1 && !strings.Contains(args[0], "=") && args[0] != "no-etymology" {
+		return RunSavedQuery(args[0])
+	}
+	q, err := ParseLexiconQuery(args)
+	if err != nil {
+		return nil, err
+	}
+	return FilterLexicon(q)
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"l2/storage"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// UnsavedEntry is a word the conversation defined that never reached the
+// lexicon, with where it was found
+type UnsavedEntry struct {
+	Entry  LexiconEntry
+	Source string
+}
+
+// definitionLine matches a definition given in a response, such as
+// "- **kela** /ˈke.la/ (noun): water"
+var definitionLine = regexp.MustCompile(`^\s*(?:[-*+]|\d+\.)?\s*\*\*([^*\s]+)\*\*\s*(?:/([^/]+)/|\[([^\]]+)\])?\s*(?:\(([^)]+)\))?\s*[:—–-]\s*(.+)$`)
+
+// ReconcileConversation finds words the conversation defined that are not in
+// the lexicon: add_lexicon_entry calls whose word is missing, and
+// definitions given in responses that were never saved. Words waiting for
+// review or moved to the trash were dealt with already and are skipped.
+func ReconcileConversation(history []*schema.Message) ([]UnsavedEntry, error) {
+	entries, err := loadLexicon()
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, entry := range entries {
+		known[strings.ToLower(entry.Word)] = true
+	}
+	proposals, err := storage.ReadReview()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range proposals {
+		known[strings.ToLower(p.Word)] = true
+	}
+	trash, err := storage.ReadTrash()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range trash {
+		if item.Kind == storage.TrashKindLexicon {
+			known[strings.ToLower(item.Name)] = true
+		}
+	}
+
+	var unsaved []UnsavedEntry
+	add := func(entry LexiconEntry, source string) {
+		word := strings.ToLower(entry.Word)
+		if word == "" || known[word] {
+			return
+		}
+		known[word] = true
+		unsaved = append(unsaved, UnsavedEntry{Entry: entry, Source: source})
+	}
+
+	// Tool calls come first: their arguments are complete entries
+	for _, msg := range history {
+		if msg.Role != schema.Tool || msg.Name != "add_lexicon_entry" {
+			continue
+		}
+		args := toolArguments(msg)
+		var entry LexiconEntry
+		if json.Unmarshal([]byte(args), &entry) != nil {
+			continue
+		}
+		source := "add_lexicon_entry call"
+		var result Result
+		if json.Unmarshal([]byte(msg.Content), &result) == nil && !result.Success {
+			source = "failed add_lexicon_entry call: " + result.Message
+		}
+		add(entry, source)
+	}
+
+	responses := 0
+	for _, msg := range history {
+		if msg.Role != schema.Assistant {
+			continue
+		}
+		responses++
+		for _, line := range strings.Split(msg.Content, "\n") {
+			match := definitionLine.FindStringSubmatch(line)
+			if match == nil || len(TokenizeWords(match[1])) != 1 {
+				continue
+			}
+			pos, err := normalizePartOfSpeech(match[4])
+			if err != nil {
+				pos = ""
+			}
+			definition := strings.Trim(strings.TrimSpace(match[5]), `"“”*_`)
+			if definition == "" {
+				continue
+			}
+			entry := LexiconEntry{Word: match[1], Definition: definition, PartOfSpeech: pos, IPA: match[2] + match[3]}
+			add(entry, fmt.Sprintf("defined in response %d", responses))
+		}
+	}
+	return unsaved, nil
+}
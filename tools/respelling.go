@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// respellings maps IPA segments to spellings an English reader will
+// pronounce roughly right. Segments not listed are written as they are.
+var respellings = map[string][]string{
+	// Vowels and diphthongs
+	"a": {"ah"}, "ɑ": {"ah"}, "ɐ": {"uh"}, "æ": {"a"}, "e": {"eh"}, "ɛ": {"eh"}, "ə": {"uh"}, "ɜ": {"ur"},
+	"i": {"ee"}, "ɪ": {"ih"}, "ɨ": {"ih"}, "o": {"oh"}, "ɔ": {"aw"}, "ɒ": {"o"}, "u": {"oo"}, "ʊ": {"uu"},
+	"ʉ": {"oo"}, "ɯ": {"oo"}, "ʌ": {"uh"}, "y": {"ew"}, "ʏ": {"ew"}, "ø": {"ur"}, "œ": {"ur"}, "ɤ": {"uh"},
+	"ai": {"eye"}, "aj": {"eye"}, "au": {"ow"}, "aw": {"ow"}, "ei": {"ay"}, "ej": {"ay"}, "oi": {"oy"}, "oj": {"oy"}, "ou": {"oh"},
+	// Consonants
+	"ʃ": {"sh"}, "ʒ": {"zh"}, "tʃ": {"ch"}, "dʒ": {"j"}, "ts": {"ts"}, "dz": {"dz"}, "θ": {"th"}, "ð": {"dh"},
+	"ŋ": {"ng"}, "ɲ": {"ny"}, "ʎ": {"ly"}, "j": {"y"}, "x": {"kh"}, "χ": {"kh"}, "ɣ": {"gh"}, "ʁ": {"r"},
+	"ɾ": {"r"}, "r": {"rr"}, "ɹ": {"r"}, "ɻ": {"r"}, "ʔ": {"'"}, "ħ": {"h"}, "ɦ": {"h"}, "ɸ": {"f"}, "β": {"v"},
+	"c": {"ky"}, "ɟ": {"gy"}, "q": {"k"}, "ɡ": {"g"}, "ɬ": {"hl"}, "ʰ": {"h"}, "ʲ": {"y"}, "ʷ": {"w"}, "ɕ": {"sh"}, "ʑ": {"zh"},
+	// Marks the respelling can't show
+	"ː": {""}, "͡": {""}, "͜": {""},
+}
+
+// RespellRequest represents a request for a pronunciation respelling
+type RespellRequest struct {
+	IPA  string `json:"ipa" jsonschema:"description=IPA transcription to respell; mark stress with ˈ and syllables with ."`
+	Word string `json:"word" jsonschema:"description=Lexicon word to respell from its IPA, or from its romanization when it has none"`
+}
+
+// RespellResult represents a pronunciation respelling
+type RespellResult struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	IPA        string `json:"ipa,omitempty"`
+	Respelling string `json:"respelling,omitempty"`
+}
+
+// Respell produces an English-friendly respelling such as KAH-loo-teh
+func Respell(ctx context.Context, req *RespellRequest) (*RespellResult, error) {
+	ipa := req.IPA
+	if ipa == "" && req.Word != "" {
+		entries, err := loadLexicon()
+		if err != nil {
+			return &RespellResult{
+				Success: false,
+				Message: failure("read lexicon", err),
+			}, nil
+		}
+		entry, ok := findEntry(entries, req.Word)
+		if !ok {
+			return &RespellResult{
+				Success: false,
+				Message: fmt.Sprintf("%s is not in the lexicon", req.Word),
+			}, nil
+		}
+		ipa = entry.IPA
+		if ipa == "" {
+			o, err := loadOrthography()
+			if err != nil {
+				return &RespellResult{
+					Success: false,
+					Message: failure("load orthography", err),
+				}, nil
+			}
+			ipa, _, _ = convert(strings.ToLower(entry.Word), o.readings)
+		}
+	}
+	if strings.TrimSpace(ipa) == "" {
+		return &RespellResult{
+			Success: false,
+			Message: "IPA or a lexicon word is required",
+		}, nil
+	}
+
+	return &RespellResult{
+		Success:    true,
+		Message:    "Respelled for English readers; stressed syllables are in capitals",
+		IPA:        ipa,
+		Respelling: respellIPA(ipa),
+	}, nil
+}
+
+// respellIPA respells a transcription syllable by syllable with the stressed
+// syllable in capitals. Syllables and stress are taken from . and ˈ marks
+// where the transcription has them; unmarked words are stressed on the first
+// syllable.
+func respellIPA(ipa string) string {
+	ipa = strings.NewReplacer("/", "", "[", "", "]", "").Replace(ipa)
+	words := []string{}
+	for _, word := range strings.Fields(strings.ToLower(ipa)) {
+		// Marked syllables are syllabified further in case marks are missing
+		syllables, stressed := []string{}, -1
+		chunk, stress := "", false
+		flush := func() {
+			if chunk != "" {
+				if stress {
+					stressed = len(syllables)
+				}
+				syllables = append(syllables, syllabify(chunk)...)
+			}
+			chunk, stress = "", false
+		}
+		for _, r := range word {
+			switch r {
+			case '.', 'ˈ', 'ˌ':
+				flush()
+				stress = r == 'ˈ'
+			default:
+				chunk += string(r)
+			}
+		}
+		flush()
+		if !strings.ContainsRune(word, 'ˈ') && len(syllables) > 1 {
+			stressed = 0
+		}
+
+		parts := make([]string, len(syllables))
+		for i, syllable := range syllables {
+			parts[i], _, _ = convert(syllable, respellings)
+			if i == stressed {
+				parts[i] = strings.ToUpper(parts[i])
+			}
+		}
+		words = append(words, strings.Join(parts, "-"))
+	}
+	return strings.Join(words, " ")
+}
+
+// createRespellTool creates the pronunciation respelling tool
+func createRespellTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"respell",
+		"Produce an approximate English-friendly pronunciation respelling such as KAH-loo-teh from IPA or a lexicon word, with the stressed syllable in capitals, for readers unfamiliar with IPA. Lexicon exports can include it as the respelling column.",
+		Respell,
+	)
+}
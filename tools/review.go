@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ProposalRequest represents a word proposed for the review queue
+type ProposalRequest struct {
+	Word         string   `json:"word" jsonschema:"required,description=The proposed word"`
+	Definition   string   `json:"definition" jsonschema:"required,description=The definition of the word"`
+	PartOfSpeech string   `json:"part_of_speech" jsonschema:"description=Part of speech"`
+	Etymology    string   `json:"etymology" jsonschema:"description=Free-text notes on the word's history"`
+	DerivedFrom  []string `json:"derived_from,omitempty" jsonschema:"description=Lexicon words this word was derived or compounded from"`
+	ProtoForm    string   `json:"proto_form,omitempty" jsonschema:"description=Reconstructed ancestral form such as *kana"`
+	BorrowedFrom string   `json:"borrowed_from,omitempty" jsonschema:"description=Language and form the word was borrowed from such as Vathi tesu"`
+	Pack         string   `json:"pack" jsonschema:"description=Concept pack the word was proposed for during a vocabulary sprint"`
+	Concept      string   `json:"concept" jsonschema:"description=The concept from the pack the word expresses, exactly as given in the sprint"`
+}
+
+// ProposalResult represents the result of queueing a proposal
+type ProposalResult struct {
+	Success  bool              `json:"success"`
+	Message  string            `json:"message"`
+	Proposal *storage.Proposal `json:"proposal,omitempty"`
+}
+
+// ProposeLexiconEntry stages a word in the review queue instead of adding it
+// to the lexicon directly
+func ProposeLexiconEntry(ctx context.Context, req *ProposalRequest) (*ProposalResult, error) {
+	if req.Word == "" || req.Definition == "" {
+		return &ProposalResult{
+			Success: false,
+			Message: "Word and definition are required",
+		}, nil
+	}
+
+	pos, err := normalizePartOfSpeech(req.PartOfSpeech)
+	if err != nil {
+		return &ProposalResult{
+			Success: false,
+			Message: "Invalid part of speech: " + err.Error(),
+		}, nil
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return &ProposalResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Word, req.Word) {
+			return &ProposalResult{
+				Success: false,
+				Message: fmt.Sprintf("%s is already in the lexicon meaning %q", entry.Word, entry.Definition),
+			}, nil
+		}
+	}
+	for _, source := range req.DerivedFrom {
+		if _, ok := findEntry(entries, source); !ok {
+			return &ProposalResult{
+				Success: false,
+				Message: fmt.Sprintf("%s derives from %s, which is not in the lexicon", req.Word, source),
+			}, nil
+		}
+	}
+	proposals, err := storage.ReadReview()
+	if err != nil {
+		return &ProposalResult{
+			Success: false,
+			Message: failure("read review queue", err),
+		}, nil
+	}
+	for _, p := range proposals {
+		if strings.EqualFold(p.Word, req.Word) {
+			return &ProposalResult{
+				Success: false,
+				Message: fmt.Sprintf("%s is already waiting for review meaning %q", p.Word, p.Definition),
+			}, nil
+		}
+	}
+
+	proposal, err := storage.QueueProposal(storage.Proposal{
+		Word:         req.Word,
+		Definition:   req.Definition,
+		PartOfSpeech: pos,
+		Etymology:    req.Etymology,
+		DerivedFrom:  req.DerivedFrom,
+		ProtoForm:    req.ProtoForm,
+		BorrowedFrom: req.BorrowedFrom,
+		Pack:         req.Pack,
+		Concept:      req.Concept,
+	})
+	if err != nil {
+		return &ProposalResult{
+			Success: false,
+			Message: failure("queue proposal", err),
+		}, nil
+	}
+
+	return &ProposalResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Queued %s for review as #%d", proposal.Word, proposal.ID),
+		Proposal: &proposal,
+	}, nil
+}
+
+// AcceptProposal moves a proposal from the review queue into the lexicon.
+// Words proposed for a concept pack are tagged with the pack name.
+func AcceptProposal(id string) (LexiconEntry, error) {
+	proposals, err := storage.ReadReview()
+	if err != nil {
+		return LexiconEntry{}, err
+	}
+	var proposal *storage.Proposal
+	for i := range proposals {
+		if fmt.Sprint(proposals[i].ID) == id {
+			proposal = &proposals[i]
+			break
+		}
+	}
+	if proposal == nil {
+		return LexiconEntry{}, fmt.Errorf("no proposal with id %s", id)
+	}
+
+	entry := LexiconEntry{
+		Word:         proposal.Word,
+		Definition:   proposal.Definition,
+		PartOfSpeech: proposal.PartOfSpeech,
+		Etymology:    proposal.Etymology,
+		DerivedFrom:  proposal.DerivedFrom,
+		ProtoForm:    proposal.ProtoForm,
+		BorrowedFrom: proposal.BorrowedFrom,
+	}
+	if proposal.Pack != "" {
+		entry.Tags = []string{proposal.Pack}
+	}
+	result, _ := AddLexiconEntry(context.Background(), &entry)
+	if !result.Success {
+		return LexiconEntry{}, fmt.Errorf("%s: %s", proposal.Word, result.Message)
+	}
+
+	_, err = storage.TakeProposal(id)
+	return entry, err
+}
+
+// createProposeLexiconTool creates the tool that stages words for review
+func createProposeLexiconTool() (tool.InvokableTool, error) {
+	return createLexiconEntryTool(
+		"propose_lexicon_entry",
+		"Stage a proposed word in the review queue instead of adding it to the lexicon directly. The user accepts or rejects queued words with /review. Use this during vocabulary sprints, passing the pack and concept.",
+		ProposeLexiconEntry,
+		map[string]*schema.ParameterInfo{
+			"word":       {Type: schema.String, Desc: "The proposed word", Required: true},
+			"definition": {Type: schema.String, Desc: "The definition of the word", Required: true},
+			"etymology":  {Type: schema.String, Desc: "Free-text notes on the word's history"},
+			"derived_from": {
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Desc:     "Lexicon words this word was derived or compounded from",
+			},
+			"proto_form":    {Type: schema.String, Desc: "Reconstructed ancestral form such as *kana"},
+			"borrowed_from": {Type: schema.String, Desc: "Language and form the word was borrowed from such as Vathi tesu"},
+			"pack":          {Type: schema.String, Desc: "Concept pack the word was proposed for during a vocabulary sprint"},
+			"concept":       {Type: schema.String, Desc: "The concept from the pack the word expresses, exactly as given in the sprint"},
+		},
+	)
+}
@@ -0,0 +1,24 @@
+package tools
+
+import "context"
+
+// Sampling overrides how one request is answered, as for /retry with
+// another model or temperature
+type Sampling struct {
+	Model       string   // OpenRouter model name; empty keeps the configured one
+	Temperature *float32 // Nil keeps the default
+}
+
+// samplingKey is the context key the sampling override is stored under
+type samplingKey struct{}
+
+// WithSampling returns a context whose requests are answered with the override
+func WithSampling(ctx context.Context, sampling Sampling) context.Context {
+	return context.WithValue(ctx, samplingKey{}, sampling)
+}
+
+// SamplingFrom returns the context's sampling override, empty if none is set
+func SamplingFrom(ctx context.Context) Sampling {
+	sampling, _ := ctx.Value(samplingKey{}).(Sampling)
+	return sampling
+}
@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"l2/storage"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ImageTypes are the image formats a vision model is sent, by file extension
+var ImageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// maxScanPages is how many pages of a PDF are sent to the model at once
+const maxScanPages = 10
+
+// paradigmsDir is the data directory imported paradigms are saved to
+const paradigmsDir = "paradigms"
+
+// ParadigmScanPrompt asks a vision model to transcribe the paradigm tables
+// in scanned pages as JSON that ParseParadigmScan reads
+const ParadigmScanPrompt = `The images are scans or photos of inflection paradigm tables from a conlang notebook.
+Transcribe every paradigm table you find. Reply with only a JSON array, one object per table:
+
+[{"lexeme": "citation form or stem the table inflects",
+  "part_of_speech": "noun, verb or other, if the page says",
+  "dimensions": [{"name": "number", "values": [{"label": "SG"}, {"label": "PL"}]}],
+  "forms": [{"labels": ["SG"], "form": "written form"}],
+  "notes": "cells you couldn't read or aren't sure of"}]
+
+List the row categories first and the column category last. Give each form the labels of its row and column, one per
+dimension in the same order. Copy forms exactly as written, including IPA and diacritics; write [?] for an illegible cell
+and mention it in notes. Don't invent forms for empty cells.`
+
+// ImportedParadigm is a paradigm table transcribed from a scan, waiting for
+// review before it is saved
+type ImportedParadigm struct {
+	Lexeme       string              `json:"lexeme"`
+	PartOfSpeech string              `json:"part_of_speech,omitempty"`
+	Dimensions   []ParadigmDimension `json:"dimensions"`
+	Forms        []ParadigmForm      `json:"forms"`
+	Notes        string              `json:"notes,omitempty"` // Cells the model couldn't read or wasn't sure of
+}
+
+// ScanImages loads an image, or renders the pages of a PDF, as message
+// parts for a vision model
+func ScanImages(ctx context.Context, path string) ([]schema.ChatMessagePart, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".pdf" {
+		return pdfPageImages(ctx, path)
+	}
+	mimeType, ok := ImageTypes[ext]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a PDF or a PNG, JPEG, GIF or WebP image", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return []schema.ChatMessagePart{imagePart(mimeType, data)}, nil
+}
+
+// imagePart wraps image data as a message part
+func imagePart(mimeType string, data []byte) schema.ChatMessagePart {
+	return schema.ChatMessagePart{
+		Type:     schema.ChatMessagePartTypeImageURL,
+		ImageURL: &schema.ChatMessageImageURL{URL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)},
+	}
+}
+
+// pdfPageImages renders the first pages of a PDF as PNG images with
+// pdftoppm from poppler
+func pdfPageImages(ctx context.Context, source string) ([]schema.ChatMessagePart, error) {
+	if _, err := os.Stat(source); err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		return nil, fmt.Errorf("reading PDF pages needs pdftoppm; install poppler (poppler-utils on Linux)")
+	}
+	dir, err := os.MkdirTemp("", "l2-scan-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	last := fmt.Sprint(maxScanPages)
+	if out, err := exec.CommandContext(ctx, "pdftoppm", "-png", "-r", "150", "-l", last, source, filepath.Join(dir, "page")).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	pages, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(pages) // pdftoppm pads page numbers, so names sort in page order
+	parts := []schema.ChatMessagePart{}
+	for _, page := range pages {
+		data, err := os.ReadFile(page)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, imagePart("image/png", data))
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%s has no pages", source)
+	}
+	return parts, nil
+}
+
+// ParseParadigmScan reads the model's transcription of scanned paradigm
+// tables, putting each table's forms in the order of its cells
+func ParseParadigmScan(reply string) ([]ImportedParadigm, error) {
+	reply = strings.TrimSpace(reply)
+	// Models often wrap JSON in a fenced block despite being asked not to
+	if rest, ok := strings.CutPrefix(reply, "```"); ok {
+		_, rest, _ = strings.Cut(rest, "\n")
+		reply, _, _ = strings.Cut(rest, "```")
+	}
+	var paradigms []ImportedParadigm
+	if err := json.Unmarshal([]byte(reply), &paradigms); err != nil {
+		return nil, fmt.Errorf("the model's transcription isn't the JSON asked for: %w", err)
+	}
+	for i := range paradigms {
+		if err := paradigms[i].arrange(); err != nil {
+			return nil, err
+		}
+	}
+	return paradigms, nil
+}
+
+// ReadImportedParadigm reads one paradigm as JSON, such as after the user
+// corrected its transcription by hand
+func ReadImportedParadigm(data []byte) (ImportedParadigm, error) {
+	var p ImportedParadigm
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, err
+	}
+	return p, p.arrange()
+}
+
+// arrange checks a transcribed paradigm and orders its forms cell by cell,
+// as renderParadigmTable expects. Cells the model left out are shown as —.
+func (p *ImportedParadigm) arrange() error {
+	if p.Lexeme == "" {
+		return fmt.Errorf("a transcribed table has no lexeme")
+	}
+	if len(p.Dimensions) == 0 {
+		return fmt.Errorf("the table of %s has no categories", p.Lexeme)
+	}
+	forms := map[string]ParadigmForm{}
+	for _, f := range p.Forms {
+		if len(f.Labels) != len(p.Dimensions) {
+			return fmt.Errorf("%s of %s has %d labels for %d categories", f.Form, p.Lexeme, len(f.Labels), len(p.Dimensions))
+		}
+		forms[strings.Join(f.Labels, ".")] = f
+	}
+	arranged := []ParadigmForm{}
+	for _, cell := range paradigmCells(p.Dimensions) {
+		labels := make([]string, len(cell))
+		for i, v := range cell {
+			labels[i] = v.Label
+		}
+		f, ok := forms[strings.Join(labels, ".")]
+		if !ok {
+			f = ParadigmForm{Labels: labels, Form: "—"}
+		}
+		arranged = append(arranged, f)
+	}
+	p.Forms = arranged
+	return nil
+}
+
+// Table renders the transcribed paradigm as a Markdown table for review
+func (p ImportedParadigm) Table() string {
+	return renderParadigmTable(p.Lexeme, p.Dimensions, p.Forms)
+}
+
+// SaveImportedParadigm keeps a reviewed paradigm in the data files as JSON,
+// and as a Markdown table for reading, and returns the JSON file's path.
+// Files it replaces go to the trash.
+func SaveImportedParadigm(p ImportedParadigm) (string, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	base := filepath.ToSlash(filepath.Join(paradigmsDir, p.Lexeme))
+	files := map[string][]byte{base + ".json": data}
+	table := p.Table()
+	if p.Notes != "" {
+		table += "\n" + p.Notes + "\n"
+	}
+	files[base+".md"] = []byte(table)
+
+	for name, content := range files {
+		if previous, err := storage.ReadDataFile(name); err == nil {
+			storage.MoveToTrash(storage.TrashKindFile, name, previous)
+		}
+		if err := storage.WriteDataFile(name, content); err != nil {
+			return "", err
+		}
+	}
+	return base + ".json", nil
+}
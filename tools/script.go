@@ -0,0 +1,534 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"l2/storage"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// scriptFile is the data file holding the native script's glyph inventory
+const scriptFile = "script.json"
+
+// Bounds of the Basic Multilingual Plane Private Use Area
+const (
+	puaStart = 0xE000
+	puaEnd   = 0xF8FF
+)
+
+// Glyph is a single character of the conlang's native script
+type Glyph struct {
+	Name      string `json:"name" jsonschema:"required,description=Unique name of the glyph"`
+	Codepoint string `json:"codepoint" jsonschema:"description=Private Use Area codepoint such as U+E000 (assigned automatically when empty)"`
+	Grapheme  string `json:"grapheme" jsonschema:"description=The romanized letter or sequence the glyph writes"`
+	Image     string `json:"image" jsonschema:"description=Path to an SVG or image file with the glyph's outline"`
+	// Phonemic glyphs write sounds directly, so words are spelled from their IPA
+	Phonemes []string `json:"phonemes,omitempty" jsonschema:"description=Phonemes the glyph writes such as k or tʃ, for a script spelled from pronunciation rather than from the romanization"`
+	// Logographic glyphs write morphemes rather than (or as well as) sounds
+	Morphemes  []string `json:"morphemes,omitempty" jsonschema:"description=Morphemes the glyph writes as a logogram, by form (kano or -ka) or gloss (dog or PL); a glyph can write several morphemes and a morpheme can have several glyphs"`
+	Radical    string   `json:"radical,omitempty" jsonschema:"description=Name of the glyph that is this glyph's radical"`
+	Components []string `json:"components,omitempty" jsonschema:"description=Names of the glyphs this glyph is composed of"`
+}
+
+// UpdateGlyphRequest represents a change to an existing glyph; empty fields are kept
+type UpdateGlyphRequest struct {
+	Name      string   `json:"name" jsonschema:"required,description=Name of the glyph to change"`
+	NewName   string   `json:"new_name" jsonschema:"description=New name for the glyph; radicals and components naming it are updated"`
+	Codepoint string   `json:"codepoint" jsonschema:"description=New Private Use Area codepoint such as U+E010"`
+	Grapheme  string   `json:"grapheme" jsonschema:"description=The romanized letter or sequence the glyph writes"`
+	Phonemes  []string `json:"phonemes" jsonschema:"description=Phonemes the glyph writes, replacing the current ones"`
+	Image     string   `json:"image" jsonschema:"description=Path to an SVG or image file with the glyph's outline"`
+}
+
+// RemoveGlyphRequest represents a request to remove a glyph from the script
+type RemoveGlyphRequest struct {
+	Name string `json:"name" jsonschema:"required,description=Name of the glyph to remove"`
+}
+
+// ListGlyphsRequest represents a request to list the script's glyphs
+type ListGlyphsRequest struct {
+	// Empty struct for consistency with other tools
+}
+
+// GlyphResult represents the result of a glyph operation
+type GlyphResult struct {
+	Success bool    `json:"success"`
+	Message string  `json:"message"`
+	Glyphs  []Glyph `json:"glyphs,omitempty"`
+	Table   string  `json:"table,omitempty"`
+}
+
+// PUAExportRequest represents a request to export the script's codepoint mapping
+type PUAExportRequest struct {
+	Path      string `json:"path" jsonschema:"description=Data file path for the mapping (defaults to script-mapping.txt)"`
+	FontForge bool   `json:"fontforge" jsonschema:"description=Also write a FontForge script that builds a font skeleton from the mapping"`
+	FontName  string `json:"font_name" jsonschema:"description=Font name used in the FontForge script"`
+}
+
+func loadGlyphs() ([]Glyph, error) {
+	glyphs := []Glyph{}
+	data, err := storage.ReadDataFile(scriptFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return glyphs, nil
+		}
+		return nil, err
+	}
+	if err := storage.DecodeDataFile(scriptFile, data, &glyphs); err != nil {
+		return nil, err
+	}
+	return glyphs, nil
+}
+
+func saveGlyphs(glyphs []Glyph) error {
+	data, err := json.MarshalIndent(glyphs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize script: %w", err)
+	}
+	return storage.WriteDataFile(scriptFile, data)
+}
+
+// parseCodepoint accepts U+E000, 0xE000 or E000 and returns the code point
+func parseCodepoint(s string) (rune, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "U+"), "0X")
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid codepoint %q", s)
+	}
+	return rune(n), nil
+}
+
+// parseGlyphCodepoint parses a codepoint given for a glyph, which must lie in
+// the Private Use Area so it can't take over a character with a meaning of
+// its own
+func parseGlyphCodepoint(s string) (rune, error) {
+	r, err := parseCodepoint(s)
+	if err != nil {
+		return 0, err
+	}
+	if r < puaStart || r > puaEnd {
+		return 0, fmt.Errorf("codepoint %s is outside the Private Use Area (%s to %s)",
+			formatCodepoint(r), formatCodepoint(puaStart), formatCodepoint(puaEnd))
+	}
+	return r, nil
+}
+
+func formatCodepoint(r rune) string {
+	return fmt.Sprintf("U+%04X", r)
+}
+
+// AddGlyph adds a glyph to the native script, assigning the next free PUA codepoint if needed
+func AddGlyph(ctx context.Context, glyph *Glyph) (*GlyphResult, error) {
+	if glyph.Name == "" {
+		return &GlyphResult{
+			Success: false,
+			Message: "Glyph name is required",
+		}, nil
+	}
+
+	defer storage.BeginDataUpdate(scriptFile)()
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("read script", err),
+		}, nil
+	}
+
+	used := map[rune]bool{}
+	for _, existing := range glyphs {
+		if existing.Name == glyph.Name {
+			return &GlyphResult{
+				Success: false,
+				Message: "A glyph with this name already exists",
+			}, nil
+		}
+		if r, err := parseCodepoint(existing.Codepoint); err == nil {
+			used[r] = true
+		}
+	}
+
+	if glyph.Codepoint == "" {
+		next := rune(puaStart)
+		for used[next] {
+			next++
+		}
+		if next > puaEnd {
+			return &GlyphResult{
+				Success: false,
+				Message: "No free Private Use Area codepoints left",
+			}, nil
+		}
+		glyph.Codepoint = formatCodepoint(next)
+	} else {
+		r, err := parseGlyphCodepoint(glyph.Codepoint)
+		if err != nil {
+			return &GlyphResult{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+		if used[r] {
+			return &GlyphResult{
+				Success: false,
+				Message: "Codepoint " + formatCodepoint(r) + " is already assigned",
+			}, nil
+		}
+		glyph.Codepoint = formatCodepoint(r)
+	}
+
+	if err := checkGlyphParts(glyphs, *glyph); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+	if err := checkGlyphPhonemes(*glyph); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	glyphs = append(glyphs, *glyph)
+	if err := saveGlyphs(glyphs); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("save script", err),
+		}, nil
+	}
+
+	return &GlyphResult{
+		Success: true,
+		Message: fmt.Sprintf("Added glyph %s at %s", glyph.Name, glyph.Codepoint),
+		Glyphs:  []Glyph{*glyph},
+	}, nil
+}
+
+// UpdateGlyph changes the codepoint, grapheme, phonemes, image or name of a glyph
+func UpdateGlyph(ctx context.Context, req *UpdateGlyphRequest) (*GlyphResult, error) {
+	defer storage.BeginDataUpdate(scriptFile)()
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("read script", err),
+		}, nil
+	}
+	index := slices.IndexFunc(glyphs, func(g Glyph) bool { return g.Name == req.Name })
+	if index < 0 {
+		return &GlyphResult{
+			Success: false,
+			Message: fmt.Sprintf("No glyph named %s", req.Name),
+		}, nil
+	}
+	glyph := glyphs[index]
+
+	if req.Codepoint != "" {
+		r, err := parseGlyphCodepoint(req.Codepoint)
+		if err != nil {
+			return &GlyphResult{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+		for i, other := range glyphs {
+			if used, err := parseCodepoint(other.Codepoint); err == nil && used == r && i != index {
+				return &GlyphResult{
+					Success: false,
+					Message: fmt.Sprintf("Codepoint %s is already assigned to %s", formatCodepoint(r), other.Name),
+				}, nil
+			}
+		}
+		glyph.Codepoint = formatCodepoint(r)
+	}
+	if req.Grapheme != "" {
+		glyph.Grapheme = req.Grapheme
+	}
+	if req.Phonemes != nil {
+		glyph.Phonemes = req.Phonemes
+	}
+	if req.Image != "" {
+		glyph.Image = req.Image
+	}
+	if err := checkGlyphPhonemes(glyph); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	if req.NewName != "" && req.NewName != glyph.Name {
+		if slices.ContainsFunc(glyphs, func(g Glyph) bool { return g.Name == req.NewName }) {
+			return &GlyphResult{
+				Success: false,
+				Message: "A glyph with this name already exists",
+			}, nil
+		}
+		// Other glyphs built from this one follow the rename
+		for i := range glyphs {
+			if glyphs[i].Radical == glyph.Name {
+				glyphs[i].Radical = req.NewName
+			}
+			for j, component := range glyphs[i].Components {
+				if component == glyph.Name {
+					glyphs[i].Components[j] = req.NewName
+				}
+			}
+		}
+		glyph.Name = req.NewName
+	}
+	glyphs[index] = glyph
+
+	if err := saveGlyphs(glyphs); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("save script", err),
+		}, nil
+	}
+	return &GlyphResult{
+		Success: true,
+		Message: fmt.Sprintf("Updated glyph %s", glyph.Name),
+		Glyphs:  []Glyph{glyph},
+	}, nil
+}
+
+// RemoveGlyph removes a glyph that no other glyph is built from
+func RemoveGlyph(ctx context.Context, req *RemoveGlyphRequest) (*GlyphResult, error) {
+	defer storage.BeginDataUpdate(scriptFile)()
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("read script", err),
+		}, nil
+	}
+	index := slices.IndexFunc(glyphs, func(g Glyph) bool { return g.Name == req.Name })
+	if index < 0 {
+		return &GlyphResult{
+			Success: false,
+			Message: fmt.Sprintf("No glyph named %s", req.Name),
+		}, nil
+	}
+	for _, g := range glyphs {
+		if g.Radical == req.Name || slices.Contains(g.Components, req.Name) {
+			return &GlyphResult{
+				Success: false,
+				Message: fmt.Sprintf("Glyph %s is built from %s; change it first", g.Name, req.Name),
+			}, nil
+		}
+	}
+
+	removed := glyphs[index]
+	glyphs = slices.Delete(glyphs, index, index+1)
+	if err := saveGlyphs(glyphs); err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("save script", err),
+		}, nil
+	}
+	return &GlyphResult{
+		Success: true,
+		Message: fmt.Sprintf("Removed glyph %s and freed %s", removed.Name, removed.Codepoint),
+		Glyphs:  []Glyph{removed},
+	}, nil
+}
+
+// ListGlyphs returns the script's glyph inventory in codepoint order
+func ListGlyphs(ctx context.Context, req *ListGlyphsRequest) (*GlyphResult, error) {
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return &GlyphResult{
+			Success: false,
+			Message: failure("read script", err),
+		}, nil
+	}
+	if len(glyphs) == 0 {
+		return &GlyphResult{
+			Success: true,
+			Message: "The script has no glyphs yet",
+		}, nil
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i].Codepoint < glyphs[j].Codepoint })
+	return &GlyphResult{
+		Success: true,
+		Message: fmt.Sprintf("%d glyphs", len(glyphs)),
+		Glyphs:  glyphs,
+		Table:   renderGlyphTable(glyphs),
+	}, nil
+}
+
+// checkGlyphPhonemes checks that the phonemes a glyph writes are in the
+// declared inventory, when there is one
+func checkGlyphPhonemes(glyph Glyph) error {
+	if len(glyph.Phonemes) == 0 {
+		return nil
+	}
+	inventory, err := storage.ReadInventory()
+	if err != nil || inventory.Empty() {
+		return err
+	}
+	symbols := symbolSpellings(inventory)
+	for _, p := range glyph.Phonemes {
+		if _, ok := symbols[p]; !ok {
+			return fmt.Errorf("/%s/ is not in the phoneme inventory", p)
+		}
+	}
+	return nil
+}
+
+// renderGlyphTable renders the glyphs as a markdown table
+func renderGlyphTable(glyphs []Glyph) string {
+	var out strings.Builder
+	out.WriteString("| Glyph | Name | Codepoint | Grapheme | Phonemes | Morphemes | Image |\n|---|---|---|---|---|---|---|\n")
+	for _, g := range glyphs {
+		char := ""
+		if r, err := parseCodepoint(g.Codepoint); err == nil {
+			char = string(r)
+		}
+		phonemes := make([]string, len(g.Phonemes))
+		for i, p := range g.Phonemes {
+			phonemes[i] = "/" + p + "/"
+		}
+		out.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s |\n", char, g.Name, g.Codepoint, g.Grapheme,
+			strings.Join(phonemes, " "), strings.Join(g.Morphemes, ", "), g.Image))
+	}
+	return out.String()
+}
+
+// ExportPUAMapping writes a codepoint-to-glyph mapping table and optionally a FontForge script
+func ExportPUAMapping(ctx context.Context, req *PUAExportRequest) (*ExportResult, error) {
+	glyphs, err := loadGlyphs()
+	if err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("read script", err),
+		}, nil
+	}
+	if len(glyphs) == 0 {
+		return &ExportResult{
+			Success: false,
+			Message: "The script has no glyphs yet",
+		}, nil
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i].Codepoint < glyphs[j].Codepoint })
+
+	var mapping strings.Builder
+	mapping.WriteString("# codepoint\tglyph\tgrapheme\tphonemes\timage\tmorphemes\tradical\tcomponents\n")
+	for _, g := range glyphs {
+		mapping.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", g.Codepoint, g.Name, g.Grapheme, strings.Join(g.Phonemes, ","), g.Image,
+			strings.Join(g.Morphemes, ","), g.Radical, strings.Join(g.Components, ",")))
+	}
+
+	path := req.Path
+	if path == "" {
+		path = "script-mapping.txt"
+	}
+	if err := storage.WriteDataFile(path, []byte(mapping.String())); err != nil {
+		return &ExportResult{
+			Success: false,
+			Message: failure("write mapping", err),
+		}, nil
+	}
+
+	message := fmt.Sprintf("Exported %d glyph mappings to %s", len(glyphs), path)
+	if req.FontForge {
+		scriptPath := strings.TrimSuffix(path, ".txt") + ".pe"
+		if err := storage.WriteDataFile(scriptPath, []byte(fontForgeScript(glyphs, req.FontName))); err != nil {
+			return &ExportResult{
+				Success: false,
+				Message: failure("write FontForge script", err),
+			}, nil
+		}
+		message += fmt.Sprintf(" and a FontForge script to %s (run with fontforge -script %s)", scriptPath, scriptPath)
+	}
+
+	return &ExportResult{
+		Success: true,
+		Message: message,
+		Path:    path,
+	}, nil
+}
+
+// fontForgeScript builds a native FontForge script that creates a font with
+// one named glyph per PUA codepoint, importing outlines where images are set
+func fontForgeScript(glyphs []Glyph, fontName string) string {
+	if fontName == "" {
+		fontName = "Conlang"
+	}
+	fontName = strings.ReplaceAll(fontName, " ", "")
+
+	var out strings.Builder
+	out.WriteString("#!/usr/bin/env fontforge\n")
+	out.WriteString("# Generated by L2 from script.json\n")
+	out.WriteString("New()\n")
+	out.WriteString("Reencode(\"UnicodeFull\")\n")
+	out.WriteString(fmt.Sprintf("SetFontNames(%q, %q, %q)\n", fontName, fontName, fontName))
+	for _, g := range glyphs {
+		r, err := parseGlyphCodepoint(g.Codepoint)
+		if err != nil {
+			continue // Added before codepoints were checked
+		}
+		out.WriteString(fmt.Sprintf("Select(0u%04x)\n", r))
+		out.WriteString(fmt.Sprintf("SetGlyphName(%q)\n", g.Name))
+		if g.Image != "" {
+			out.WriteString(fmt.Sprintf("Import(%q)\n", g.Image))
+		}
+	}
+	out.WriteString(fmt.Sprintf("Save(%q)\n", fontName+".sfd"))
+	return out.String()
+}
+
+// createAddGlyphTool creates the add glyph tool
+func createAddGlyphTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"add_glyph",
+		"Add a glyph to the conlang's native script with the grapheme or phonemes it writes, image reference and a Unicode Private Use Area codepoint (assigned automatically if omitted).",
+		AddGlyph,
+	)
+}
+
+// createUpdateGlyphTool creates the glyph editing tool
+func createUpdateGlyphTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"update_glyph",
+		"Change a native script glyph's name, codepoint, grapheme, phonemes or image. Renaming updates the glyphs that use it as a radical or component.",
+		UpdateGlyph,
+	)
+}
+
+// createRemoveGlyphTool creates the glyph removal tool
+func createRemoveGlyphTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"remove_glyph",
+		"Remove a glyph from the native script, freeing its codepoint. Glyphs other glyphs are built from can't be removed.",
+		RemoveGlyph,
+	)
+}
+
+// createListGlyphsTool creates the glyph inventory listing tool
+func createListGlyphsTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"list_glyphs",
+		"List the native script's glyph inventory with codepoints, graphemes, phonemes, morphemes and image references.",
+		ListGlyphs,
+	)
+}
+
+// createPUAExportTool creates the PUA mapping export tool
+func createPUAExportTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"export_pua_mapping",
+		"Export the native script's glyph-to-codepoint mapping as a text table, optionally with a FontForge script, so a custom font stays in sync with L2.",
+		ExportPUAMapping,
+	)
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"l2/storage"
+	"strings"
+	"testing"
+)
+
+func TestParseGlyphCodepoint(t *testing.T) {
+	tests := []struct {
+		codepoint string
+		want      rune
+		wantErr   string
+	}{
+		{"U+E000", 0xE000, ""},
+		{"0xf8ff", 0xF8FF, ""},
+		{" e123 ", 0xE123, ""},
+		{"U+0041", 0, "outside the Private Use Area"},
+		{"U+DFFF", 0, "outside the Private Use Area"},
+		{"U+F900", 0, "outside the Private Use Area"},
+		{"0x110000", 0, "outside the Private Use Area"},
+		{"U+XYZ", 0, "invalid codepoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.codepoint, func(t *testing.T) {
+			got, err := parseGlyphCodepoint(tt.codepoint)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseGlyphCodepoint(%q) = %U, %v, want error containing %q", tt.codepoint, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseGlyphCodepoint(%q) = %U, %v, want %U", tt.codepoint, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlyphCodepointOutsidePUA(t *testing.T) {
+	storage.SetRoot(t.TempDir())
+	defer storage.SetRoot("")
+	ctx := context.Background()
+
+	result, err := AddGlyph(ctx, &Glyph{Name: "ka", Codepoint: "U+0041"})
+	if err != nil || result.Success {
+		t.Fatalf("AddGlyph at U+0041 = %+v, %v, want it refused", result, err)
+	}
+
+	result, err = AddGlyph(ctx, &Glyph{Name: "ka"})
+	if err != nil || !result.Success || result.Glyphs[0].Codepoint != "U+E000" {
+		t.Fatalf("AddGlyph = %+v, %v, want ka at U+E000", result, err)
+	}
+	result, err = UpdateGlyph(ctx, &UpdateGlyphRequest{Name: "ka", Codepoint: "0x110000"})
+	if err != nil || result.Success {
+		t.Fatalf("UpdateGlyph to 0x110000 = %+v, %v, want it refused", result, err)
+	}
+	glyphs, err := loadGlyphs()
+	if err != nil || len(glyphs) != 1 || glyphs[0].Codepoint != "U+E000" {
+		t.Errorf("glyphs = %+v, %v, want ka kept at U+E000", glyphs, err)
+	}
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// defaultSearchPageSize is the number of entries returned per page when none is requested
+const defaultSearchPageSize = 25
+
+// maxSearchPageSize is the most entries returned per page
+const maxSearchPageSize = 200
+
+// SearchLexiconRequest represents a filtered, paginated lexicon search
+type SearchLexiconRequest struct {
+	Prefix       string `json:"prefix" jsonschema:"description=Only return words starting with this prefix"`
+	Contains     string `json:"contains" jsonschema:"description=Only return words containing this substring"`
+	PartOfSpeech string `json:"part_of_speech" jsonschema:"description=Only return words with this part of speech"`
+	Keyword      string `json:"keyword" jsonschema:"description=Only return words whose definition contains this keyword"`
+	Tag          string `json:"tag" jsonschema:"description=Only return words with this topic tag"`
+	Page         int    `json:"page" jsonschema:"description=Page number starting at 1 (defaults to 1)"`
+	PageSize     int    `json:"page_size" jsonschema:"description=Entries per page (defaults to 25, at most 200)"`
+}
+
+// SearchLexiconResult represents one page of lexicon search results
+type SearchLexiconResult struct {
+	Success    bool           `json:"success"`
+	Message    string         `json:"message"`
+	Entries    []LexiconEntry `json:"entries,omitempty"`
+	Total      int            `json:"total"`
+	Page       int            `json:"page"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// SearchLexicon returns one page of lexicon entries matching the given filters
+func SearchLexicon(ctx context.Context, req *SearchLexiconRequest) (*SearchLexiconResult, error) {
+	matches, err := FilterLexicon(storage.LexiconQuery{
+		Prefix:       req.Prefix,
+		Contains:     req.Contains,
+		PartOfSpeech: req.PartOfSpeech,
+		Keyword:      req.Keyword,
+		Tag:          req.Tag,
+	})
+	if err != nil {
+		return &SearchLexiconResult{
+			Success: false,
+			Message: failure("search lexicon", err),
+		}, nil
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	pageSize = min(pageSize, maxSearchPageSize)
+	totalPages := (len(matches) + pageSize - 1) / pageSize
+	// Any page past the last is empty; clamping it keeps the offset from
+	// overflowing
+	page := min(max(req.Page, 1), totalPages+1)
+
+	start := min((page-1)*pageSize, len(matches))
+	end := min(start+pageSize, len(matches))
+
+	return &SearchLexiconResult{
+		Success:    true,
+		Message:    fmt.Sprintf("Found %d matching entries (page %d of %d)", len(matches), page, totalPages),
+		Entries:    matches[start:end],
+		Total:      len(matches),
+		Page:       page,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// createSearchLexiconTool creates the search lexicon tool
+func createSearchLexiconTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"search_lexicon",
+		"Search the conlang lexicon by word prefix, substring, part of speech, definition keyword, or tag. Results are paginated; prefer this over get_lexicon for large lexicons.",
+		SearchLexicon,
+	)
+}
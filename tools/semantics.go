@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"l2/storage"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// SemanticDomain is a broad area of meaning with the core concepts a
+// lexicon is expected to cover in it
+type SemanticDomain struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+}
+
+// semanticDomains are the domains lexicon entries are tagged with, loosely
+// after the core vocabulary lists used in lexical typology
+var semanticDomains = []SemanticDomain{
+	{"body", []string{"head", "eye", "ear", "nose", "mouth", "tooth", "tongue", "hand", "arm", "leg", "foot", "heart", "blood", "bone", "skin", "hair", "belly", "neck", "finger", "breath"}},
+	{"kinship", []string{"mother", "father", "child", "son", "daughter", "brother", "sister", "husband", "wife", "family", "grandmother", "grandfather", "uncle", "aunt", "cousin", "ancestor", "marry", "parent", "sibling", "orphan"}},
+	{"nature", []string{"sun", "moon", "star", "sky", "water", "fire", "earth", "stone", "mountain", "river", "sea", "rain", "wind", "cloud", "tree", "forest", "grass", "flower", "snow", "ice"}},
+	{"animals", []string{"animal", "dog", "bird", "fish", "snake", "horse", "cow", "sheep", "goat", "pig", "wolf", "bear", "insect", "egg", "wing", "feather", "tail", "horn", "hunt", "louse"}},
+	{"food", []string{"eat", "drink", "bread", "meat", "salt", "milk", "honey", "fruit", "seed", "grain", "cook", "hungry", "thirsty", "taste", "soup", "oil", "wine", "bean", "feast", "sweet"}},
+	{"tools", []string{"tool", "knife", "axe", "rope", "needle", "hammer", "pot", "basket", "bow", "arrow", "spear", "net", "plow", "wheel", "boat", "cart", "key", "cup", "bowl", "sword"}},
+	{"emotions", []string{"love", "hate", "fear", "anger", "joy", "happy", "sad", "shame", "hope", "grief", "envy", "pride", "calm", "worry", "laugh", "cry", "longing", "want", "trust", "pity"}},
+	{"motion", []string{"go", "come", "walk", "run", "fly", "swim", "fall", "climb", "jump", "enter", "leave", "return", "carry", "push", "pull", "throw", "turn", "follow", "arrive", "sit"}},
+	{"perception", []string{"see", "hear", "smell", "touch", "feel", "look", "listen", "know", "think", "remember", "forget", "understand", "learn", "dream", "believe", "notice", "sound", "light", "dark", "color"}},
+	{"speech", []string{"say", "speak", "word", "name", "ask", "answer", "call", "tell", "sing", "song", "story", "shout", "whisper", "promise", "lie", "language", "write", "read", "greet", "thank"}},
+	{"time", []string{"day", "night", "morning", "evening", "year", "month", "season", "summer", "winter", "spring", "today", "yesterday", "tomorrow", "now", "before", "after", "old", "new", "always", "never"}},
+	{"quantity", []string{"one", "two", "three", "four", "five", "ten", "hundred", "many", "few", "every", "several", "none", "half", "pair", "dozen", "big", "small", "long", "short", "count"}},
+	{"society", []string{"person", "man", "woman", "friend", "enemy", "king", "chief", "village", "house", "people", "guest", "stranger", "war", "peace", "trade", "gift", "law", "god", "work", "slave"}},
+}
+
+// SemanticCoverageRequest represents a request for semantic field coverage
+type SemanticCoverageRequest struct {
+	Tag       bool `json:"tag" jsonschema:"description=Save the matched domains as tags on the lexicon entries"`
+	Suggest   int  `json:"suggest" jsonschema:"description=Number of missing concepts to suggest per thin domain (default 5)"`
+	ThinBelow int  `json:"thin_below" jsonschema:"description=Coverage percentage below which a domain is reported as thin (default half the average coverage)"`
+}
+
+// DomainCoverage is how well the lexicon covers one semantic domain
+type DomainCoverage struct {
+	Domain  string   `json:"domain"`
+	Words   []string `json:"words,omitempty"` // Lexicon words in the domain
+	Covered int      `json:"covered"`         // Core concepts with a word
+	Total   int      `json:"total"`
+	Percent int      `json:"percent"`
+	Thin    bool     `json:"thin,omitempty"`
+	Missing []string `json:"missing,omitempty"` // Suggested concepts still without a word
+}
+
+// SemanticCoverageResult represents the semantic field coverage of the lexicon
+type SemanticCoverageResult struct {
+	Success      bool             `json:"success"`
+	Message      string           `json:"message"`
+	Domains      []DomainCoverage `json:"domains,omitempty"`
+	Unclassified []string         `json:"unclassified,omitempty"` // Words in no domain
+	Table        string           `json:"table,omitempty"`
+}
+
+// entryDomains returns the domains a lexicon entry belongs to, from its tags
+// and from core concepts its definition mentions, and the concepts matched
+func entryDomains(entry LexiconEntry) (domains []string, concepts map[string][]string) {
+	words := map[string]bool{}
+	for _, word := range TokenizeWords(entry.Definition) {
+		words[word] = true
+	}
+	concepts = map[string][]string{}
+	for _, domain := range semanticDomains {
+		for _, keyword := range domain.Keywords {
+			if words[keyword] || words[keyword+"s"] || words[keyword+"es"] {
+				concepts[domain.Name] = append(concepts[domain.Name], keyword)
+			}
+		}
+		tagged := slices.ContainsFunc(entry.Tags, func(tag string) bool { return strings.EqualFold(tag, domain.Name) })
+		if tagged || len(concepts[domain.Name]) > 0 {
+			domains = append(domains, domain.Name)
+		}
+	}
+	return domains, concepts
+}
+
+// SemanticCoverage classifies lexicon entries into semantic domains and
+// reports how much of each domain's core vocabulary has words, so thin
+// areas can be filled first
+func SemanticCoverage(ctx context.Context, req *SemanticCoverageRequest) (*SemanticCoverageResult, error) {
+	if req.Tag {
+		defer storage.BeginDataUpdate(lexiconFile)()
+	}
+	entries, err := loadLexicon()
+	if err != nil {
+		return &SemanticCoverageResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	if len(entries) == 0 {
+		return &SemanticCoverageResult{
+			Success: false,
+			Message: "The lexicon is empty; add words before measuring coverage",
+		}, nil
+	}
+	suggest := req.Suggest
+	if suggest <= 0 {
+		suggest = 5
+	}
+
+	result := &SemanticCoverageResult{Success: true}
+	words := map[string][]string{}
+	covered := map[string]map[string]bool{}
+	tagged := 0
+	for i, entry := range entries {
+		domains, concepts := entryDomains(entry)
+		if len(domains) == 0 {
+			result.Unclassified = append(result.Unclassified, entry.Word)
+			continue
+		}
+		for _, domain := range domains {
+			words[domain] = append(words[domain], entry.Word)
+			if covered[domain] == nil {
+				covered[domain] = map[string]bool{}
+			}
+			for _, concept := range concepts[domain] {
+				covered[domain][concept] = true
+			}
+			if req.Tag && !slices.ContainsFunc(entry.Tags, func(tag string) bool { return strings.EqualFold(tag, domain) }) {
+				entries[i].Tags = append(entries[i].Tags, domain)
+				tagged++
+			}
+		}
+	}
+
+	total := 0
+	for _, domain := range semanticDomains {
+		c := DomainCoverage{
+			Domain:  domain.Name,
+			Words:   words[domain.Name],
+			Covered: len(covered[domain.Name]),
+			Total:   len(domain.Keywords),
+		}
+		c.Percent = c.Covered * 100 / c.Total
+		total += c.Percent
+		result.Domains = append(result.Domains, c)
+	}
+	thinBelow := req.ThinBelow
+	if thinBelow <= 0 {
+		thinBelow = total / len(semanticDomains) / 2
+	}
+	thin := []string{}
+	for i, c := range result.Domains {
+		if c.Percent >= thinBelow && len(c.Words) > 0 {
+			continue
+		}
+		result.Domains[i].Thin = true
+		thin = append(thin, c.Domain)
+		for _, keyword := range semanticDomains[i].Keywords {
+			if len(result.Domains[i].Missing) == suggest {
+				break
+			}
+			if !covered[c.Domain][keyword] {
+				result.Domains[i].Missing = append(result.Domains[i].Missing, keyword)
+			}
+		}
+	}
+	result.Table = renderDomainCoverage(result.Domains)
+
+	if req.Tag && tagged > 0 {
+		if err := saveLexicon(entries); err != nil {
+			return &SemanticCoverageResult{
+				Success: false,
+				Message: failure("save domain tags", err),
+			}, nil
+		}
+	}
+
+	result.Message = fmt.Sprintf("Classified %d of %d words into %d domains", len(entries)-len(result.Unclassified), len(entries), len(semanticDomains))
+	if len(thin) > 0 {
+		result.Message += "; thin domains: " + strings.Join(thin, ", ")
+	}
+	if req.Tag {
+		result.Message += fmt.Sprintf("; added %d domain tags", tagged)
+	}
+	return result, nil
+}
+
+func renderDomainCoverage(domains []DomainCoverage) string {
+	var out strings.Builder
+	out.WriteString("| Domain | Words | Core concepts | Coverage | Missing |\n|---|---|---|---|---|\n")
+	for _, c := range domains {
+		bar := strings.Repeat("█", c.Percent/10) + strings.Repeat("░", 10-c.Percent/10)
+		name := c.Domain
+		if c.Thin {
+			name = "⚠ " + name
+		}
+		out.WriteString(fmt.Sprintf("| %s | %d | %d/%d | %s %d%% | %s |\n",
+			name, len(c.Words), c.Covered, c.Total, bar, c.Percent, strings.Join(c.Missing, ", ")))
+	}
+	return out.String()
+}
+
+// createSemanticCoverageTool creates the semantic field coverage tool
+func createSemanticCoverageTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"semantic_coverage",
+		"Classify lexicon entries into semantic domains (body, kinship, nature, animals, food, tools, emotions, motion, perception, speech, time, quantity, society) from their definitions and tags, "+
+			"and report how many core concepts of each domain have words, flagging thin domains with missing concepts to coin next. Set tag to save the domains as entry tags.",
+		SemanticCoverage,
+	)
+}
@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"os"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// SentenceFrame represents a semantic frame to render as a sentence: who did
+// what to whom, and when
+type SentenceFrame struct {
+	Agent     string `json:"agent" jsonschema:"required,description=Who does the action: a lexicon word or an English word matching a definition"`
+	Action    string `json:"action" jsonschema:"required,description=The action: a lexicon verb or an English word matching a definition"`
+	Patient   string `json:"patient" jsonschema:"description=Who or what the action is done to; leave empty for an intransitive sentence"`
+	Recipient string `json:"recipient" jsonschema:"description=Who receives the patient, for verbs like give"`
+	Tense     string `json:"tense" jsonschema:"description=Tense such as past, present or future"`
+}
+
+// SentenceResult represents a generated sentence
+type SentenceResult struct {
+	Success   bool     `json:"success"`
+	Message   string   `json:"message"`
+	Sentence  string   `json:"sentence,omitempty"`
+	Morphemes string   `json:"morphemes,omitempty"`
+	Gloss     string   `json:"gloss,omitempty"`
+	Text      string   `json:"text,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// SyntaxResult represents the result of storing the clause rules
+type SyntaxResult struct {
+	Success  bool           `json:"success"`
+	Message  string         `json:"message"`
+	Syntax   storage.Syntax `json:"syntax"`
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+// defaultTenseLabels are the tense glosses used when the syntax doesn't name them
+var defaultTenseLabels = map[string]string{
+	"past":    "PST",
+	"present": "PRS",
+	"future":  "FUT",
+}
+
+// SetSyntax stores the word order and case alignment sentence generation follows
+func SetSyntax(ctx context.Context, req *storage.Syntax) (*SyntaxResult, error) {
+	req.WordOrder = strings.ToUpper(strings.TrimSpace(req.WordOrder))
+	if len(req.WordOrder) != 3 || strings.Count(req.WordOrder, "S") != 1 || strings.Count(req.WordOrder, "O") != 1 || strings.Count(req.WordOrder, "V") != 1 {
+		return &SyntaxResult{
+			Success: false,
+			Message: fmt.Sprintf("Word order %q must be an ordering of S, O and V such as SOV", req.WordOrder),
+		}, nil
+	}
+	for _, t := range req.Tenses {
+		if t.Tense == "" || t.Label == "" {
+			return &SyntaxResult{
+				Success: false,
+				Message: "Every tense needs a name and a gloss label",
+			}, nil
+		}
+	}
+
+	if err := storage.WriteSyntax(*req); err != nil {
+		return &SyntaxResult{
+			Success: false,
+			Message: failure("save syntax", err),
+		}, nil
+	}
+	return &SyntaxResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Saved %s word order", req.WordOrder),
+		Syntax:   *req,
+		Warnings: budgetWarnings(),
+	}, nil
+}
+
+// GenerateSentence composes a sentence from a semantic frame using the stored
+// word order, case alignment, affixes and suppletive forms. The result is
+// checked against the grammar file when one exists.
+func GenerateSentence(ctx context.Context, req *SentenceFrame) (*SentenceResult, error) {
+	if req.Agent == "" || req.Action == "" {
+		return &SentenceResult{
+			Success: false,
+			Message: "A sentence frame needs at least an agent and an action",
+		}, nil
+	}
+
+	syntax, err := storage.ReadSyntax()
+	if err != nil {
+		return &SentenceResult{
+			Success: false,
+			Message: failure("read syntax", err),
+		}, nil
+	}
+	if syntax.WordOrder == "" {
+		return &SentenceResult{
+			Success: false,
+			Message: "No word order set yet; store one with set_syntax first",
+		}, nil
+	}
+	entries, err := loadLexicon()
+	if err != nil {
+		return &SentenceResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	store, err := loadAffixStore()
+	if err != nil {
+		return &SentenceResult{
+			Success: false,
+			Message: failure("read affixes", err),
+		}, nil
+	}
+	suppletion, err := storage.ReadSuppletion()
+	if err != nil {
+		return &SentenceResult{
+			Success: false,
+			Message: failure("read suppletive forms", err),
+		}, nil
+	}
+
+	subjectCase := syntax.AgentCase
+	if req.Patient == "" && syntax.SubjectCase != "" {
+		subjectCase = syntax.SubjectCase
+	}
+	tense := ""
+	if req.Tense != "" {
+		tense = tenseLabel(syntax, req.Tense)
+	}
+
+	warnings := []string{}
+	inflect := func(role, word, label string) (inflectedForm, error) {
+		entry, ok := findLexeme(entries, word)
+		if !ok {
+			return inflectedForm{}, fmt.Errorf("no lexicon word for %s %q", role, word)
+		}
+		values := []ParadigmValue{}
+		if label != "" {
+			values = append(values, ParadigmValue{Label: label})
+		}
+		form := realizeCell(entry.Word, entry.Word, headGloss(entry.Definition), values, store, suppletion)
+		if label != "" && !form.suppletive && len(form.morphemes) == 1 {
+			if _, ok := store.glossed(label); !ok {
+				warnings = append(warnings, fmt.Sprintf("No affix glossed %s in the affix store; %s is left unmarked", label, entry.Word))
+			}
+		}
+		return form, nil
+	}
+
+	subject, err := inflect("agent", req.Agent, subjectCase)
+	if err != nil {
+		return &SentenceResult{Success: false, Message: failure("generate sentence", err)}, nil
+	}
+	verb, err := inflect("action", req.Action, tense)
+	if err != nil {
+		return &SentenceResult{Success: false, Message: failure("generate sentence", err)}, nil
+	}
+	// The object slot holds the recipient followed by the patient
+	object := []inflectedForm{}
+	if req.Recipient != "" {
+		recipient, err := inflect("recipient", req.Recipient, syntax.RecipientCase)
+		if err != nil {
+			return &SentenceResult{Success: false, Message: failure("generate sentence", err)}, nil
+		}
+		object = append(object, recipient)
+	}
+	if req.Patient != "" {
+		patient, err := inflect("patient", req.Patient, syntax.PatientCase)
+		if err != nil {
+			return &SentenceResult{Success: false, Message: failure("generate sentence", err)}, nil
+		}
+		object = append(object, patient)
+	}
+
+	words := []inflectedForm{}
+	for _, slot := range syntax.WordOrder {
+		switch slot {
+		case 'S':
+			words = append(words, subject)
+		case 'O':
+			words = append(words, object...)
+		case 'V':
+			words = append(words, verb)
+		}
+	}
+
+	surface := make([]string, len(words))
+	morphemes := make([]string, len(words))
+	glosses := make([]string, len(words))
+	for i, w := range words {
+		surface[i] = w.form()
+		morphemes[i] = strings.Join(w.morphemes, "-")
+		glosses[i] = strings.Join(w.glosses, "-")
+	}
+	sentence := strings.Join(surface, " ")
+
+	result := &SentenceResult{
+		Success:   true,
+		Message:   "Generated sentence",
+		Sentence:  sentence,
+		Morphemes: strings.Join(morphemes, " "),
+		Gloss:     strings.Join(glosses, " "),
+	}
+	if gloss, err := NewInterlinear(sentence, result.Morphemes, result.Gloss, ""); err == nil {
+		result.Text = formatAligned(gloss)
+	}
+
+	source, err := storage.ReadDataFile(defaultGrammarFile)
+	switch {
+	case err == nil:
+		if grammar, err := parseGrammar(string(source)); err != nil {
+			warnings = append(warnings, "Invalid grammar in "+defaultGrammarFile+": "+err.Error())
+		} else if failure := grammar.parse(tokenizeSentence(sentence), entries); failure != nil {
+			warnings = append(warnings, describeGrammarFailure(failure))
+		} else {
+			result.Message = "Generated sentence, grammatical according to " + defaultGrammarFile
+		}
+	case !os.IsNotExist(err):
+		warnings = append(warnings, failure("load "+defaultGrammarFile, err))
+	}
+	result.Warnings = warnings
+	return result, nil
+}
+
+// tenseLabel returns the gloss label of a tense named in a frame
+func tenseLabel(syntax storage.Syntax, tense string) string {
+	for _, t := range syntax.Tenses {
+		if strings.EqualFold(t.Tense, tense) {
+			return t.Label
+		}
+	}
+	if label, ok := defaultTenseLabels[strings.ToLower(tense)]; ok {
+		return label
+	}
+	return strings.ToUpper(tense)
+}
+
+// findLexeme finds the lexicon entry for a frame participant, by the word
+// itself or by the head word of its definition
+func findLexeme(entries []LexiconEntry, word string) (LexiconEntry, bool) {
+	if entry, ok := findEntry(entries, word); ok {
+		return entry, true
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(headGloss(entry.Definition), headGloss(word)) {
+			return entry, true
+		}
+	}
+	return LexiconEntry{}, false
+}
+
+// createSetSyntaxTool creates the tool that stores the clause rules
+func createSetSyntaxTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_syntax",
+		"Store the language's basic word order, the case labels of agent, intransitive subject, patient and recipient, and the gloss labels of its tenses. generate_sentence follows these rules.",
+		SetSyntax,
+	)
+}
+
+// createGenerateSentenceTool creates the sentence generation tool
+func createGenerateSentenceTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"generate_sentence",
+		"Compose a conlang sentence from a semantic frame (agent, action, patient, recipient, tense) deterministically: words are looked up in the lexicon, inflected for case and tense with the stored affixes and suppletive forms, and ordered by the stored word order. Returns the sentence with an interlinear gloss and checks it against grammar.peg. Use this instead of composing sentences yourself.",
+		GenerateSentence,
+	)
+}
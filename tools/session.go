@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"fmt"
+	"l2/storage"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// designFiles are the stored grammar files compared at the end of a session
+var designFiles = []int{
+	storage.InventoryFile, storage.PhonotacticsFile, storage.HarmonyFile, storage.PartsOfSpeechFile,
+	storage.AffixFile, storage.OrthographyFile, storage.SuppletionFile, storage.SyntaxFile,
+	storage.ProfileFile, storage.DecisionsFile,
+}
+
+// SessionSnapshot is the state of the language when a session started,
+// compared against at exit to report what the session changed
+type SessionSnapshot struct {
+	started   time.Time
+	stats     storage.Stats // Token usage and cost so far
+	words     []string
+	decisions int
+	files     map[string]string // Content hash by file name
+}
+
+// TakeSnapshot records the usage so far, the lexicon, decision log and
+// stored files
+func TakeSnapshot(stats storage.Stats) (*SessionSnapshot, error) {
+	s := &SessionSnapshot{started: time.Now(), stats: stats}
+	entries, err := loadLexicon()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		s.words = append(s.words, entry.Word)
+	}
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return nil, err
+	}
+	s.decisions = len(log.Decisions)
+	if s.files, err = hashStoredFiles(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// hashStoredFiles hashes the design files and every data file
+func hashStoredFiles() (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, file := range designFiles {
+		exists, err := storage.CheckFile(file)
+		if err != nil || !exists {
+			continue
+		}
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		path, _ := storage.GetPath(file)
+		hashes[filepath.Base(path)] = storage.HashContent(data)
+	}
+	files, err := storage.ListDataFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := storage.ReadDataFile(file)
+		if err != nil {
+			return nil, err
+		}
+		hashes[filepath.Join("data", file)] = storage.HashContent(data)
+	}
+	return hashes, nil
+}
+
+// StartingStats returns the token usage and cost when the session started
+func (s *SessionSnapshot) StartingStats() storage.Stats {
+	return s.stats
+}
+
+// Summary compares the language and the usage now with the snapshot
+func (s *SessionSnapshot) Summary(stats storage.Stats) (storage.SessionSummary, error) {
+	summary := storage.SessionSummary{
+		Started:      s.started,
+		Ended:        time.Now(),
+		Tokens:       stats.TotalTokens - s.stats.TotalTokens,
+		PromptTokens: stats.PromptTokens - s.stats.PromptTokens,
+		Cost:         stats.Cost - s.stats.Cost,
+	}
+	now, start := stats.Total(), s.stats.Total()
+	summary.Requests = now.Requests - start.Requests
+	summary.ToolCalls = now.ToolCalls - start.ToolCalls
+	if timed := now.Timed - start.Timed; timed > 0 {
+		summary.LatencyMS = (now.LatencyMS - start.LatencyMS) / int64(timed)
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return summary, err
+	}
+	words := []string{}
+	for _, entry := range entries {
+		words = append(words, entry.Word)
+		if !slices.Contains(s.words, entry.Word) {
+			summary.WordsAdded = append(summary.WordsAdded, entry.Word)
+		}
+	}
+	for _, word := range s.words {
+		if !slices.Contains(words, word) {
+			summary.WordsRemoved = append(summary.WordsRemoved, word)
+		}
+	}
+
+	log, err := storage.ReadDecisionLog()
+	if err != nil {
+		return summary, err
+	}
+	for _, d := range log.Decisions[min(s.decisions, len(log.Decisions)):] {
+		summary.Decisions = append(summary.Decisions, d.Summary)
+	}
+
+	hashes, err := hashStoredFiles()
+	if err != nil {
+		return summary, err
+	}
+	for name, hash := range hashes {
+		if s.files[name] != hash {
+			summary.FilesChanged = append(summary.FilesChanged, name)
+		}
+	}
+	for name := range s.files {
+		if _, ok := hashes[name]; !ok {
+			summary.FilesChanged = append(summary.FilesChanged, name+" (deleted)")
+		}
+	}
+	slices.Sort(summary.FilesChanged)
+	return summary, nil
+}
+
+// FormatSessionSummary renders a session summary as lines of plain text
+func FormatSessionSummary(summary storage.SessionSummary) string {
+	list := func(items []string, limit int) string {
+		if len(items) > limit {
+			return strings.Join(items[:limit], ", ") + fmt.Sprintf(" and %d more", len(items)-limit)
+		}
+		return strings.Join(items, ", ")
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Duration: %s\n", summary.Ended.Sub(summary.Started).Round(time.Second)))
+	out.WriteString(fmt.Sprintf("Tokens used: %d", summary.Tokens))
+	if summary.PromptTokens > 0 {
+		out.WriteString(fmt.Sprintf(" (%d prompt, %d completion)", summary.PromptTokens, summary.Tokens-summary.PromptTokens))
+	}
+	out.WriteString("\n")
+	if summary.Cost > 0 {
+		out.WriteString(fmt.Sprintf("Cost: about $%.4f\n", summary.Cost))
+	}
+	if summary.Requests > 0 {
+		out.WriteString(fmt.Sprintf("Requests: %d", summary.Requests))
+		if summary.LatencyMS > 0 {
+			out.WriteString(fmt.Sprintf(", %s on average", FormatLatency(time.Duration(summary.LatencyMS)*time.Millisecond)))
+		}
+		out.WriteString(fmt.Sprintf(", %s\n", pluralize(summary.ToolCalls, "tool call")))
+	}
+	out.WriteString(fmt.Sprintf("Words added: %d", len(summary.WordsAdded)))
+	if len(summary.WordsAdded) > 0 {
+		out.WriteString(" — " + list(summary.WordsAdded, 10))
+	}
+	out.WriteString("\n")
+	if len(summary.WordsRemoved) > 0 {
+		out.WriteString(fmt.Sprintf("Words removed: %d — %s\n", len(summary.WordsRemoved), list(summary.WordsRemoved, 10)))
+	}
+	out.WriteString(fmt.Sprintf("Files changed: %d", len(summary.FilesChanged)))
+	if len(summary.FilesChanged) > 0 {
+		out.WriteString(" — " + list(summary.FilesChanged, 6))
+	}
+	out.WriteString("\n")
+	out.WriteString(fmt.Sprintf("Decisions recorded: %d\n", len(summary.Decisions)))
+	for _, decision := range summary.Decisions {
+		out.WriteString("  • " + decision + "\n")
+	}
+	return out.String()
+}
+
+// FormatLatency shows a request's duration to a tenth of a second
+func FormatLatency(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
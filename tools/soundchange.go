@@ -0,0 +1,372 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// DefaultSoundChangeFile is the data file the diachronic rules are read from
+const DefaultSoundChangeFile = "sound_changes.txt"
+
+// ruleToken is one position in a sound change: a literal phoneme or a class
+// (C for any consonant, V for any vowel)
+type ruleToken struct {
+	class   rune
+	phoneme string
+}
+
+// soundChange is a rule such as "k > tʃ / _i" or "e > Ø / VC_#". The
+// environment after the slash places the target at _, with # marking a word
+// boundary.
+type soundChange struct {
+	source      string
+	target      []ruleToken
+	replacement []string
+	before      []ruleToken
+	after       []ruleToken
+	wordStart   bool
+	wordEnd     bool
+}
+
+// soundChanger applies an ordered list of sound changes to words written as
+// phoneme symbols
+type soundChanger struct {
+	rules    []soundChange
+	phonemes *phonemeSet
+}
+
+// parseSoundChanges parses one rule per line. Blank lines and lines
+// starting with # are ignored.
+func parseSoundChanges(source string, set *phonemeSet) (*soundChanger, error) {
+	sc := &soundChanger{phonemes: set}
+	for n, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := sc.parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		sc.rules = append(sc.rules, rule)
+	}
+	if len(sc.rules) == 0 {
+		return nil, fmt.Errorf("no sound change rules found")
+	}
+	return sc, nil
+}
+
+func (sc *soundChanger) parseRule(line string) (soundChange, error) {
+	rule := soundChange{source: line}
+	lhs, rest, ok := strings.Cut(line, ">")
+	if !ok {
+		return rule, fmt.Errorf("rule %q needs the form target > replacement / environment", line)
+	}
+	rhs, env, _ := strings.Cut(rest, "/")
+	if strings.Contains(rest, ">") || strings.Contains(env, "/") {
+		return rule, fmt.Errorf("rule %q has more than one > or /", line)
+	}
+
+	rule.target = sc.tokens(lhs)
+	if len(rule.target) == 0 {
+		return rule, fmt.Errorf("rule %q has no target", line)
+	}
+	if r := strings.TrimSpace(rhs); r != "Ø" && r != "∅" && r != "0" && r != "" {
+		for _, token := range sc.tokens(r) {
+			if token.class != 0 {
+				return rule, fmt.Errorf("rule %q: replacements must be phonemes, not classes", line)
+			}
+			rule.replacement = append(rule.replacement, token.phoneme)
+		}
+	}
+
+	if env = strings.TrimSpace(env); env != "" {
+		before, after, ok := strings.Cut(env, "_")
+		if !ok {
+			return rule, fmt.Errorf("rule %q: environment must mark the target position with _", line)
+		}
+		if strings.Contains(after, "_") {
+			return rule, fmt.Errorf("rule %q: environment has more than one target position _", line)
+		}
+		before, rule.wordStart = strings.CutPrefix(strings.TrimSpace(before), "#")
+		after, rule.wordEnd = strings.CutSuffix(strings.TrimSpace(after), "#")
+		if strings.Contains(before+after, "#") {
+			return rule, fmt.Errorf("rule %q: # marks a word boundary only at the start or end of the environment", line)
+		}
+		rule.before = sc.tokens(before)
+		rule.after = sc.tokens(after)
+	}
+	return rule, nil
+}
+
+// tokens splits part of a rule into classes and phoneme symbols
+func (sc *soundChanger) tokens(text string) []ruleToken {
+	tokens := []ruleToken{}
+	literal := strings.Builder{}
+	flush := func() {
+		for _, seg := range sc.phonemes.segment(strings.ToLower(literal.String())) {
+			if p, ok := sc.phonemes.phoneme(seg); ok {
+				seg = p.Symbol
+			}
+			tokens = append(tokens, ruleToken{phoneme: seg})
+		}
+		literal.Reset()
+	}
+	for _, r := range strings.ReplaceAll(text, " ", "") {
+		if r == 'C' || r == 'V' {
+			flush()
+			tokens = append(tokens, ruleToken{class: r})
+			continue
+		}
+		literal.WriteRune(r)
+	}
+	flush()
+	return tokens
+}
+
+func (sc *soundChanger) matches(token ruleToken, segment string) bool {
+	switch token.class {
+	case 'C':
+		return !sc.phonemes.vowel(segment)
+	case 'V':
+		return sc.phonemes.vowel(segment)
+	}
+	return token.phoneme == segment
+}
+
+func (sc *soundChanger) matchAt(tokens []ruleToken, word []string, at int) bool {
+	if at < 0 || at+len(tokens) > len(word) {
+		return false
+	}
+	for i, token := range tokens {
+		if !sc.matches(token, word[at+i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRule rewrites every match of a rule at once, so the environment is
+// always checked against the word as it was before the rule applied
+func (sc *soundChanger) applyRule(rule soundChange, word []string) []string {
+	out := []string{}
+	for i := 0; i < len(word); {
+		end := i + len(rule.target)
+		start := i - len(rule.before)
+		if sc.matchAt(rule.target, word, i) &&
+			sc.matchAt(rule.before, word, start) &&
+			sc.matchAt(rule.after, word, end) &&
+			(!rule.wordStart || start == 0) &&
+			(!rule.wordEnd || end+len(rule.after) == len(word)) {
+			out = append(out, rule.replacement...)
+			i = end
+			continue
+		}
+		out = append(out, word[i])
+		i++
+	}
+	return out
+}
+
+// apply runs every rule in order
+func (sc *soundChanger) apply(word []string) []string {
+	for _, rule := range sc.rules {
+		word = sc.applyRule(rule, word)
+	}
+	return word
+}
+
+// symbols segments a romanized word into the phoneme symbols the rules match
+func (sc *soundChanger) symbols(word string) []string {
+	segs := sc.phonemes.segment(strings.ToLower(word))
+	for i, seg := range segs {
+		if p, ok := sc.phonemes.phoneme(seg); ok {
+			segs[i] = p.Symbol
+		}
+	}
+	return segs
+}
+
+// PhonemeShift is the change in a phoneme's share of all segments
+type PhonemeShift struct {
+	Phoneme string  `json:"phoneme"`
+	Before  float64 `json:"before"` // Percentage of segments before the changes
+	After   float64 `json:"after"`  // Percentage of segments after the changes
+}
+
+// SimulationReport summarizes the effect of sound changes on generated texts
+type SimulationReport struct {
+	Rules         []string       `json:"rules"`
+	Texts         int            `json:"texts"`
+	Words         int            `json:"words"`
+	ChangedWords  float64        `json:"changed_words"`  // Percentage of word tokens altered
+	HomophonyRate float64        `json:"homophony_rate"` // Percentage of distinct words that merged with another
+	Shifts        []PhonemeShift `json:"shifts"`
+	Mergers       []string       `json:"mergers,omitempty"`
+	Examples      []string       `json:"examples,omitempty"`
+}
+
+// SimulateSoundChanges generates random pseudo-texts from the phoneme
+// inventory and phonotactics, runs the sound change rules from rulesFile over
+// them and reports the aggregate effects
+func SimulateSoundChanges(ctx context.Context, rulesFile string, texts, wordsPerText int, seed int64) (*SimulationReport, error) {
+	if rulesFile == "" {
+		rulesFile = DefaultSoundChangeFile
+	}
+	source, err := storage.ReadDataFile(rulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s (write one rule per line, e.g. k > tʃ / _i): %w", rulesFile, err)
+	}
+	set, err := loadPhonemeSet()
+	if err != nil {
+		return nil, err
+	}
+	changer, err := parseSoundChanges(string(source), set)
+	if err != nil {
+		return nil, err
+	}
+	generator, err := newWordGenerator(rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SimulationReport{Texts: texts}
+	for _, rule := range changer.rules {
+		report.Rules = append(report.Rules, rule.source)
+	}
+
+	before := map[string]int{}
+	after := map[string]int{}
+	outputs := map[string]map[string]bool{} // Changed form to the distinct words that produced it
+	changed := 0
+	for t := 0; t < texts; t++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		reportProgress(ctx, t, texts)
+		for w := 0; w < wordsPerText; w++ {
+			word := generator.word(3)
+			result := changer.apply(word)
+			for _, seg := range word {
+				before[seg]++
+			}
+			for _, seg := range result {
+				after[seg]++
+			}
+
+			original, form := strings.Join(word, ""), strings.Join(result, "")
+			if original != form {
+				changed++
+				if len(report.Examples) < 5 {
+					report.Examples = append(report.Examples, original+" → "+form)
+				}
+			}
+			if outputs[form] == nil {
+				outputs[form] = map[string]bool{}
+			}
+			outputs[form][original] = true
+			report.Words++
+		}
+	}
+	if report.Words == 0 {
+		return report, nil
+	}
+	report.ChangedWords = percent(changed, report.Words)
+
+	distinct, merged := 0, 0
+	forms := make([]string, 0, len(outputs))
+	for form := range outputs {
+		forms = append(forms, form)
+	}
+	sort.Strings(forms)
+	for _, form := range forms {
+		sources := outputs[form]
+		distinct += len(sources)
+		if len(sources) < 2 {
+			continue
+		}
+		merged += len(sources)
+		if len(report.Mergers) < 5 {
+			words := make([]string, 0, len(sources))
+			for word := range sources {
+				words = append(words, word)
+			}
+			sort.Strings(words)
+			report.Mergers = append(report.Mergers, strings.Join(words, ", ")+" → "+form)
+		}
+	}
+	report.HomophonyRate = percent(merged, distinct)
+
+	totalBefore, totalAfter := 0, 0
+	for _, n := range before {
+		totalBefore += n
+	}
+	for _, n := range after {
+		totalAfter += n
+	}
+	phonemes := map[string]bool{}
+	for p := range before {
+		phonemes[p] = true
+	}
+	for p := range after {
+		phonemes[p] = true
+	}
+	for p := range phonemes {
+		shift := PhonemeShift{Phoneme: p, Before: percent(before[p], totalBefore), After: percent(after[p], totalAfter)}
+		if math.Abs(shift.After-shift.Before) >= 0.1 {
+			report.Shifts = append(report.Shifts, shift)
+		}
+	}
+	sort.Slice(report.Shifts, func(i, j int) bool {
+		di := math.Abs(report.Shifts[i].After - report.Shifts[i].Before)
+		dj := math.Abs(report.Shifts[j].After - report.Shifts[j].Before)
+		if di != dj {
+			return di > dj
+		}
+		return report.Shifts[i].Phoneme < report.Shifts[j].Phoneme
+	})
+	return report, nil
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(total)) / 10
+}
+
+// FormatSimulationReport renders a simulation report as markdown
+func FormatSimulationReport(report *SimulationReport) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("**Sound change simulation** over %d texts (%d words)\n\n", report.Texts, report.Words))
+	out.WriteString("Rules:\n")
+	for i, rule := range report.Rules {
+		out.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, rule))
+	}
+	out.WriteString(fmt.Sprintf("\n- Words changed: %.1f%%\n- Homophony rate: %.1f%% of distinct words merged with another\n", report.ChangedWords, report.HomophonyRate))
+
+	if len(report.Shifts) > 0 {
+		out.WriteString("\n| Phoneme | Before | After | Shift |\n|---|---|---|---|\n")
+		for _, s := range report.Shifts {
+			out.WriteString(fmt.Sprintf("| /%s/ | %.1f%% | %.1f%% | %+.1f |\n", s.Phoneme, s.Before, s.After, s.After-s.Before))
+		}
+	}
+	if len(report.Examples) > 0 {
+		out.WriteString("\nExamples:\n")
+		for _, example := range report.Examples {
+			out.WriteString("- " + example + "\n")
+		}
+	}
+	if len(report.Mergers) > 0 {
+		out.WriteString("\nMergers:\n")
+		for _, merger := range report.Mergers {
+			out.WriteString("- " + merger + "\n")
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSoundChangesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"empty", "", "no sound change rules found"},
+		{"only comments", "# nothing yet\n\n   \n", "no sound change rules found"},
+		{"no arrow", "k tʃ / _i", "line 1: rule \"k tʃ / _i\" needs the form"},
+		{"no target", "> a", "has no target"},
+		{"class replacement", "a > V", "replacements must be phonemes"},
+		{"no target position", "k > g / V", "must mark the target position with _"},
+		{"two target positions", "k > g / V_ _V", "one target position"},
+		{"boundary inside", "k > g / V#_", "word boundary only at the start or end"},
+		{"two environments", "k > g / V_ / _V", "more than one > or /"},
+		{"line number", "a > e\n\nb >> c / _", "line 3: rule \"b >> c / _\" has more than one > or /"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSoundChanges(tt.src, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseSoundChanges(%q) error = %v, want it to contain %q", tt.src, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSoundChangeApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		word  string
+		want  string
+	}{
+		{"unconditioned", "p > f", "papa", "fafa"},
+		{"before a vowel", "k > tʃ / _i", "kiko", "tʃiko"},
+		{"between vowels", "t > d / V_V", "tata", "tada"},
+		{"final deletion", "e > Ø / VC_#", "kate", "kat"},
+		{"deletion with empty replacement", "h >", "aha", "aa"},
+		{"deletion with zero", "h > 0 / #_", "haha", "aha"},
+		{"word start only", "a > e / #_", "aka", "eka"},
+		{"word end only", "a > e / _#", "aka", "ake"},
+		{"whole word", "ka > ta / #_#", "ka", "ta"},
+		{"whole word only", "ka > ta / #_#", "kaka", "kaka"},
+		{"consonant class", "C > Ø / _#", "kanat", "kana"},
+		{"multi-segment target", "ai > e", "kaita", "keta"},
+		{"simultaneous", "a > b / _a", "aaa", "bba"},
+		{"ordered rules", "s > z / V_V\nz > r", "asa", "ara"},
+		{"counterfeeding order", "z > r\ns > z / V_V", "asa", "aza"},
+		{"case folded", "K > g", "Kaka", "gaga"},
+		{"spaces ignored", "k i > tʃ i", "kiki", "tʃitʃi"},
+		{"no match", "x > y", "kata", "kata"},
+		{"empty word", "a > e", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := parseSoundChanges(tt.rules, nil)
+			if err != nil {
+				t.Fatalf("parseSoundChanges: %v", err)
+			}
+			if got := strings.Join(sc.apply(sc.symbols(tt.word)), ""); got != tt.want {
+				t.Errorf("applying %q to %q = %q, want %q", tt.rules, tt.word, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// maxSuggestionDistance is the largest edit distance at which a known word is suggested
+const maxSuggestionDistance = 2
+
+// SpellcheckRequest represents a request to spellcheck conlang text
+type SpellcheckRequest struct {
+	Text string `json:"text" jsonschema:"description=The conlang text to check"`
+	File string `json:"file" jsonschema:"description=Path of a stored corpus file to check instead of text"`
+}
+
+// SpellingIssue is an unknown word form found by the spellchecker
+type SpellingIssue struct {
+	Word        string   `json:"word"`
+	Position    int      `json:"position"` // Index of the word in the text, starting at 0
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// SpellcheckResult represents the result of a spellcheck
+type SpellcheckResult struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Checked int             `json:"checked"`
+	Issues  []SpellingIssue `json:"issues,omitempty"`
+}
+
+// Spellcheck checks conlang text against the lexicon and its affixes
+func Spellcheck(ctx context.Context, req *SpellcheckRequest) (*SpellcheckResult, error) {
+	text := req.Text
+	if req.File != "" {
+		data, err := storage.ReadDataFile(req.File)
+		if err != nil {
+			return &SpellcheckResult{
+				Success: false,
+				Message: failure("read file", err),
+			}, nil
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return &SpellcheckResult{
+			Success: false,
+			Message: "Text or file is required for spellchecking",
+		}, nil
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return &SpellcheckResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	checker := newSpellchecker(entries)
+
+	words := TokenizeWords(text)
+	// Suggestions compare unknown words against the whole lexicon, so the
+	// words are checked on the worker pool
+	positions := make([]int, len(words))
+	for i := range positions {
+		positions[i] = i
+	}
+	found, err := parallelMap(ctx, positions, func(i int) *SpellingIssue {
+		if checker.known(words[i]) {
+			return nil
+		}
+		return &SpellingIssue{Word: words[i], Position: i, Suggestions: checker.suggest(words[i])}
+	})
+	if err != nil {
+		return &SpellcheckResult{
+			Success: false,
+			Message: "Spellcheck stopped: " + err.Error(),
+		}, nil
+	}
+	issues := []SpellingIssue{}
+	for _, issue := range found {
+		if issue != nil {
+			issues = append(issues, *issue)
+		}
+	}
+
+	return &SpellcheckResult{
+		Success: true,
+		Message: fmt.Sprintf("Checked %d words, found %d unknown forms", len(words), len(issues)),
+		Checked: len(words),
+		Issues:  issues,
+	}, nil
+}
+
+// spellchecker knows the lexicon's words plus the affixes and clitics that
+// can attach to them. They come from the affix store and from lexicon
+// entries written with boundary markers, such as "-ka" for a suffix, "ta-"
+// for a prefix or "=la" for an enclitic. Particles count as words.
+type spellchecker struct {
+	words      map[string]bool
+	prefixes   []string
+	suffixes   []string
+	proclitics []string
+	enclitics  []string
+}
+
+func newSpellchecker(entries []LexiconEntry) *spellchecker {
+	s := &spellchecker{words: map[string]bool{}}
+	forms := []string{}
+	for _, entry := range entries {
+		forms = append(forms, entry.Word)
+	}
+	// A missing or unreadable affix store leaves just the lexicon's affixes
+	affixes, _ := storage.ReadAffixes()
+	for _, affix := range affixes {
+		if affix.Kind == storage.ProcessKind {
+			continue
+		}
+		forms = append(forms, affix.Form)
+		for _, allomorph := range affix.Allomorphs {
+			forms = append(forms, allomorph.Form)
+		}
+	}
+	// Suppletive forms are words (or stems) of their own
+	suppletion, _ := storage.ReadSuppletion()
+	for _, f := range suppletion {
+		forms = append(forms, f.Form)
+	}
+	for _, form := range forms {
+		word := strings.ToLower(form)
+		body := strings.Trim(word, "-=")
+		switch attachment(word) {
+		case "suffix":
+			s.suffixes = append(s.suffixes, body)
+		case "prefix":
+			s.prefixes = append(s.prefixes, body)
+		case "enclitic":
+			s.enclitics = append(s.enclitics, body)
+		case "proclitic":
+			s.proclitics = append(s.proclitics, body)
+		default:
+			s.words[word] = true
+		}
+	}
+	return s
+}
+
+// known reports whether a word is in the lexicon, possibly with affixes and clitics stripped
+func (s *spellchecker) known(word string) bool {
+	_, ok := s.segment(word)
+	return ok
+}
+
+// segment splits a known word into its root, affixes and clitics, marking
+// affix boundaries with - and clitic boundaries with =
+func (s *spellchecker) segment(word string) (string, bool) {
+	return s.segmentDepth(strings.ToLower(word), 4)
+}
+
+func (s *spellchecker) segmentDepth(word string, depth int) (string, bool) {
+	if s.words[word] {
+		return word, true
+	}
+	if depth == 0 {
+		return "", false
+	}
+	for _, bound := range []struct {
+		forms     []string
+		prefix    bool
+		separator string
+	}{
+		{s.enclitics, false, "="},
+		{s.proclitics, true, "="},
+		{s.suffixes, false, "-"},
+		{s.prefixes, true, "-"},
+	} {
+		for _, form := range bound.forms {
+			if bound.prefix {
+				if stem, ok := strings.CutPrefix(word, form); ok && stem != "" {
+					if segmented, ok := s.segmentDepth(stem, depth-1); ok {
+						return form + bound.separator + segmented, true
+					}
+				}
+			} else if stem, ok := strings.CutSuffix(word, form); ok && stem != "" {
+				if segmented, ok := s.segmentDepth(stem, depth-1); ok {
+					return segmented + bound.separator + form, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// suggest returns up to three known words closest to the given word
+func (s *spellchecker) suggest(word string) []string {
+	type candidate struct {
+		word     string
+		distance int
+	}
+	word = strings.ToLower(word)
+	candidates := []candidate{}
+	for known := range s.words {
+		if d := editDistance(word, known); d <= maxSuggestionDistance {
+			candidates = append(candidates, candidate{known, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].word < candidates[j].word
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		suggestions = append(suggestions, candidates[i].word)
+	}
+	return suggestions
+}
+
+// TokenizeWords splits text into lowercase words, keeping letters, combining
+// marks and apostrophes so IPA and diacritics survive
+func TokenizeWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && r != '\''
+	})
+}
+
+// editDistance computes the Levenshtein distance between two strings by rune
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
+
+// createSpellcheckTool creates the spellcheck tool
+func createSpellcheckTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"spellcheck",
+		"Spellcheck conlang text or a stored corpus file against the lexicon, including words formed with prefixes (ta-), suffixes (-ka) and clitics (=la) from the lexicon or affix store. Flags unknown forms and suggests the nearest known words.",
+		Spellcheck,
+	)
+}
@@ -0,0 +1,413 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// stressRules are the fixed stress positions, plus lexical stress marked per word
+var stressRules = []string{"initial", "final", "penultimate", "antepenultimate", "lexical"}
+
+// SetStressRulesRequest represents a request to declare the stress rules
+type SetStressRulesRequest struct {
+	Position        string                    `json:"position" jsonschema:"required,description=Where stress falls: initial, final, penultimate, antepenultimate, or lexical when it is marked per word"`
+	Weight          *storage.StressWeight     `json:"weight,omitempty" jsonschema:"description=Makes stress weight-sensitive; omit for purely positional stress"`
+	Exceptions      []storage.StressException `json:"exceptions,omitempty" jsonschema:"description=Words whose stress the rules don't predict"`
+	MergeExceptions bool                      `json:"merge_exceptions" jsonschema:"description=Add the exceptions to the declared ones instead of replacing them"`
+}
+
+// AssignStressRequest represents a request to mark stress by the rules
+type AssignStressRequest struct {
+	Words        []string `json:"words" jsonschema:"description=Words in IPA or romanization to mark stress on"`
+	CheckLexicon bool     `json:"check_lexicon" jsonschema:"description=Check the stress recorded in the IPA of lexicon entries against the rules"`
+}
+
+// StressedWord is a word with stress marked by the rules
+type StressedWord struct {
+	Word     string `json:"word"`
+	Stressed string `json:"stressed"`           // Syllabified with ˈ before the stressed syllable
+	Syllable int    `json:"syllable,omitempty"` // Stressed syllable counting from 1
+	Reason   string `json:"reason,omitempty"`
+}
+
+// StressConflict is a lexicon entry whose recorded stress the rules don't predict
+type StressConflict struct {
+	Word      string `json:"word"`
+	IPA       string `json:"ipa"`
+	Recorded  int    `json:"recorded"`
+	Expected  int    `json:"expected"`
+	Reason    string `json:"reason"`
+	Suggested string `json:"suggested"`
+}
+
+// AssignStressResult represents stress marked on words and lexicon conflicts
+type AssignStressResult struct {
+	Success   bool             `json:"success"`
+	Message   string           `json:"message"`
+	Rules     string           `json:"rules,omitempty"`
+	Words     []StressedWord   `json:"words,omitempty"`
+	Conflicts []StressConflict `json:"conflicts,omitempty"`
+	Warnings  []string         `json:"warnings,omitempty"`
+}
+
+// SetStressRules declares the stress position, weight sensitivity and
+// lexical exceptions with the other suprasegmentals
+func SetStressRules(ctx context.Context, req *SetStressRulesRequest) (*InventoryResult, error) {
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	supra := inventory.Suprasegmentals
+	supra.Stress = req.Position
+	supra.StressWeight = req.Weight
+	if !req.MergeExceptions {
+		supra.StressExceptions = nil
+	}
+	for _, e := range req.Exceptions {
+		supra.StressExceptions = slices.DeleteFunc(supra.StressExceptions, func(old storage.StressException) bool {
+			return strings.EqualFold(old.Word, e.Word)
+		})
+		supra.StressExceptions = append(supra.StressExceptions, e)
+	}
+	normalizeStressRules(&supra)
+	if err := validateSuprasegmentals(supra); err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: "Invalid stress rules: " + err.Error(),
+		}, nil
+	}
+
+	inventory.Suprasegmentals = supra
+	if err := storage.WriteInventory(inventory); err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: failure("save stress rules", err),
+		}, nil
+	}
+	return &InventoryResult{
+		Success:   true,
+		Message:   fmt.Sprintf("Saved stress rules: %s, with %d exceptions", describeStress(supra), len(supra.StressExceptions)),
+		Inventory: &inventory,
+		Table:     renderSuprasegmentals(supra),
+	}, nil
+}
+
+// AssignStress marks stress on words by the declared rules and checks the
+// stress recorded in the lexicon against them
+func AssignStress(ctx context.Context, req *AssignStressRequest) (*AssignStressResult, error) {
+	if len(req.Words) == 0 && !req.CheckLexicon {
+		return &AssignStressResult{
+			Success: false,
+			Message: "Words to stress or check_lexicon is required",
+		}, nil
+	}
+	template, supra, err := loadProsody()
+	if err != nil {
+		return &AssignStressResult{
+			Success: false,
+			Message: failure("read phonology", err),
+		}, nil
+	}
+	if supra.Stress == "" && supra.StressWeight == nil && len(supra.StressExceptions) == 0 {
+		return &AssignStressResult{
+			Success: false,
+			Message: "No stress rules have been declared; declare them with set_stress_rules first",
+		}, nil
+	}
+	ts := newToneSet(supra)
+
+	result := &AssignStressResult{Success: true, Rules: describeStress(supra)}
+	for _, word := range req.Words {
+		for _, token := range prosodicTokens(word) {
+			_, prosodic, _ := prosodify(stripStress(token), template, supra, ts)
+			position, reason := predictStress(supra, prosodic.Syllables, token, prosodic.Word)
+			stressed := StressedWord{Word: token, Stressed: renderStressed(prosodic.Syllables), Syllable: position + 1, Reason: reason}
+			if position < 0 {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: the rules don't predict its stress; mark it with ˈ or add an exception", token))
+			}
+			result.Words = append(result.Words, stressed)
+		}
+	}
+
+	checked := 0
+	if req.CheckLexicon {
+		entries, err := loadLexicon()
+		if err != nil {
+			return &AssignStressResult{
+				Success: false,
+				Message: failure("read lexicon", err),
+			}, nil
+		}
+		// Entries with recorded stress are checked on the worker pool; a nil
+		// conflict with checked set means the recorded stress is right
+		type stressCheck struct {
+			checked  bool
+			conflict *StressConflict
+		}
+		checks, err := parallelMap(ctx, entries, func(entry LexiconEntry) stressCheck {
+			// Syllable dots are dropped since words are syllabified afresh
+			tokens := prosodicTokens(strings.ReplaceAll(entry.IPA, ".", ""))
+			if len(tokens) != 1 || !strings.ContainsRune(entry.IPA, primaryStress) {
+				return stressCheck{}
+			}
+			_, prosodic, _ := prosodify(tokens[0], template, supra, ts)
+			recorded := slices.IndexFunc(prosodic.Syllables, func(s ProsodicSyllable) bool { return s.Stress == "primary" })
+			expected, reason := predictStress(supra, prosodic.Syllables, entry.Word, stripStress(tokens[0]))
+			if expected < 0 || recorded == expected {
+				return stressCheck{checked: true}
+			}
+			suggested := slices.Clone(prosodic.Syllables)
+			for i := range suggested {
+				suggested[i].Stress = ""
+			}
+			suggested[expected].Stress = "primary"
+			return stressCheck{checked: true, conflict: &StressConflict{
+				Word:      entry.Word,
+				IPA:       entry.IPA,
+				Recorded:  recorded + 1,
+				Expected:  expected + 1,
+				Reason:    reason,
+				Suggested: renderStressed(suggested),
+			}}
+		})
+		if err != nil {
+			return &AssignStressResult{
+				Success: false,
+				Message: "Stress check stopped: " + err.Error(),
+			}, nil
+		}
+		for _, check := range checks {
+			if check.checked {
+				checked++
+			}
+			if check.conflict != nil {
+				result.Conflicts = append(result.Conflicts, *check.conflict)
+			}
+		}
+	}
+
+	result.Message = fmt.Sprintf("Marked stress on %d words", len(result.Words))
+	if req.CheckLexicon {
+		result.Message += fmt.Sprintf("; %d of %d lexicon entries with recorded stress conflict with the rules", len(result.Conflicts), checked)
+	}
+	return result, nil
+}
+
+// loadProsody reads the syllable template, phoneme inventory and
+// suprasegmentals the prosody tools work from
+func loadProsody() (*syllableTemplate, storage.Suprasegmentals, error) {
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return nil, storage.Suprasegmentals{}, err
+	}
+	phonotactics, err := storage.ReadPhonotactics()
+	if err != nil {
+		return nil, storage.Suprasegmentals{}, err
+	}
+	template, err := parseSyllableTemplate(phonotactics.Template, phonotactics.Onsets)
+	if err != nil {
+		return nil, storage.Suprasegmentals{}, err
+	}
+	template.phonemes = newPhonemeSet(inventory)
+	return template, inventory.Suprasegmentals, nil
+}
+
+// predictStress returns the syllable the rules stress and why, or -1 when
+// stress is lexical and the word is no exception. Exceptions are matched
+// against any of the word's names, such as its spelling and its IPA.
+func predictStress(supra storage.Suprasegmentals, syllables []ProsodicSyllable, names ...string) (int, string) {
+	n := len(syllables)
+	if n == 0 {
+		return -1, ""
+	}
+	for _, e := range supra.StressExceptions {
+		for _, name := range names {
+			if strings.EqualFold(e.Word, name) && e.Syllable <= n {
+				return e.Syllable - 1, "the lexical exception"
+			}
+		}
+	}
+	if w := supra.StressWeight; w != nil {
+		// Syllables are tried from the edge inwards
+		order := make([]int, n)
+		for i := range order {
+			order[i] = n - 1 - i
+			if w.Edge == "left" {
+				order[i] = i
+			}
+		}
+		if w.Extrametrical && n > 1 {
+			order = order[1:]
+		}
+		if w.Window > 0 && len(order) > w.Window {
+			order = order[:w.Window]
+		}
+		for _, i := range order {
+			if syllables[i].Heavy {
+				return i, "the heavy syllable rule"
+			}
+		}
+	}
+	if position := stressPosition(supra.Stress, n); position >= 0 {
+		return position, supra.Stress + " stress"
+	}
+	return -1, ""
+}
+
+// stressPosition returns the syllable a fixed stress rule stresses, or -1
+// for lexical or undeclared stress
+func stressPosition(rule string, syllables int) int {
+	if syllables == 0 {
+		return -1
+	}
+	switch rule {
+	case "initial":
+		return 0
+	case "final":
+		return syllables - 1
+	case "penultimate":
+		return max(syllables-2, 0)
+	case "antepenultimate":
+		return max(syllables-3, 0)
+	}
+	return -1
+}
+
+// stressPredictable reports whether the rules fix stress, so it need not be written
+func stressPredictable(supra storage.Suprasegmentals) bool {
+	return stressPosition(supra.Stress, 1) >= 0
+}
+
+// heavySyllable reports whether a syllable is heavy: closed by a consonant,
+// long by a length mark or a second vowel in the nucleus, or either
+func heavySyllable(s ProsodicSyllable, set *phonemeSet, kind string) bool {
+	segs := set.segment(s.Syllable)
+	closed := len(segs) > 0 && !set.vowel(segs[len(segs)-1])
+	vowels := 0
+	for _, seg := range segs {
+		if set.vowel(seg) {
+			vowels++
+		}
+	}
+	long := s.Long || vowels > 1
+	switch kind {
+	case "closed":
+		return closed
+	case "long":
+		return long
+	}
+	return closed || long
+}
+
+// renderStressed writes syllables separated by dots with stress marks
+// before the stressed ones, as in ka.ˈta.ma
+func renderStressed(syllables []ProsodicSyllable) string {
+	out := make([]string, len(syllables))
+	for i, s := range syllables {
+		switch s.Stress {
+		case "primary":
+			out[i] = string(primaryStress)
+		case "secondary":
+			out[i] = string(secondaryStress)
+		}
+		out[i] += s.Syllable
+	}
+	return strings.Join(out, ".")
+}
+
+func stripStress(word string) string {
+	return strings.NewReplacer(string(primaryStress), "", string(secondaryStress), "").Replace(word)
+}
+
+// normalizeStressRules cleans up the spelling of declared stress rules
+func normalizeStressRules(supra *storage.Suprasegmentals) {
+	supra.Stress = strings.ToLower(strings.TrimSpace(supra.Stress))
+	if w := supra.StressWeight; w != nil {
+		w.Heavy = strings.ToLower(strings.TrimSpace(w.Heavy))
+		w.Edge = strings.ToLower(strings.TrimSpace(w.Edge))
+	}
+	for i, e := range supra.StressExceptions {
+		supra.StressExceptions[i].Word = strings.ToLower(strings.TrimSpace(stripStress(e.Word)))
+	}
+}
+
+// validateStressRules rejects unknown stress positions and weight settings
+func validateStressRules(supra storage.Suprasegmentals) error {
+	if supra.Stress != "" && !slices.Contains(stressRules, supra.Stress) {
+		return fmt.Errorf("stress must be one of %s", strings.Join(stressRules, ", "))
+	}
+	if w := supra.StressWeight; w != nil {
+		if w.Heavy != "" && w.Heavy != "closed" && w.Heavy != "long" && w.Heavy != "either" {
+			return fmt.Errorf("heavy syllables must be closed, long or either")
+		}
+		if w.Edge != "" && w.Edge != "left" && w.Edge != "right" {
+			return fmt.Errorf("the stress edge must be left or right")
+		}
+		if w.Window < 0 {
+			return fmt.Errorf("the stress window can't be negative")
+		}
+		if stressPosition(supra.Stress, 1) < 0 {
+			return fmt.Errorf("weight-sensitive stress needs a fixed position for words without a heavy syllable")
+		}
+	}
+	for _, e := range supra.StressExceptions {
+		if e.Word == "" {
+			return fmt.Errorf("stress exceptions need a word")
+		}
+		if e.Syllable < 1 {
+			return fmt.Errorf("the stressed syllable of %s must be counted from 1", e.Word)
+		}
+	}
+	return nil
+}
+
+// describeStress writes the stress rules as a sentence fragment, such as
+// "antepenultimate, or the nearest heavy (closed or long) syllable within 1
+// of the right edge, not counting the edge syllable"
+func describeStress(supra storage.Suprasegmentals) string {
+	description := orDefault(supra.Stress, "unspecified")
+	w := supra.StressWeight
+	if w == nil {
+		return description
+	}
+	heavy := orDefault(w.Heavy, "closed or long")
+	if heavy == "either" {
+		heavy = "closed or long"
+	}
+	description += fmt.Sprintf(", or the nearest heavy (%s) syllable", heavy)
+	if w.Window > 0 {
+		description += fmt.Sprintf(" within %d", w.Window)
+	}
+	description += fmt.Sprintf(" of the %s edge", orDefault(w.Edge, "right"))
+	if w.Extrametrical {
+		description += ", not counting the edge syllable"
+	}
+	return description
+}
+
+// createSetStressRulesTool creates the stress rule declaration tool
+func createSetStressRulesTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_stress_rules",
+		"Declare the stress rules: a fixed position (initial, final, penultimate, antepenultimate) or lexical stress, optional weight sensitivity (which syllables are heavy, the edge and window a heavy syllable attracts stress from, extrametricality) and lexical exceptions. Latin stress is antepenultimate with weight {heavy: either, edge: right, window: 1, extrametrical: true}.",
+		SetStressRules,
+	)
+}
+
+// createAssignStressTool creates the stress assignment tool
+func createAssignStressTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"assign_stress",
+		"Mark stress on words by the declared stress rules, explaining which rule placed it, and flag lexicon entries whose IPA records stress on a different syllable than the rules predict, with the corrected transcription.",
+		AssignStress,
+	)
+}
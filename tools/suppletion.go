@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// SuppletionRequest represents a request to register the suppletive forms of a lexeme
+type SuppletionRequest struct {
+	Lexeme string                   `json:"lexeme" jsonschema:"required,description=Citation form of the lexeme such as go"`
+	Forms  []storage.SuppletiveForm `json:"forms" jsonschema:"description=The lexeme's suppletive forms; replaces any registered before. Leave empty to remove them all"`
+}
+
+// SuppletionReportRequest represents a request to list suppletive forms
+type SuppletionReportRequest struct {
+	// Empty struct for consistency with other tools
+}
+
+// SuppletionResult represents the result of suppletion operations
+type SuppletionResult struct {
+	Success  bool                     `json:"success"`
+	Message  string                   `json:"message"`
+	Forms    []storage.SuppletiveForm `json:"forms,omitempty"`
+	Report   string                   `json:"report,omitempty"`
+	Warnings []string                 `json:"warnings,omitempty"`
+}
+
+// SetSuppletion replaces the suppletive forms registered for a lexeme
+func SetSuppletion(ctx context.Context, req *SuppletionRequest) (*SuppletionResult, error) {
+	if req.Lexeme == "" {
+		return &SuppletionResult{
+			Success: false,
+			Message: "Lexeme is required",
+		}, nil
+	}
+	for i := range req.Forms {
+		req.Forms[i].Lexeme = req.Lexeme
+		if len(req.Forms[i].Cell) == 0 || req.Forms[i].Form == "" {
+			return &SuppletionResult{
+				Success: false,
+				Message: "Every suppletive form needs a form and the cell labels it fills",
+			}, nil
+		}
+	}
+
+	current, err := storage.ReadSuppletion()
+	if err != nil {
+		return &SuppletionResult{
+			Success: false,
+			Message: failure("read suppletive forms", err),
+		}, nil
+	}
+	forms := []storage.SuppletiveForm{}
+	for _, f := range current {
+		if !strings.EqualFold(f.Lexeme, req.Lexeme) {
+			forms = append(forms, f)
+		}
+	}
+	forms = append(forms, req.Forms...)
+
+	if err := storage.WriteSuppletion(forms); err != nil {
+		return &SuppletionResult{
+			Success: false,
+			Message: failure("save suppletive forms", err),
+		}, nil
+	}
+	return &SuppletionResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Registered %d suppletive forms of %s", len(req.Forms), req.Lexeme),
+		Forms:    req.Forms,
+		Warnings: budgetWarnings(),
+	}, nil
+}
+
+// SuppletionReport lists every registered suppletive form by lexeme
+func SuppletionReport(ctx context.Context, req *SuppletionReportRequest) (*SuppletionResult, error) {
+	forms, err := storage.ReadSuppletion()
+	if err != nil {
+		return &SuppletionResult{
+			Success: false,
+			Message: failure("read suppletive forms", err),
+		}, nil
+	}
+	if len(forms) == 0 {
+		return &SuppletionResult{
+			Success: true,
+			Message: "No suppletive forms registered yet",
+		}, nil
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return &SuppletionResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+	known := map[string]bool{}
+	for _, entry := range entries {
+		known[strings.ToLower(entry.Word)] = true
+	}
+
+	sort.SliceStable(forms, func(i, j int) bool {
+		return strings.ToLower(forms[i].Lexeme) < strings.ToLower(forms[j].Lexeme)
+	})
+	lexemes := map[string]bool{}
+	var report strings.Builder
+	report.WriteString("| Lexeme | Cell | Form | Type |\n|---|---|---|---|\n")
+	for _, f := range forms {
+		lexeme := f.Lexeme
+		if !known[strings.ToLower(f.Lexeme)] {
+			lexeme += " (not in lexicon)"
+		}
+		kind := "word"
+		if f.Stem {
+			kind = "stem"
+		}
+		report.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", lexeme, strings.Join(f.Cell, "."), f.Form, kind))
+		lexemes[strings.ToLower(f.Lexeme)] = true
+	}
+
+	return &SuppletionResult{
+		Success: true,
+		Message: fmt.Sprintf("%d suppletive forms across %d lexemes", len(forms), len(lexemes)),
+		Forms:   forms,
+		Report:  report.String(),
+	}, nil
+}
+
+// suppletiveFor returns the most specific suppletive word and stem of a
+// lexeme whose cell labels are all among the given labels
+func suppletiveFor(forms []storage.SuppletiveForm, lexeme string, labels []string) (full, stem *storage.SuppletiveForm) {
+	has := map[string]bool{}
+	for _, label := range labels {
+		has[strings.ToLower(label)] = true
+	}
+	for i := range forms {
+		f := &forms[i]
+		if !strings.EqualFold(f.Lexeme, lexeme) {
+			continue
+		}
+		matches := true
+		for _, label := range f.Cell {
+			if !has[strings.ToLower(label)] {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+		if f.Stem {
+			if stem == nil || len(f.Cell) > len(stem.Cell) {
+				stem = f
+			}
+		} else if full == nil || len(f.Cell) > len(full.Cell) {
+			full = f
+		}
+	}
+	return full, stem
+}
+
+// createSetSuppletionTool creates the tool that registers suppletive forms
+func createSetSuppletionTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_suppletion",
+		"Register the suppletive forms of a lexeme (like went for go) for the paradigm cells they fill. generate_paradigm uses them instead of the regular affixes. A suppletive stem still takes the affixes of the other categories.",
+		SetSuppletion,
+	)
+}
+
+// createSuppletionReportTool creates the suppletion report tool
+func createSuppletionReportTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"suppletion_report",
+		"List every registered suppletive form by lexeme and paradigm cell.",
+		SuppletionReport,
+	)
+}
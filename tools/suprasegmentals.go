@@ -0,0 +1,683 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"l2/storage"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// DefaultToneSandhiFile is the data file tone sandhi rules are read from
+const DefaultToneSandhiFile = "tone_sandhi.txt"
+
+// Stress marks precede the syllable they stress
+const (
+	primaryStress   = 'ˈ'
+	secondaryStress = 'ˌ'
+)
+
+// chaoLetters are the tone letters from ˥ (5, highest) to ˩ (1, lowest).
+// They always write tone; undeclared sequences are named by their digits,
+// so ˨˩˦ is tone 214.
+var chaoLetters = map[rune]rune{'˥': '5', '˦': '4', '˧': '3', '˨': '2', '˩': '1'}
+
+// precomposedTones splits vowels and syllabic nasals written with a tone
+// diacritic as one character, so á matches a tone declared as ◌́
+var precomposedTones = map[rune][2]rune{
+	'á': {'a', '́'}, 'à': {'a', '̀'}, 'â': {'a', '̂'}, 'ǎ': {'a', '̌'}, 'ā': {'a', '̄'},
+	'é': {'e', '́'}, 'è': {'e', '̀'}, 'ê': {'e', '̂'}, 'ě': {'e', '̌'}, 'ē': {'e', '̄'},
+	'í': {'i', '́'}, 'ì': {'i', '̀'}, 'î': {'i', '̂'}, 'ǐ': {'i', '̌'}, 'ī': {'i', '̄'},
+	'ó': {'o', '́'}, 'ò': {'o', '̀'}, 'ô': {'o', '̂'}, 'ǒ': {'o', '̌'}, 'ō': {'o', '̄'},
+	'ú': {'u', '́'}, 'ù': {'u', '̀'}, 'û': {'u', '̂'}, 'ǔ': {'u', '̌'}, 'ū': {'u', '̄'},
+	'ń': {'n', '́'}, 'ǹ': {'n', '̀'}, 'ḿ': {'m', '́'},
+}
+
+// ProsodicSyllable is a syllable with its tone, stress and length
+type ProsodicSyllable struct {
+	Syllable    string `json:"syllable"`
+	Tone        string `json:"tone,omitempty"`
+	DefaultTone bool   `json:"default_tone,omitempty"` // Unmarked, so it has the default tone
+	Stress      string `json:"stress,omitempty"`       // primary or secondary
+	Long        bool   `json:"long,omitempty"`
+	Heavy       bool   `json:"heavy,omitempty"` // Only reported when stress is weight-sensitive
+}
+
+// ProsodicWord is a word's syllables with their suprasegmentals
+type ProsodicWord struct {
+	Word      string             `json:"word"`
+	Syllables []ProsodicSyllable `json:"syllables"`
+}
+
+// ApplyToneSandhiRequest represents a request to apply tone sandhi rules
+type ApplyToneSandhiRequest struct {
+	Text      string `json:"text" jsonschema:"required,description=Phrase written with tone marks such as ma˨˩˦ ma˨˩˦ or ákà bá"`
+	RulesFile string `json:"rules_file" jsonschema:"description=Data file with one sandhi rule per line (defaults to tone_sandhi.txt)"`
+}
+
+// SandhiSyllable is a syllable's tone before and after sandhi
+type SandhiSyllable struct {
+	Syllable   string `json:"syllable"`
+	Underlying string `json:"underlying,omitempty"`
+	Surface    string `json:"surface,omitempty"`
+}
+
+// ApplyToneSandhiResult represents a phrase after tone sandhi
+type ApplyToneSandhiResult struct {
+	Success    bool             `json:"success"`
+	Message    string           `json:"message"`
+	Underlying string           `json:"underlying,omitempty"` // Tones of the input, words separated by |
+	Surface    string           `json:"surface,omitempty"`    // Tones after sandhi
+	Text       string           `json:"text,omitempty"`       // The phrase rewritten with the surface tones
+	Syllables  []SandhiSyllable `json:"syllables,omitempty"`
+	Applied    []string         `json:"applied,omitempty"`
+	Warnings   []string         `json:"warnings,omitempty"`
+}
+
+// SetSuprasegmentals declares the tones, stress rule and length status
+// stored with the phoneme inventory
+func SetSuprasegmentals(ctx context.Context, req *storage.Suprasegmentals) (*InventoryResult, error) {
+	supra := *req
+	supra.Length = strings.ToLower(strings.TrimSpace(supra.Length))
+	supra.DefaultTone = strings.TrimSpace(supra.DefaultTone)
+	normalizeStressRules(&supra)
+	for i := range supra.Tones {
+		supra.Tones[i].Name = strings.TrimSpace(supra.Tones[i].Name)
+	}
+	if err := validateSuprasegmentals(supra); err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: "Invalid suprasegmentals: " + err.Error(),
+		}, nil
+	}
+
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: failure("read phoneme inventory", err),
+		}, nil
+	}
+	if supra.StressWeight == nil {
+		supra.StressWeight = inventory.Suprasegmentals.StressWeight
+	}
+	if supra.StressExceptions == nil {
+		supra.StressExceptions = inventory.Suprasegmentals.StressExceptions
+	}
+	inventory.Suprasegmentals = supra
+	if err := storage.WriteInventory(inventory); err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: failure("save suprasegmentals", err),
+		}, nil
+	}
+
+	return &InventoryResult{
+		Success:   true,
+		Message:   fmt.Sprintf("Saved %d tones, %s stress and %s length", len(supra.Tones), orDefault(supra.Stress, "unspecified"), orDefault(supra.Length, "unspecified")),
+		Inventory: &inventory,
+		Table:     renderInventoryTable(inventory),
+	}, nil
+}
+
+// validateSuprasegmentals rejects tones that can't be told apart and
+// unknown stress or length settings
+func validateSuprasegmentals(supra storage.Suprasegmentals) error {
+	names := map[string]bool{}
+	marks := map[string]string{}
+	for _, tone := range supra.Tones {
+		if tone.Name == "" {
+			return fmt.Errorf("tone name is required")
+		}
+		if strings.ContainsAny(tone.Name, " #>/_|") {
+			return fmt.Errorf("tone name %q may not contain spaces or any of # > / _ |", tone.Name)
+		}
+		if names[tone.Name] {
+			return fmt.Errorf("tone %s is listed more than once", tone.Name)
+		}
+		names[tone.Name] = true
+		if len(tone.Marks) == 0 {
+			return fmt.Errorf("tone %s needs at least one mark", tone.Name)
+		}
+		for _, mark := range tone.Marks {
+			key := toneKey(mark)
+			if key == "" {
+				return fmt.Errorf("tone %s: %q has no tone diacritic or tone letter", tone.Name, mark)
+			}
+			if other, ok := marks[key]; ok && other != tone.Name {
+				return fmt.Errorf("tones %s and %s are both written %q", other, tone.Name, mark)
+			}
+			marks[key] = tone.Name
+		}
+	}
+	if supra.DefaultTone != "" && !names[supra.DefaultTone] {
+		return fmt.Errorf("default tone %s is not one of the declared tones", supra.DefaultTone)
+	}
+	if err := validateStressRules(supra); err != nil {
+		return err
+	}
+	if supra.Length != "" && supra.Length != "contrastive" && supra.Length != "allophonic" {
+		return fmt.Errorf("length must be contrastive or allophonic")
+	}
+	return nil
+}
+
+// toneKey reduces a written tone mark to its diacritics and tone letters,
+// dropping the vowel it was written on
+func toneKey(mark string) string {
+	var key strings.Builder
+	for _, r := range mark {
+		if d, ok := precomposedTones[r]; ok {
+			r = d[1]
+		}
+		if _, ok := chaoLetters[r]; ok || unicode.IsMark(r) {
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
+
+// toneSet maps the declared tones to and from the way they are written
+type toneSet struct {
+	names       map[string]string // Mark to tone name
+	spellings   map[string]string // Tone name to its first mark
+	marks       map[rune]bool     // Diacritics that write tone
+	defaultTone string
+}
+
+func newToneSet(supra storage.Suprasegmentals) *toneSet {
+	ts := &toneSet{names: map[string]string{}, spellings: map[string]string{}, marks: map[rune]bool{}, defaultTone: supra.DefaultTone}
+	for _, tone := range supra.Tones {
+		for _, mark := range tone.Marks {
+			key := toneKey(mark)
+			ts.names[key] = tone.Name
+			if _, ok := ts.spellings[tone.Name]; !ok {
+				ts.spellings[tone.Name] = key
+			}
+			for _, r := range key {
+				ts.marks[r] = true
+			}
+		}
+	}
+	return ts
+}
+
+// isToneMark reports whether r writes tone
+func (ts *toneSet) isToneMark(r rune) bool {
+	_, chao := chaoLetters[r]
+	return chao || ts.marks[r]
+}
+
+// name returns the tone a mark writes, naming undeclared tone letters by
+// their digits
+func (ts *toneSet) name(key string) (string, bool) {
+	if name, ok := ts.names[key]; ok {
+		return name, true
+	}
+	digits := strings.Map(func(r rune) rune { return chaoLetters[r] }, key)
+	if !strings.ContainsRune(digits, 0) {
+		return digits, len(ts.names) == 0
+	}
+	return key, false
+}
+
+// known reports whether a tone name can be written
+func (ts *toneSet) known(name string) bool {
+	_, ok := ts.spellings[name]
+	return ok || (len(ts.spellings) == 0 && chaoDigits(name))
+}
+
+// spelling returns the mark a tone is written with
+func (ts *toneSet) spelling(name string) string {
+	if key, ok := ts.spellings[name]; ok {
+		return key
+	}
+	if !chaoDigits(name) {
+		return ""
+	}
+	letters := map[rune]rune{}
+	for letter, digit := range chaoLetters {
+		letters[digit] = letter
+	}
+	return strings.Map(func(r rune) rune { return letters[r] }, name)
+}
+
+func chaoDigits(name string) bool {
+	return name != "" && strings.Trim(name, "12345") == ""
+}
+
+// writeTone writes a syllable with a tone: diacritics go on the first vowel
+// (or the first letter of a syllabic consonant) and tone letters after it
+func (ts *toneSet) writeTone(syllable, tone string) string {
+	key := ts.spelling(tone)
+	if key == "" {
+		return syllable
+	}
+	if _, ok := chaoLetters[[]rune(key)[0]]; ok {
+		return syllable + key
+	}
+	runes := []rune(syllable)
+	at := 0
+	for i, r := range runes {
+		if isVowel(r) {
+			at = i
+			break
+		}
+	}
+	return string(runes[:at+1]) + key + string(runes[at+1:])
+}
+
+// prosodyMarks are the suprasegmental marks of a word, indexed by the rune
+// of the bare word they belong to
+type prosodyMarks struct {
+	bare   string
+	tones  map[int]string // Tone marks, on the rune they follow
+	stress map[int]string // Stress, on the rune it precedes
+	long   map[int]bool   // Length, on the length mark itself
+}
+
+// splitProsody strips tone and stress marks from a word, recording where
+// they were. Length marks stay in the bare word since long segments may be
+// phonemes of their own.
+func splitProsody(word string, ts *toneSet) prosodyMarks {
+	p := prosodyMarks{tones: map[int]string{}, stress: map[int]string{}, long: map[int]bool{}}
+	runes := []rune{}
+	for _, r := range word {
+		if d, ok := precomposedTones[r]; ok && ts.marks[d[1]] {
+			runes = append(runes, d[0], d[1])
+			continue
+		}
+		runes = append(runes, r)
+	}
+
+	bare := []rune{}
+	pending := ""
+	for _, r := range runes {
+		switch {
+		case r == primaryStress:
+			pending = "primary"
+		case r == secondaryStress:
+			pending = "secondary"
+		case ts.isToneMark(r):
+			if len(bare) > 0 {
+				p.tones[len(bare)-1] += string(r)
+			}
+		default:
+			if r == 'ː' || r == 'ˑ' {
+				p.long[len(bare)] = true
+			}
+			if pending != "" {
+				p.stress[len(bare)] = pending
+				pending = ""
+			}
+			bare = append(bare, r)
+		}
+	}
+	p.bare = string(bare)
+	return p
+}
+
+// prosodicTokens splits text into words, keeping tone letters and stress
+// marks that TokenizeWords would treat as separators
+func prosodicTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		_, chao := chaoLetters[r]
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && r != '\'' && !chao
+	})
+}
+
+// prosodify syllabifies a word written with suprasegmental marks and
+// assigns each syllable its tone, stress and length. Problems such as
+// undeclared tones or stress off the declared position are returned as
+// warnings.
+func prosodify(word string, template *syllableTemplate, supra storage.Suprasegmentals, ts *toneSet) (SyllabifiedWord, ProsodicWord, []string) {
+	p := splitProsody(word, ts)
+	w := template.syllabify(p.bare)
+	prosodic := ProsodicWord{Word: word, Syllables: []ProsodicSyllable{}}
+	warnings := []string{}
+
+	start := 0
+	stressed := -1
+	for i, syllable := range w.Syllables {
+		end := start + len([]rune(syllable))
+		s := ProsodicSyllable{Syllable: syllable}
+		key := ""
+		for j := start; j < end; j++ {
+			key += p.tones[j]
+			if p.long[j] {
+				s.Long = true
+			}
+			if stress, ok := p.stress[j]; ok {
+				s.Stress = stress
+			}
+		}
+		if s.Stress == "primary" && stressed < 0 {
+			stressed = i
+		}
+		switch {
+		case key != "":
+			name, declared := ts.name(key)
+			if !declared {
+				warnings = append(warnings, fmt.Sprintf("%s: syllable %s has the undeclared tone mark %q", word, syllable, key))
+			}
+			s.Tone = name
+		case ts.defaultTone != "":
+			s.Tone, s.DefaultTone = ts.defaultTone, true
+		case len(supra.Tones) > 0:
+			warnings = append(warnings, fmt.Sprintf("%s: syllable %s has no tone and no default tone is declared", word, syllable))
+		}
+		prosodic.Syllables = append(prosodic.Syllables, s)
+		start = end
+	}
+
+	if supra.StressWeight != nil {
+		for i := range prosodic.Syllables {
+			prosodic.Syllables[i].Heavy = heavySyllable(prosodic.Syllables[i], template.phonemes, supra.StressWeight.Heavy)
+		}
+	}
+
+	// Predictable stress is filled in where unmarked and checked where marked
+	if position, reason := predictStress(supra, prosodic.Syllables, p.bare, word); position >= 0 {
+		if stressed < 0 {
+			prosodic.Syllables[position].Stress = "primary"
+		} else if stressed != position {
+			warnings = append(warnings, fmt.Sprintf("%s: stress is marked on syllable %d but %s puts it on syllable %d", word, stressed+1, reason, position+1))
+		}
+	} else if supra.Stress == "lexical" && stressed < 0 && len(prosodic.Syllables) > 1 {
+		warnings = append(warnings, fmt.Sprintf("%s: stress is lexical but not marked with ˈ", word))
+	}
+	return w, prosodic, warnings
+}
+
+// hasProsody reports whether any syllable carries tone, stress or length
+func hasProsody(words []ProsodicWord) bool {
+	for _, word := range words {
+		for _, s := range word.Syllables {
+			if s.Tone != "" || s.Stress != "" || s.Long {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderProsody writes a word's syllables with stress marks and tones, as
+// in ˈma[H].ta[L]
+func renderProsody(word ProsodicWord) string {
+	syllables := make([]string, len(word.Syllables))
+	for i, s := range word.Syllables {
+		switch s.Stress {
+		case "primary":
+			syllables[i] = string(primaryStress)
+		case "secondary":
+			syllables[i] = string(secondaryStress)
+		}
+		syllables[i] += s.Syllable
+		if s.Tone != "" {
+			syllables[i] += "[" + s.Tone + "]"
+		}
+	}
+	return strings.Join(syllables, ".")
+}
+
+// renderSuprasegmentals renders the declared suprasegmentals as markdown
+func renderSuprasegmentals(supra storage.Suprasegmentals) string {
+	var out strings.Builder
+	if len(supra.Tones) > 0 {
+		out.WriteString("### Tones\n\n| Tone | Marks | Description |\n|---|---|---|\n")
+		for _, tone := range supra.Tones {
+			out.WriteString(fmt.Sprintf("| %s | %s | %s |\n", tone.Name, strings.Join(tone.Marks, " "), tone.Description))
+		}
+		out.WriteString("\n")
+	}
+	if supra.DefaultTone != "" {
+		out.WriteString(fmt.Sprintf("**Default tone:** %s\n\n", supra.DefaultTone))
+	}
+	if supra.Stress != "" || supra.StressWeight != nil {
+		out.WriteString(fmt.Sprintf("**Stress:** %s\n\n", describeStress(supra)))
+	}
+	if len(supra.StressExceptions) > 0 {
+		exceptions := make([]string, len(supra.StressExceptions))
+		for i, e := range supra.StressExceptions {
+			exceptions[i] = fmt.Sprintf("%s (syllable %d)", e.Word, e.Syllable)
+		}
+		out.WriteString(fmt.Sprintf("**Stress exceptions:** %s\n\n", strings.Join(exceptions, ", ")))
+	}
+	if supra.Length != "" {
+		out.WriteString(fmt.Sprintf("**Length:** %s\n\n", supra.Length))
+	}
+	return out.String()
+}
+
+// toneSandhiRule is a rule such as "214 > 35 / _ 214" or "H > M / # _": a
+// tone becomes another after or before a context tone. The context may be
+// # for a word boundary; tone contexts reach across word boundaries.
+type toneSandhiRule struct {
+	source      string
+	from, to    string
+	left, right string // Empty matches anything
+}
+
+// parseToneSandhi parses one rule per line. Blank lines and lines starting
+// with // are ignored, since # marks word boundaries.
+func parseToneSandhi(source string, ts *toneSet) ([]toneSandhiRule, error) {
+	rules := []toneSandhiRule{}
+	for n, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		rule, err := parseToneSandhiRule(line, ts)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no tone sandhi rules found")
+	}
+	return rules, nil
+}
+
+func parseToneSandhiRule(line string, ts *toneSet) (toneSandhiRule, error) {
+	rule := toneSandhiRule{source: line}
+	lhs, rest, ok := strings.Cut(line, ">")
+	if !ok {
+		return rule, fmt.Errorf("rule %q needs the form tone > tone / environment", line)
+	}
+	rhs, env, _ := strings.Cut(rest, "/")
+	rule.from, rule.to = strings.TrimSpace(lhs), strings.TrimSpace(rhs)
+	if env = strings.TrimSpace(env); env != "" {
+		before, after, ok := strings.Cut(env, "_")
+		if !ok {
+			return rule, fmt.Errorf("rule %q: environment must mark the tone's position with _", line)
+		}
+		rule.left, rule.right = strings.TrimSpace(before), strings.TrimSpace(after)
+	}
+
+	for _, tone := range []string{rule.from, rule.to, rule.left, rule.right} {
+		if strings.Contains(tone, " ") {
+			return rule, fmt.Errorf("rule %q: each position holds a single tone", line)
+		}
+	}
+	if rule.from == "" || rule.to == "" {
+		return rule, fmt.Errorf("rule %q needs a tone on both sides of >", line)
+	}
+	for _, tone := range []string{rule.from, rule.to, rule.left, rule.right} {
+		if tone != "" && tone != "#" && !ts.known(tone) {
+			return rule, fmt.Errorf("rule %q: %s is not a declared tone", line, tone)
+		}
+	}
+	return rule, nil
+}
+
+// sandhiSlot is one syllable of a phrase in a sandhi derivation
+type sandhiSlot struct {
+	syllable           string
+	stress             string
+	tone               string
+	marked             bool // Written with a tone mark rather than the default tone
+	wordStart, wordEnd bool
+}
+
+// applies reports whether a rule changes the tone of slot i
+func (r toneSandhiRule) applies(slots []sandhiSlot, i int) bool {
+	if slots[i].tone != r.from {
+		return false
+	}
+	switch r.left {
+	case "":
+	case "#":
+		if !slots[i].wordStart {
+			return false
+		}
+	default:
+		if i == 0 || slots[i-1].tone != r.left {
+			return false
+		}
+	}
+	switch r.right {
+	case "":
+	case "#":
+		if !slots[i].wordEnd {
+			return false
+		}
+	default:
+		if i == len(slots)-1 || slots[i+1].tone != r.right {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyToneSandhi applies the tone sandhi rules in order to a phrase. Each
+// rule applies to every matching syllable at once, reading the tones left
+// by the rules before it.
+func ApplyToneSandhi(ctx context.Context, req *ApplyToneSandhiRequest) (*ApplyToneSandhiResult, error) {
+	words := prosodicTokens(req.Text)
+	if len(words) == 0 {
+		return &ApplyToneSandhiResult{
+			Success: false,
+			Message: "Text is required for tone sandhi",
+		}, nil
+	}
+	template, supra, err := loadProsody()
+	if err != nil {
+		return &ApplyToneSandhiResult{
+			Success: false,
+			Message: failure("read phonology", err),
+		}, nil
+	}
+
+	rulesFile := req.RulesFile
+	if rulesFile == "" {
+		rulesFile = DefaultToneSandhiFile
+	}
+	source, err := storage.ReadDataFile(rulesFile)
+	if err != nil {
+		return &ApplyToneSandhiResult{
+			Success: false,
+			Message: failure("read tone sandhi rules", err),
+		}, nil
+	}
+	ts := newToneSet(supra)
+	rules, err := parseToneSandhi(string(source), ts)
+	if err != nil {
+		return &ApplyToneSandhiResult{
+			Success: false,
+			Message: "Invalid tone sandhi rules: " + err.Error(),
+		}, nil
+	}
+
+	result := &ApplyToneSandhiResult{Success: true}
+	slots := []sandhiSlot{}
+	wordStarts := []int{}
+	for _, word := range words {
+		_, prosodic, warnings := prosodify(word, template, supra, ts)
+		result.Warnings = append(result.Warnings, warnings...)
+		wordStarts = append(wordStarts, len(slots))
+		for i, s := range prosodic.Syllables {
+			slots = append(slots, sandhiSlot{
+				syllable:  s.Syllable,
+				stress:    s.Stress,
+				tone:      s.Tone,
+				marked:    s.Tone != "" && !s.DefaultTone,
+				wordStart: i == 0,
+				wordEnd:   i == len(prosodic.Syllables)-1,
+			})
+		}
+	}
+	underlying := make([]string, len(slots))
+	for i, slot := range slots {
+		underlying[i] = slot.tone
+	}
+
+	for _, rule := range rules {
+		matched := []int{}
+		for i := range slots {
+			if rule.applies(slots, i) {
+				matched = append(matched, i)
+			}
+		}
+		for _, i := range matched {
+			slots[i].tone, slots[i].marked = rule.to, true
+			result.Applied = append(result.Applied, fmt.Sprintf("%s: %s → %s (%s)", slots[i].syllable, rule.from, rule.to, rule.source))
+		}
+	}
+
+	var text, under, surface strings.Builder
+	fixedStress := stressPredictable(supra)
+	for i, slot := range slots {
+		if i > 0 && slot.wordStart {
+			text.WriteString(" ")
+			under.WriteString(" | ")
+			surface.WriteString(" | ")
+		} else if i > 0 {
+			under.WriteString(" ")
+			surface.WriteString(" ")
+		}
+		// Rule-governed stress is predictable, so only lexical stress is written
+		if !fixedStress {
+			switch slot.stress {
+			case "primary":
+				text.WriteRune(primaryStress)
+			case "secondary":
+				text.WriteRune(secondaryStress)
+			}
+		}
+		if slot.marked {
+			text.WriteString(ts.writeTone(slot.syllable, slot.tone))
+		} else {
+			text.WriteString(slot.syllable)
+		}
+		under.WriteString(orDefault(underlying[i], "∅"))
+		surface.WriteString(orDefault(slot.tone, "∅"))
+		result.Syllables = append(result.Syllables, SandhiSyllable{Syllable: slot.syllable, Underlying: underlying[i], Surface: slot.tone})
+	}
+	result.Text = text.String()
+	result.Underlying = under.String()
+	result.Surface = surface.String()
+	result.Message = fmt.Sprintf("Applied %d sandhi changes from %d rules to %d syllables", len(result.Applied), len(rules), len(slots))
+	return result, nil
+}
+
+// createSetSuprasegmentalsTool creates the tone, stress and length declaration tool
+func createSetSuprasegmentalsTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"set_suprasegmentals",
+		"Declare the conlang's suprasegmentals alongside the phoneme inventory: contrastive tones with the diacritics or Chao tone letters that write them, the default tone of unmarked syllables, the stress rule (initial, final, penultimate, antepenultimate or lexical) and whether length is contrastive. analyze_phonology and apply_tone_sandhi read these.",
+		SetSuprasegmentals,
+	)
+}
+
+// createApplyToneSandhiTool creates the tone sandhi tool
+func createApplyToneSandhiTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"apply_tone_sandhi",
+		"Apply the tone sandhi rules in tone_sandhi.txt to a phrase. Rules are written one per line as tone > tone / left _ right, such as 214 > 35 / _ 214 or H > M / # _, where # is a word boundary and // starts a comment. Returns the underlying and surface tones, the phrase rewritten with surface tones and which rules applied.",
+		ApplyToneSandhi,
+	)
+}
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// vowels lists the characters treated as syllable nuclei, covering plain
+// Latin vowels and the common IPA vowel symbols
+const vowels = "aeiouyáéíóúàèìòùâêîôûäëïöüāēīōūæøœɑɒɐəɛɜɪɨʉʊʌɔɯɤʏ"
+
+// defaultSyllableTemplate is used when no phonotactic template is given
+const defaultSyllableTemplate = "(C)V(C)"
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune(vowels, unicode.ToLower(r))
+}
+
+// isSegmentModifier reports whether r belongs to the preceding segment, such
+// as a combining diacritic, length mark, tie bar or superscript release
+func isSegmentModifier(r rune) bool {
+	return unicode.IsMark(r) || unicode.Is(unicode.Lm, r)
+}
+
+// segments splits a word into phonological segments, keeping diacritics and
+// modifier letters (ː, ʰ, ʲ) attached to the letter they modify
+func segments(word string) []string {
+	segs := []string{}
+	for _, r := range word {
+		if len(segs) > 0 && isSegmentModifier(r) {
+			segs[len(segs)-1] += string(r)
+			continue
+		}
+		segs = append(segs, string(r))
+	}
+	return segs
+}
+
+// syllableTemplate is a phonotactic template such as (C)(C)V(V)(C), given as
+// the minimum and maximum number of segments in each part of the syllable
+type syllableTemplate struct {
+	source                 string
+	minOnset, maxOnset     int
+	minNucleus, maxNucleus int
+	minCoda, maxCoda       int
+	onsets                 map[string]bool // Permitted onset clusters; nil allows any
+	phonemes               *phonemeSet     // Declared inventory used to segment words; nil uses single letters
+}
+
+// parseSyllableTemplate parses a template written with C for consonants, V for
+// vowels and parentheses around optional segments. An optional list of onset
+// clusters restricts which multi-consonant onsets are permitted.
+func parseSyllableTemplate(template string, onsets []string) (*syllableTemplate, error) {
+	if strings.TrimSpace(template) == "" {
+		template = defaultSyllableTemplate
+	}
+	t := &syllableTemplate{source: template}
+
+	part := 0 // 0 onset, 1 nucleus, 2 coda
+	optional := false
+	for _, r := range strings.ToUpper(strings.ReplaceAll(template, " ", "")) {
+		switch r {
+		case '(':
+			if optional {
+				return nil, fmt.Errorf("nested parentheses in template %q", template)
+			}
+			optional = true
+		case ')':
+			if !optional {
+				return nil, fmt.Errorf("unbalanced parentheses in template %q", template)
+			}
+			optional = false
+		case 'C':
+			if part == 1 {
+				part = 2
+			}
+			if part == 0 {
+				t.maxOnset++
+				if !optional {
+					t.minOnset++
+				}
+			} else {
+				t.maxCoda++
+				if !optional {
+					t.minCoda++
+				}
+			}
+		case 'V':
+			if part == 2 {
+				return nil, fmt.Errorf("template %q has more than one nucleus", template)
+			}
+			part = 1
+			t.maxNucleus++
+			if !optional {
+				t.minNucleus++
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in template %q, use C, V and parentheses", r, template)
+		}
+	}
+	if optional {
+		return nil, fmt.Errorf("unbalanced parentheses in template %q", template)
+	}
+	if t.maxNucleus == 0 {
+		return nil, fmt.Errorf("template %q has no vowel nucleus", template)
+	}
+	if t.minNucleus == 0 {
+		t.minNucleus = 1
+	}
+
+	if len(onsets) > 0 {
+		t.onsets = map[string]bool{}
+		for _, onset := range onsets {
+			t.onsets[strings.ToLower(strings.TrimSpace(onset))] = true
+		}
+	}
+	return t, nil
+}
+
+// legalOnset reports whether a consonant cluster may begin a syllable.
+// Single consonants are always permitted.
+func (t *syllableTemplate) legalOnset(cluster []string) bool {
+	if len(cluster) > t.maxOnset {
+		return false
+	}
+	if len(cluster) <= 1 || t.onsets == nil {
+		return true
+	}
+	return t.onsets[strings.ToLower(strings.Join(cluster, ""))]
+}
+
+// PhonotacticViolation is a part of a word that breaks a phonotactic constraint
+type PhonotacticViolation struct {
+	Constraint string `json:"constraint"` // template, onset, coda, cluster, harmony or inventory
+	Syllable   string `json:"syllable,omitempty"`
+	Detail     string `json:"detail"`
+}
+
+// SyllabifiedWord is a word split into syllables
+type SyllabifiedWord struct {
+	Word       string                 `json:"word"`
+	Syllables  []string               `json:"syllables"`
+	Boundaries []int                  `json:"boundaries"` // Segment offsets at which a new syllable starts
+	Skeleton   string                 `json:"skeleton"`   // CV skeleton with syllables separated by dots, e.g. CV.CVC
+	Violations []PhonotacticViolation `json:"violations,omitempty"`
+}
+
+// syllabify splits a word into syllables using the default template
+func syllabify(word string) []string {
+	t, _ := parseSyllableTemplate(defaultSyllableTemplate, nil)
+	return t.syllabify(word).Syllables
+}
+
+// syllabify splits a word into syllables by onset maximization: consonants
+// between two nuclei go to the following syllable's onset as far as the
+// template and permitted onsets allow, the rest close the preceding syllable.
+// Vowel runs longer than the template's nucleus are split into hiatus.
+func (t *syllableTemplate) syllabify(word string) SyllabifiedWord {
+	segs := t.phonemes.segment(word)
+	result := SyllabifiedWord{Word: word, Boundaries: []int{}}
+
+	// Find the start and end of each nucleus
+	type span struct{ start, end int }
+	nuclei := []span{}
+	for i := 0; i < len(segs); i++ {
+		if !t.phonemes.vowel(segs[i]) {
+			continue
+		}
+		start := i
+		for i+1 < len(segs) && i+1-start < t.maxNucleus && t.phonemes.vowel(segs[i+1]) {
+			i++
+		}
+		nuclei = append(nuclei, span{start, i + 1})
+	}
+	if len(nuclei) == 0 {
+		result.Syllables = []string{word}
+		result.Skeleton = strings.Repeat("C", len(segs))
+		result.Violations = []PhonotacticViolation{{Constraint: "template", Syllable: word, Detail: "no vowel nucleus"}}
+		return result
+	}
+
+	starts := []int{0}
+	for i := 0; i < len(nuclei)-1; i++ {
+		cluster := segs[nuclei[i].end:nuclei[i+1].start]
+		onset := 0
+		for k := min(len(cluster), t.maxOnset); k > 0; k-- {
+			if t.legalOnset(cluster[len(cluster)-k:]) {
+				onset = k
+				break
+			}
+		}
+		start := nuclei[i+1].start - onset
+		starts = append(starts, start)
+		result.Boundaries = append(result.Boundaries, start)
+	}
+	starts = append(starts, len(segs))
+
+	skeletons := []string{}
+	for i, nucleus := range nuclei {
+		syllable := segs[starts[i]:starts[i+1]]
+		result.Syllables = append(result.Syllables, strings.Join(syllable, ""))
+
+		onset := nucleus.start - starts[i]
+		coda := starts[i+1] - nucleus.end
+		skeleton := strings.Repeat("C", onset) + strings.Repeat("V", nucleus.end-nucleus.start) + strings.Repeat("C", coda)
+		skeletons = append(skeletons, skeleton)
+
+		text := strings.Join(syllable, "")
+		violate := func(constraint, format string, args ...any) {
+			result.Violations = append(result.Violations, PhonotacticViolation{
+				Constraint: constraint,
+				Syllable:   text,
+				Detail:     fmt.Sprintf(format, args...),
+			})
+		}
+		switch {
+		case onset > t.maxOnset:
+			violate("template", "onset of %d exceeds template %s", onset, t.source)
+		case onset < t.minOnset:
+			violate("template", "onset required by template %s", t.source)
+		case onset > 1 && !t.legalOnset(segs[starts[i]:nucleus.start]):
+			violate("onset", "onset %s is not permitted", strings.Join(segs[starts[i]:nucleus.start], ""))
+		}
+		if nucleus.end-nucleus.start < t.minNucleus {
+			violate("template", "nucleus shorter than template %s", t.source)
+		}
+		switch {
+		case coda > t.maxCoda:
+			violate("template", "coda of %d exceeds template %s", coda, t.source)
+		case coda < t.minCoda:
+			violate("template", "coda required by template %s", t.source)
+		}
+	}
+	result.Skeleton = strings.Join(skeletons, ".")
+	return result
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSyllableTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		want     [6]int // Minimum and maximum onset, nucleus and coda
+	}{
+		{"", [6]int{0, 1, 1, 1, 0, 1}},
+		{"  ", [6]int{0, 1, 1, 1, 0, 1}},
+		{"CV", [6]int{1, 1, 1, 1, 0, 0}},
+		{"c v c", [6]int{1, 1, 1, 1, 1, 1}},
+		{"(C)(C)V(V)(C)", [6]int{0, 2, 1, 2, 0, 1}},
+		{"(V)", [6]int{0, 0, 1, 1, 0, 0}}, // A syllable always has a nucleus
+		{"V(C)C", [6]int{0, 0, 1, 1, 1, 2}},
+	}
+	for _, tt := range tests {
+		got, err := parseSyllableTemplate(tt.template, nil)
+		if err != nil {
+			t.Errorf("parseSyllableTemplate(%q): %v", tt.template, err)
+			continue
+		}
+		counts := [6]int{got.minOnset, got.maxOnset, got.minNucleus, got.maxNucleus, got.minCoda, got.maxCoda}
+		if counts != tt.want {
+			t.Errorf("parseSyllableTemplate(%q) = %v, want %v", tt.template, counts, tt.want)
+		}
+	}
+}
+
+func TestParseSyllableTemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{"((C))V", "nested parentheses"},
+		{"C)V", "unbalanced parentheses"},
+		{"(CV", "unbalanced parentheses"},
+		{"CVCV", "more than one nucleus"},
+		{"VCV", "more than one nucleus"},
+		{"CC", "no vowel nucleus"},
+		{"()", "no vowel nucleus"},
+		{"CXV", `unexpected 'X'`},
+		{"C-V", `unexpected '-'`},
+	}
+	for _, tt := range tests {
+		_, err := parseSyllableTemplate(tt.template, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseSyllableTemplate(%q) error = %v, want it to contain %q", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSyllabify(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		onsets     []string
+		word       string
+		syllables  []string
+		skeleton   string
+		violations []string // Constraints broken, in order
+	}{
+		{"coda before onset", "", nil, "kanta", []string{"kan", "ta"}, "CVC.CV", nil},
+		{"onset maximized", "(C)(C)V(C)", []string{"tr"}, "patra", []string{"pa", "tra"}, "CV.CCV", nil},
+		{"unlisted onset split", "(C)(C)V(C)", []string{"pl"}, "patra", []string{"pat", "ra"}, "CVC.CV", nil},
+		{"onset case ignored", "(C)(C)V(C)", []string{" TR "}, "patra", []string{"pa", "tra"}, "CV.CCV", nil},
+		{"hiatus", "", nil, "aia", []string{"a", "i", "a"}, "V.V.V", nil},
+		{"diphthong", "(C)V(V)(C)", nil, "taika", []string{"tai", "ka"}, "CVV.CV", nil},
+		{"long nucleus split", "(C)V(V)", nil, "taua", []string{"tau", "a"}, "CVV.V", nil},
+		{"length mark kept", "", nil, "kaːta", []string{"kaː", "ta"}, "CV.CV", nil},
+		{"no vowel", "", nil, "brr", []string{"brr"}, "CCC", []string{"template"}},
+		{"empty word", "", nil, "", []string{""}, "", []string{"template"}},
+		{"onset and coda required", "CVC", nil, "ata", []string{"a", "ta"}, "V.CV", []string{"template", "template", "template"}},
+		{"coda too long", "(C)V(C)", nil, "astra", []string{"ast", "ra"}, "VCC.CV", []string{"template"}},
+		{"word-initial onset not permitted", "(C)(C)V", []string{"tr"}, "pla", []string{"pla"}, "CCV", []string{"onset"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := parseSyllableTemplate(tt.template, tt.onsets)
+			if err != nil {
+				t.Fatalf("parseSyllableTemplate: %v", err)
+			}
+			got := template.syllabify(tt.word)
+			if !slices.Equal(got.Syllables, tt.syllables) {
+				t.Errorf("syllables = %q, want %q", got.Syllables, tt.syllables)
+			}
+			if got.Skeleton != tt.skeleton {
+				t.Errorf("skeleton = %q, want %q", got.Skeleton, tt.skeleton)
+			}
+			constraints := []string{}
+			for _, v := range got.Violations {
+				constraints = append(constraints, v.Constraint)
+			}
+			if !slices.Equal(constraints, tt.violations) {
+				t.Errorf("violations = %+v, want constraints %q", got.Violations, tt.violations)
+			}
+		})
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"", []string{}},
+		{"kata", []string{"k", "a", "t", "a"}},
+		{"tʰaː", []string{"tʰ", "aː"}},
+		{"a\u0303", []string{"a\u0303"}}, // Combining tilde
+		{"ːa", []string{"ː", "a"}},
+	}
+	for _, tt := range tests {
+		if got := segments(tt.word); !slices.Equal(got, tt.want) {
+			t.Errorf("segments(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"fmt"
+	"l2/storage"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// builtinTemplates are the prompt templates every project starts with. A
+// saved template of the same name takes the place of one.
+var builtinTemplates = storage.Templates{
+	"case-system": "Design a case system for {{language}}. It has {{word_order}} word order and these parts of speech: {{parts_of_speech}}. " +
+		"Propose the cases with their functions and affixes that fit the phonotactics ({{syllable}}), and record the affixes once I agree.",
+	"nature-words": "Make 20 nature words for {{language}} (landscape, weather, plants and animals) that aren't in the lexicon yet. " +
+		"Use only the consonants {{consonants}} and the vowels {{vowels}}, follow the syllable template {{syllable}}, and add each word to the lexicon.",
+	"phonology-review": "Review the phonology of {{language}}: the consonants {{consonants}}, the vowels {{vowels}} and the syllable template {{syllable}}. " +
+		"Point out gaps, unusual choices and anything that will be hard to pronounce or spell.",
+	"sample-text": "Write a short sample text in {{language}} using only words from the lexicon ({{word_count}} words so far), " +
+		"with an interlinear gloss and a free translation. List any words you had to leave in English.",
+}
+
+// templateVariable matches a {{name}} placeholder in a template
+var templateVariable = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// Templates returns the built-in templates together with the saved ones,
+// and which names were saved by the user
+func Templates() (storage.Templates, map[string]bool, error) {
+	saved, err := storage.ReadTemplates()
+	if err != nil {
+		return nil, nil, err
+	}
+	templates := storage.Templates{}
+	for name, text := range builtinTemplates {
+		templates[name] = text
+	}
+	custom := map[string]bool{}
+	for name, text := range saved {
+		templates[name] = text
+		custom[name] = true
+	}
+	return templates, custom, nil
+}
+
+// TemplateNames returns the names of the templates in alphabetical order
+func TemplateNames(templates storage.Templates) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SaveTemplate stores a template under a name, replacing a saved or
+// built-in one of that name
+func SaveTemplate(name, text string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !templateName(name) {
+		return fmt.Errorf("template names may only hold lowercase letters, digits and -: %q", name)
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("the template %s has no text", name)
+	}
+	defer storage.BeginUpdate(storage.TemplatesFile)()
+	templates, err := storage.ReadTemplates()
+	if err != nil {
+		return err
+	}
+	templates[name] = text
+	return storage.WriteTemplates(templates)
+}
+
+// DeleteTemplate removes a saved template. A built-in template of the same
+// name comes back in its place.
+func DeleteTemplate(name string) error {
+	defer storage.BeginUpdate(storage.TemplatesFile)()
+	templates, err := storage.ReadTemplates()
+	if err != nil {
+		return err
+	}
+	if _, ok := templates[name]; !ok {
+		if _, builtin := builtinTemplates[name]; builtin {
+			return fmt.Errorf("%s is a built-in template; save one of the same name to replace it", name)
+		}
+		return fmt.Errorf("no template named %s", name)
+	}
+	delete(templates, name)
+	return storage.WriteTemplates(templates)
+}
+
+func templateName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// TemplateVariables returns the values templates can use, taken from the
+// active language's stored data. Parts not designed yet read "not decided".
+func TemplateVariables() (map[string]string, error) {
+	const undecided = "not decided"
+	variables := map[string]string{
+		"language":        storage.Language(),
+		"word_count":      "0",
+		"consonants":      undecided,
+		"vowels":          undecided,
+		"syllable":        undecided,
+		"word_order":      undecided,
+		"parts_of_speech": undecided,
+	}
+
+	entries, err := loadLexicon()
+	if err != nil {
+		return nil, err
+	}
+	variables["word_count"] = strconv.Itoa(len(entries))
+
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return nil, err
+	}
+	if symbols := phonemeSymbols(inventory.Consonants); symbols != "" {
+		variables["consonants"] = symbols
+	}
+	if symbols := phonemeSymbols(inventory.Vowels); symbols != "" {
+		variables["vowels"] = symbols
+	}
+
+	phonotactics, err := storage.ReadPhonotactics()
+	if err != nil {
+		return nil, err
+	}
+	if phonotactics.Template != "" {
+		variables["syllable"] = phonotactics.Template
+	}
+
+	syntax, err := storage.ReadSyntax()
+	if err != nil {
+		return nil, err
+	}
+	if syntax.WordOrder != "" {
+		variables["word_order"] = syntax.WordOrder
+	}
+
+	tags, err := storage.ReadPartsOfSpeech()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = tag.Tag
+		}
+		variables["parts_of_speech"] = strings.Join(names, ", ")
+	}
+	return variables, nil
+}
+
+func phonemeSymbols(phonemes []storage.Phoneme) string {
+	symbols := make([]string, len(phonemes))
+	for i, p := range phonemes {
+		symbols[i] = p.Symbol
+	}
+	return strings.Join(symbols, " ")
+}
+
+// FillTemplate replaces the template's {{name}} placeholders with the
+// variables' values and returns the names it had no value for, which are
+// left in place for the user to fill in
+func FillTemplate(text string, variables map[string]string) (string, []string) {
+	missing := []string{}
+	filled := templateVariable.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := templateVariable.FindStringSubmatch(placeholder)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		if !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+		return placeholder
+	})
+	return filled, missing
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// EstimateTokens approximates the token count of text at four characters per token
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// CountTokens approximates the token count of a list of messages, counting
+// their text and the arguments of their tool calls
+func CountTokens(messages []*schema.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += EstimateTokens(MessageText(msg))
+		for _, call := range msg.ToolCalls {
+			total += EstimateTokens(call.Function.Name + call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// MessageText returns a message's text, from its parts when it has them
+func MessageText(msg *schema.Message) string {
+	if msg.Content != "" || len(msg.MultiContent) == 0 {
+		return msg.Content
+	}
+	var text strings.Builder
+	for _, part := range msg.MultiContent {
+		if part.Type == schema.ChatMessagePartTypeText {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
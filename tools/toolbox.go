@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"l2/storage"
+)
+
+// toolboxPartsOfSpeech expands the part of speech abbreviations common in
+// MDF lexicons to the names L2 uses
+var toolboxPartsOfSpeech = map[string]string{
+	"n":      "noun",
+	"v":      "verb",
+	"vi":     "verb",
+	"vt":     "verb",
+	"adj":    "adjective",
+	"adv":    "adverb",
+	"pro":    "pronoun",
+	"pron":   "pronoun",
+	"prep":   "preposition",
+	"post":   "postposition",
+	"conj":   "conjunction",
+	"interj": "interjection",
+	"num":    "numeral",
+	"part":   "particle",
+	"prt":    "particle",
+	"det":    "determiner",
+	"class":  "classifier",
+	"clf":    "classifier",
+}
+
+// ToolboxImport is what came of importing a Toolbox lexicon
+type ToolboxImport struct {
+	Added   []string
+	Skipped []string // Words left out, each with the reason
+}
+
+// ParseToolbox reads a SIL Toolbox (Shoebox) lexicon in MDF, the standard
+// format in which \lx starts each record. \ps gives the part of speech, \de
+// or, failing that, \ge the definition, \ph the IPA, \et and \eg the
+// etymology, \bw the language a loan came from and \sd the semantic domains.
+// Each subentry (\se) becomes an entry derived from its headword. Markers L2
+// has no field for are ignored. Warnings name the records left out.
+func ParseToolbox(data []byte) (entries []LexiconEntry, warnings []string) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	text := string(data)
+	if !utf8.Valid(data) {
+		// Older Toolbox projects are often saved in a legacy 8-bit encoding
+		text = latin1(data)
+	}
+
+	var (
+		entry    *LexiconEntry
+		headword string
+		defs     []string
+		glosses  []string
+		marker   string
+	)
+	finish := func() {
+		if entry == nil {
+			return
+		}
+		if len(defs) == 0 {
+			defs = glosses
+		}
+		entry.Definition = strings.Join(defs, "; ")
+		switch {
+		case entry.Word == "":
+			warnings = append(warnings, "a record without a headword")
+		case entry.Definition == "":
+			warnings = append(warnings, entry.Word+": no \\de or \\ge definition")
+		default:
+			entries = append(entries, *entry)
+		}
+		entry, defs, glosses = nil, nil, nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		value := strings.TrimSpace(line)
+		if !strings.HasPrefix(line, `\`) {
+			// A line without a marker continues the field before it
+			if value != "" && marker != "" {
+				appendToolboxField(entry, marker, value, true, &defs, &glosses)
+			}
+			continue
+		}
+		marker, value, _ = strings.Cut(strings.TrimSpace(line[1:]), " ")
+		value = strings.TrimSpace(value)
+
+		switch marker {
+		case "lx":
+			finish()
+			headword = value
+			entry = &LexiconEntry{Word: value}
+		case "se":
+			finish()
+			entry = &LexiconEntry{Word: value}
+			if headword != "" {
+				entry.Root = headword
+				entry.DerivedFrom = []string{headword}
+			}
+		default:
+			if entry != nil {
+				appendToolboxField(entry, marker, value, false, &defs, &glosses)
+			}
+		}
+	}
+	finish()
+	return entries, warnings
+}
+
+// appendToolboxField puts the value of one MDF field into the entry. A
+// continued value wraps onto the line before; a repeated \de or \ge is
+// another sense.
+func appendToolboxField(entry *LexiconEntry, marker, value string, continued bool, defs, glosses *[]string) {
+	if entry == nil || value == "" {
+		return
+	}
+	switch marker {
+	case "ps":
+		if entry.PartOfSpeech == "" {
+			entry.PartOfSpeech = value
+		}
+	case "de":
+		*defs = appendSense(*defs, value, continued)
+	case "ge":
+		*glosses = appendSense(*glosses, value, continued)
+	case "ph":
+		entry.IPA = strings.Trim(value, "[]/ ")
+	case "et", "eg", "es":
+		entry.Etymology = strings.TrimSpace(entry.Etymology + " " + value)
+	case "bw":
+		entry.BorrowedFrom = value
+	case "sd", "is":
+		for _, tag := range strings.Split(value, ";") {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(entry.Tags, tag) {
+				entry.Tags = append(entry.Tags, tag)
+			}
+		}
+	}
+}
+
+// appendSense adds a sense, or joins a continued line onto the last one
+func appendSense(senses []string, value string, continued bool) []string {
+	if continued && len(senses) > 0 {
+		senses[len(senses)-1] += " " + value
+		return senses
+	}
+	return append(senses, value)
+}
+
+// latin1 decodes bytes in ISO 8859-1
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// toolboxPartOfSpeech finds the declared tag for an MDF part of speech,
+// preferring the expanded name of an abbreviation such as n for noun
+func toolboxPartOfSpeech(pos string) (string, error) {
+	if full, ok := toolboxPartsOfSpeech[strings.ToLower(strings.TrimSuffix(pos, "."))]; ok {
+		if tag, err := normalizePartOfSpeech(full); err == nil {
+			return tag, nil
+		}
+	}
+	return normalizePartOfSpeech(pos)
+}
+
+// ImportToolbox adds parsed Toolbox entries to the lexicon in one write,
+// leaving out words already in it and entries whose part of speech isn't in
+// the declared tag set
+func ImportToolbox(entries []LexiconEntry) (ToolboxImport, error) {
+	var result ToolboxImport
+	defer storage.BeginDataUpdate(lexiconFile)()
+	lexicon, err := loadLexicon()
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		if _, ok := findEntry(lexicon, entry.Word); ok {
+			result.Skipped = append(result.Skipped, entry.Word+": already in the lexicon")
+			continue
+		}
+		pos, err := toolboxPartOfSpeech(entry.PartOfSpeech)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", entry.Word, err))
+			continue
+		}
+		entry.PartOfSpeech = pos
+		// A subentry whose headword was left out keeps its root as a note
+		entry.DerivedFrom = slices.DeleteFunc(entry.DerivedFrom, func(source string) bool {
+			_, ok := findEntry(lexicon, source)
+			return !ok
+		})
+		lexicon = append(lexicon, entry)
+		result.Added = append(result.Added, entry.Word)
+	}
+
+	if len(result.Added) == 0 {
+		return result, nil
+	}
+	return result, saveLexicon(lexicon)
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"log"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// toolFactory pairs a tool's display name with the function that creates it
+type toolFactory struct {
+	name   string
+	create func() (tool.InvokableTool, error)
+}
+
+// toolFactories lists every tool exposed to the model, in registration order
+var toolFactories = []toolFactory{
+	{"plan steps", createPlanStepsTool},
+	{"add file", createAddFileTool},
+	{"read file", createReadFileTool},
+	{"delete file", createDeleteFileTool},
+	{"fetch url", createFetchURLTool},
+	{"extract document", createExtractDocumentTool},
+	{"record decision", createRecordDecisionTool},
+	{"cite source", createCiteSourceTool},
+	{"list decisions", createListDecisionsTool},
+	{"export grammar sketch", createExportGrammarSketchTool},
+	{"remember preference", createRememberPreferenceTool},
+	{"get preferences", createGetPreferencesTool},
+	{"find content", createFindContentTool},
+	{"phonology", createPhonologyTool},
+	{"set phoneme inventory", createSetPhonemeInventoryTool},
+	{"get phoneme inventory", createGetPhonemeInventoryTool},
+	{"set suprasegmentals", createSetSuprasegmentalsTool},
+	{"apply tone sandhi", createApplyToneSandhiTool},
+	{"set stress rules", createSetStressRulesTool},
+	{"assign stress", createAssignStressTool},
+	{"set phonotactics", createSetPhonotacticsTool},
+	{"check phonotactics", createCheckPhonotacticsTool},
+	{"set vowel harmony", createSetVowelHarmonyTool},
+	{"check harmony", createCheckHarmonyTool},
+	{"set parts of speech", createSetPartsOfSpeechTool},
+	{"set affixes", createSetAffixesTool},
+	{"get affixes", createGetAffixesTool},
+	{"grammar", createGrammarTool},
+	{"set syntax", createSetSyntaxTool},
+	{"generate sentence", createGenerateSentenceTool},
+	{"filler text", createFillerTextTool},
+	{"set language profile", createSetLanguageProfileTool},
+	{"complexity report", createComplexityReportTool},
+	{"add lexicon", createAddLexiconTool},
+	{"propose lexicon", createProposeLexiconTool},
+	{"derive word", createDeriveWordTool},
+	{"etymology tree", createEtymologyTreeTool},
+	{"evolve lexicon", createEvolveLexiconTool},
+	{"language family", createLanguageFamilyTool},
+	{"export graph", createExportGraphTool},
+	{"get lexicon", createGetLexiconTool},
+	{"search lexicon", createSearchLexiconTool},
+	{"spellcheck", createSpellcheckTool},
+	{"analyze frequency", createAnalyzeFrequencyTool},
+	{"semantic coverage", createSemanticCoverageTool},
+	{"hyphenation", createHyphenationTool},
+	{"export lexicon", createExportLexiconTool},
+	{"anki", createAnkiTool},
+	{"add glyph", createAddGlyphTool},
+	{"update glyph", createUpdateGlyphTool},
+	{"remove glyph", createRemoveGlyphTool},
+	{"list glyphs", createListGlyphsTool},
+	{"pua export", createPUAExportTool},
+	{"set orthography", createSetOrthographyTool},
+	{"romanize", createRomanizeTool},
+	{"to native script", createToNativeScriptTool},
+	{"transcribe", createTranscribeTool},
+	{"respell", createRespellTool},
+	{"set logogram", createSetLogogramTool},
+	{"write logographic", createWriteLogographicTool},
+	{"gloss text", createGlossTextTool},
+	{"paradigm", createParadigmTool},
+	{"inflect", createInflectTool},
+	{"set numeral system", createSetNumeralSystemTool},
+	{"number names", createNumberNamesTool},
+	{"design kinship", createDesignKinshipTool},
+	{"check kin term", createCheckKinTermTool},
+	{"set suppletion", createSetSuppletionTool},
+	{"suppletion report", createSuppletionReportTool},
+	{"export gloss", createExportGlossTool},
+	{"delete lexicon", createDeleteLexiconTool},
+}
+
+// buildTools creates every registered tool and the tools of connected MCP
+// servers, skipping any that fail, and wraps each so its calls can require
+// approval
+func buildTools() []tool.BaseTool {
+	tools := []tool.BaseTool{}
+	for _, factory := range toolFactories {
+		t, err := factory.create()
+		if err != nil {
+			log.Printf("Failed to create %s tool: %v", factory.name, err)
+			continue
+		}
+		info, err := t.Info(context.Background())
+		if err != nil {
+			log.Printf("Failed to get %s tool info: %v", factory.name, err)
+			continue
+		}
+		tools = append(tools, &approvedTool{InvokableTool: t, name: info.Name})
+	}
+	// Tools of the MCP servers declared in the config come after the built-in ones
+	for _, t := range connectMCPServers() {
+		info, err := t.Info(context.Background())
+		if err != nil {
+			log.Printf("Failed to get MCP tool info: %v", err)
+			continue
+		}
+		tools = append(tools, &approvedTool{InvokableTool: t, name: info.Name})
+	}
+	return tools
+}
+
+// builtinToolNames returns the names of the registered tools
+func builtinToolNames() []string {
+	names := []string{}
+	for _, factory := range toolFactories {
+		t, err := factory.create()
+		if err != nil {
+			continue // Reported when the tools are built
+		}
+		if info, err := t.Info(context.Background()); err == nil {
+			names = append(names, info.Name)
+		}
+	}
+	return names
+}
+
+// Tools creates and returns a ToolsNode with all available tools
+func Tools() *compose.ToolsNode {
+	tools := buildTools()
+	if len(tools) == 0 {
+		log.Printf("No tools could be created")
+		return nil
+	}
+
+	conf := &compose.ToolsNodeConfig{
+		Tools: tools,
+	}
+
+	toolsNode, err := compose.NewToolNode(context.Background(), conf)
+	if err != nil {
+		log.Printf("Failed to create tools node: %v", err)
+		return nil
+	}
+
+	return toolsNode
+}
+
+// ToolsInfo returns information about all available tools
+func ToolsInfo() []*schema.ToolInfo {
+	tools := buildTools()
+
+	ctx := context.Background()
+	toolInfos := make([]*schema.ToolInfo, 0, len(tools))
+
+	for _, t := range tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			log.Printf("Failed to get tool info: %v", err)
+			continue
+		}
+		toolInfos = append(toolInfos, info)
+	}
+
+	return toolInfos
+}
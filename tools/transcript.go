@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"l2/storage"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// StampMessage records when a message was sent
+func StampMessage(msg *schema.Message, at time.Time) {
+	storage.Meta(msg).Time = at
+}
+
+// ToolCallItem is a tool call and its result kept in the history as a tool
+// message, apart from the answer it was made for, with its arguments and
+// outcome as metadata
+func ToolCallItem(id, name, arguments, result string) *schema.Message {
+	msg := &schema.Message{
+		Role:       schema.Tool,
+		Content:    result,
+		ToolCallID: id,
+		Name:       name, // Tool messages carry the name of the tool called
+	}
+	meta := storage.Meta(msg)
+	meta.Arguments = arguments
+	var outcome Result
+	if json.Unmarshal([]byte(result), &outcome) == nil {
+		meta.Outcome = storage.OutcomeFailure
+		if outcome.Success {
+			meta.Outcome = storage.OutcomeSuccess
+		}
+	}
+	return msg
+}
+
+// sentAt returns when a message was sent, if it was stamped
+func sentAt(msg *schema.Message) (time.Time, bool) {
+	meta, ok := storage.MetaOf(msg)
+	if !ok || meta.Time.IsZero() {
+		return time.Time{}, false
+	}
+	return meta.Time, true
+}
+
+// toolArguments returns the arguments a tool call item was made with
+func toolArguments(msg *schema.Message) string {
+	if meta, ok := storage.MetaOf(msg); ok {
+		return meta.Arguments
+	}
+	return ""
+}
+
+// WriteTranscript writes a conversation as a Markdown document: a header per
+// message with its role and time, and the tool calls made for each answer as
+// fenced blocks with their arguments and outcome
+func WriteTranscript(w io.Writer, history []*schema.Message) error {
+	var out strings.Builder
+	out.WriteString("# Conversation\n\n")
+	out.WriteString(fmt.Sprintf("Exported %s\n", time.Now().Format("2006-01-02 15:04")))
+
+	// Tool calls are stored ahead of the answer they were made for and are
+	// written under its header
+	var calls []string
+	for _, msg := range history {
+		role := string(msg.Role)
+		if role == "" {
+			continue
+		}
+		if msg.Role == schema.Tool {
+			calls = append(calls, transcriptToolCall(msg))
+			continue
+		}
+		header := strings.ToUpper(role[:1]) + role[1:]
+		if at, ok := sentAt(msg); ok {
+			header += " — " + at.Local().Format("2006-01-02 15:04")
+		}
+		if usage, ok := UsageOf(msg); ok {
+			header += fmt.Sprintf(" — %d tokens", usage.PromptTokens+usage.CompletionTokens)
+		}
+		out.WriteString("\n## " + header + "\n\n")
+		for _, call := range calls {
+			out.WriteString(call + "\n")
+		}
+		calls = nil
+		out.WriteString(transcriptContent(msg) + "\n")
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// transcriptToolCall renders a tool call item as a fenced block with its
+// arguments, followed by the result's message
+func transcriptToolCall(msg *schema.Message) string {
+	block := "```tool_call\n" + msg.Name
+	if args := toolArguments(msg); args != "" && args != "{}" {
+		block += "\n" + indentArguments(args)
+	}
+	block += "\n```\n"
+
+	var result Result
+	if json.Unmarshal([]byte(msg.Content), &result) == nil && result.Message != "" {
+		mark := "✅"
+		if !result.Success {
+			mark = "❌"
+		}
+		block += "\n" + mark + " " + result.Message + "\n"
+	}
+	return block
+}
+
+// transcriptContent turns the tool call markers of answers stored before
+// tool calls were kept as their own items into fenced blocks
+func transcriptContent(msg *schema.Message) string {
+	lines := strings.Split(strings.TrimSpace(msg.Content), "\n")
+	for i, line := range lines {
+		name, ok := strings.CutPrefix(strings.TrimSpace(line), "[Tool Call: ")
+		if ok && strings.HasSuffix(name, "]") {
+			lines[i] = "\n```tool_call\n" + strings.TrimSuffix(name, "]") + "\n```\n"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentArguments pretty-prints JSON arguments, leaving invalid JSON as it is
+func indentArguments(args string) string {
+	var value any
+	if err := json.Unmarshal([]byte(args), &value); err != nil {
+		return args
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return args
+	}
+	return string(data)
+}
+
+// transcriptHeader matches a message header written by WriteTranscript
+var transcriptHeader = regexp.MustCompile(`^## (User|Assistant|System|Tool)(?: — (\d{4}-\d{2}-\d{2} \d{2}:\d{2}))?(?: — \d+ tokens)?\s*$`)
+
+// ReadTranscript loads a conversation exported as conversation.json or as a
+// Markdown transcript, checking every message before it is used as history
+func ReadTranscript(data []byte, markdown bool) ([]*schema.Message, error) {
+	var history []*schema.Message
+	if markdown {
+		history = parseTranscript(string(data))
+	} else if err := json.Unmarshal(data, (*storage.Conversation)(&history)); err != nil {
+		return nil, fmt.Errorf("not a conversation file: %w", err)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("the transcript has no messages")
+	}
+	for i, msg := range history {
+		switch {
+		case msg == nil:
+			return nil, fmt.Errorf("message %d is empty", i+1)
+		case msg.Role == schema.Tool:
+			if msg.Name == "" {
+				return nil, fmt.Errorf("message %d is a tool call without a tool name", i+1)
+			}
+		case msg.Role == schema.User || msg.Role == schema.Assistant || msg.Role == schema.System:
+			if msg.Role == schema.User && strings.TrimSpace(msg.Content) == "" {
+				return nil, fmt.Errorf("message %d is an empty user message", i+1)
+			}
+		default:
+			return nil, fmt.Errorf("message %d has unknown role %q", i+1, msg.Role)
+		}
+	}
+	return history, nil
+}
+
+// parseTranscript rebuilds the history from a Markdown transcript: tool call
+// blocks at the top of an answer become tool call items ahead of it
+func parseTranscript(text string) []*schema.Message {
+	var history []*schema.Message
+	var current *schema.Message
+	var body []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		lines := body
+		if current.Role == schema.Assistant {
+			var calls []*schema.Message
+			calls, lines = parseToolCalls(lines)
+			history = append(history, calls...)
+		}
+		current.Content = strings.TrimSpace(strings.Join(lines, "\n"))
+		history = append(history, current)
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		match := transcriptHeader.FindStringSubmatch(line)
+		if match == nil {
+			body = append(body, line)
+			continue
+		}
+		flush()
+		current = &schema.Message{Role: schema.RoleType(strings.ToLower(match[1]))}
+		if at, err := time.ParseInLocation("2006-01-02 15:04", match[2], time.Local); err == nil {
+			StampMessage(current, at)
+		}
+		body = nil
+	}
+	flush()
+	return history
+}
+
+// parseToolCalls takes the tool call blocks, and the outcome line after
+// each, off the top of an answer
+func parseToolCalls(lines []string) ([]*schema.Message, []string) {
+	var calls []*schema.Message
+	for {
+		for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+			lines = lines[1:]
+		}
+		if len(lines) < 3 || strings.TrimSpace(lines[0]) != "```tool_call" {
+			return calls, lines
+		}
+		end := -1
+		for i := 2; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "```" {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return calls, lines
+		}
+		name := strings.TrimSpace(lines[1])
+		arguments := strings.TrimSpace(strings.Join(lines[2:end], "\n"))
+		var compact bytes.Buffer
+		if json.Compact(&compact, []byte(arguments)) == nil {
+			arguments = compact.String()
+		}
+		lines = lines[end+1:]
+
+		// The outcome line is the tool's message, marked as success or failure
+		result := ""
+		rest := lines
+		for len(rest) > 0 && strings.TrimSpace(rest[0]) == "" {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			outcome := strings.TrimSpace(rest[0])
+			for mark, success := range map[string]bool{"✅ ": true, "❌ ": false} {
+				if message, ok := strings.CutPrefix(outcome, mark); ok {
+					data, _ := json.Marshal(Result{Success: success, Message: message})
+					result = string(data)
+					lines = rest[1:]
+				}
+			}
+		}
+		calls = append(calls, ToolCallItem("", name, arguments, result))
+	}
+}
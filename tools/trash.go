@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"l2/storage"
+	"slices"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// DeleteLexiconRequest represents a request to delete a lexicon entry
+type DeleteLexiconRequest struct {
+	Word string `json:"word" jsonschema:"required,description=The word to remove from the lexicon"`
+}
+
+// DeleteFile moves a data file to the trash
+func DeleteFile(ctx context.Context, file *File) (*Result, error) {
+	if file.Path == "" {
+		return &Result{
+			Success: false,
+			Message: "File path is required",
+		}, nil
+	}
+
+	if err := trashDataFile(file.Path); err != nil {
+		return &Result{
+			Success: false,
+			Message: failure("delete file", err),
+		}, nil
+	}
+
+	return &Result{
+		Success: true,
+		Message: "File moved to trash",
+	}, nil
+}
+
+// DeleteLexiconEntry moves a lexicon entry to the trash
+func DeleteLexiconEntry(ctx context.Context, req *DeleteLexiconRequest) (*LexiconResult, error) {
+	if req.Word == "" {
+		return &LexiconResult{
+			Success: false,
+			Message: "Word is required",
+		}, nil
+	}
+
+	defer storage.BeginDataUpdate(lexiconFile)()
+	entries, err := loadLexicon()
+	if err != nil {
+		return &LexiconResult{
+			Success: false,
+			Message: failure("read lexicon", err),
+		}, nil
+	}
+
+	for i, entry := range entries {
+		if entry.Word != req.Word {
+			continue
+		}
+
+		payload, err := json.Marshal(entry)
+		if err != nil {
+			return &LexiconResult{
+				Success: false,
+				Message: failure("serialize entry", err),
+			}, nil
+		}
+
+		// The entry only goes to the trash once the lexicon is saved without
+		// it, and is put back if the trash can't take it
+		remaining := slices.Delete(slices.Clone(entries), i, i+1)
+		if err := saveLexicon(remaining); err != nil {
+			return &LexiconResult{
+				Success: false,
+				Message: failure("save lexicon", err),
+			}, nil
+		}
+		if _, err := storage.MoveToTrash(storage.TrashKindLexicon, entry.Word, payload); err != nil {
+			if restoreErr := saveLexicon(entries); restoreErr != nil {
+				err = fmt.Errorf("%w; restoring the entry also failed: %v", err, restoreErr)
+			}
+			return &LexiconResult{
+				Success: false,
+				Message: failure("move entry to trash", err),
+			}, nil
+		}
+
+		return &LexiconResult{
+			Success: true,
+			Message: "Lexicon entry moved to trash",
+			Entries: []LexiconEntry{entry},
+		}, nil
+	}
+
+	return &LexiconResult{
+		Success: false,
+		Message: "Word not found in lexicon",
+	}, nil
+}
+
+// trashDataFile copies a data file into the trash and removes the original
+func trashDataFile(path string) error {
+	data, err := storage.ReadDataFile(path)
+	if err != nil {
+		return err
+	}
+	item, err := storage.MoveToTrash(storage.TrashKindFile, path, data)
+	if err != nil {
+		return err
+	}
+	if err := storage.RemoveDataFile(path); err != nil {
+		// The file is still in place, so it mustn't also be restorable
+		if _, takeErr := storage.TakeFromTrash(item.ID); takeErr != nil {
+			return fmt.Errorf("%w; taking it back out of the trash also failed: %v", err, takeErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// RestoreFromTrash puts a trashed file or lexicon entry back where it came from
+func RestoreFromTrash(id string) (storage.TrashItem, error) {
+	item, err := storage.TakeFromTrash(id)
+	if err != nil {
+		return item, err
+	}
+
+	switch item.Kind {
+	case storage.TrashKindFile:
+		err = restoreDataFile(item.Name, item.Payload)
+	case storage.TrashKindLexicon:
+		err = restoreLexiconEntry(item.Payload)
+	default:
+		err = fmt.Errorf("unknown trash item kind: %s", item.Kind)
+	}
+
+	if err != nil {
+		// Put the item back so a failed restore doesn't lose it
+		if _, putErr := storage.MoveToTrash(item.Kind, item.Name, item.Payload); putErr != nil {
+			return item, fmt.Errorf("%w; putting it back in the trash also failed: %v", err, putErr)
+		}
+		return item, err
+	}
+	return item, nil
+}
+
+// restoreDataFile writes a trashed file back, first moving whatever is at
+// its path now into the trash so restoring an old version can be undone too
+func restoreDataFile(path string, payload []byte) error {
+	current, err := storage.ReadDataFile(path)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return storage.WriteDataFile(path, payload)
+	case err != nil:
+		return err
+	case string(current) == string(payload):
+		return nil
+	}
+
+	displaced, err := storage.TrashVersion(path, current)
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteDataFile(path, payload); err != nil {
+		if _, takeErr := storage.TakeFromTrash(displaced.ID); takeErr != nil {
+			return fmt.Errorf("%w; taking the current version back out of the trash also failed: %v", err, takeErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func restoreLexiconEntry(payload []byte) error {
+	var entry LexiconEntry
+	if err := json.Unmarshal(payload, &entry); err != nil {
+		return err
+	}
+	defer storage.BeginDataUpdate(lexiconFile)()
+	entries, err := loadLexicon()
+	if err != nil {
+		return err
+	}
+	for _, existing := range entries {
+		if existing.Word == entry.Word {
+			return fmt.Errorf("word %q already exists in lexicon", entry.Word)
+		}
+	}
+	return saveLexicon(append(entries, entry))
+}
+
+// createDeleteFileTool creates the delete file tool
+func createDeleteFileTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"delete_file",
+		"Delete a stored file. The file is moved to the trash and can be restored by the user.",
+		DeleteFile,
+	)
+}
+
+// createDeleteLexiconTool creates the delete lexicon entry tool
+func createDeleteLexiconTool() (tool.InvokableTool, error) {
+	return utils.InferTool(
+		"delete_lexicon_entry",
+		"Remove a word from the conlang lexicon. The entry is moved to the trash and can be restored by the user.",
+		DeleteLexiconEntry,
+	)
+}
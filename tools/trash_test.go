@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"l2/storage"
+	"testing"
+)
+
+func TestRestoreFromTrashKeepsCurrentFile(t *testing.T) {
+	storage.SetRoot(t.TempDir())
+	defer storage.SetRoot("")
+
+	const path = "notes.md"
+	for _, content := range []string{"first", "second"} {
+		result, err := AddFile(context.Background(), &File{Path: path, Content: content})
+		if err != nil || !result.Success {
+			t.Fatalf("AddFile(%q) = %+v, %v", content, result, err)
+		}
+	}
+
+	items, err := storage.ReadTrash()
+	if err != nil {
+		t.Fatalf("ReadTrash: %v", err)
+	}
+	if len(items) != 1 || string(items[0].Payload) != "first" {
+		t.Fatalf("trash after overwrite = %+v, want only the first version", items)
+	}
+
+	if _, err := RestoreFromTrash(items[0].ID); err != nil {
+		t.Fatalf("RestoreFromTrash(first): %v", err)
+	}
+	assertDataFile(t, path, "first")
+
+	// The version the restore replaced can be brought back in turn
+	items, err = storage.ReadTrash()
+	if err != nil {
+		t.Fatalf("ReadTrash: %v", err)
+	}
+	if len(items) != 1 || string(items[0].Payload) != "second" {
+		t.Fatalf("trash after restore = %+v, want only the displaced second version", items)
+	}
+	if _, err := RestoreFromTrash(items[0].ID); err != nil {
+		t.Fatalf("RestoreFromTrash(second): %v", err)
+	}
+	assertDataFile(t, path, "second")
+}
+
+func TestRestoreFromTrashDeletedFile(t *testing.T) {
+	storage.SetRoot(t.TempDir())
+	defer storage.SetRoot("")
+
+	const path = "notes.md"
+	if result, err := AddFile(context.Background(), &File{Path: path, Content: "kept"}); err != nil || !result.Success {
+		t.Fatalf("AddFile = %+v, %v", result, err)
+	}
+	if result, err := DeleteFile(context.Background(), &File{Path: path}); err != nil || !result.Success {
+		t.Fatalf("DeleteFile = %+v, %v", result, err)
+	}
+	items, err := storage.ReadTrash()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("ReadTrash = %+v, %v, want the deleted file", items, err)
+	}
+
+	if _, err := RestoreFromTrash(items[0].ID); err != nil {
+		t.Fatalf("RestoreFromTrash: %v", err)
+	}
+	assertDataFile(t, path, "kept")
+	if items, err := storage.ReadTrash(); err != nil || len(items) != 0 {
+		t.Errorf("trash after restore = %+v, %v, want it empty", items, err)
+	}
+}
+
+func assertDataFile(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := storage.ReadDataFile(path)
+	if err != nil {
+		t.Fatalf("ReadDataFile(%s): %v", path, err)
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", path, data, want)
+	}
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"l2/storage"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// TurnUsage adds up the tokens and time of the model calls one request
+// makes, its tool rounds included
+type TurnUsage struct {
+	mu               sync.Mutex
+	Model            string // Model of the latest call
+	PromptTokens     int
+	CompletionTokens int
+	Estimated        bool // A call's usage was estimated, the provider not reporting it
+	Latency          time.Duration
+}
+
+type turnUsageKey struct{}
+
+// WithTurnUsage returns a context whose model calls add their usage to the
+// returned TurnUsage
+func WithTurnUsage(ctx context.Context) (context.Context, *TurnUsage) {
+	usage := &TurnUsage{}
+	return context.WithValue(ctx, turnUsageKey{}, usage), usage
+}
+
+// Total returns the turn's tokens so far, and whether any were estimated
+func (u *TurnUsage) Total() (promptTokens, completionTokens int, estimated bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.PromptTokens, u.CompletionTokens, u.Estimated
+}
+
+// RecordUsage adds the tokens of a model call, their cost, how long the call
+// took and the tools it called to the stored stats, and the tokens to the
+// request's TurnUsage. The provider's count is used when the reply carries
+// one; otherwise the tokens of the input and the reply are estimated.
+func RecordUsage(ctx context.Context, model string, input []*schema.Message, reply *schema.Message, latency time.Duration) {
+	if reply == nil {
+		return
+	}
+	var promptTokens, completionTokens int
+	estimated := reply.ResponseMeta == nil || reply.ResponseMeta.Usage == nil
+	if estimated {
+		promptTokens, completionTokens = CountTokens(input), CountTokens([]*schema.Message{reply})
+	} else {
+		promptTokens, completionTokens = reply.ResponseMeta.Usage.PromptTokens, reply.ResponseMeta.Usage.CompletionTokens
+	}
+
+	if turn, ok := ctx.Value(turnUsageKey{}).(*TurnUsage); ok {
+		turn.mu.Lock()
+		turn.PromptTokens += promptTokens
+		turn.CompletionTokens += completionTokens
+		turn.Estimated = turn.Estimated || estimated
+		turn.Model = model
+		turn.Latency += latency
+		turn.mu.Unlock()
+	}
+	if storage.ReadOnly() {
+		return
+	}
+	settings, err := storage.ReadConfig()
+	if err != nil {
+		log.Printf("Failed to read config: %v", err)
+	}
+	price, _ := PriceOf(model, settings)
+	request := storage.ModelRequest{
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Estimated:        estimated,
+		Cost:             CostOf(price, promptTokens, completionTokens),
+		Latency:          latency,
+	}
+	for _, call := range reply.ToolCalls {
+		request.ToolCalls = append(request.ToolCalls, call.Function.Name)
+	}
+	if _, err := storage.AddRequest(request); err != nil {
+		log.Printf("Failed to record token usage: %v", err)
+	}
+}
+
+// StampUsage records the model, token usage and latency of a turn on its
+// answer
+func StampUsage(msg *schema.Message, usage *TurnUsage) {
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	if usage.PromptTokens+usage.CompletionTokens == 0 {
+		return
+	}
+	meta := storage.Meta(msg)
+	meta.Model = usage.Model
+	meta.PromptTokens = usage.PromptTokens
+	meta.CompletionTokens = usage.CompletionTokens
+	meta.Estimated = usage.Estimated
+	meta.LatencyMS = usage.Latency.Milliseconds()
+}
+
+// UsageOf returns the token usage stamped on an answer
+func UsageOf(msg *schema.Message) (*TurnUsage, bool) {
+	meta, ok := storage.MetaOf(msg)
+	if !ok || meta.PromptTokens+meta.CompletionTokens == 0 {
+		return nil, false
+	}
+	return &TurnUsage{
+		Model:            meta.Model,
+		PromptTokens:     meta.PromptTokens,
+		CompletionTokens: meta.CompletionTokens,
+		Estimated:        meta.Estimated,
+		Latency:          time.Duration(meta.LatencyMS) * time.Millisecond,
+	}, true
+}
@@ -0,0 +1,45 @@
+package tools
+
+import "context"
+
+// Verbosity levels: terse answers give just the requested material, normal
+// answers explain briefly, teacher answers walk through the reasoning
+const (
+	VerbosityTerse   = "terse"
+	VerbosityNormal  = "normal"
+	VerbosityTeacher = "teacher"
+)
+
+// VerbosityLevels lists the levels from least to most explanation
+var VerbosityLevels = []string{VerbosityTerse, VerbosityNormal, VerbosityTeacher}
+
+// verbosityPrompts are the instructions added to the system prompt at each
+// level; normal keeps the system prompt as it is
+var verbosityPrompts = map[string]string{
+	VerbosityTerse: "Answer tersely: give only what was asked for, such as the word list, table or form, " +
+		"with no introduction, explanation or follow-up suggestions unless the user asks for them.",
+	VerbosityTeacher: "Answer as a teacher: explain the reasoning behind each choice, name the linguistic concepts involved, " +
+		"compare with natural languages that do something similar, and end with a short exercise or question to check understanding.",
+}
+
+// verbosityKey is the context key the verbosity level is stored under
+type verbosityKey struct{}
+
+// WithVerbosity returns a context whose requests are answered at the given level
+func WithVerbosity(ctx context.Context, level string) context.Context {
+	return context.WithValue(ctx, verbosityKey{}, level)
+}
+
+// VerbosityFrom returns the context's verbosity level, normal if none is set
+func VerbosityFrom(ctx context.Context) string {
+	if level, ok := ctx.Value(verbosityKey{}).(string); ok && level != "" {
+		return level
+	}
+	return VerbosityNormal
+}
+
+// VerbosityPrompt returns the system prompt instruction for a level, empty
+// for normal
+func VerbosityPrompt(level string) string {
+	return verbosityPrompts[level]
+}
@@ -0,0 +1,451 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"l2/storage"
+)
+
+const (
+	// webhookDelay gathers the changes of a burst of writes, such as a
+	// response adding twenty words, into one notification
+	webhookDelay = 3 * time.Second
+	// maxListedWords keeps a notification within chat message limits
+	maxListedWords = 15
+)
+
+// Webhook events
+const (
+	EventLexicon = "lexicon"
+	EventGrammar = "grammar"
+)
+
+// WebhookPayload is the JSON posted to webhooks. Content and Text carry the
+// summary for Discord and for Slack-style webhooks; the rest is for scripts
+// such as a site rebuild.
+type WebhookPayload struct {
+	Event    string     `json:"event"`
+	Language string     `json:"language"`
+	Summary  string     `json:"summary"`
+	Content  string     `json:"content"`
+	Text     string     `json:"text"`
+	Added    []string   `json:"added,omitempty"`   // Words added to the lexicon
+	Removed  []string   `json:"removed,omitempty"` // Words removed from the lexicon
+	Changed  []string   `json:"changed,omitempty"` // Words whose entries changed
+	Files    []string   `json:"files,omitempty"`   // Grammar files changed
+	Diffs    []FileDiff `json:"diffs,omitempty"`   // What changed in each grammar file
+	Time     time.Time  `json:"time"`
+}
+
+// FileDiff is what changed in one grammar file: the top-level keys of a
+// JSON object, the entries of a JSON list, or else the lines
+type FileDiff struct {
+	File    string   `json:"file"`
+	Unit    string   `json:"unit"` // key, entry or line
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"` // Keys whose values changed
+}
+
+// pendingChange is what changed in one language since the last notification
+type pendingChange struct {
+	event    string
+	language string
+	files    map[string]*fileVersions // By file name
+}
+
+// fileVersions is a file before the first change and after the last
+type fileVersions struct {
+	previous, current []byte
+}
+
+// webhookPost is a payload waiting to be posted to one webhook
+type webhookPost struct {
+	url     string
+	payload WebhookPayload
+}
+
+// webhookNotifier gathers changes and posts them once writes pause
+type webhookNotifier struct {
+	hooks   []storage.Webhook
+	mu      sync.Mutex
+	pending map[string]*pendingChange // By event and language
+	timer   *time.Timer
+	closed  bool // Set by FlushWebhooks; later changes aren't sent
+	posts   sync.WaitGroup
+}
+
+var notifier *webhookNotifier
+
+// StartWebhooks notifies the webhooks of changes to the lexicon and the
+// grammar files from now on
+func StartWebhooks(hooks []storage.Webhook) {
+	if len(hooks) == 0 {
+		return
+	}
+	notifier = &webhookNotifier{hooks: hooks, pending: map[string]*pendingChange{}}
+	storage.OnChange(changeEvent, notifier.changed)
+}
+
+// FlushWebhooks sends the changes still gathering and waits for the posts
+// to finish, before the program exits
+func FlushWebhooks() {
+	if notifier == nil {
+		return
+	}
+	notifier.close()
+}
+
+// changeEvent tells whether a written file is the lexicon or part of the grammar
+func changeEvent(file int, name string) bool {
+	return webhookEvent(file, name) != ""
+}
+
+func webhookEvent(file int, name string) string {
+	if file == storage.DataFile {
+		if name == lexiconFile {
+			return EventLexicon
+		}
+		return ""
+	}
+	if slices.Contains(designFiles, file) {
+		return EventGrammar
+	}
+	return ""
+}
+
+func (n *webhookNotifier) changed(change storage.Change) {
+	event := webhookEvent(change.File, change.Name)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	key := event + "/" + change.Language
+	pending, ok := n.pending[key]
+	if !ok {
+		pending = &pendingChange{event: event, language: change.Language, files: map[string]*fileVersions{}}
+		n.pending[key] = pending
+	}
+	name := filepath.Base(change.Name)
+	versions, ok := pending.files[name]
+	if !ok {
+		versions = &fileVersions{previous: change.Previous}
+		pending.files[name] = versions
+	}
+	versions.current = change.Current
+	if n.timer == nil {
+		n.timer = time.AfterFunc(webhookDelay, n.flush)
+	} else {
+		n.timer.Reset(webhookDelay)
+	}
+}
+
+// flush posts every gathered change when writes pause
+func (n *webhookNotifier) flush() {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return // FlushWebhooks already sent them
+	}
+	posts := n.takePending()
+	n.mu.Unlock()
+	n.send(posts)
+}
+
+// close posts the changes still gathering, ignores any after them, and
+// waits for every post to finish
+func (n *webhookNotifier) close() {
+	n.mu.Lock()
+	n.closed = true
+	posts := n.takePending()
+	n.mu.Unlock()
+	n.send(posts)
+	n.posts.Wait()
+}
+
+// takePending stops the timer and turns the gathered changes into posts.
+// It is called with n.mu held, and counts the posts before the lock is
+// released so that close waits for those a timer is about to send.
+func (n *webhookNotifier) takePending() []webhookPost {
+	pending := n.pending
+	n.pending = map[string]*pendingChange{}
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+
+	posts := []webhookPost{}
+	for _, change := range pending {
+		payload, ok := change.payload()
+		if !ok {
+			continue
+		}
+		for _, hook := range n.hooks {
+			if len(hook.Events) > 0 && !slices.Contains(hook.Events, change.event) {
+				continue
+			}
+			posts = append(posts, webhookPost{url: hook.URL, payload: payload})
+		}
+	}
+	n.posts.Add(len(posts))
+	return posts
+}
+
+// send posts each payload in the background
+func (n *webhookNotifier) send(posts []webhookPost) {
+	for _, post := range posts {
+		go func() {
+			defer n.posts.Done()
+			if err := postWebhook(post.url, post.payload); err != nil {
+				log.Printf("Failed to notify webhook %s: %v", post.url, err)
+			}
+		}()
+	}
+}
+
+// payload summarizes the change, or reports false when the files ended up
+// as they started
+func (c *pendingChange) payload() (WebhookPayload, bool) {
+	p := WebhookPayload{Event: c.event, Language: c.language, Time: time.Now()}
+	if c.event == EventGrammar {
+		parts := []string{}
+		for _, name := range slices.Sorted(maps.Keys(c.files)) {
+			diff := grammarDiff(name, c.files[name].previous, c.files[name].current)
+			if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+				continue
+			}
+			p.Files = append(p.Files, name)
+			p.Diffs = append(p.Diffs, diff)
+			parts = append(parts, name+" ("+diff.summary()+")")
+		}
+		if len(p.Diffs) == 0 {
+			return p, false
+		}
+		p.Summary = fmt.Sprintf("📐 The grammar of %s changed: %s", c.language, strings.Join(parts, "; "))
+	} else {
+		lexicon := c.files[filepath.Base(lexiconFile)]
+		p.Added, p.Removed, p.Changed = lexiconDiff(lexicon.previous, lexicon.current)
+		if len(p.Added)+len(p.Removed)+len(p.Changed) == 0 {
+			return p, false
+		}
+		parts := []string{}
+		for _, group := range []struct {
+			words []string
+			verb  string
+		}{{p.Added, "added"}, {p.Removed, "removed"}, {p.Changed, "changed"}} {
+			if len(group.words) > 0 {
+				parts = append(parts, fmt.Sprintf("%s %s (%s)", pluralize(len(group.words), "word"), group.verb, listWords(group.words)))
+			}
+		}
+		p.Summary = fmt.Sprintf("📖 The lexicon of %s: %s", c.language, strings.Join(parts, "; "))
+	}
+	p.Content, p.Text = p.Summary, p.Summary
+	return p, true
+}
+
+// lexiconDiff compares two versions of the lexicon by word
+func lexiconDiff(previous, current []byte) (added, removed, changed []string) {
+	before, after := map[string]LexiconEntry{}, map[string]LexiconEntry{}
+	for _, v := range []struct {
+		data    []byte
+		entries map[string]LexiconEntry
+	}{{previous, before}, {current, after}} {
+		var entries []LexiconEntry
+		if len(v.data) > 0 {
+			if err := storage.DecodeDataFile(lexiconFile, v.data, &entries); err != nil {
+				log.Printf("Failed to read the lexicon for the webhook: %v", err)
+			}
+		}
+		for _, entry := range entries {
+			v.entries[entry.Word] = entry
+		}
+	}
+	for word, entry := range after {
+		old, ok := before[word]
+		if !ok {
+			added = append(added, word)
+		} else if !entriesEqual(old, entry) {
+			changed = append(changed, word)
+		}
+	}
+	for word := range before {
+		if _, ok := after[word]; !ok {
+			removed = append(removed, word)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	slices.Sort(changed)
+	return added, removed, changed
+}
+
+// grammarDiff compares two versions of a grammar file by top-level key when
+// both are JSON objects, by entry when they are JSON lists, and otherwise by
+// line, ignoring order
+func grammarDiff(name string, previous, current []byte) FileDiff {
+	diff := FileDiff{File: name, Unit: "key"}
+	before, after := map[string]json.RawMessage{}, map[string]json.RawMessage{}
+	if jsonObject(previous, &before) && jsonObject(current, &after) {
+		for key, value := range after {
+			old, ok := before[key]
+			if !ok {
+				diff.Added = append(diff.Added, key)
+			} else if !jsonEqual(old, value) {
+				diff.Changed = append(diff.Changed, key)
+			}
+		}
+		for key := range before {
+			if _, ok := after[key]; !ok {
+				diff.Removed = append(diff.Removed, key)
+			}
+		}
+		slices.Sort(diff.Added)
+		slices.Sort(diff.Removed)
+		slices.Sort(diff.Changed)
+		return diff
+	}
+
+	diff.Unit = "entry"
+	oldItems, okOld := jsonList(previous)
+	newItems, okNew := jsonList(current)
+	if !okOld || !okNew {
+		diff.Unit = "line"
+		oldItems, newItems = fileLines(previous), fileLines(current)
+	}
+	diff.Added, diff.Removed = diffItems(oldItems, newItems)
+	return diff
+}
+
+// summary describes a file's changes for the notification text. Keys are
+// named; entries and lines, which can be long, are counted.
+func (d FileDiff) summary() string {
+	parts := []string{}
+	for _, group := range []struct {
+		items []string
+		verb  string
+	}{{d.Added, "added"}, {d.Removed, "removed"}, {d.Changed, "changed"}} {
+		if len(group.items) == 0 {
+			continue
+		}
+		part := pluralize(len(group.items), d.Unit) + " " + group.verb
+		if d.Unit == "key" {
+			part += " (" + listWords(group.items) + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// jsonObject decodes a JSON object into keys, treating a missing file as empty
+func jsonObject(data []byte, keys *map[string]json.RawMessage) bool {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return true
+	}
+	return json.Unmarshal(data, keys) == nil && *keys != nil
+}
+
+// jsonList returns the compacted entries of a JSON list, treating a
+// missing file as empty
+func jsonList(data []byte) ([]string, bool) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, true
+	}
+	var raw []json.RawMessage
+	if json.Unmarshal(data, &raw) != nil {
+		return nil, false
+	}
+	items := make([]string, len(raw))
+	for i, item := range raw {
+		var compact bytes.Buffer
+		if json.Compact(&compact, item) != nil {
+			return nil, false
+		}
+		items[i] = compact.String()
+	}
+	return items, true
+}
+
+// fileLines returns the non-blank lines of a file, trimmed
+func fileLines(data []byte) []string {
+	lines := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// diffItems returns the items only in current and those only in previous,
+// counting repeats
+func diffItems(previous, current []string) (added, removed []string) {
+	counts := map[string]int{}
+	for _, item := range previous {
+		counts[item]++
+	}
+	for _, item := range current {
+		if counts[item] > 0 {
+			counts[item]--
+		} else {
+			added = append(added, item)
+		}
+	}
+	for _, item := range previous {
+		if counts[item] > 0 {
+			counts[item]--
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
+
+// jsonEqual reports whether two JSON values are the same apart from spacing
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y bytes.Buffer
+	if json.Compact(&x, a) != nil || json.Compact(&y, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(x.Bytes(), y.Bytes())
+}
+
+func entriesEqual(a, b LexiconEntry) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+// listWords lists words, cutting long lists short
+func listWords(words []string) string {
+	if len(words) > maxListedWords {
+		return strings.Join(words[:maxListedWords], ", ") + fmt.Sprintf(" and %d more", len(words)-maxListedWords)
+	}
+	return strings.Join(words, ", ")
+}
+
+func postWebhook(url string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"l2/storage"
+)
+
+func TestGrammarDiff(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current string
+		unit              string
+		added, removed    []string
+		changed           []string
+	}{
+		{"object keys", `{"order":"SOV","case":true}`, `{"order": "SVO", "case": true, "articles": "none"}`,
+			"key", []string{"articles"}, nil, []string{"order"}},
+		{"key removed", `{"order":"SOV","case":true}`, `{"order":"SOV"}`, "key", nil, []string{"case"}, nil},
+		{"spacing only", `{"order":"SOV"}`, "{\n  \"order\": \"SOV\"\n}", "key", nil, nil, nil},
+		{"new file", ``, `{"order":"SOV"}`, "key", []string{"order"}, nil, nil},
+		{"removed file", `{"order":"SOV"}`, ``, "key", nil, []string{"order"}, nil},
+		{"list entries", `[{"form":"-ka"},{"form":"-ta"}]`, `[{"form": "-ta"}, {"form": "-ri"}]`,
+			"entry", []string{`{"form":"-ri"}`}, []string{`{"form":"-ka"}`}, nil},
+		{"lines", "a > b\nc > d\n", "c > d\n\ne > f\n", "line", []string{"e > f"}, []string{"a > b"}, nil},
+		{"repeated lines", "x\nx\n", "x\n", "line", nil, []string{"x"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := grammarDiff("file", []byte(tt.previous), []byte(tt.current))
+			if diff.Unit != tt.unit {
+				t.Errorf("unit = %q, want %q", diff.Unit, tt.unit)
+			}
+			if !slices.Equal(diff.Added, tt.added) || !slices.Equal(diff.Removed, tt.removed) || !slices.Equal(diff.Changed, tt.changed) {
+				t.Errorf("diff = +%q -%q ~%q, want +%q -%q ~%q",
+					diff.Added, diff.Removed, diff.Changed, tt.added, tt.removed, tt.changed)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierClose(t *testing.T) {
+	var posted atomic.Int32
+	var last atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		last.Store(payload)
+		posted.Add(1)
+	}))
+	defer server.Close()
+
+	n := &webhookNotifier{hooks: []storage.Webhook{{URL: server.URL}}, pending: map[string]*pendingChange{}}
+	n.changed(storage.Change{File: storage.SyntaxFile, Name: "syntax.json", Language: "vathi",
+		Previous: []byte(`{"order":"SOV"}`), Current: []byte(`{"order":"SVO"}`)})
+	n.close()
+	if got := posted.Load(); got != 1 {
+		t.Fatalf("posts after close = %d, want 1", got)
+	}
+	payload := last.Load().(WebhookPayload)
+	if want := "📐 The grammar of vathi changed: syntax.json (1 key changed (order))"; payload.Summary != want {
+		t.Errorf("summary = %q, want %q", payload.Summary, want)
+	}
+	if len(payload.Diffs) != 1 || !slices.Equal(payload.Diffs[0].Changed, []string{"order"}) {
+		t.Errorf("diffs = %+v, want order changed in syntax.json", payload.Diffs)
+	}
+
+	// Changes after close and a timer firing late send nothing
+	n.changed(storage.Change{File: storage.SyntaxFile, Name: "syntax.json", Language: "vathi",
+		Previous: []byte(`{"order":"SVO"}`), Current: []byte(`{"order":"VSO"}`)})
+	n.flush()
+	n.posts.Wait()
+	if got := posted.Load(); got != 1 {
+		t.Errorf("posts after late changes = %d, want 1", got)
+	}
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"fmt"
+	"l2/storage"
+	"math/rand"
+	"strings"
+)
+
+// maxGenerateAttempts bounds how often a word is regenerated when it breaks
+// a phonotactic constraint the generator doesn't model directly
+const maxGenerateAttempts = 20
+
+// wordGenerator builds random words from the declared phoneme inventory and
+// phonotactics. Words are returned as lists of phoneme symbols.
+type wordGenerator struct {
+	rng        *rand.Rand
+	consonants []string
+	vowels     []string
+	onsets     [][]string // Permitted onset clusters
+	codas      [][]string // Permitted codas; nil allows any consonants
+	checker    *phonotacticsChecker
+}
+
+// newWordGenerator loads the inventory and phonotactics, failing if no
+// inventory has been declared
+func newWordGenerator(rng *rand.Rand) (*wordGenerator, error) {
+	inventory, err := storage.ReadInventory()
+	if err != nil {
+		return nil, err
+	}
+	if len(inventory.Consonants) == 0 || len(inventory.Vowels) == 0 {
+		return nil, fmt.Errorf("declare consonants and vowels with set_phoneme_inventory first")
+	}
+	checker, err := loadPhonotacticsChecker()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &wordGenerator{rng: rng, checker: checker}
+	for _, p := range inventory.Consonants {
+		g.consonants = append(g.consonants, p.Symbol)
+	}
+	for _, p := range inventory.Vowels {
+		g.vowels = append(g.vowels, p.Symbol)
+	}
+	for _, onset := range checker.rules.Onsets {
+		g.onsets = append(g.onsets, g.symbols(onset))
+	}
+	for _, coda := range checker.rules.Codas {
+		g.codas = append(g.codas, g.symbols(coda))
+	}
+	return g, nil
+}
+
+// symbols segments a spelling into phoneme symbols
+func (g *wordGenerator) symbols(text string) []string {
+	segs := g.checker.phonemes.segment(strings.ToLower(text))
+	for i, seg := range segs {
+		segs[i] = g.checker.symbol(seg)
+	}
+	return segs
+}
+
+// word generates a word of between one and maxSyllables syllables, retrying
+// words that the phonotactics checker rejects
+func (g *wordGenerator) word(maxSyllables int) []string {
+	var word []string
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		word = []string{}
+		for n := 1 + g.rng.Intn(maxSyllables); n > 0; n-- {
+			word = append(word, g.syllable()...)
+		}
+		if g.checker.check(strings.Join(word, "")).Valid {
+			break
+		}
+	}
+	return word
+}
+
+// syllable generates one syllable that fits the syllable template
+func (g *wordGenerator) syllable() []string {
+	t := g.checker.template
+	syllable := []string{}
+
+	onset := t.minOnset + g.rng.Intn(t.maxOnset-t.minOnset+1)
+	if onset > 1 && len(g.onsets) > 0 {
+		syllable = append(syllable, g.onsets[g.rng.Intn(len(g.onsets))]...)
+	} else {
+		for i := 0; i < onset; i++ {
+			syllable = append(syllable, g.pick(g.consonants))
+		}
+	}
+
+	for n := t.minNucleus + g.rng.Intn(t.maxNucleus-t.minNucleus+1); n > 0; n-- {
+		syllable = append(syllable, g.pick(g.vowels))
+	}
+
+	coda := t.minCoda + g.rng.Intn(t.maxCoda-t.minCoda+1)
+	if coda > 0 && g.codas != nil {
+		syllable = append(syllable, g.codas[g.rng.Intn(len(g.codas))]...)
+	} else {
+		for i := 0; i < coda; i++ {
+			syllable = append(syllable, g.pick(g.consonants))
+		}
+	}
+	return syllable
+}
+
+func (g *wordGenerator) pick(options []string) string {
+	return options[g.rng.Intn(len(options))]
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// SGR sequences toggling a single attribute, so annotations don't reset the
+// colors glamour has already applied around them
+const (
+	knownWordStart   = "\x1b[4m"  // Underline
+	knownWordEnd     = "\x1b[24m" // Underline off
+	unknownWordStart = "\x1b[7m"  // Reverse video
+	unknownWordEnd   = "\x1b[27m" // Reverse video off
+)
+
+// emphasizedWord matches single words set in bold or italics, which is how
+// the assistant usually presents conlang vocabulary
+var emphasizedWord = regexp.MustCompile(`(?:\*\*|\*|__|_)([^\s*_]+)(?:\*\*|\*|__|_)`)
+
+// refreshAnnotations reloads the lexicon words used to annotate the viewport
+func (m *Model) refreshAnnotations() {
+	if !m.annotate {
+		return
+	}
+	entries, err := tools.LoadLexicon()
+	if err != nil {
+		log.Printf("Failed to load lexicon for annotations: %v", err)
+		return
+	}
+	m.lexiconWords = make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		m.lexiconWords[strings.ToLower(entry.Word)] = true
+	}
+}
+
+// conlangCandidates returns emphasized words from assistant messages that
+// are not in the lexicon, i.e. vocabulary the model invented on the fly
+func (m *Model) conlangCandidates(messages []*schema.Message) map[string]bool {
+	candidates := map[string]bool{}
+	for _, msg := range messages {
+		if msg.Role != schema.Assistant {
+			continue
+		}
+		for _, match := range emphasizedWord.FindAllStringSubmatch(msg.Content, -1) {
+			for _, word := range tools.TokenizeWords(match[1]) {
+				if !m.lexiconWords[word] {
+					candidates[word] = true
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// annotateRendered underlines lexicon words and highlights unknown
+// conlang-looking words in glamour output, leaving escape sequences intact
+func annotateRendered(rendered string, known, unknown map[string]bool) string {
+	var out, word strings.Builder
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := word.String()
+		switch lower := strings.ToLower(w); {
+		case known[lower]:
+			out.WriteString(knownWordStart + w + knownWordEnd)
+		case unknown[lower]:
+			out.WriteString(unknownWordStart + w + unknownWordEnd)
+		default:
+			out.WriteString(w)
+		}
+		word.Reset()
+	}
+
+	runes := []rune(rendered)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\x1b' {
+			flush()
+			// Copy the whole escape sequence up to its final letter
+			out.WriteRune(r)
+			for i+1 < len(runes) {
+				i++
+				out.WriteRune(runes[i])
+				if unicode.IsLetter(runes[i]) {
+					break
+				}
+			}
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsMark(r) || r == '\'' {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+	return out.String()
+}
+
+func annotateCommand(m *Model, args []string) (string, tea.Cmd) {
+	switch {
+	case len(args) == 0:
+		m.annotate = !m.annotate
+	case args[0] == "on":
+		m.annotate = true
+	case args[0] == "off":
+		m.annotate = false
+	default:
+		return "Usage: `" + commands["annotate"].usage + "`", nil
+	}
+
+	err := storage.UpdateConfig(func(config *storage.Config) error {
+		config.AnnotateLexicon = m.annotate
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to save annotation setting: %v", err)
+	}
+
+	m.refreshAnnotations()
+	if m.annotate {
+		return "Lexicon annotation **on**: lexicon words are underlined, unknown emphasized words are highlighted", nil
+	}
+	return "Lexicon annotation **off**", nil
+}
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// approvalPreviewRunes is how much of a long argument the approval prompt shows
+const approvalPreviewRunes = 400
+
+// approvalRequest is a tool call waiting for the user to approve or deny it
+type approvalRequest struct {
+	name      string
+	arguments string
+	reply     chan bool
+}
+
+// needsApproval reports whether calls to the tool wait for approval
+func needsApproval(confirm []string, name string) bool {
+	return slices.Contains(confirm, "all") || slices.Contains(confirm, name)
+}
+
+// approver returns the ApproveFunc for a response. Calls to the tools in
+// confirm are handed to Update through the approvals channel and wait for the
+// user's answer; the rest run straight away.
+func (m *Model) approver(confirm []string) tools.ApproveFunc {
+	approvals := m.approvals
+	return func(ctx context.Context, name, arguments string) bool {
+		if !needsApproval(confirm, name) {
+			return true
+		}
+		req := approvalRequest{name: name, arguments: arguments, reply: make(chan bool, 1)}
+		select {
+		case approvals <- req:
+		case <-ctx.Done():
+			return false
+		}
+		select {
+		case approved := <-req.reply:
+			return approved
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// answerApproval approves or denies the pending tool call
+func (m *Model) answerApproval(approved bool) {
+	if m.approval == nil {
+		return
+	}
+	m.approval.reply <- approved
+	if !approved {
+		m.currentResponse.WriteString(fmt.Sprintf("\n[Declined: %s]\n", m.approval.name))
+	}
+	m.approval = nil
+}
+
+// approvalCard renders the pending tool call with its arguments
+func (m *Model) approvalCard() string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("🔧 **%s** is waiting for approval\n\n", m.approval.name))
+	out.WriteString("```json\n" + formatArguments(m.approval.arguments) + "\n```\n\n")
+	if warning := tools.ToolCallWarning(m.approval.name, m.approval.arguments); warning != "" {
+		out.WriteString("⚠️ " + warning + "\n\n")
+	}
+	out.WriteString("Press `y` or `enter` to run it, `n` or `esc` to deny")
+	return out.String()
+}
+
+// formatArguments pretty-prints tool arguments, shortening long values
+func formatArguments(arguments string) string {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return arguments
+	}
+	for key, value := range args {
+		if text, ok := value.(string); ok {
+			if runes := []rune(text); len(runes) > approvalPreviewRunes {
+				args[key] = string(runes[:approvalPreviewRunes]) + fmt.Sprintf("… (%d more characters)", len(runes)-approvalPreviewRunes)
+			}
+		}
+	}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(args); err != nil {
+		return arguments
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func confirmCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "off":
+			m.confirmTools = nil
+		case "all":
+			m.confirmTools = []string{"all"}
+		default:
+			m.confirmTools = args
+		}
+
+		err := storage.UpdateConfig(func(config *storage.Config) error {
+			config.ConfirmTools = m.confirmTools
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to save tool confirmation setting: %v", err)
+		}
+	}
+
+	switch {
+	case len(m.confirmTools) == 0:
+		return "Tool confirmation **off**: tools run as soon as the model calls them", nil
+	case needsApproval(m.confirmTools, "all"):
+		return "Tool confirmation **on** for every tool", nil
+	}
+	return "Tool confirmation **on** for `" + strings.Join(m.confirmTools, "`, `") + "`", nil
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// maxAttachmentBytes is the largest file /attach accepts
+	maxAttachmentBytes = 512 * 1024
+	// largeAttachmentTokens is the attachment size that gets a token warning
+	largeAttachmentTokens = 4000
+	// pasteAttachRunes is the paste length above which a paste becomes an
+	// attachment instead of going into the single-line input
+	pasteAttachRunes = 500
+	// maxImageBytes is the largest image /attach accepts
+	maxImageBytes = 4 * 1024 * 1024
+)
+
+// attachment is text included as a context block in the next request, or
+// an image for models that can read them
+type attachment struct {
+	name    string
+	content string
+	image   string // Data URL of an attached image, which has no content
+}
+
+// attachmentMessage wraps the attachments in clearly delimited blocks.
+// Images go alongside the text as parts of the message.
+func attachmentMessage(attachments []attachment) *schema.Message {
+	var out strings.Builder
+	out.WriteString("ATTACHMENTS: The user attached the following content for this request.\n")
+	images := []schema.ChatMessagePart{}
+	for _, a := range attachments {
+		if a.image != "" {
+			out.WriteString(fmt.Sprintf("\n<<<IMAGE %s>>>\n", a.name))
+			images = append(images, schema.ChatMessagePart{
+				Type:     schema.ChatMessagePartTypeImageURL,
+				ImageURL: &schema.ChatMessageImageURL{URL: a.image},
+			})
+			continue
+		}
+		out.WriteString(fmt.Sprintf("\n<<<BEGIN %s>>>\n%s\n<<<END %s>>>\n", a.name, strings.TrimRight(a.content, "\n"), a.name))
+	}
+	msg := schema.UserMessage(out.String())
+	if len(images) > 0 {
+		// A message carries either plain content or parts, not both
+		text := schema.ChatMessagePart{Type: schema.ChatMessagePartTypeText, Text: msg.Content}
+		msg.MultiContent = append([]schema.ChatMessagePart{text}, images...)
+		msg.Content = ""
+	}
+	return msg
+}
+
+// checkImage reports why an image can't be attached, if it can't
+func (m *Model) checkImage(path string, data []byte) error {
+	if !m.capabilities.Vision {
+		return fmt.Errorf("%s can't read images; set `model` in config.json to one that can", m.model)
+	}
+	if len(data) > maxImageBytes {
+		return fmt.Errorf("%s is %d KB; images are limited to %d KB", path, len(data)/1024, maxImageBytes/1024)
+	}
+	return nil
+}
+
+// attachImage adds an image to the next request if the model can read it
+func (m *Model) attachImage(path, mimeType string, data []byte) string {
+	if err := m.checkImage(path, data); err != nil {
+		return errorNotice(err)
+	}
+	name := filepath.Base(path)
+	m.attachments = append(m.attachments, attachment{
+		name:  name,
+		image: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+	})
+	return fmt.Sprintf("📎 **Attached image %s** (%d KB) to your next message", name, len(data)/1024)
+}
+
+// attach adds text to the next request and describes it with any size warning
+func (m *Model) attach(name, content string) string {
+	m.attachments = append(m.attachments, attachment{name: name, content: content})
+	tokens := tools.EstimateTokens(content)
+	out := fmt.Sprintf("📎 **Attached %s** (%d lines, %d bytes, ~%d tokens) to your next message",
+		name, strings.Count(content, "\n")+1, len(content), tokens)
+	if tokens > largeAttachmentTokens {
+		out += fmt.Sprintf("\n\n⚠️ This attachment is large; it alone is more than %d tokens", largeAttachmentTokens)
+	}
+	if total := m.attachmentTokens(); total > m.summarizeAbove && total != tokens {
+		out += fmt.Sprintf("\n\n⚠️ Attachments total ~%d tokens, more than the %d-token history threshold", total, m.summarizeAbove)
+	}
+	return out
+}
+
+func (m *Model) attachmentTokens() int {
+	total := 0
+	for _, a := range m.attachments {
+		total += tools.EstimateTokens(a.content)
+	}
+	return total
+}
+
+// attachPaste turns a large bracketed paste into an attachment
+func (m *Model) attachPaste(text string) {
+	m.pastes++
+	m.notice = m.attach(fmt.Sprintf("paste %d", m.pastes), text)
+	m.lastRenderTime = time.Time{}
+	m.updateViewportContent()
+}
+
+// readAttachment reads a file to attach, given by its path or by its name
+// in the data files
+func readAttachment(name string) (string, []byte, error) {
+	path := name
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Stored data files can be attached by their name too
+		data, err = storage.ReadDataFile(name)
+	}
+	return path, data, err
+}
+
+func attachCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		if len(m.attachments) == 0 {
+			return "No attachments. Attach a file with `/attach <path>` or paste a long text", nil
+		}
+		var out strings.Builder
+		out.WriteString(fmt.Sprintf("**Attached to your next message** (~%d tokens):\n\n", m.attachmentTokens()))
+		for _, a := range m.attachments {
+			if a.image != "" {
+				out.WriteString(fmt.Sprintf("• **%s** (image)\n", a.name))
+				continue
+			}
+			out.WriteString(fmt.Sprintf("• **%s** (~%d tokens)\n", a.name, tools.EstimateTokens(a.content)))
+		}
+		return out.String(), nil
+	}
+	if args[0] == "clear" && len(args) == 1 {
+		count := len(m.attachments)
+		m.attachments = nil
+		return fmt.Sprintf("✅ **Removed %d attachments**", count), nil
+	}
+
+	path, data, err := readAttachment(strings.Join(args, " "))
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	if mimeType, ok := tools.ImageTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		return m.attachImage(path, mimeType, data), nil
+	}
+	if len(data) > maxAttachmentBytes {
+		return fmt.Sprintf("❌ **Error:** %s is %d KB; attachments are limited to %d KB", path, len(data)/1024, maxAttachmentBytes/1024), nil
+	}
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("❌ **Error:** %s is not a text file", path), nil
+	}
+	return m.attach(filepath.Base(path), string(data)), nil
+}
@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"log"
+	"time"
+
+	"l2/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autosaveMsg asks for the conversation to be saved if it has changed
+type autosaveMsg struct{}
+
+// autosaveTick schedules the next autosave, or nothing when the timer is off
+func (m *Model) autosaveTick() tea.Cmd {
+	if m.autosaveEvery <= 0 {
+		return nil
+	}
+	return tea.Tick(m.autosaveEvery, func(time.Time) tea.Msg {
+		return autosaveMsg{}
+	})
+}
+
+// saveConversation writes the history if it has changed since the last
+// save. A rewrite in progress has taken the last response out of the
+// history, so saving waits until its replacement is in.
+func (m *Model) saveConversation() {
+	if !m.dirty || m.rewriting || storage.ReadOnly() {
+		return
+	}
+	if err := storage.WriteConversation(m.history); err != nil {
+		log.Printf("Failed to save the conversation: %v", err)
+		return
+	}
+	m.dirty = false
+}
@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"l2/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// forkBranch saves the conversation and starts a branch holding its first n
+// messages, counting only the user's messages and the answers
+func (m *Model) forkBranch(n int, name string) error {
+	conversation := m.conversation()
+	if n < 1 || n > len(conversation) {
+		return fmt.Errorf("the conversation has messages 1 to %d", len(conversation))
+	}
+	return m.forkAt(slices.Index(m.history, conversation[n-1])+1, name)
+}
+
+// forkAt saves the conversation and starts a branch holding the history
+// before index cut
+func (m *Model) forkAt(cut int, name string) error {
+	prefix := slices.Clone(m.history[:cut])
+	if err := storage.WriteConversation(m.history); err != nil {
+		return err
+	}
+	previous := storage.Branch()
+	if err := storage.SetBranch(name); err != nil {
+		return err
+	}
+	if exists, err := storage.CheckFile(storage.ConversationFile); err != nil || exists {
+		storage.SetBranch(previous)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("a branch named %s already exists", name)
+	}
+	if err := storage.WriteConversation(prefix); err != nil {
+		storage.SetBranch(previous)
+		return err
+	}
+	m.useConversation(prefix)
+	saveBranchChoice()
+	return nil
+}
+
+// switchBranch saves the conversation and loads another branch's
+func (m *Model) switchBranch(name string) error {
+	if err := storage.WriteConversation(m.history); err != nil {
+		return err
+	}
+	previous := storage.Branch()
+	if err := storage.SetBranch(name); err != nil {
+		return err
+	}
+	exists, err := storage.CheckFile(storage.ConversationFile)
+	if err == nil && !exists && storage.Branch() != storage.MainBranch {
+		err = fmt.Errorf("no branch named %s", name)
+	}
+	history := []*schema.Message{} // The main branch may not have been saved yet
+	if err == nil && exists {
+		history, err = storage.ReadConversation()
+	}
+	if err != nil {
+		storage.SetBranch(previous)
+		return err
+	}
+	m.useConversation(history)
+	saveBranchChoice()
+	return nil
+}
+
+// saveBranchChoice remembers the active branch for the active language
+func saveBranchChoice() {
+	err := storage.UpdateConfig(func(config *storage.Config) error {
+		if config.Branches == nil {
+			config.Branches = map[string]string{}
+		}
+		config.Branches[storage.Language()] = storage.Branch()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to save the active branch: %v", err)
+	}
+}
+
+// nextBranchName returns the first free name of the form branch-<n>
+func nextBranchName(branches []string) string {
+	for n := 1; ; n++ {
+		name := fmt.Sprintf("branch-%d", n)
+		if !slices.Contains(branches, name) {
+			return name
+		}
+	}
+}
+
+func branchCommand(m *Model, args []string) (string, tea.Cmd) {
+	branches, err := storage.ListBranches()
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	if len(args) == 0 {
+		var out strings.Builder
+		out.WriteString("**Branches:**\n\n")
+		for _, name := range branches {
+			if name == storage.Branch() {
+				fmt.Fprintf(&out, "• **%s** (active, %d messages)\n", name, len(m.conversation()))
+			} else {
+				out.WriteString("• " + name + "\n")
+			}
+		}
+		out.WriteString("\n`/branch <n> [<name>]` forks from message n, counting your messages and the answers from 1; `/branch <name>` switches to a branch")
+		return out.String(), nil
+	}
+	if m.streaming {
+		return "Wait for the current response to finish before branching", nil
+	}
+	if m.plan != nil && m.plan.active() {
+		return "Branching is unavailable while a plan is running", nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		if args[0] == storage.Branch() {
+			return fmt.Sprintf("**%s** is already the active branch", args[0]), nil
+		}
+		if err := m.switchBranch(args[0]); err != nil {
+			return errorNotice(err), nil
+		}
+		return fmt.Sprintf("Switched to branch **%s** (%d messages)", storage.Branch(), len(m.conversation())), nil
+	}
+
+	name := nextBranchName(branches)
+	if len(args) > 1 {
+		if _, err := strconv.Atoi(args[1]); err == nil {
+			return "Branch names can't be numbers, so they aren't mistaken for message numbers", nil
+		}
+		name = args[1]
+	}
+	previous := storage.Branch()
+	if err := m.forkBranch(n, name); err != nil {
+		return errorNotice(err), nil
+	}
+	return fmt.Sprintf("Started branch **%s** from message %d; `/branch %s` goes back to the original", name, n, previous), nil
+}
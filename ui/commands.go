@@ -0,0 +1,402 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// command is a slash command handled locally instead of being sent to the LLM
+type command struct {
+	usage       string
+	description string
+	run         func(m *Model, args []string) (string, tea.Cmd)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"annotate": {
+			usage:       "/annotate [on | off]",
+			description: "Underline lexicon words in the conversation and highlight unknown emphasized words",
+			run:         annotateCommand,
+		},
+		"attach": {
+			usage:       "/attach [<path> | clear]",
+			description: "Include a file's contents as a context block in your next message, or an image if the model reads them; list and clear attachments; long pastes are attached automatically",
+			run:         attachCommand,
+		},
+		"attach-image": {
+			usage:       "/attach-image <path> [glyphs | table | notes]",
+			description: "Send a photo of glyph sketches, a notebook table or notes with your next message, for models that read images, to have it transcribed into the script or lexicon",
+			run:         attachImageCommand,
+		},
+		"branch": {
+			usage:       "/branch [<n> [<name>] | <name>]",
+			description: "List the conversation's branches, fork a new one from message n to explore an alternative, or switch to another branch",
+			run:         branchCommand,
+		},
+		"confirm": {
+			usage:       "/confirm [off | all | <tool>...]",
+			description: "Pause before the listed tools (such as add_file) run, showing their arguments for approval; all pauses before every tool",
+			run:         confirmCommand,
+		},
+		"context": {
+			usage:       "/context [show | threshold [<tokens>]]",
+			description: "Inspect the exact prompt sent next turn with token counts per section, or set the token count above which history is summarized",
+			run:         contextCommand,
+		},
+		"export": {
+			usage:       "/export [<file>]",
+			description: "Write the conversation as Markdown, with timestamps and tool calls as fenced blocks, to a file (conversation-<date>.md by default)",
+			run:         exportCommand,
+		},
+		"help": {
+			usage:       "/help",
+			description: "List available commands",
+			run:         helpCommand,
+		},
+		"jobs": {
+			usage:       "/jobs [list | show <#> | cancel <#> | evolve <name> [rules-file] [parent]]",
+			description: "Follow, cancel and read the results of background jobs, or evolve a descendant lexicon in the background",
+			run:         jobsCommand,
+		},
+		"lang": {
+			usage:       "/lang [<name>]",
+			description: "List the languages, or switch to another language's lexicon, grammar, data files and conversation",
+			run:         langCommand,
+		},
+		"lexicon": {
+			usage:       "/lexicon [<query> | <filters> | save <name> <filters> | forget <name> | queries]",
+			description: "Browse the lexicon with saved or ad hoc filters (prefix=, contains=, pos=, keyword=, tag=, no-etymology)",
+			run:         lexiconCommand,
+		},
+		"lint": {
+			usage:       "/lint [on | llm | off]",
+			description: "Flag statements in new responses that contradict the inventory, phonotactics, word order or language profile; llm adds a model pass over the decision log",
+			run:         lintCommand,
+		},
+		"mcp": {
+			usage:       "/mcp",
+			description: "List the MCP servers declared under mcp_servers in config.json and the tools they offer",
+			run:         mcpCommand,
+		},
+		"plan": {
+			usage:       "/plan abort",
+			description: "Stop the plan the model is carrying out, cancelling the step in progress",
+			run:         planCommand,
+		},
+		"preferences": {
+			usage:       "/preferences [forget <key> | clear]",
+			description: "Show the preferences remembered across sessions and included in every request, or forget them",
+			run:         preferencesCommand,
+		},
+		"raw": {
+			usage:       "/raw [on | off | <n>]",
+			description: "Show messages as plain monospace text instead of rendered markdown, or toggle just the nth most recent response",
+			run:         rawCommand,
+		},
+		"record": {
+			usage:       "/record [stop | cancel | settings | backend <whisper-api|whisper-cpp> | model <name|path> | endpoint <url|binary> | language <code> | recorder <command>]",
+			description: "Dictate into the input: start recording from the microphone, then run again to transcribe with Whisper",
+			run:         recordCommand,
+		},
+		"review": {
+			usage:       "/review [list | accept <#...|all> | reject <#...|all>]",
+			description: "Accept or reject words the model proposed for the lexicon",
+			run:         reviewCommand,
+		},
+		"retry": {
+			usage:       "/retry [<model>] [<temperature>]",
+			description: "Drop the last response and answer the request again, optionally with another model or temperature; /rewrite undo brings the dropped response back",
+			run:         retryCommand,
+		},
+		"rewrite": {
+			usage:       "/rewrite [<style> | <instruction...> | diff | undo]",
+			description: "Regenerate the last response with a transformation, replacing it in the conversation and showing what changed; undo brings back the previous version",
+			run:         rewriteCommand,
+		},
+		"sessionlog": {
+			usage:       "/sessionlog [on | off]",
+			description: "Save the exit summary (words added, files changed, decisions, tokens, cost, duration) to the session log",
+			run:         sessionLogCommand,
+		},
+		"simulate": {
+			usage:       "/simulate [rules-file]",
+			description: "Preview the effect of sound change rules (default sound_changes.txt) on random pseudo-texts in a background job",
+			run:         simulateCommand,
+		},
+		"sprint": {
+			usage:       "/sprint [<pack> [batch size]]",
+			description: "Show concept pack progress, or have the model coin words for the next batch of a pack",
+			run:         sprintCommand,
+		},
+		"stats": {
+			usage:       "/stats",
+			description: "Show prompt and completion tokens and their cost, for this session and in total, per model",
+			run:         statsCommand,
+		},
+		"style": {
+			usage:       "/style [<name> | <file.json>]",
+			description: "Choose a built-in glamour markdown style or load a glamour style JSON file",
+			run:         styleCommand,
+		},
+		"system": {
+			usage:       "/system [show]",
+			description: "Edit the system prompt in $EDITOR and use it from the next message on, or show it",
+			run:         systemCommand,
+		},
+		"templates": {
+			usage:       "/templates [<name> [variable=value...] | save <name> <text...> | rm <name>]",
+			description: "List reusable prompt templates, put one in the input with {{variables}} filled in from the project, or save and remove your own",
+			run:         templatesCommand,
+		},
+		"tools": {
+			usage:       "/tools [on | off | <#> | collapse]",
+			description: "Show or hide the panel listing the latest response's tool calls; a call's number expands its arguments and pretty-printed result",
+			run:         toolsCommand,
+		},
+		"trash": {
+			usage:       "/trash [list | restore <id> | purge]",
+			description: "Inspect and restore deleted lexicon entries and files",
+			run:         trashCommand,
+		},
+		"tutorial": {
+			usage:       "/tutorial [next | quit]",
+			description: "Take a guided tour that builds a phoneme inventory, phonotactics, 20 first words and a sample sentence with the real tools",
+			run:         tutorialCommand,
+		},
+		"verbosity": {
+			usage:       "/verbosity [terse | normal | teacher]",
+			description: "Set how much answers explain: terse gives just the word list or table and caps the reply length, teacher walks through the reasoning",
+			run:         verbosityCommand,
+		},
+	}
+}
+
+// isCommand reports whether the input should be handled as a slash command
+func isCommand(input string) bool {
+	return strings.HasPrefix(input, "/")
+}
+
+// handleCommand runs a slash command and shows its output in the viewport
+func (m *Model) handleCommand(input string) tea.Cmd {
+	fields := strings.Fields(strings.TrimPrefix(input, "/"))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var output string
+	var cmd tea.Cmd
+	m.showJobs = false
+	if c, ok := commands[fields[0]]; ok {
+		output, cmd = c.run(m, fields[1:])
+	} else {
+		output = fmt.Sprintf("Unknown command `/%s`. Type `/help` for a list of commands.", fields[0])
+	}
+
+	m.notice = output
+	m.lastRenderTime = time.Time{} // Force an immediate refresh
+	m.updateViewportContent()
+	return cmd
+}
+
+// errorNotice shows a command's error with a hint at the remedy, when the
+// kind of error has one
+func errorNotice(err error) string {
+	notice := "❌ **Error:** " + err.Error()
+	if hint := tools.Remediation(err); hint != "" {
+		notice += "\n\n" + hint
+	}
+	return notice
+}
+
+func helpCommand(m *Model, args []string) (string, tea.Cmd) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	out.WriteString("**Commands:**\n\n")
+	for _, name := range names {
+		c := commands[name]
+		out.WriteString(fmt.Sprintf("• `%s` — %s\n", c.usage, c.description))
+	}
+	out.WriteString("\n**Keys:**\n\n")
+	out.WriteString("• `ctrl+k` — Look up a word from the latest message (or the input) in the lexicon\n")
+	out.WriteString("• `ctrl+r` — Recall your latest message into the input to edit and resend it; press again for earlier ones\n")
+	out.WriteString("• `esc` — Abort the running plan, or cancel editing a recalled message\n")
+	return out.String(), nil
+}
+
+func trashCommand(m *Model, args []string) (string, tea.Cmd) {
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "list":
+		items, err := storage.ReadTrash()
+		if err != nil {
+			return errorNotice(err), nil
+		}
+		if len(items) == 0 {
+			return "Trash is empty", nil
+		}
+		var out strings.Builder
+		out.WriteString("**Trash:**\n\n")
+		for _, item := range items {
+			out.WriteString(fmt.Sprintf("• `%s` %s **%s** (deleted %s)\n",
+				item.ID, item.Kind, item.Name, item.DeletedAt.Format("2006-01-02 15:04")))
+		}
+		return out.String(), nil
+
+	case "restore":
+		if len(args) < 2 {
+			return "Usage: `/trash restore <id>`", nil
+		}
+		item, err := tools.RestoreFromTrash(args[1])
+		if err != nil {
+			return errorNotice(err), nil
+		}
+		return fmt.Sprintf("✅ **Restored %s %s**", item.Kind, item.Name), nil
+
+	case "purge":
+		purged, err := storage.PurgeTrash(0)
+		if err != nil {
+			return errorNotice(err), nil
+		}
+		return fmt.Sprintf("✅ **Purged %d items from trash**", purged), nil
+	}
+
+	return "Usage: `" + commands["trash"].usage + "`", nil
+}
+
+func lexiconCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "queries":
+			names, queries, err := tools.SavedQueries()
+			if err != nil {
+				return errorNotice(err), nil
+			}
+			if len(names) == 0 {
+				return "No saved queries. Create one with `/lexicon save <name> <filters>`", nil
+			}
+			var out strings.Builder
+			out.WriteString("**Saved queries:**\n\n")
+			for _, name := range names {
+				out.WriteString(fmt.Sprintf("• **%s**: `%s`\n", name, tools.DescribeQuery(queries[name])))
+			}
+			return out.String(), nil
+
+		case "save":
+			if len(args) < 3 {
+				return "Usage: `/lexicon save <name> <filters>`", nil
+			}
+			q, err := tools.ParseLexiconQuery(args[2:])
+			if err != nil {
+				return errorNotice(err), nil
+			}
+			if err := tools.SaveQuery(args[1], q); err != nil {
+				return errorNotice(err), nil
+			}
+			return fmt.Sprintf("✅ **Saved query %s**: `%s`", args[1], tools.DescribeQuery(q)), nil
+
+		case "forget":
+			if len(args) < 2 {
+				return "Usage: `/lexicon forget <name>`", nil
+			}
+			if err := tools.DeleteQuery(args[1]); err != nil {
+				return errorNotice(err), nil
+			}
+			return fmt.Sprintf("✅ **Forgot query %s**", args[1]), nil
+		}
+	}
+
+	entries, err := tools.QueryLexicon(args)
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	if len(entries) == 0 {
+		return "No matching lexicon entries", nil
+	}
+	return fmt.Sprintf("**%d matching entries:**\n\n", len(entries)) + formatLexiconEntries(entries), nil
+}
+
+func simulateCommand(m *Model, args []string) (string, tea.Cmd) {
+	rulesFile := ""
+	if len(args) > 0 {
+		rulesFile = args[0]
+	}
+	j, cmd := m.startJob("simulate "+orDefault(rulesFile, tools.DefaultSoundChangeFile), func(ctx context.Context) (string, error) {
+		report, err := tools.SimulateSoundChanges(ctx, rulesFile, 50, 20, time.Now().UnixNano())
+		if err != nil {
+			return "", err
+		}
+		return tools.FormatSimulationReport(report), nil
+	})
+	return fmt.Sprintf("Simulating in the background as job %d; follow it with `/jobs`", j.id), cmd
+}
+
+func preferencesCommand(m *Model, args []string) (string, tea.Cmd) {
+	preferences, err := storage.ReadPreferences()
+	if err != nil {
+		return errorNotice(err), nil
+	}
+
+	if len(args) == 0 {
+		if len(preferences) == 0 {
+			return "No remembered preferences. Tell the model how you like things done and it will remember", nil
+		}
+		return "**Remembered preferences:**\n\n" + tools.FormatPreferences(preferences), nil
+	}
+
+	switch args[0] {
+	case "forget":
+		if len(args) < 2 {
+			return "Usage: `/preferences forget <key>`", nil
+		}
+		result, _ := tools.RememberPreference(context.Background(), &tools.RememberPreferenceRequest{Key: args[1]})
+		if !result.Success {
+			return "❌ **Error:** " + result.Message, nil
+		}
+		return "✅ **" + result.Message + "**", nil
+
+	case "clear":
+		if err := storage.WritePreferences(storage.Preferences{}); err != nil {
+			return errorNotice(err), nil
+		}
+		return fmt.Sprintf("✅ **Forgot %d preferences**", len(preferences)), nil
+	}
+
+	return "Usage: `" + commands["preferences"].usage + "`", nil
+}
+
+func mcpCommand(m *Model, args []string) (string, tea.Cmd) {
+	servers := tools.MCPStatus()
+	if len(servers) == 0 {
+		return "No MCP servers. Declare them under `mcp_servers` in config.json with a name, command, args and env, then restart", nil
+	}
+	var out strings.Builder
+	out.WriteString("**MCP servers:**\n\n")
+	for _, server := range servers {
+		if server.Error != "" {
+			out.WriteString(fmt.Sprintf("• **%s** ❌ %s\n", server.Name, server.Error))
+			continue
+		}
+		out.WriteString(fmt.Sprintf("• **%s** — %d tools: `%s`\n", server.Name, len(server.Tools), strings.Join(server.Tools, "`, `")))
+	}
+	return out.String(), nil
+}
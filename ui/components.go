@@ -1,10 +1,12 @@
 package ui
 
 import (
+	"cmp"
 	"log"
 	"time"
 
 	"l2/storage"
+	"l2/tools"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/lipgloss"
@@ -29,6 +31,12 @@ func NewModel() *Model {
 		}
 	}
 
+	if purged, err := storage.PurgeTrash(storage.TrashRetention); err != nil {
+		log.Printf("Failed to purge trash: %v", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d expired items from trash", purged)
+	}
+
 	ti := textarea.New()
 	ti.Placeholder = ""
 	ti.Focus()
@@ -44,16 +52,42 @@ func NewModel() *Model {
 		stats = storage.Stats{TotalTokens: 0}
 	}
 
-	return &Model{
+	config, err := storage.ReadConfig()
+	if err != nil {
+		log.Printf("Failed to read config: %v", err)
+	}
+	model, capabilities := tools.ActiveModel()
+
+	m := &Model{
 		ta:        ti,
 		ready:     false,
 		tokenChan: make(chan string, 100),
 		history:   history,
 		stats:     stats,
+		annotate:  config.AnnotateLexicon,
+		approvals: make(chan approvalRequest),
+		lint:      cmp.Or(config.Lint, lintLocal),
+		verbosity: cmp.Or(config.Verbosity, tools.VerbosityNormal),
+		raw:       config.RawMarkdown,
+		lints:     map[*schema.Message][]string{},
+		showTools: true,
+
+		summarizeAbove: min(config.SummarizeAboveTokens, tools.HistoryBudget(capabilities)),
+		rawMessages:    map[*schema.Message]bool{},
+		markdownStyle:  cmp.Or(config.MarkdownStyle, defaultMarkdownStyle),
+		confirmTools:   config.ConfirmTools,
+		autosaveEvery:  time.Duration(max(config.AutosaveSeconds, 0)) * time.Second,
+		model:          model,
+		capabilities:   capabilities,
 
 		// Initialize optimization fields for long responses
 		maxHistoryDisplay: 10,                     // Show last 10 messages
 		renderBuffer:      5,                      // 5 line buffer for smooth scrolling
 		renderThrottle:    100 * time.Millisecond, // Throttle renders to 100ms
 	}
+	m.refreshAnnotations()
+	if m.session, err = tools.TakeSnapshot(stats); err != nil {
+		log.Printf("Failed to record the session's starting state: %v", err)
+	}
+	return m
 }
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// contextMsg carries the rendered prompt built in the background for /context show
+type contextMsg string
+
+// recentWithinTokens returns the longest tail of messages that fits in limit
+// tokens, always keeping at least the last message
+func recentWithinTokens(messages []*schema.Message, limit int) []*schema.Message {
+	start := len(messages)
+	used := 0
+	for start > 0 {
+		used += tools.EstimateTokens(messages[start-1].Content)
+		if used > limit && start < len(messages) {
+			break
+		}
+		start--
+	}
+	return messages[start:]
+}
+
+// conversation returns the user and assistant messages of the history; the
+// system prompts and the tool calls kept alongside answers are neither shown
+// nor sent back to the model
+func (m *Model) conversation() []*schema.Message {
+	messages := make([]*schema.Message, 0, len(m.history))
+	for _, msg := range m.history {
+		if msg.Role == schema.User || msg.Role == schema.Assistant {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// promptSection is a named part of the composed prompt
+type promptSection struct {
+	name     string
+	messages []*schema.Message
+}
+
+// composePrompt builds the sections sent to the model for a request: the
+// system prompts, the remembered preferences, the condensed conversation,
+// any attachments and the request itself
+func (m *Model) composePrompt(conversation []*schema.Message, request string, attachments []attachment) []promptSection {
+	system := promptSection{name: "System"}
+	for _, msg := range m.history {
+		if msg.Role == schema.System {
+			system.messages = append(system.messages, msg)
+		}
+	}
+
+	preferences := promptSection{name: "Preferences"}
+	if prompt := tools.PreferencesPrompt(); prompt != "" {
+		preferences.messages = append(preferences.messages, schema.SystemMessage(prompt))
+	}
+
+	history := promptSection{name: "History", messages: m.createCondensedHistory(conversation)}
+	if tools.CountTokens(conversation) > m.summarizeAbove {
+		history.name = "Summary"
+	}
+
+	sections := []promptSection{system, preferences, history}
+	if len(attachments) > 0 {
+		sections = append(sections, promptSection{name: "Attachments", messages: []*schema.Message{attachmentMessage(attachments)}})
+	}
+	return append(sections, promptSection{name: "Request", messages: []*schema.Message{schema.UserMessage("REQUEST: " + request)}})
+}
+
+// buildPrompt flattens the composed prompt into the messages sent to the model
+func (m *Model) buildPrompt(conversation []*schema.Message, request string, attachments []attachment) []*schema.Message {
+	messages := make([]*schema.Message, 0)
+	for _, section := range m.composePrompt(conversation, request, attachments) {
+		messages = append(messages, section.messages...)
+	}
+	return messages
+}
+
+// renderPrompt shows the token count of each prompt section followed by the
+// exact messages in it
+func renderPrompt(sections []promptSection) string {
+	total := 0
+	for _, section := range sections {
+		total += tools.CountTokens(section.messages)
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("**Next prompt** (~%d tokens)\n\n", total))
+	out.WriteString("| Section | Messages | Tokens |\n|---|---|---|\n")
+	for _, section := range sections {
+		out.WriteString(fmt.Sprintf("| %s | %d | ~%d |\n", section.name, len(section.messages), tools.CountTokens(section.messages)))
+	}
+	out.WriteString("\n")
+
+	for _, section := range sections {
+		out.WriteString(fmt.Sprintf("### %s (~%d tokens)\n\n", section.name, tools.CountTokens(section.messages)))
+		if len(section.messages) == 0 {
+			out.WriteString("_empty_\n\n")
+		}
+		for _, msg := range section.messages {
+			out.WriteString(fmt.Sprintf("**%s**\n\n```\n%s\n```\n\n", msg.Role, tools.MessageText(msg)))
+		}
+	}
+	return out.String()
+}
+
+func contextCommand(m *Model, args []string) (string, tea.Cmd) {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "show":
+		conversation := m.conversation()
+		status := "sent verbatim"
+		if tokens := tools.CountTokens(conversation); tokens > m.summarizeAbove {
+			status = "summarized"
+		}
+		header := fmt.Sprintf("Conversation is ~%d tokens, %s (threshold %d tokens).\n\n",
+			tools.CountTokens(conversation), status, m.summarizeAbove)
+
+		// Summarizing calls the model, so build the prompt off the UI thread
+		attachments := m.attachments
+		return header + "Building prompt...", func() tea.Msg {
+			sections := m.composePrompt(conversation, "<your next message>", attachments)
+			return contextMsg(header + renderPrompt(sections))
+		}
+
+	case "threshold":
+		if len(args) < 2 {
+			return fmt.Sprintf("Conversations above **%d tokens** are summarized", m.summarizeAbove), nil
+		}
+		tokens, err := strconv.Atoi(args[1])
+		if err != nil || tokens <= 0 {
+			return "❌ **Error:** threshold must be a positive number of tokens", nil
+		}
+		if budget := tools.HistoryBudget(m.capabilities); tokens > budget {
+			return fmt.Sprintf("❌ **Error:** %s has a %d-token context; keep the threshold at %d tokens or less so the prompt and answer fit", m.model, m.capabilities.ContextTokens, budget), nil
+		}
+
+		err = storage.UpdateConfig(func(config *storage.Config) error {
+			config.SummarizeAboveTokens = tokens
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to save summarize threshold: %v", err)
+		}
+
+		m.summarizeAbove = tokens
+		return fmt.Sprintf("✅ **Conversations above %d tokens will be summarized**", tokens), nil
+	}
+
+	return "Usage: `" + commands["context"].usage + "`", nil
+}
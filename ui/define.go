@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var cardStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("#45B7D1")).
+	Padding(1, 2)
+
+// defineState holds the word lookup popup. The cursor walks the words of the
+// latest message; the card shows the lexicon entry under the cursor.
+type defineState struct {
+	active  bool
+	words   []string
+	index   int
+	entries map[string]tools.LexiconEntry
+}
+
+// startDefine opens the lookup popup. A word typed in the input is looked up
+// directly, otherwise the cursor starts on the first known word of the latest message.
+func (m *Model) startDefine() {
+	entries, err := tools.LoadLexicon()
+	if err != nil {
+		m.notice = errorNotice(err)
+		m.updateViewportContentInternal()
+		return
+	}
+	lookup := make(map[string]tools.LexiconEntry, len(entries))
+	for _, entry := range entries {
+		lookup[strings.ToLower(entry.Word)] = entry
+	}
+
+	words := tools.TokenizeWords(m.ta.Value())
+	if len(words) == 0 {
+		if conversation := m.conversation(); len(conversation) > 0 {
+			words = tools.TokenizeWords(conversation[len(conversation)-1].Content)
+		}
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	index := 0
+	for i, word := range words {
+		if _, ok := lookup[word]; ok {
+			index = i
+			break
+		}
+	}
+
+	m.define = defineState{
+		active:  true,
+		words:   words,
+		index:   index,
+		entries: lookup,
+	}
+}
+
+// updateDefine handles keys while the lookup popup is open
+func (m *Model) updateDefine(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyEnter, tea.KeyCtrlK:
+		m.define = defineState{}
+	case tea.KeyLeft, tea.KeyShiftTab:
+		if m.define.index > 0 {
+			m.define.index--
+		}
+	case tea.KeyRight, tea.KeyTab:
+		if m.define.index < len(m.define.words)-1 {
+			m.define.index++
+		}
+	}
+}
+
+// defineCard renders the lookup card for the word under the cursor
+func (m *Model) defineCard() string {
+	word := m.define.words[m.define.index]
+
+	var card strings.Builder
+	card.WriteString(lipgloss.NewStyle().Faint(true).Render(
+		fmt.Sprintf("◀ %d/%d ▶  ←/→ move · esc close", m.define.index+1, len(m.define.words))))
+	card.WriteString("\n\n")
+
+	entry, ok := m.define.entries[word]
+	if !ok {
+		card.WriteString(lipgloss.NewStyle().Bold(true).Render(word))
+		card.WriteString("\n\nNot in the lexicon")
+		return cardStyle.Render(card.String())
+	}
+
+	title := entry.Word
+	if entry.IPA != "" {
+		title += "  /" + entry.IPA + "/"
+	}
+	card.WriteString(lipgloss.NewStyle().Bold(true).Render(title))
+	if entry.PartOfSpeech != "" {
+		card.WriteString("  " + lipgloss.NewStyle().Italic(true).Render(entry.PartOfSpeech))
+	}
+	card.WriteString("\n\n" + entry.Definition)
+	if entry.Etymology != "" {
+		card.WriteString("\n\nEtymology: " + entry.Etymology)
+	}
+	if len(entry.DerivedFrom) > 0 {
+		card.WriteString("\nFrom: " + strings.Join(entry.DerivedFrom, " + "))
+	}
+	if entry.ProtoForm != "" {
+		card.WriteString("\nProto-form: " + entry.ProtoForm)
+	}
+	if entry.BorrowedFrom != "" {
+		card.WriteString("\nBorrowed from: " + entry.BorrowedFrom)
+	}
+	if len(entry.Tags) > 0 {
+		card.WriteString("\nTags: " + strings.Join(entry.Tags, ", "))
+	}
+
+	examples := m.findExamples(word, 2)
+	if len(examples) > 0 {
+		card.WriteString("\n\nExamples:")
+		for _, example := range examples {
+			card.WriteString("\n• " + example)
+		}
+	}
+
+	return cardStyle.Render(card.String())
+}
+
+// findExamples returns up to limit lines from the conversation that use the word
+func (m *Model) findExamples(word string, limit int) []string {
+	examples := []string{}
+	conversation := m.conversation()
+	for i := len(conversation) - 1; i >= 0 && len(examples) < limit; i-- {
+		for _, line := range strings.Split(conversation[i].Content, "\n") {
+			for _, w := range tools.TokenizeWords(line) {
+				if w == word {
+					examples = append(examples, strings.TrimSpace(line))
+					break
+				}
+			}
+			if len(examples) >= limit {
+				break
+			}
+		}
+	}
+	return examples
+}
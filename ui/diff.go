@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"l2/tools"
+)
+
+// ipaTranscription matches phonemic and phonetic transcriptions such as /ka.ta/ or [ˈka.ta]
+var ipaTranscription = regexp.MustCompile(`/[^/\s][^/\n]*/|\[[^\]\n]+\]`)
+
+// ruleLine matches lines stating a rule, such as a sound change or a
+// phonotactic constraint written with an arrow
+var ruleLine = regexp.MustCompile(`→|->|=>|\s>\s`)
+
+// vocabulary returns the emphasized words and transcriptions in a response
+func vocabulary(text string) []string {
+	seen := map[string]bool{}
+	var items []string
+	add := func(item string) {
+		if !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	for _, match := range emphasizedWord.FindAllStringSubmatch(text, -1) {
+		for _, word := range tools.TokenizeWords(match[1]) {
+			add(word)
+		}
+	}
+	for _, match := range ipaTranscription.FindAllString(text, -1) {
+		add(match)
+	}
+	return items
+}
+
+// rules returns the lines of a response that state rules, stripped of list markers
+func rules(text string) []string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		if ruleLine.MatchString(line) {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*+•"))
+			if !slices.Contains(out, line) {
+				out = append(out, line)
+			}
+		}
+	}
+	return out
+}
+
+// difference returns the items of a missing from b
+func difference(a, b []string) []string {
+	var out []string
+	for _, item := range a {
+		if !slices.Contains(b, item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// diffLines returns a line diff of two texts in unified diff notation, with
+// unchanged lines kept for context
+func diffLines(before, after string) []string {
+	a := strings.Split(strings.TrimSpace(before), "\n")
+	b := strings.Split(strings.TrimSpace(after), "\n")
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	return out
+}
+
+// answerDiff describes how a regenerated response differs from the previous
+// attempt: the vocabulary and rules it dropped or introduced, then a line diff
+func answerDiff(before, after string) string {
+	if strings.TrimSpace(before) == strings.TrimSpace(after) {
+		return "The new version is identical to the previous one"
+	}
+
+	var out strings.Builder
+	section := func(title string, removed, added []string) {
+		if len(removed) == 0 && len(added) == 0 {
+			return
+		}
+		out.WriteString(fmt.Sprintf("**%s**\n\n", title))
+		for _, item := range removed {
+			out.WriteString("- ~~" + item + "~~\n")
+		}
+		for _, item := range added {
+			out.WriteString("- **" + item + "** (new)\n")
+		}
+		out.WriteString("\n")
+	}
+	oldWords, newWords := vocabulary(before), vocabulary(after)
+	section("Vocabulary changed", difference(oldWords, newWords), difference(newWords, oldWords))
+	oldRules, newRules := rules(before), rules(after)
+	section("Rules changed", difference(oldRules, newRules), difference(newRules, oldRules))
+
+	out.WriteString("```diff\n" + strings.Join(diffLines(before, after), "\n") + "\n```")
+	return out.String()
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"l2/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// userMessages returns the user's messages in the conversation, oldest first
+func (m *Model) userMessages() []*schema.Message {
+	messages := []*schema.Message{}
+	for _, msg := range m.conversation() {
+		if msg.Role == schema.User {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// recallMessage puts the user message before the one being edited, or the
+// latest one, into the input for editing
+func (m *Model) recallMessage() {
+	if m.streaming || m.tutorial != nil || (m.plan != nil && m.plan.active()) {
+		return
+	}
+	messages := m.userMessages()
+	i := len(messages) - 1
+	if m.editing != nil {
+		i = slices.Index(messages, m.editing) - 1
+	}
+	if i < 0 {
+		if len(messages) == 0 {
+			m.notice = "No earlier messages to edit"
+		}
+		return // Stay on the oldest message
+	}
+
+	m.editing = messages[i]
+	m.ta.SetValue(m.editing.Content)
+	m.ta.CursorEnd()
+	if i == len(messages)-1 {
+		m.notice = "✏️ **Editing your latest message:** `enter` resends it in place of the original and its answer, `ctrl+r` goes further back, `esc` cancels"
+	} else {
+		m.notice = fmt.Sprintf("✏️ **Editing message %d of %d:** `enter` resends it in a new branch, keeping the original thread in **%s**; `ctrl+r` goes further back, `esc` cancels", i+1, len(messages), storage.Branch())
+	}
+	m.lastRenderTime = time.Time{}
+	m.updateViewportContent()
+}
+
+// cancelEdit leaves the message being edited as it was
+func (m *Model) cancelEdit() {
+	m.editing = nil
+	m.ta.SetValue("")
+	m.notice = ""
+	m.lastRenderTime = time.Time{}
+	m.updateViewportContent()
+}
+
+// resendEdited sends the edited text in place of the recalled message. The
+// latest message is replaced along with its answer; an earlier one starts a
+// branch from just before it, so the original thread stays as it was.
+func (m *Model) resendEdited(text string) tea.Cmd {
+	edited := m.editing
+	m.editing = nil
+	m.showJobs = false
+	cut := slices.Index(m.history, edited)
+	if cut < 0 {
+		return m.sendMessage(text) // The history changed under the edit
+	}
+
+	messages := m.userMessages()
+	if edited == messages[len(messages)-1] {
+		m.history = m.history[:cut]
+		m.dirty = true
+		m.notice = ""
+		return m.sendMessage(text)
+	}
+
+	branches, err := storage.ListBranches()
+	if err == nil {
+		original := storage.Branch()
+		name := nextBranchName(branches)
+		if err = m.forkAt(cut, name); err == nil {
+			m.notice = fmt.Sprintf("Resent in branch **%s**; `/branch %s` goes back to the original thread", name, original)
+		}
+	}
+	if err != nil {
+		m.notice = errorNotice(err)
+		return nil
+	}
+	return m.sendMessage(text)
+}
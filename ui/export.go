@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func exportCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) > 1 {
+		return "Usage: `" + commands["export"].usage + "`", nil
+	}
+	if len(m.history) == 0 {
+		return "The conversation is empty", nil
+	}
+	path := "conversation-" + time.Now().Format("2006-01-02-1504") + ".md"
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	defer file.Close()
+	if err := tools.WriteTranscript(file, m.history); err != nil {
+		return errorNotice(err), nil
+	}
+	return fmt.Sprintf("Exported %d messages to `%s`", len(m.conversation()), path), nil
+}
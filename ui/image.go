@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// imagesDir holds attached images in the data files, so glyphs transcribed
+// from a photo can refer to it
+const imagesDir = "images"
+
+// transcriptions are what /attach-image asks the model to do with a photo,
+// by kind; %s is where the image is kept in the data files
+var transcriptions = map[string]string{
+	"glyphs": "The image is a photo of handwritten glyph sketches for the native script. Transcribe it glyph by glyph: " +
+		"describe each glyph's shape and read any label giving the sound, grapheme or morpheme it writes. " +
+		"Add each labelled glyph with add_glyph, using %s as its image reference, and list the unlabelled ones " +
+		"to ask about instead of guessing what they write.",
+	"table": "The image is a photo of a handwritten notebook table. Transcribe it as a Markdown table, keeping its " +
+		"columns and IPA exactly as written and marking illegible cells with [?]. If it is a word list, add its words " +
+		"with add_lexicon_entry; otherwise store it with add_file. The photo is kept as %s.",
+	"notes": "The image is a photo of handwritten notes. Transcribe them faithfully as Markdown, marking illegible " +
+		"words with [?], and ask before storing anything. The photo is kept as %s.",
+}
+
+func attachImageCommand(m *Model, args []string) (string, tea.Cmd) {
+	kinds := make([]string, 0, len(transcriptions))
+	for kind := range transcriptions {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	kind := "notes"
+	if len(args) > 1 && transcriptions[args[len(args)-1]] != "" {
+		kind, args = args[len(args)-1], args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		return "Usage: `" + commands["attach-image"].usage + "`\n\nKinds: `" + strings.Join(kinds, "`, `") + "`", nil
+	}
+
+	path, data, err := readAttachment(strings.Join(args, " "))
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	mimeType, ok := tools.ImageTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return fmt.Sprintf("❌ **Error:** %s is not a PNG, JPEG, GIF or WebP image", path), nil
+	}
+	if err := m.checkImage(path, data); err != nil {
+		return errorNotice(err), nil
+	}
+
+	// Glyphs and lexicon entries transcribed from the photo can point at it
+	stored := filepath.ToSlash(filepath.Join(imagesDir, filepath.Base(path)))
+	if err := storage.WriteDataFile(stored, data); err != nil {
+		return errorNotice(err), nil
+	}
+	out := m.attachImage(path, mimeType, data)
+	m.attachments = append(m.attachments, attachment{
+		name:    "instructions for " + filepath.Base(path),
+		content: fmt.Sprintf(transcriptions[kind], stored),
+	})
+	return out + fmt.Sprintf(", to be transcribed as **%s** (kept as `%s`). Send a message, such as \"transcribe this\", to start", kind, stored), nil
+}
@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jobStatus is how far a background job has got
+type jobStatus int
+
+const (
+	jobRunning jobStatus = iota
+	jobDone
+	jobFailed
+	jobCancelled
+)
+
+// jobStatusNames label job statuses in the /jobs screen
+var jobStatusNames = map[jobStatus]string{
+	jobRunning:   "⏳ running",
+	jobDone:      "✅ done",
+	jobFailed:    "❌ failed",
+	jobCancelled: "⏹ cancelled",
+}
+
+// job is a long-running operation run in the background so the chat stays
+// responsive while it works
+type job struct {
+	id       int
+	name     string
+	started  time.Time
+	finished time.Time
+	done     int // Items processed so far
+	total    int // Items to process, zero until the job reports progress
+	status   jobStatus
+	result   string // Markdown report, or the error when the job failed
+	cancel   context.CancelFunc
+}
+
+// jobProgressMsg reports how far a background job has got. It carries the
+// job's event channel so the next event can be waited for.
+type jobProgressMsg struct {
+	id     int
+	done   int
+	total  int
+	events <-chan tea.Msg
+}
+
+// jobDoneMsg carries the report of a finished background job
+type jobDoneMsg struct {
+	id     int
+	result string
+	err    error
+}
+
+// startJob runs work in the background and returns the command that
+// delivers its progress and result to Update
+func (m *Model) startJob(name string, work func(ctx context.Context) (string, error)) (*job, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{id: len(m.jobs) + 1, name: name, started: time.Now(), cancel: cancel}
+	m.jobs = append(m.jobs, j)
+
+	events := make(chan tea.Msg, 1)
+	ctx = tools.WithProgress(ctx, func(done, total int) {
+		// Updates the interface hasn't caught up with yet are dropped
+		select {
+		case events <- jobProgressMsg{id: j.id, done: done, total: total, events: events}:
+		default:
+		}
+	})
+	go func() {
+		defer cancel()
+		result, err := work(ctx)
+		events <- jobDoneMsg{id: j.id, result: result, err: err}
+	}()
+	return j, waitForJob(events)
+}
+
+// waitForJob waits for the next event of a background job
+func waitForJob(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// findJob returns the job with the given number
+func (m *Model) findJob(id int) *job {
+	if id < 1 || id > len(m.jobs) {
+		return nil
+	}
+	return m.jobs[id-1]
+}
+
+// updateJobProgress records a progress report and refreshes the /jobs screen
+func (m *Model) updateJobProgress(msg jobProgressMsg) {
+	if j := m.findJob(msg.id); j != nil && j.status == jobRunning {
+		j.done, j.total = msg.done, msg.total
+	}
+	if m.showJobs {
+		m.updateViewportContent()
+	}
+}
+
+// finishJob records a job's result and announces it unless the /jobs screen
+// is already showing it
+func (m *Model) finishJob(msg jobDoneMsg) {
+	j := m.findJob(msg.id)
+	if j == nil || j.status == jobCancelled {
+		return
+	}
+	j.finished = time.Now()
+	switch {
+	case msg.err != nil:
+		j.status, j.result = jobFailed, msg.err.Error()
+	default:
+		j.status, j.result, j.done = jobDone, msg.result, j.total
+	}
+
+	if !m.showJobs {
+		if j.status == jobFailed {
+			m.notice = fmt.Sprintf("❌ **Job %d (%s) failed:** %s", j.id, j.name, j.result)
+		} else {
+			m.notice = fmt.Sprintf("✅ **Job %d (%s) finished** in %s\n\n%s", j.id, j.name, j.elapsed(), j.result)
+		}
+	}
+	m.lastRenderTime = time.Time{}
+	m.updateViewportContentInternal()
+}
+
+// elapsed is how long the job has been running, or ran for
+func (j *job) elapsed() time.Duration {
+	end := j.finished
+	if j.status == jobRunning {
+		end = time.Now()
+	}
+	return end.Sub(j.started).Round(100 * time.Millisecond)
+}
+
+// progressBar renders how far the job has got
+func (j *job) progressBar() string {
+	const width = 10
+	if j.total == 0 {
+		if j.status == jobDone {
+			return strings.Repeat("█", width) + " 100%"
+		}
+		return strings.Repeat("░", width) + " —"
+	}
+	filled := j.done * width / j.total
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + fmt.Sprintf(" %d%%", j.done*100/j.total)
+}
+
+// jobsScreen renders every job of the session with its progress
+func (m *Model) jobsScreen() string {
+	if len(m.jobs) == 0 {
+		return "No background jobs. Start one with `/jobs evolve <name>` or `/simulate`"
+	}
+	var out strings.Builder
+	out.WriteString("**Background jobs:**\n\n| # | Job | Progress | Status | Time |\n|---|---|---|---|---|\n")
+	for _, j := range m.jobs {
+		out.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s |\n", j.id, j.name, j.progressBar(), jobStatusNames[j.status], j.elapsed()))
+	}
+	out.WriteString("\nRead a result with `/jobs show <#>` and stop a job with `/jobs cancel <#>`")
+	return out.String()
+}
+
+func jobsCommand(m *Model, args []string) (string, tea.Cmd) {
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "list":
+		m.showJobs = true
+		return "", nil
+
+	case "show", "cancel":
+		if len(args) < 2 {
+			return "Usage: `" + commands["jobs"].usage + "`", nil
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		j := m.findJob(id)
+		if err != nil || j == nil {
+			return fmt.Sprintf("❌ **Error:** no job %s", args[1]), nil
+		}
+		if action == "show" {
+			if j.status == jobRunning {
+				return fmt.Sprintf("Job %d (%s) is still running: %s", j.id, j.name, j.progressBar()), nil
+			}
+			return fmt.Sprintf("**Job %d (%s)** %s\n\n%s", j.id, j.name, jobStatusNames[j.status], j.result), nil
+		}
+		if j.status != jobRunning {
+			return fmt.Sprintf("Job %d has already finished", j.id), nil
+		}
+		j.cancel()
+		j.status, j.finished, j.result = jobCancelled, time.Now(), "Cancelled before it finished"
+		return fmt.Sprintf("✅ **Cancelled job %d (%s)**", j.id, j.name), nil
+
+	case "evolve":
+		if len(args) < 2 {
+			return "Usage: `/jobs evolve <name> [rules-file] [parent]`", nil
+		}
+		req := &tools.EvolveRequest{Name: args[1]}
+		if len(args) > 2 {
+			req.RulesFile = args[2]
+		}
+		if len(args) > 3 {
+			req.Parent = args[3]
+		}
+		j, cmd := m.startJob("evolve "+req.Name, func(ctx context.Context) (string, error) {
+			result, _ := tools.EvolveLexicon(ctx, req)
+			if !result.Success {
+				return "", errors.New(result.Message)
+			}
+			return formatEvolveResult(result), nil
+		})
+		return fmt.Sprintf("Evolving %s in the background as job %d; follow it with `/jobs`", req.Name, j.id), cmd
+	}
+
+	return "Usage: `" + commands["jobs"].usage + "`", nil
+}
+
+// formatEvolveResult renders an evolved descendant as markdown
+func formatEvolveResult(result *tools.EvolveResult) string {
+	var out strings.Builder
+	out.WriteString(result.Message + " (saved to `" + result.Path + "`)\n")
+	if len(result.Examples) > 0 {
+		out.WriteString("\nExamples:\n")
+		for _, example := range result.Examples {
+			out.WriteString("- " + example + "\n")
+		}
+	}
+	if len(result.Mergers) > 0 {
+		out.WriteString("\nMergers:\n")
+		for _, merger := range result.Mergers {
+			out.WriteString("- " + merger + "\n")
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cloudwego/eino/schema"
+)
+
+// statusStyle is the line under the input showing the active language
+var statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+// statusBar renders the active language and session details
+func (m *Model) statusBar() string {
+	parts := []string{"🌐 " + storage.Language(), fmt.Sprintf("%d tokens", m.stats.TotalTokens)}
+	if m.stats.Cost > 0 {
+		parts[1] += fmt.Sprintf(" ($%.2f)", m.stats.Cost)
+	}
+	if storage.Branch() != storage.MainBranch {
+		parts[0] += " ⎇ " + storage.Branch()
+	}
+	if m.verbosity != tools.VerbosityNormal {
+		parts = append(parts, m.verbosity)
+	}
+	if m.raw {
+		parts = append(parts, "raw")
+	}
+	if storage.ReadOnly() {
+		parts = append(parts, "read-only")
+	}
+	return statusStyle.Render(strings.Join(parts, " · "))
+}
+
+// switchLanguage saves the current language's conversation and loads the
+// other language's, starting a new session summary for it
+func (m *Model) switchLanguage(name string) error {
+	if err := storage.WriteConversation(m.history); err != nil {
+		return err
+	}
+	if summary, err := m.SessionSummary(); err == nil {
+		if config, err := storage.ReadConfig(); err == nil && config.LogSessions {
+			if err := storage.AppendSession(summary); err != nil {
+				log.Printf("Failed to write the session log: %v", err)
+			}
+		}
+	}
+
+	previous := storage.Language()
+	if err := storage.SetLanguage(name); err != nil {
+		return err
+	}
+	config, _ := storage.ReadConfig()
+	storage.RestoreBranch(config.Branches) // Each language remembers its own branch
+	history, err := storage.ReadConversation()
+	if err != nil {
+		history = []*schema.Message{}
+	}
+	m.useConversation(history)
+	if m.session, err = tools.TakeSnapshot(m.stats); err != nil {
+		log.Printf("Failed to record the session's starting state: %v", err)
+	}
+
+	err = storage.UpdateConfig(func(config *storage.Config) error {
+		config.Language = name
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to save the active language after switching from %s: %v", previous, err)
+	}
+	return nil
+}
+
+// useConversation replaces the history with another conversation, such as
+// another language's or branch's, and resets what belonged to the old one
+func (m *Model) useConversation(history []*schema.Message) {
+	m.history = history
+	m.dirty = false
+	if !slices.ContainsFunc(history, func(msg *schema.Message) bool { return msg.Role == schema.System }) {
+		m.SetPrompts()
+	}
+	m.rewrites = nil
+	m.lints = map[*schema.Message][]string{}
+	m.rawMessages = map[*schema.Message]bool{}
+	m.summary, m.summarized = "", ""
+	m.sentAttachments = nil
+	m.firstCall = len(m.toolCalls)
+	m.expandedTool = 0
+	m.refreshAnnotations()
+}
+
+func langCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		languages, err := storage.ListLanguages()
+		if err != nil {
+			return errorNotice(err), nil
+		}
+		var out strings.Builder
+		out.WriteString("**Languages:**\n\n")
+		for _, name := range languages {
+			if name == storage.Language() {
+				out.WriteString("• **" + name + "** (active)\n")
+			} else {
+				out.WriteString("• " + name + "\n")
+			}
+		}
+		out.WriteString("\n`/lang <name>` switches to a language, creating it if it's new")
+		return out.String(), nil
+	}
+	if m.streaming {
+		return "Wait for the current response to finish before switching languages", nil
+	}
+	if m.plan != nil && m.plan.active() {
+		return "Switching languages is unavailable while a plan is running", nil
+	}
+	if args[0] == storage.Language() {
+		return fmt.Sprintf("**%s** is already the active language", args[0]), nil
+	}
+
+	if err := m.switchLanguage(args[0]); err != nil {
+		return errorNotice(err), nil
+	}
+	return fmt.Sprintf("Switched to **%s**: its lexicon, grammar, data files and conversation are now in use", storage.Language()), nil
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Lint modes: contradiction checks after each response use local heuristics,
+// add a model pass, or are off
+const (
+	lintLocal = "local"
+	lintLLM   = "llm"
+	lintOff   = "off"
+)
+
+// lintMsg carries the contradictions the model found in a response
+type lintMsg struct {
+	msg    *schema.Message
+	issues []string
+}
+
+// lintResponse checks a response against the stored design and flags the
+// contradictions below it. In llm mode the returned command adds the
+// model's findings.
+func (m *Model) lintResponse(msg *schema.Message) tea.Cmd {
+	if m.lint == lintOff || msg.Content == "" {
+		return nil
+	}
+	issues, err := tools.LintResponse(msg.Content)
+	if err != nil {
+		log.Printf("Failed to lint response: %v", err)
+	}
+	m.flagIssues(msg, issues)
+	if m.lint != lintLLM {
+		return nil
+	}
+
+	llm := m.llm
+	return func() tea.Msg {
+		prompt, err := tools.LintPrompt(msg.Content)
+		if err != nil || prompt == "" {
+			return lintMsg{msg: msg}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		reply, err := llm.Invoke(ctx, []*schema.Message{schema.UserMessage(prompt)})
+		if err != nil || len(reply) == 0 {
+			log.Printf("Failed to lint response with the model: %v", err)
+			return lintMsg{msg: msg}
+		}
+		return lintMsg{msg: msg, issues: tools.ParseLintReply(reply[len(reply)-1].Content)}
+	}
+}
+
+// flagIssues records contradictions found in a message, skipping repeats
+func (m *Model) flagIssues(msg *schema.Message, issues []string) {
+	for _, issue := range issues {
+		seen := false
+		for _, flagged := range m.lints[msg] {
+			seen = seen || strings.EqualFold(flagged, issue)
+		}
+		if !seen {
+			m.lints[msg] = append(m.lints[msg], issue)
+		}
+	}
+}
+
+// lintFlags renders the contradictions flagged in a message
+func (m *Model) lintFlags(msg *schema.Message) string {
+	var out strings.Builder
+	for _, issue := range m.lints[msg] {
+		out.WriteString("> ⚠️ **Contradiction:** " + issue + "\n")
+	}
+	return out.String()
+}
+
+func lintCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "on", lintLocal:
+			m.lint = lintLocal
+		case lintLLM, lintOff:
+			m.lint = args[0]
+		default:
+			return "Usage: `" + commands["lint"].usage + "`", nil
+		}
+
+		err := storage.UpdateConfig(func(config *storage.Config) error {
+			config.Lint = m.lint
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to save lint setting: %v", err)
+		}
+	}
+
+	switch m.lint {
+	case lintOff:
+		return "Contradiction checks **off**", nil
+	case lintLLM:
+		return "Contradiction checks **on**, with a model pass over the decision log after each response", nil
+	}
+	return "Contradiction checks **on**: transcriptions, word order and case counts are checked after each response", nil
+}
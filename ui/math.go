@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mathSpan matches LaTeX math in a response: $$…$$, \[…\], \(…\), and $…$
+// when it holds a command, so prices and other dollar signs are left alone
+var mathSpan = regexp.MustCompile(`\$\$([\s\S]+?)\$\$|\\\[([\s\S]+?)\\\]|\\\(([\s\S]+?)\\\)|\$([^$\n]*\\[^$\n]*)\$`)
+
+// mathEnvironment matches a LaTeX environment such as a feature matrix
+var mathEnvironment = regexp.MustCompile(`(?s)\\begin\{(\w+\*?)\}(.*?)\\end\{\w+\*?\}`)
+
+// mathDelimiter matches \left and \right with the delimiter they size, which
+// matrices draw themselves
+var mathDelimiter = regexp.MustCompile(`\\(?:left|right)\s*(?:\\[{}|]|[\[\]().|])`)
+
+// mathSymbols are the commands common in phonological rules and their
+// Unicode forms
+var mathSymbols = map[string]string{
+	"rightarrow": "→", "to": "→", "longrightarrow": "⟶", "Rightarrow": "⇒", "implies": "⇒",
+	"leftarrow": "←", "gets": "←", "leftrightarrow": "↔", "mapsto": "↦",
+	"emptyset": "∅", "varnothing": "∅", "in": "∈", "notin": "∉", "cup": "∪", "cap": "∩",
+	"neg": "¬", "lnot": "¬", "pm": "±", "mp": "∓", "times": "×", "cdot": "·", "ldots": "…", "dots": "…",
+	"neq": "≠", "ne": "≠", "leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "approx": "≈", "sim": "~",
+	"infty": "∞", "prime": "′", "vert": "|", "mid": "|", "langle": "⟨", "rangle": "⟩", "slash": "/",
+	"quad": "  ", "qquad": "    ", "sharp": "#",
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε", "varepsilon": "ε", "zeta": "ζ",
+	"eta": "η", "theta": "θ", "iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "rho": "ρ", "sigma": "σ", "tau": "τ", "upsilon": "υ", "phi": "φ", "varphi": "φ", "chi": "χ",
+	"psi": "ψ", "omega": "ω", "Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+// mathTextCommands show their argument as it is
+var mathTextCommands = map[string]bool{
+	"text": true, "textrm": true, "textit": true, "textbf": true, "textsc": true, "textsf": true,
+	"mathrm": true, "mathit": true, "mathbf": true, "mathsf": true, "mathtt": true, "operatorname": true,
+	"overline": true, "boldsymbol": true, "mbox": true,
+}
+
+// superscripts and subscripts map characters to their raised and lowered forms
+var (
+	superscripts = map[rune]rune{
+		'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+		'+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ', 'h': 'ʰ', 'j': 'ʲ', 'w': 'ʷ',
+		'C': 'ᶜ', 'V': 'ⱽ', 'ʕ': 'ˤ', 'ɣ': 'ˠ', 'l': 'ˡ', 's': 'ˢ', 'x': 'ˣ', 'r': 'ʳ', 'y': 'ʸ',
+	}
+	subscripts = map[rune]rune{
+		'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+		'+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'o': 'ₒ', 'x': 'ₓ', 'h': 'ₕ',
+		'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ', 'p': 'ₚ', 's': 'ₛ', 't': 'ₜ', 'i': 'ᵢ', 'r': 'ᵣ', 'u': 'ᵤ', 'v': 'ᵥ',
+	}
+)
+
+// renderMath replaces the LaTeX math in a response with Unicode. Rules with
+// multi-row feature matrices become aligned code blocks; everything else
+// stays inline. Fenced code is left as it is.
+func renderMath(text string) string {
+	if !strings.Contains(text, "$") && !strings.Contains(text, `\`) {
+		return text
+	}
+	parts := strings.Split(text, "```")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = mathSpan.ReplaceAllStringFunc(parts[i], func(span string) string {
+			match := mathSpan.FindStringSubmatch(span)
+			return formatMath(match[1] + match[2] + match[3] + match[4])
+		})
+	}
+	return strings.Join(parts, "```")
+}
+
+// formatMath lays out one math span, stacking matrix rows between brackets
+// with the rest of the rule centred beside them
+func formatMath(math string) string {
+	math = mathDelimiter.ReplaceAllString(math, "")
+
+	var blocks [][]string
+	last := 0
+	for _, loc := range mathEnvironment.FindAllStringSubmatchIndex(math, -1) {
+		if text := mathInline(math[last:loc[0]]); text != "" {
+			blocks = append(blocks, []string{text})
+		}
+		blocks = append(blocks, mathMatrix(math[loc[2]:loc[3]], math[loc[4]:loc[5]]))
+		last = loc[1]
+	}
+	if text := mathInline(math[last:]); text != "" {
+		blocks = append(blocks, []string{text})
+	}
+
+	height := 0
+	for _, block := range blocks {
+		height = max(height, len(block))
+	}
+	if height <= 1 {
+		var line []string
+		for _, block := range blocks {
+			line = append(line, block...)
+		}
+		return escapeMarkdown(strings.Join(line, " "))
+	}
+
+	lines := make([]string, height)
+	for b, block := range blocks {
+		width := 0
+		for _, line := range block {
+			width = max(width, lipgloss.Width(line))
+		}
+		top := (height - len(block)) / 2
+		for row := range lines {
+			cell := ""
+			if row >= top && row < top+len(block) {
+				cell = block[row-top]
+			}
+			if b > 0 {
+				lines[row] += " "
+			}
+			lines[row] += cell + strings.Repeat(" ", width-lipgloss.Width(cell))
+		}
+	}
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return "\n```\n" + strings.Join(lines, "\n") + "\n```\n"
+}
+
+// mathMatrix draws the rows of a matrix environment between brackets;
+// other environments, such as aligned, become plain stacked lines
+func mathMatrix(environment, body string) []string {
+	if environment == "array" {
+		// Drop the column specification
+		if strings.HasPrefix(strings.TrimSpace(body), "{") {
+			body = body[strings.Index(body, "}")+1:]
+		}
+	}
+
+	var rows []string
+	width := 0
+	for _, row := range strings.Split(body, `\\`) {
+		var cells []string
+		for _, cell := range strings.Split(row, "&") {
+			cell = mathInline(cell)
+			if strings.HasPrefix(cell, "-") {
+				cell = "−" + cell[1:] // A feature's minus sign
+			}
+			if cell != "" {
+				cells = append(cells, cell)
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		rows = append(rows, strings.Join(cells, " "))
+		width = max(width, lipgloss.Width(rows[len(rows)-1]))
+	}
+
+	// Single rows use plain brackets; taller ones are drawn from pieces
+	left, right := "[", "]"
+	first, middle, last := "⎡", "⎢", "⎣"
+	closeFirst, closeMiddle, closeLast := "⎤", "⎥", "⎦"
+	switch environment {
+	case "pmatrix":
+		left, right = "(", ")"
+		first, middle, last = "⎛", "⎜", "⎝"
+		closeFirst, closeMiddle, closeLast = "⎞", "⎟", "⎠"
+	case "matrix", "aligned", "align", "align*", "gathered", "split", "cases":
+		return rows
+	}
+	if len(rows) == 1 {
+		return []string{left + rows[0] + right}
+	}
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		open, close := middle, closeMiddle
+		switch i {
+		case 0:
+			open, close = first, closeFirst
+		case len(rows) - 1:
+			open, close = last, closeLast
+		}
+		lines[i] = open + row + strings.Repeat(" ", width-lipgloss.Width(row)) + close
+	}
+	return lines
+}
+
+// mathInline converts commands, scripts and grouping braces to plain Unicode
+func mathInline(math string) string {
+	runes := []rune(math)
+	var out strings.Builder
+
+	// argument reads a braced group or a single character
+	argument := func(i int) (string, int) {
+		for i < len(runes) && runes[i] == ' ' {
+			i++
+		}
+		if i >= len(runes) {
+			return "", i
+		}
+		if runes[i] != '{' {
+			return string(runes[i]), i + 1
+		}
+		depth := 0
+		for j := i; j < len(runes); j++ {
+			switch runes[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					return string(runes[i+1 : j]), j + 1
+				}
+			}
+		}
+		return string(runes[i+1:]), len(runes)
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			j := i + 1
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			name := string(runes[i+1 : j])
+			if name == "" {
+				// An escaped character, or spacing such as \, and \;
+				if j < len(runes) {
+					switch runes[j] {
+					case ',', ';', ':', ' ':
+						out.WriteRune(' ')
+					case '\\', '!':
+					default:
+						out.WriteRune(runes[j])
+					}
+					j++
+				}
+				i = j
+				continue
+			}
+			switch {
+			case mathTextCommands[name]:
+				var arg string
+				arg, j = argument(j)
+				out.WriteString(mathInline(arg))
+			case name == "underline" || name == "hspace" || name == "phantom":
+				var arg string
+				arg, j = argument(j)
+				if name == "underline" && strings.TrimSpace(mathInline(arg)) != "" {
+					out.WriteString(mathInline(arg))
+				} else {
+					out.WriteRune('_') // The focus of a rule's environment
+				}
+			case name == "frac":
+				var a, b string
+				a, j = argument(j)
+				b, j = argument(j)
+				out.WriteString(mathInline(a) + "/" + mathInline(b))
+			case name == "sqrt":
+				var arg string
+				arg, j = argument(j)
+				out.WriteString("√" + mathInline(arg))
+			default:
+				if symbol, ok := mathSymbols[name]; ok {
+					out.WriteString(symbol)
+				} else {
+					out.WriteString(name)
+				}
+			}
+			i = j
+		case r == '^' || r == '_':
+			next := i + 1
+			if r == '_' && (next >= len(runes) || (runes[next] != '{' && !unicode.IsDigit(runes[next]))) {
+				// A bare underscore marks the focus, as in / V _ V
+				out.WriteRune('_')
+				i++
+				continue
+			}
+			arg, j := argument(next)
+			table := superscripts
+			if r == '_' {
+				table = subscripts
+			}
+			out.WriteString(script(mathInline(arg), table, r))
+			i = j
+		case r == '{' || r == '}':
+			i++
+		case r == '~':
+			out.WriteRune(' ')
+			i++
+		default:
+			out.WriteRune(r)
+			i++
+		}
+	}
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// script raises or lowers text when every character has a Unicode form,
+// otherwise keeps the LaTeX marker in front of it
+func script(text string, table map[rune]rune, marker rune) string {
+	var out strings.Builder
+	for _, r := range text {
+		mapped, ok := table[r]
+		if !ok {
+			if len([]rune(text)) == 1 {
+				return string(marker) + text
+			}
+			return string(marker) + "(" + text + ")"
+		}
+		out.WriteRune(mapped)
+	}
+	return out.String()
+}
+
+// markdownSpecials are escaped so converted rules aren't read as emphasis
+var markdownSpecials = strings.NewReplacer(`*`, `\*`, `_`, `\_`)
+
+func escapeMarkdown(text string) string {
+	return markdownSpecials.Replace(text)
+}
@@ -2,12 +2,19 @@ package ui
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"l2/storage"
 	"l2/tools"
@@ -35,11 +42,54 @@ type Model struct {
 	history         []*schema.Message
 	streaming       bool
 	currentResponse strings.Builder
+	answer          strings.Builder // Model text of the response being streamed, without tool calls and results
 	tokenChan       chan string
 	glam            *glamour.TermRenderer
 	stats           storage.Stats
 	quit            bool
 	thinking        bool
+	notice          string // Output of the last slash command, shown below the history
+	define          defineState
+	summarizeAbove  int                          // Conversation size in tokens above which the context is summarized
+	summary         string                       // Cached model summary of the conversation
+	summarized      string                       // Fingerprint of the conversation the cached summary covers
+	annotate        bool                         // Underline lexicon words in the conversation
+	lexiconWords    map[string]bool              // Lowercased lexicon words used for annotation
+	recording       *recording                   // Microphone recording in progress for /record
+	attachments     []attachment                 // Files and pastes included in the next request
+	sentAttachments []attachment                 // Files and pastes the last request went with, for /retry
+	pastes          int                          // Number of pastes attached so far, for naming them
+	plan            *planState                   // Plan being carried out one step per turn
+	proposedPlan    *tools.PlanResult            // Plan proposed by the response being streamed
+	responseFailed  bool                         // A tool failed during the response being streamed
+	streamErr       error                        // Why the response being streamed stopped early; nil if it finished
+	cancelStream    context.CancelFunc           // Cancels the response being streamed
+	turnUsage       *tools.TurnUsage             // Tokens of the response being streamed
+	jobs            []*job                       // Background jobs of this session, numbered from 1
+	showJobs        bool                         // Show the live /jobs screen below the history
+	confirmTools    []string                     // Tools whose calls wait for approval; "all" for every tool
+	approvals       chan approvalRequest         // Tool calls handed over for approval while streaming
+	approval        *approvalRequest             // Tool call waiting for the user's answer
+	rewrites        []*schema.Message            // Earlier versions of the last response, for /rewrite undo
+	rewriting       bool                         // The response being streamed rewrites the last one
+	lint            string                       // Contradiction checks after each response: local, llm or off
+	lints           map[*schema.Message][]string // Contradictions flagged in responses
+	toolCalls       []*toolCall                  // Tool calls of this session, numbered from 1
+	toolMu          sync.Mutex                   // Guards toolCalls while a response streams in
+	firstCall       int                          // Index of the latest response's first tool call
+	showTools       bool                         // Show the tool call panel below the response
+	expandedTool    int                          // Number of the tool call expanded in the panel; 0 for none
+	tutorial        *tutorialState               // Guided tutorial in progress
+	session         *tools.SessionSnapshot       // State of the language when the session started
+	verbosity       string                       // How much answers explain: terse, normal or teacher
+	raw             bool                         // Show messages as raw text instead of rendered markdown
+	rawMessages     map[*schema.Message]bool     // Messages toggled away from the raw mode setting
+	markdownStyle   string                       // Glamour style name or style JSON file
+	dirty           bool                         // The history has changed since it was last saved
+	autosaveEvery   time.Duration                // Time between autosaves; 0 turns the timer off
+	model           string                       // Model answering the chat
+	capabilities    storage.ModelCapabilities    // What the model supports
+	editing         *schema.Message              // Earlier user message recalled into the input for editing
 
 	// Optimization fields for long responses
 	maxHistoryDisplay int           // Maximum number of history messages to display
@@ -54,9 +104,12 @@ type streamStartMsg struct{}
 type exitMsg struct{}
 type tickMsg struct{}
 
+// streamErrorMsg reports a response that could not be started
+type streamErrorMsg struct{ err error }
+
 // Init implements tea.Model.
 func (m *Model) Init() tea.Cmd {
-	return tea.Batch(textarea.Blink)
+	return tea.Batch(textarea.Blink, m.autosaveTick())
 }
 
 // tick returns a command that sends a tick message
@@ -68,7 +121,9 @@ func tick() tea.Cmd {
 
 // AddToHistory adds a message to the conversation history
 func (m *Model) AddToHistory(msg *schema.Message) {
+	tools.StampMessage(msg, time.Now())
 	m.history = append(m.history, msg)
+	m.dirty = true
 }
 
 // Update implements tea.Model.
@@ -79,7 +134,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		viewportWidth := msg.Width - 2
-		viewportHeight := msg.Height - (len(ascii) + 3)
+		viewportHeight := msg.Height - (len(ascii) + 4) // Less the banner, input and status bar
 
 		if viewportWidth < 1 {
 			viewportWidth = 1
@@ -98,40 +153,70 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.ready = true
 
-		glam, err := glamour.NewTermRenderer(
-			glamour.WithStandardStyle("dark"),
-			glamour.WithEmoji(),
-			glamour.WithWordWrap(viewportWidth-4),
-		)
+		glam, err := newRenderer(m.markdownStyle, viewportWidth-4)
 		if err != nil {
-			log.Fatal(err)
+			// A broken style file falls back to the default style
+			log.Printf("Failed to load markdown style %s: %v", m.markdownStyle, err)
+			if glam, err = newRenderer(defaultMarkdownStyle, viewportWidth-4); err != nil {
+				log.Fatal(err)
+			}
 		}
 		m.glam = glam
 
 		m.updateViewportContent()
 
+	case autosaveMsg:
+		m.saveConversation()
+		return m, m.autosaveTick()
+
 	case tickMsg:
 		if m.streaming {
 			select {
 			case token, ok := <-m.tokenChan:
 				if !ok {
 					m.streaming = false
-					m.AddToHistory(schema.AssistantMessage(m.currentResponse.String(), nil))
+					m.approval = nil
+					// Tool calls are kept as their own items; the answer holds only the model's text
+					for _, item := range m.latestToolCalls() {
+						m.AddToHistory(item)
+					}
+					response := schema.AssistantMessage(m.answer.String(), nil)
+					if m.streamErr != nil {
+						markInterrupted(response, m.streamErr)
+					}
+					tools.StampUsage(response, m.turnUsage)
+					m.AddToHistory(response)
+					lint := m.lintResponse(response)
+					if m.rewriting {
+						m.rewriting = false
+						m.notice = m.rewriteDiff()
+					}
 					m.resetOptimizationParams() // Reset to default values
+					m.refreshAnnotations()      // Tools may have added words during the response
+					m.refreshStats()
 					// Force a viewport refresh by bypassing throttling
 					m.lastRenderTime = time.Time{} // Reset to force immediate update
 					m.updateViewportContentInternal()
-					storage.WriteConversation(m.history)
+					m.saveConversation()
+					m.continueTutorial()
+					if cmd := m.continuePlan(); cmd != nil {
+						return m, tea.Batch(cmd, lint)
+					}
 					// Add a small delay to ensure UI processes the state change
-					return m, tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg {
+					return m, tea.Batch(lint, tea.Tick(50*time.Millisecond, func(t time.Time) tea.Msg {
 						return nil
-					})
+					}))
 				}
 				m.currentResponse.WriteString(token)
 				m.adjustOptimizationParams() // Adjust parameters based on response length
 				m.cleanupLongResponse()      // Clean up if response gets too long
 				m.updateViewportContent()
 				return m, tick()
+			case req := <-m.approvals:
+				m.approval = &req
+				m.lastRenderTime = time.Time{}
+				m.updateViewportContentInternal()
+				return m, tick()
 			default:
 				return m, tick()
 			}
@@ -141,13 +226,96 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case exitMsg:
 		return m, tea.Sequence(tea.ExitAltScreen, tea.Quit)
 
+	case contextMsg:
+		m.notice = string(msg)
+		m.lastRenderTime = time.Time{}
+		m.updateViewportContentInternal()
+
+	case transcriptionMsg:
+		if msg.err != nil {
+			m.notice = errorNotice(msg.err)
+		} else if msg.text == "" {
+			m.notice = "No speech was recognized"
+		} else {
+			m.ta.InsertString(msg.text)
+			m.notice = "✅ **Transcribed** into the input; edit it and press enter to send"
+		}
+		m.lastRenderTime = time.Time{}
+		m.updateViewportContentInternal()
+
+	case systemEditedMsg:
+		m.notice = m.finishSystemEdit(msg)
+		m.lastRenderTime = time.Time{}
+		m.updateViewportContentInternal()
+
+	case jobProgressMsg:
+		m.updateJobProgress(msg)
+		return m, waitForJob(msg.events)
+
+	case lintMsg:
+		m.flagIssues(msg.msg, msg.issues)
+		m.lastRenderTime = time.Time{}
+		m.updateViewportContentInternal()
+
+	case jobDoneMsg:
+		m.finishJob(msg)
+		return m, nil
+
+	case streamErrorMsg:
+		m.notice = errorNotice(msg.err)
+		m.refreshStats() // Model calls before the error were still paid for
+		m.responseFailed = true
+		m.restoreRewritten()
+		m.continueTutorial()
+		m.continuePlan()
+		m.lastRenderTime = time.Time{}
+		m.updateViewportContentInternal()
+
 	case streamStartMsg:
 		// Start the ticker for streaming
 		return m, tick()
 
 	case tea.KeyMsg:
+		if m.approval != nil {
+			switch msg.String() {
+			case "y", "enter":
+				m.answerApproval(true)
+			case "n", "esc":
+				m.answerApproval(false)
+			}
+			m.lastRenderTime = time.Time{}
+			m.updateViewportContentInternal()
+			return m, nil
+		}
+		if m.define.active {
+			m.updateDefine(msg)
+			return m, nil
+		}
+
+		// Long pastes don't fit the single-line input, so they are attached
+		if msg.Paste && utf8.RuneCountInString(string(msg.Runes)) > pasteAttachRunes {
+			m.attachPaste(string(msg.Runes))
+			return m, nil
+		}
+
 		switch msg.Type {
+		case tea.KeyCtrlK:
+			m.startDefine()
+			return m, nil
+		case tea.KeyCtrlR:
+			m.recallMessage()
+			return m, nil
 		case tea.KeyEsc:
+			if m.editing != nil {
+				m.cancelEdit()
+				return m, nil
+			}
+			if m.plan != nil && m.plan.active() {
+				m.abortPlan()
+				m.lastRenderTime = time.Time{}
+				m.updateViewportContentInternal()
+				return m, nil
+			}
 			if m.ta.Focused() {
 				m.ta.Blur()
 			}
@@ -161,25 +329,34 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			// Add user message to history
-			m.AddToHistory(schema.UserMessage(userMessage))
-
-			// Update viewport to show the new message
-			m.updateViewportContent()
-
-			// Start streaming response
-			m.streaming = true
-			m.currentResponse.Reset()
-			m.tokenChan = make(chan string, 100) // Buffer for tokens
-
-			// Start streaming in background with the user message
-			cmds = append(cmds, m.startStreaming(userMessage))
+			if isCommand(userMessage) {
+				m.editing = nil
+				m.ta.SetValue("")
+				return m, m.handleCommand(userMessage)
+			}
+			if m.editing != nil {
+				m.ta.SetValue("")
+				return m, m.resendEdited(userMessage)
+			}
+			m.notice = ""
+			m.showJobs = false
+			if m.plan != nil && !m.plan.active() {
+				m.plan = nil
+			}
+			if m.tutorial != nil {
+				cmds = append(cmds, m.answerTutorial(userMessage))
+			} else {
+				cmds = append(cmds, m.sendMessage(userMessage))
+			}
 
 			m.ta.SetValue("")
 			return m, tea.Batch(cmds...)
 		case tea.KeyCtrlC:
-			storage.WriteConversation(m.history)
-			storage.WriteStats(m.stats)
+			if m.recording != nil {
+				os.Remove(m.stopRecording())
+			}
+			m.restoreRewritten() // An unfinished rewrite keeps the response it was replacing
+			m.saveConversation()
 			return m, tea.Sequence(m.Exit())
 
 		default:
@@ -214,21 +391,17 @@ func (m *Model) Exit() tea.Cmd {
 	}
 }
 
-func (m *Model) createCondensedHistory() []*schema.Message {
-	userMessages := make([]*schema.Message, 0)
-	for _, msg := range m.history {
-		if msg.Role == "user" || msg.Role == "assistant" {
-			userMessages = append(userMessages, msg)
-		}
-	}
-
+// createCondensedHistory condenses the conversation into a single context
+// message. Conversations under the summarize threshold are sent verbatim;
+// longer ones are summarized by the model.
+func (m *Model) createCondensedHistory(conversation []*schema.Message) []*schema.Message {
 	var contextMessage string
-	if len(userMessages) > 10 {
-		contextMessage = "CONTEXT: " + m.generateContextSummary(userMessages[:len(userMessages)-1])
-	} else if len(userMessages) > 0 {
-		contextMessage = "CONTEXT: " + m.formatExistingContext(userMessages)
-	} else {
+	if len(conversation) == 0 {
 		contextMessage = "CONTEXT: No previous conversation"
+	} else if tools.CountTokens(conversation) > m.summarizeAbove {
+		contextMessage = "CONTEXT: " + m.generateContextSummary(conversation)
+	} else {
+		contextMessage = "CONTEXT: " + m.formatExistingContext(conversation)
 	}
 
 	structuredMessage := schema.SystemMessage(contextMessage)
@@ -237,6 +410,15 @@ func (m *Model) createCondensedHistory() []*schema.Message {
 }
 
 func (m *Model) generateContextSummary(messages []*schema.Message) string {
+	// The summary only changes with the conversation, so /context show and
+	// the next request share one summarization call. It's keyed on content,
+	// not length, as /rewrite, /retry, /branch and editing a message replace
+	// messages without changing their count.
+	key := conversationKey(messages)
+	if m.summary != "" && m.summarized == key {
+		return m.summary
+	}
+
 	summaryPrompt := `Please provide a detailed summary of the conlang conversation so far, focusing on:
 
 **CRITICAL INFORMATION TO INCLUDE:**
@@ -278,10 +460,27 @@ Be comprehensive and include specific details rather than generic descriptions.`
 	response, err := m.llm.Invoke(ctx, summaryMessages)
 	if err != nil {
 		log.Printf("Error generating context summary: %v", err)
-		return m.formatExistingContext(messages[len(messages)-5:])
+		return m.formatExistingContext(recentWithinTokens(messages, m.summarizeAbove))
 	}
 
-	return response[0].Content
+	m.summary = response[0].Content
+	m.summarized = key
+	return m.summary
+}
+
+// conversationKey fingerprints the messages a summary was made from
+func conversationKey(messages []*schema.Message) string {
+	hash := sha256.New()
+	for _, msg := range messages {
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", msg.Role, msg.Name, msg.Content)
+		for _, part := range msg.MultiContent {
+			fmt.Fprintf(hash, "%s\x00", part.Text)
+		}
+		for _, call := range msg.ToolCalls {
+			fmt.Fprintf(hash, "%s\x00%s\x00", call.Function.Name, call.Function.Arguments)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 func (m *Model) formatExistingContext(messages []*schema.Message) string {
@@ -289,10 +488,6 @@ func (m *Model) formatExistingContext(messages []*schema.Message) string {
 		return "No previous conversation"
 	}
 
-	if len(messages) > 10 {
-		messages = messages[len(messages)-10:]
-	}
-
 	context := strings.Builder{}
 	context.WriteString("Previous conversation includes:\n\n")
 
@@ -314,39 +509,70 @@ func (m *Model) formatExistingContext(messages []*schema.Message) string {
 	return context.String()
 }
 
-// startStreaming starts the streaming process
-func (m *Model) startStreaming(userMessage string) tea.Cmd {
-	return func() tea.Msg {
+// sendMessage adds a user message to the history and streams the response
+func (m *Model) sendMessage(userMessage string) tea.Cmd {
+	// Add user message to history
+	m.AddToHistory(schema.UserMessage(userMessage))
+	m.rewrites = nil
+	m.saveConversation() // Kept even if the response never arrives
 
-		contextMessages := m.createCondensedHistory()
+	// Update viewport to show the new message
+	m.updateViewportContent()
 
-		requestMessage := schema.UserMessage("REQUEST: " + userMessage)
+	ctx := m.startResponse()
 
-		messages := append(contextMessages, requestMessage)
+	// Attachments go with this request only, and with retries of it
+	attachments := m.attachments
+	m.attachments = nil
+	m.sentAttachments = attachments
 
-		systemMessages := make([]*schema.Message, 0)
-		for _, msg := range m.history {
-			if msg.Role == "system" {
-				systemMessages = append(systemMessages, msg)
-			}
-		}
-		if len(systemMessages) > 0 {
-			messages = append(systemMessages, messages...)
-		}
+	// Start streaming in background with the user message
+	return m.startStreaming(ctx, userMessage, attachments)
+}
+
+// startResponse resets the streaming state for a new response and returns
+// its context, cancellable through cancelStream, adding up the response's
+// tokens in turnUsage and asking for approval of the tools being confirmed
+func (m *Model) startResponse() context.Context {
+	m.streaming = true
+	m.currentResponse.Reset()
+	m.answer.Reset()
+	m.tokenChan = make(chan string, 100) // Buffer for tokens
+	m.responseFailed = false
+	m.streamErr = nil
+	m.firstCall = len(m.toolCalls)
+	m.expandedTool = 0
+
+	ctx, cancel := context.WithCancel(tools.WithVerbosity(context.Background(), m.verbosity))
+	m.cancelStream = cancel
+	ctx, m.turnUsage = tools.WithTurnUsage(ctx)
+	if len(m.confirmTools) > 0 {
+		ctx = tools.WithApproval(ctx, m.approver(slices.Clone(m.confirmTools)))
+	}
+	return ctx
+}
 
-		response, err := m.llm.Stream(context.Background(), messages)
+// startStreaming starts the streaming process
+func (m *Model) startStreaming(ctx context.Context, userMessage string, attachments []attachment) tea.Cmd {
+	return func() tea.Msg {
+		// The user message is already in the history; it is sent as the request
+		conversation := m.conversation()
+		messages := m.buildPrompt(conversation[:len(conversation)-1], userMessage, attachments)
+
+		response, err := m.llm.Stream(ctx, messages)
 		if err != nil {
 			log.Printf("Streaming error: %v", err)
 			m.thinking = false
 			m.streaming = false
 			m.updateViewportContent()
-			return nil
+			return streamErrorMsg{err}
 		}
 
 		m.tokenChan = make(chan string, 100)
 
 		go func() {
 			defer close(m.tokenChan)
+			defer response.Close()
 			defer func() {
 				m.thinking = false
 			}()
@@ -357,6 +583,7 @@ func (m *Model) startStreaming(userMessage string) tea.Cmd {
 					break
 				} else if err != nil {
 					log.Printf("Error receiving message: %v", err)
+					m.streamErr = err // Read once tokenChan is closed
 					break
 				}
 
@@ -365,13 +592,35 @@ func (m *Model) startStreaming(userMessage string) tea.Cmd {
 
 					if len(message.ToolCalls) > 0 {
 						for _, toolCall := range message.ToolCalls {
+							m.recordToolCall(toolCall)
+							// Streamed calls only carry the name in their first chunk; an
+							// empty token refreshes the view with the arguments so far
+							if toolCall.Function.Name == "" {
+								m.tokenChan <- ""
+								continue
+							}
 							toolInfo := fmt.Sprintf("\n[Tool Call: %s]\n", toolCall.Function.Name)
 							m.tokenChan <- toolInfo
 						}
 					}
 
+					if message.Role == schema.Tool {
+						m.recordToolResult(message)
+					}
+
 					if message.Content != "" {
 						content := message.Content
+						if message.Role != schema.Tool {
+							m.answer.WriteString(content)
+						}
+
+						if strings.Contains(content, `"success":false`) {
+							m.responseFailed = true
+						} else if strings.Contains(content, `"steps"`) {
+							if plan := parsePlan(content); plan != nil {
+								m.proposedPlan = plan
+							}
+						}
 
 						if strings.Contains(content, `"success":true`) || strings.Contains(content, `"success":false`) {
 							content = m.formatToolResult(content)
@@ -379,8 +628,6 @@ func (m *Model) startStreaming(userMessage string) tea.Cmd {
 
 						m.tokenChan <- content
 					}
-
-					m.UpdateStats(storage.Stats{TotalTokens: m.stats.TotalTokens + 1})
 				}
 			}
 		}()
@@ -422,42 +669,71 @@ func (m *Model) updateViewportContent() {
 func (m *Model) updateViewportContentInternal() {
 	logs := strings.Builder{}
 
-	historyToShow := m.history
+	historyToShow := m.conversation()
 	if len(historyToShow) > m.maxHistoryDisplay {
 		historyToShow = historyToShow[len(historyToShow)-m.maxHistoryDisplay:]
 		logs.WriteString(fmt.Sprintf("... (showing last %d messages) ...\n\n", m.maxHistoryDisplay))
 	}
 
+	// Messages shown raw are split out of the markdown around them
+	var segments []viewSegment
 	for _, msg := range historyToShow {
 		role := string(msg.Role)
-		if role == "user" {
+		if m.showRaw(msg) {
+			segments = append(segments, viewSegment{text: logs.String()}, viewSegment{text: rawLabels[msg.Role] + msg.Content, raw: true})
+			logs.Reset()
+		} else if role == "user" {
 			logs.WriteString("👤 User: " + msg.Content + "\n\n")
 		} else if role == "assistant" {
-			logs.WriteString("🤖 Assistant: " + msg.Content + "\n\n")
-		} else if role == "system" {
-			continue
+			logs.WriteString("🤖 Assistant: " + renderMath(msg.Content) + "\n\n")
+		}
+		if role == "assistant" {
+			if flags := m.lintFlags(msg); flags != "" {
+				logs.WriteString(flags + "\n")
+			}
 		}
 	}
 
+	if m.notice != "" {
+		logs.WriteString(m.notice + "\n\n")
+	}
+
+	if m.showJobs {
+		logs.WriteString(m.jobsScreen() + "\n\n")
+	}
+
+	if m.plan != nil {
+		logs.WriteString(m.plan.checklist() + "\n\n")
+	}
+
 	if m.streaming {
 		logs.WriteString("=== Streaming Response ===\n\n")
 		currentResponse := m.currentResponse.String()
 
-		logs.WriteString(currentResponse)
+		if m.raw {
+			segments = append(segments, viewSegment{text: logs.String()}, viewSegment{text: currentResponse, raw: true})
+			logs.Reset()
+		} else {
+			logs.WriteString(renderMath(currentResponse))
+		}
+		logs.WriteString(m.pendingCalls())
 		if m.currentResponse.Len() > 0 {
 			logs.WriteString("▌")
 		}
+		if m.approval != nil {
+			logs.WriteString("\n\n" + m.approvalCard())
+		}
 	}
 
-	logsStr := logs.String()
-	rendered, err := m.glam.Render(logsStr)
-	if err != nil {
-		log.Printf("Rendering error: %v", err)
-		m.hold.SetContent(logsStr)
-	} else {
-		m.hold.SetContent(rendered)
+	if m.showTools {
+		if panel := m.toolPanel(); panel != "" {
+			logs.WriteString("\n\n" + panel)
+		}
 	}
 
+	segments = append(segments, viewSegment{text: logs.String()})
+	m.hold.SetContent(m.renderSegments(segments, historyToShow))
+
 	if m.ready && m.hold.Height > 1 && len(m.hold.View()) > 0 {
 		if m.hold.Height > 0 && m.hold.Width > 0 {
 			m.hold.GotoBottom()
@@ -478,6 +754,11 @@ func (m *Model) View() string {
 
 	m.ta.SetWidth(m.width - 2)
 
+	holdView := m.hold.View()
+	if m.define.active {
+		holdView = lipgloss.Place(m.hold.Width, m.hold.Height, lipgloss.Center, lipgloss.Center, m.defineCard())
+	}
+
 	var doc []string
 
 	if m.height > 20 {
@@ -508,14 +789,15 @@ func (m *Model) View() string {
 			coloredRow := colorStyle.Render(paddedRow)
 			doc = append(doc, centerStyle.Width(m.width).Render(coloredRow))
 		}
-		doc = append(doc, centerStyle.Width(m.width).Render(m.hold.View()))
+		doc = append(doc, centerStyle.Width(m.width).Render(holdView))
 		doc = append(doc, centerStyle.Width(m.width).Render(m.ta.View()))
 	} else {
 		doc = []string{
-			centerStyle.Width(m.width).Render(m.hold.View()),
+			centerStyle.Width(m.width).Render(holdView),
 			centerStyle.Width(m.width).Render(m.ta.View()),
 		}
 	}
+	doc = append(doc, centerStyle.Width(m.width).Render(m.statusBar()))
 
 	return lipgloss.JoinVertical(lipgloss.Top, doc...)
 }
@@ -546,15 +828,32 @@ func (m *Model) SetStats() {
 	m.stats = stats
 }
 
-func (m *Model) UpdateStats(newStats storage.Stats) {
-	m.stats = newStats
-	storage.WriteStats(m.stats)
+// refreshStats reloads the token usage and cost, which the agent records
+// after each model call
+func (m *Model) refreshStats() {
+	stats, err := storage.ReadStats()
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Failed to read stats: %v", err)
+		}
+		return
+	}
+	m.stats = stats
 }
 
 func (m *Model) GetStats() storage.Stats {
 	return m.stats
 }
 
+// SessionSummary reports what the session changed in the language
+func (m *Model) SessionSummary() (storage.SessionSummary, error) {
+	if m.session == nil {
+		return storage.SessionSummary{}, fmt.Errorf("the session's starting state wasn't recorded")
+	}
+	m.refreshStats() // Background requests such as lint checks may have run since
+	return m.session.Summary(m.stats)
+}
+
 // resetOptimizationParams resets optimization parameters to default values
 func (m *Model) resetOptimizationParams() {
 	m.maxHistoryDisplay = 10
@@ -565,7 +864,11 @@ func (m *Model) resetOptimizationParams() {
 
 func (m *Model) formatToolResult(content string) string {
 	if strings.Contains(content, `"success":true`) {
-		if strings.Contains(content, `"entries"`) {
+		if strings.Contains(content, `"interlinear"`) {
+			return m.formatGlossResult(content)
+		} else if strings.Contains(content, `"table"`) {
+			return m.formatTableResult(content)
+		} else if strings.Contains(content, `"entries"`) {
 			return m.formatLexiconResult(content)
 		} else if strings.Contains(content, `"content"`) {
 			return m.formatFileResult(content)
@@ -591,22 +894,69 @@ func (m *Model) formatLexiconResult(content string) string {
 
 	if len(result.Entries) > 0 {
 		formatted.WriteString("**Lexicon Entries:**\n\n")
-		for _, entry := range result.Entries {
-			formatted.WriteString(fmt.Sprintf("• **%s**", entry.Word))
-			if entry.PartOfSpeech != "" {
-				formatted.WriteString(fmt.Sprintf(" (%s)", entry.PartOfSpeech))
-			}
-			formatted.WriteString(fmt.Sprintf(": %s", entry.Definition))
-			if entry.Etymology != "" {
-				formatted.WriteString(fmt.Sprintf(" [Etymology: %s]", entry.Etymology))
-			}
-			formatted.WriteString("\n\n")
+		formatted.WriteString(formatLexiconEntries(result.Entries))
+	}
+
+	return formatted.String()
+}
+
+// markInterrupted records that an answer's stream failed before the model
+// finished, in its metadata and visibly at its end, so that neither the user
+// nor the model reads the partial answer as complete when it is sent back as
+// context
+func markInterrupted(msg *schema.Message, err error) {
+	storage.Meta(msg).Interrupted = err.Error()
+	msg.Content = strings.TrimSpace(msg.Content + "\n\n⚠ response cut off: " + err.Error())
+}
+
+// formatLexiconEntries renders lexicon entries as a markdown list
+func formatLexiconEntries(entries []tools.LexiconEntry) string {
+	var formatted strings.Builder
+	for _, entry := range entries {
+		formatted.WriteString(fmt.Sprintf("• **%s**", entry.Word))
+		if entry.PartOfSpeech != "" {
+			formatted.WriteString(fmt.Sprintf(" (%s)", entry.PartOfSpeech))
+		}
+		formatted.WriteString(fmt.Sprintf(": %s", entry.Definition))
+		if etymology := tools.EtymologyText(entry); etymology != "" {
+			formatted.WriteString(fmt.Sprintf(" [Etymology: %s]", etymology))
 		}
+		formatted.WriteString("\n\n")
+	}
+	return formatted.String()
+}
+
+func (m *Model) formatGlossResult(content string) string {
+	var result tools.GlossResult
+
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return content
+	}
+
+	var formatted strings.Builder
+	formatted.WriteString("```\n")
+	formatted.WriteString(result.Text)
+	formatted.WriteString("```\n")
+	for _, warning := range result.Warnings {
+		formatted.WriteString(fmt.Sprintf("⚠️ %s\n\n", warning))
 	}
 
 	return formatted.String()
 }
 
+func (m *Model) formatTableResult(content string) string {
+	var result struct {
+		Message string `json:"message"`
+		Table   string `json:"table"`
+	}
+
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return content
+	}
+
+	return fmt.Sprintf("✅ **%s**\n\n%s", result.Message, result.Table)
+}
+
 func (m *Model) formatFileResult(content string) string {
 	var result tools.Result
 
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stepStatus is how far a plan step has got
+type stepStatus int
+
+const (
+	stepPending stepStatus = iota
+	stepRunning
+	stepDone
+	stepFailed
+	stepSkipped
+)
+
+// stepMarks are the checklist marks for each step status
+var stepMarks = map[stepStatus]string{
+	stepPending: "⬜",
+	stepRunning: "⏳",
+	stepDone:    "✅",
+	stepFailed:  "❌",
+	stepSkipped: "⏭️",
+}
+
+// planState is a plan from plan_steps being carried out one step per turn
+type planState struct {
+	plan    *tools.PlanResult
+	status  []stepStatus
+	current int // Index of the step running or next to run
+	aborted bool
+}
+
+// active reports whether the plan still has steps to run
+func (p *planState) active() bool {
+	return !p.aborted && p.current < len(p.status) && p.status[p.current] != stepFailed
+}
+
+// checklist renders the plan with the status of every step
+func (p *planState) checklist() string {
+	done := 0
+	for _, status := range p.status {
+		if status == stepDone {
+			done++
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("**Plan:** %s (%d/%d done)\n\n", p.plan.Goal, done, len(p.status)))
+	for i, step := range p.plan.Steps {
+		out.WriteString(fmt.Sprintf("%d. %s %s", i+1, stepMarks[p.status[i]], step.Description))
+		if len(step.Tools) > 0 {
+			out.WriteString(" — `" + strings.Join(step.Tools, "`, `") + "`")
+		}
+		out.WriteString("\n")
+	}
+	switch {
+	case p.aborted:
+		out.WriteString("\nPlan aborted")
+	case p.current < len(p.status) && p.status[p.current] == stepFailed:
+		out.WriteString(fmt.Sprintf("\nPlan stopped: step %d failed", p.current+1))
+	case p.active():
+		out.WriteString("\nPress `esc` or run `/plan abort` to stop the plan")
+	}
+	return out.String()
+}
+
+// parsePlan reads a plan_steps result out of a tool message
+func parsePlan(content string) *tools.PlanResult {
+	var result tools.PlanResult
+	if err := json.Unmarshal([]byte(content), &result); err != nil || !result.Success || len(result.Steps) == 0 {
+		return nil
+	}
+	return &result
+}
+
+// continuePlan is called when a response finishes. It settles the step that
+// was running, starts a plan the response proposed and sends the next step.
+func (m *Model) continuePlan() tea.Cmd {
+	if m.plan != nil && m.plan.active() && m.plan.status[m.plan.current] == stepRunning {
+		if m.responseFailed {
+			m.plan.status[m.plan.current] = stepFailed
+		} else {
+			m.plan.status[m.plan.current] = stepDone
+			m.plan.current++
+		}
+	}
+
+	// A new plan only replaces one that has finished
+	if proposed := m.proposedPlan; proposed != nil {
+		m.proposedPlan = nil
+		if m.plan == nil || !m.plan.active() {
+			m.plan = &planState{plan: proposed, status: make([]stepStatus, len(proposed.Steps))}
+		}
+	}
+
+	if m.plan == nil || !m.plan.active() {
+		return nil
+	}
+	m.plan.status[m.plan.current] = stepRunning
+	return m.sendMessage(tools.PlanStepPrompt(m.plan.plan, m.plan.current))
+}
+
+// abortPlan stops the plan, cancelling the step in progress
+func (m *Model) abortPlan() {
+	if m.plan == nil || !m.plan.active() {
+		return
+	}
+	for i := m.plan.current; i < len(m.plan.status); i++ {
+		m.plan.status[i] = stepSkipped
+	}
+	m.plan.aborted = true
+	if m.streaming && m.cancelStream != nil {
+		m.cancelStream()
+	}
+}
+
+func planCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) != 1 || args[0] != "abort" {
+		return "Usage: `" + commands["plan"].usage + "`", nil
+	}
+	if m.plan == nil || !m.plan.active() {
+		return "No plan is running", nil
+	}
+	m.abortPlan()
+	return "✅ **Aborted the plan**", nil
+}
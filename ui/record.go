@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"l2/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Speech-to-text backends and their defaults
+const (
+	whisperAPI           = "whisper-api"
+	whisperCpp           = "whisper-cpp"
+	defaultWhisperURL    = "https://api.openai.com/v1/audio/transcriptions"
+	defaultWhisperModel  = "whisper-1"
+	defaultWhisperBinary = "whisper-cli"
+
+	// whisper.cpp only reads 16 kHz mono WAV, which the API accepts too
+	defaultRecorder = "sox -d -q -r 16000 -c 1 -b 16"
+)
+
+// recording is a microphone recording in progress
+type recording struct {
+	cmd  *exec.Cmd
+	path string
+}
+
+// transcriptionMsg carries the text transcribed in the background for /record
+type transcriptionMsg struct {
+	text string
+	err  error
+}
+
+func recordCommand(m *Model, args []string) (string, tea.Cmd) {
+	config, err := storage.ReadConfig()
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	speech := config.Speech
+
+	if len(args) == 0 || args[0] == "stop" {
+		if m.recording == nil {
+			if len(args) > 0 {
+				return "Not recording", nil
+			}
+			if err := m.startRecording(speech); err != nil {
+				return errorNotice(err), nil
+			}
+			return fmt.Sprintf("🎙 **Recording** for %s... type `/record` again to stop and transcribe", backendName(speech)), nil
+		}
+
+		path := m.stopRecording()
+		return "Transcribing...", func() tea.Msg {
+			defer os.Remove(path)
+			text, err := transcribe(speech, path)
+			return transcriptionMsg{text: text, err: err}
+		}
+	}
+
+	switch args[0] {
+	case "cancel":
+		if m.recording == nil {
+			return "Not recording", nil
+		}
+		os.Remove(m.stopRecording())
+		return "Recording discarded", nil
+
+	case "settings":
+		return fmt.Sprintf("**Speech-to-text:** %s\n\n• Model: `%s`\n• Endpoint: `%s`\n• Language: `%s`\n• Recorder: `%s`",
+			backendName(speech), orDefault(speech.Model, defaultModel(speech)), orDefault(speech.Endpoint, defaultEndpoint(speech)),
+			orDefault(speech.Language, "auto"), orDefault(speech.Recorder, defaultRecorder)), nil
+
+	case "backend", "model", "endpoint", "language", "recorder":
+		if len(args) < 2 {
+			return "Usage: `" + commands["record"].usage + "`", nil
+		}
+		value := strings.Join(args[1:], " ")
+		if args[0] == "backend" && value != whisperAPI && value != whisperCpp {
+			return fmt.Sprintf("❌ **Error:** backend must be %s or %s", whisperAPI, whisperCpp), nil
+		}
+
+		err := storage.UpdateConfig(func(config *storage.Config) error {
+			switch args[0] {
+			case "backend":
+				config.Speech.Backend = value
+			case "model":
+				config.Speech.Model = value
+			case "endpoint":
+				config.Speech.Endpoint = value
+			case "language":
+				config.Speech.Language = value
+			case "recorder":
+				config.Speech.Recorder = value
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to save speech settings: %v", err)
+		}
+		return fmt.Sprintf("✅ **Speech-to-text %s set to** `%s`", args[0], value), nil
+	}
+
+	return "Usage: `" + commands["record"].usage + "`", nil
+}
+
+// startRecording runs the recorder command in the background until it is stopped
+func (m *Model) startRecording(speech storage.SpeechConfig) error {
+	fields := strings.Fields(orDefault(speech.Recorder, defaultRecorder))
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return fmt.Errorf("recorder %s not found; install it or set another with `/record recorder <command>`", fields[0])
+	}
+	file, err := os.CreateTemp("", "l2-record-*.wav")
+	if err != nil {
+		return err
+	}
+	file.Close()
+
+	cmd := exec.Command(fields[0], append(fields[1:], file.Name())...)
+	if err := cmd.Start(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	m.recording = &recording{cmd: cmd, path: file.Name()}
+	return nil
+}
+
+// stopRecording interrupts the recorder so it finishes the file, and returns its path
+func (m *Model) stopRecording() string {
+	r := m.recording
+	m.recording = nil
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		r.cmd.Process.Kill()
+	}
+	// Recorders exit with an error status when interrupted
+	r.cmd.Wait()
+	return r.path
+}
+
+// transcribe sends a recording to the configured speech-to-text backend
+func transcribe(speech storage.SpeechConfig, path string) (string, error) {
+	if info, err := os.Stat(path); err != nil || info.Size() <= 44 {
+		return "", fmt.Errorf("nothing was recorded; check the microphone and the recorder command")
+	}
+
+	if speech.Backend == whisperCpp {
+		if speech.Model == "" {
+			return "", fmt.Errorf("whisper.cpp needs a model file; set it with `/record model <path>`")
+		}
+		args := []string{"-m", speech.Model, "-f", path, "-nt", "-np"}
+		if speech.Language != "" {
+			args = append(args, "-l", speech.Language)
+		}
+		out, err := exec.Command(orDefault(speech.Endpoint, defaultWhisperBinary), args...).Output()
+		if err != nil {
+			return "", fmt.Errorf("whisper.cpp failed: %w", err)
+		}
+		return strings.Join(strings.Fields(string(out)), " "), nil
+	}
+
+	key := os.Getenv("OPENAI_API_KEY")
+	if key == "" {
+		return "", fmt.Errorf("the Whisper API needs OPENAI_API_KEY in .env")
+	}
+	audio, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	part.Write(audio)
+	form.WriteField("model", orDefault(speech.Model, defaultWhisperModel))
+	if speech.Language != "" {
+		form.WriteField("language", speech.Language)
+	}
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, orDefault(speech.Endpoint, defaultWhisperURL), &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Whisper API returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+func backendName(speech storage.SpeechConfig) string {
+	return orDefault(speech.Backend, whisperAPI)
+}
+
+func defaultModel(speech storage.SpeechConfig) string {
+	if speech.Backend == whisperCpp {
+		return "none"
+	}
+	return defaultWhisperModel
+}
+
+func defaultEndpoint(speech storage.SpeechConfig) string {
+	if speech.Backend == whisperCpp {
+		return defaultWhisperBinary
+	}
+	return defaultWhisperURL
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"l2/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cloudwego/eino/schema"
+)
+
+// defaultMarkdownStyle is the glamour style used unless another is chosen
+const defaultMarkdownStyle = "dark"
+
+// markdownStyles are glamour's built-in styles; any other style is read from
+// a glamour style JSON file
+var markdownStyles = []string{"ascii", "dark", "dracula", "light", "notty", "pink", "tokyo-night"}
+
+// rawStyle shows text as is in monospace, keeping IPA and table columns intact
+var rawStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder(), false, false, false, true).
+	BorderForeground(lipgloss.Color("240")).
+	PaddingLeft(1)
+
+// rawLabels introduce messages shown raw, like the markdown headers do
+var rawLabels = map[schema.RoleType]string{
+	schema.User:      "👤 User: ",
+	schema.Assistant: "🤖 Assistant: ",
+}
+
+// viewSegment is a part of the viewport shown either as rendered markdown or
+// as raw text
+type viewSegment struct {
+	text string
+	raw  bool
+}
+
+// newRenderer creates the markdown renderer for the chosen style
+func newRenderer(style string, width int) (*glamour.TermRenderer, error) {
+	return glamour.NewTermRenderer(
+		glamour.WithStylePath(style),
+		glamour.WithEmoji(),
+		glamour.WithWordWrap(width),
+	)
+}
+
+// showRaw reports whether a message is shown as raw text: every message
+// while raw mode is on, flipped for the messages toggled one by one
+func (m *Model) showRaw(msg *schema.Message) bool {
+	return m.raw != m.rawMessages[msg]
+}
+
+// renderSegments renders the markdown segments and wraps the raw ones to the
+// viewport
+func (m *Model) renderSegments(segments []viewSegment, shown []*schema.Message) string {
+	var candidates map[string]bool
+	if m.annotate {
+		candidates = m.conlangCandidates(shown)
+	}
+
+	var out strings.Builder
+	for _, segment := range segments {
+		if segment.text == "" {
+			continue
+		}
+		if segment.raw {
+			out.WriteString(rawStyle.Width(max(m.hold.Width-6, 1)).Render(strings.TrimRight(segment.text, "\n")) + "\n\n")
+			continue
+		}
+		rendered, err := m.glam.Render(segment.text)
+		if err != nil {
+			log.Printf("Rendering error: %v", err)
+			out.WriteString(segment.text)
+			continue
+		}
+		if m.annotate {
+			rendered = annotateRendered(rendered, m.lexiconWords, candidates)
+		}
+		out.WriteString(rendered)
+	}
+	return out.String()
+}
+
+func rawCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		args = []string{"on"}
+		if m.raw {
+			args[0] = "off"
+		}
+	}
+
+	switch args[0] {
+	case "on", "off":
+		m.raw = args[0] == "on"
+		m.rawMessages = map[*schema.Message]bool{}
+		err := storage.UpdateConfig(func(config *storage.Config) error {
+			config.RawMarkdown = m.raw
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to save raw mode setting: %v", err)
+		}
+		if m.raw {
+			return "Raw mode **on**: messages are shown as plain text", nil
+		}
+		return "Raw mode **off**: messages are rendered as markdown", nil
+	}
+
+	// A number toggles one response, counting back from the latest
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return "Usage: `" + commands["raw"].usage + "`", nil
+	}
+	conversation := m.conversation()
+	for i := len(conversation) - 1; i >= 0; i-- {
+		if conversation[i].Role != schema.Assistant {
+			continue
+		}
+		if n--; n > 0 {
+			continue
+		}
+		msg := conversation[i]
+		m.rawMessages[msg] = !m.rawMessages[msg]
+		if m.showRaw(msg) {
+			return "Showing the response as plain text", nil
+		}
+		return "Showing the response as markdown", nil
+	}
+	return fmt.Sprintf("There are fewer than %s responses", args[0]), nil
+}
+
+func styleCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		return fmt.Sprintf("Markdown style **%s**\n\nBuilt-in styles: `%s`, or the path of a glamour style JSON file", m.markdownStyle, strings.Join(markdownStyles, "`, `")), nil
+	}
+
+	style := args[0]
+	if strings.HasSuffix(style, ".json") {
+		if _, err := os.Stat(style); err != nil {
+			return errorNotice(err), nil
+		}
+	}
+	glam, err := newRenderer(style, max(m.hold.Width-4, 1))
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	m.glam = glam
+	m.markdownStyle = style
+
+	err = storage.UpdateConfig(func(config *storage.Config) error {
+		config.MarkdownStyle = style
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to save markdown style: %v", err)
+	}
+	return fmt.Sprintf("Markdown style **%s**", style), nil
+}
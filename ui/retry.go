@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// maxTemperature is the highest temperature /retry accepts
+const maxTemperature = 2
+
+// parseRetry reads /retry's arguments: a number is the temperature and
+// anything else the model
+func parseRetry(args []string) (tools.Sampling, error) {
+	var sampling tools.Sampling
+	for _, arg := range args {
+		if t, err := strconv.ParseFloat(arg, 32); err == nil {
+			if t < 0 || t > maxTemperature {
+				return sampling, fmt.Errorf("temperature must be between 0 and %d", maxTemperature)
+			}
+			temperature := float32(t)
+			sampling.Temperature = &temperature
+			continue
+		}
+		if sampling.Model != "" {
+			return sampling, fmt.Errorf("only one model can be given")
+		}
+		sampling.Model = arg
+	}
+	return sampling, nil
+}
+
+// retryLast drops the last response and streams a fresh answer to the
+// request it answered, with the attachments it was sent with. Like a
+// rewrite, the dropped response can be brought back with /rewrite undo.
+func (m *Model) retryLast(sampling tools.Sampling) tea.Cmd {
+	last, request := m.lastExchange()
+	previous := m.history[last]
+	// The previous answer's tool calls go with it; the retry makes its own
+	for i := last - 1; i >= 0; i-- {
+		if m.history[i].Role == schema.User {
+			m.history = m.history[:i+1]
+			break
+		}
+	}
+	m.rewrites = append(m.rewrites, previous)
+	m.rewriting = true
+
+	ctx := tools.WithSampling(m.startResponse(), sampling)
+	return m.startStreaming(ctx, request, m.sentAttachments)
+}
+
+func retryCommand(m *Model, args []string) (string, tea.Cmd) {
+	if m.streaming {
+		return "Wait for the current response to finish before retrying it", nil
+	}
+	if m.plan != nil && m.plan.active() {
+		return "Retrying is unavailable while a plan is running", nil
+	}
+	if last, _ := m.lastExchange(); last < 0 {
+		return "There is no response to retry yet", nil
+	}
+	sampling, err := parseRetry(args)
+	if err != nil {
+		return "❌ **Error:** " + err.Error() + "\n\nUsage: `" + commands["retry"].usage + "`", nil
+	}
+	if sampling.Model != "" && m.capabilities.Tools {
+		config, _ := storage.ReadConfig()
+		if !tools.CapabilitiesOf(sampling.Model, config.ModelCapabilities).Tools {
+			return fmt.Sprintf("❌ **Error:** %s can't call tools, which this chat is set up with; retry with a model that can", sampling.Model), nil
+		}
+	}
+
+	var with []string
+	if sampling.Model != "" {
+		with = append(with, sampling.Model)
+	}
+	if sampling.Temperature != nil {
+		with = append(with, fmt.Sprintf("temperature %g", *sampling.Temperature))
+	}
+	notice := "Retrying the last response"
+	if len(with) > 0 {
+		notice += " with " + strings.Join(with, " at ")
+	}
+	return notice + "…", m.retryLast(sampling)
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// rewriteStyles are the named transformations /rewrite applies; anything
+// else is passed to the model as the instruction itself
+var rewriteStyles = map[string]string{
+	"shorter":     "more concisely, keeping every fact but at most half as long",
+	"longer":      "in more depth, with more explanation and examples",
+	"more-formal": "in a more formal, academic register",
+	"casual":      "in a more casual, conversational tone",
+	"simpler":     "in simpler terms, for someone new to linguistics",
+	"as-table":    "as a Markdown table wherever the content allows",
+	"as-list":     "as a bulleted list",
+}
+
+// rewritePrompt asks the model to redo its answer to request with a transformation
+func rewritePrompt(request, answer, transformation string) string {
+	return fmt.Sprintf("%s\n\nYou already answered this as follows:\n\n---\n%s\n---\n\n"+
+		"Rewrite that answer %s. Keep it faithful to the original and reply with the rewritten answer only; "+
+		"don't call tools unless the rewrite needs data the original didn't have.", request, answer, transformation)
+}
+
+// lastExchange returns the index of the last response in the history and the
+// request it answered, or -1 when the conversation doesn't end in a response
+func (m *Model) lastExchange() (int, string) {
+	last := len(m.history) - 1
+	if last < 1 || m.history[last].Role != schema.Assistant {
+		return -1, ""
+	}
+	for i := last - 1; i >= 0; i-- {
+		if m.history[i].Role == schema.User {
+			return last, m.history[i].Content
+		}
+	}
+	return -1, ""
+}
+
+// rewriteLast regenerates the last response with a transformation. The
+// response is taken out of the history while its replacement streams in, so
+// only the version kept ends up in the conversation.
+func (m *Model) rewriteLast(transformation string) tea.Cmd {
+	last, request := m.lastExchange()
+	previous := m.history[last]
+	m.history = m.history[:last]
+	m.rewrites = append(m.rewrites, previous)
+	m.rewriting = true
+
+	ctx := m.startResponse()
+	return m.startStreaming(ctx, rewritePrompt(request, previous.Content, transformation), nil)
+}
+
+// rewriteDiff shows what the last rewrite changed in the response
+func (m *Model) rewriteDiff() string {
+	previous := m.rewrites[len(m.rewrites)-1]
+	current := m.history[len(m.history)-1]
+	return "Changes from the previous version; `/rewrite undo` keeps the previous one instead\n\n" + answerDiff(previous.Content, current.Content)
+}
+
+// restoreRewritten puts back the response a failed rewrite took out of the history
+func (m *Model) restoreRewritten() {
+	if !m.rewriting {
+		return
+	}
+	m.rewriting = false
+	previous := m.rewrites[len(m.rewrites)-1]
+	m.rewrites = m.rewrites[:len(m.rewrites)-1]
+	m.AddToHistory(previous)
+}
+
+func rewriteCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		names := make([]string, 0, len(rewriteStyles))
+		for name := range rewriteStyles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "Usage: `" + commands["rewrite"].usage + "`\n\nStyles: `" + strings.Join(names, "`, `") + "`, or describe the change in your own words", nil
+	}
+	if m.streaming {
+		return "Wait for the current response to finish before rewriting it", nil
+	}
+	if m.plan != nil && m.plan.active() {
+		return "Rewriting is unavailable while a plan is running", nil
+	}
+
+	if args[0] == "undo" {
+		last, _ := m.lastExchange()
+		if last < 0 || len(m.rewrites) == 0 {
+			return "Nothing to undo", nil
+		}
+		m.history[last] = m.rewrites[len(m.rewrites)-1]
+		m.rewrites = m.rewrites[:len(m.rewrites)-1]
+		m.dirty = true
+		m.saveConversation()
+		return "✅ **Restored the previous version of the last response**", nil
+	}
+
+	if args[0] == "diff" {
+		if last, _ := m.lastExchange(); last < 0 || len(m.rewrites) == 0 {
+			return "The last response hasn't been rewritten", nil
+		}
+		return m.rewriteDiff(), nil
+	}
+
+	if last, _ := m.lastExchange(); last < 0 {
+		return "There is no response to rewrite yet", nil
+	}
+	style := strings.ToLower(strings.Join(args, "-"))
+	transformation, ok := rewriteStyles[style]
+	if !ok {
+		transformation = "following this instruction: " + strings.Join(args, " ")
+	}
+	return "Rewriting the last response " + transformation + "; `/rewrite undo` brings back the previous version", m.rewriteLast(transformation)
+}
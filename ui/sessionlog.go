@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"log"
+
+	"l2/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func sessionLogCommand(m *Model, args []string) (string, tea.Cmd) {
+	config, err := storage.ReadConfig()
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			config.LogSessions = true
+		case "off":
+			config.LogSessions = false
+		default:
+			return "Usage: `" + commands["sessionlog"].usage + "`", nil
+		}
+		err := storage.UpdateConfig(func(stored *storage.Config) error {
+			stored.LogSessions = config.LogSessions
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to save session log setting: %v", err)
+		}
+	}
+
+	if config.LogSessions {
+		return "Session log **on**: the summary shown at exit is also saved to `sessions.json`", nil
+	}
+	return "Session log **off**: the summary is only shown at exit", nil
+}
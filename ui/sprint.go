@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func sprintCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		statuses, err := tools.PackProgress()
+		if err != nil {
+			return errorNotice(err), nil
+		}
+		var out strings.Builder
+		out.WriteString("**Concept packs:**\n\n")
+		for _, s := range statuses {
+			out.WriteString(fmt.Sprintf("• **%s** — %s: %d/%d done, %d in review, %d%% by frequency\n",
+				s.Pack.Name, s.Pack.Description, len(s.Done), len(s.Pack.Concepts), len(s.Pending), s.Weighted))
+		}
+		out.WriteString("\nStart a sprint with `/sprint <pack>`")
+		return out.String(), nil
+	}
+	if m.streaming {
+		return "Wait for the current response to finish before starting a sprint", nil
+	}
+
+	size := tools.DefaultSprintBatch
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return "Usage: `" + commands["sprint"].usage + "`", nil
+		}
+		size = n
+	}
+	batch, err := tools.NextSprintBatch(args[0], size)
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	if len(batch) == 0 {
+		return fmt.Sprintf("✅ **Every concept in %s is done or waiting in `/review`**", args[0]), nil
+	}
+
+	pack, _ := tools.FindPack(args[0])
+	return fmt.Sprintf("Sprinting %d concepts from **%s**; review the proposals with `/review`", len(batch), pack.Name),
+		m.sendMessage(tools.SprintPrompt(pack.Name, batch))
+}
+
+func reviewCommand(m *Model, args []string) (string, tea.Cmd) {
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	proposals, err := storage.ReadReview()
+	if err != nil {
+		return errorNotice(err), nil
+	}
+
+	switch action {
+	case "list":
+		if len(proposals) == 0 {
+			return "Review queue is empty", nil
+		}
+		var out strings.Builder
+		out.WriteString("**Review queue:**\n\n| # | Word | Part of speech | Definition | Concept |\n|---|---|---|---|---|\n")
+		for _, p := range proposals {
+			concept := ""
+			if p.Concept != "" {
+				concept = p.Pack + ": " + p.Concept
+			}
+			out.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s |\n", p.ID, p.Word, p.PartOfSpeech, p.Definition, concept))
+		}
+		out.WriteString("\nAccept or reject with `/review accept <#|all>` and `/review reject <#|all>`")
+		return out.String(), nil
+
+	case "accept", "reject":
+		if len(args) < 2 {
+			return "Usage: `" + commands["review"].usage + "`", nil
+		}
+		ids := args[1:]
+		if args[1] == "all" {
+			ids = []string{}
+			for _, p := range proposals {
+				ids = append(ids, strconv.Itoa(p.ID))
+			}
+		}
+
+		done := []string{}
+		failed := []string{}
+		for _, id := range ids {
+			if action == "accept" {
+				entry, err := tools.AcceptProposal(id)
+				if err != nil {
+					failed = append(failed, err.Error())
+					continue
+				}
+				done = append(done, entry.Word)
+			} else {
+				p, err := storage.TakeProposal(id)
+				if err != nil {
+					failed = append(failed, err.Error())
+					continue
+				}
+				done = append(done, p.Word)
+			}
+		}
+		m.refreshAnnotations()
+
+		verb := "Added to the lexicon"
+		if action == "reject" {
+			verb = "Rejected"
+		}
+		var out strings.Builder
+		if len(done) > 0 {
+			out.WriteString(fmt.Sprintf("✅ **%s:** %s\n", verb, strings.Join(done, ", ")))
+		}
+		for _, f := range failed {
+			out.WriteString("❌ **Error:** " + f + "\n")
+		}
+		return out.String(), nil
+	}
+
+	return "Usage: `" + commands["review"].usage + "`", nil
+}
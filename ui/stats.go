@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// statsSessions is how many logged sessions the sessions table lists
+	statsSessions = 8
+	// statsTools is how many of the most called tools the tools table lists
+	statsTools = 10
+	// sparklineWidth is how many values a sparkline shows, the latest last
+	sparklineWidth = 40
+)
+
+// sparkBars are the bar heights of a sparkline, lowest first
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// usageLine sums up tokens and their cost
+func usageLine(promptTokens, completionTokens int, cost float64) string {
+	line := fmt.Sprintf("%d tokens (%d prompt, %d completion)", promptTokens+completionTokens, promptTokens, completionTokens)
+	if cost > 0 {
+		line += fmt.Sprintf(", about $%.4f", cost)
+	}
+	return line
+}
+
+// requestsLine sums up requests, their average latency and tool calls
+func requestsLine(u storage.ModelUsage) string {
+	line := fmt.Sprintf("%d requests", u.Requests)
+	if latency := u.AverageLatency(); latency > 0 {
+		line += fmt.Sprintf(", %s on average", tools.FormatLatency(latency))
+	}
+	return line + fmt.Sprintf(", %d tool calls", u.ToolCalls)
+}
+
+// sparkline draws the latest values as bars scaled to the largest
+func sparkline(values []int) string {
+	if len(values) > sparklineWidth {
+		values = values[len(values)-sparklineWidth:]
+	}
+	highest := 0
+	for _, v := range values {
+		highest = max(highest, v)
+	}
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		bars[i] = sparkBars[0]
+		if highest > 0 {
+			bars[i] = sparkBars[v*(len(sparkBars)-1)/highest]
+		}
+	}
+	return string(bars)
+}
+
+// subtractUsage returns the usage since an earlier total
+func subtractUsage(now, before storage.ModelUsage) storage.ModelUsage {
+	return storage.ModelUsage{
+		Requests:         now.Requests - before.Requests,
+		Estimated:        now.Estimated - before.Estimated,
+		PromptTokens:     now.PromptTokens - before.PromptTokens,
+		CompletionTokens: now.CompletionTokens - before.CompletionTokens,
+		Cost:             now.Cost - before.Cost,
+		ToolCalls:        now.ToolCalls - before.ToolCalls,
+		Timed:            now.Timed - before.Timed,
+		LatencyMS:        now.LatencyMS - before.LatencyMS,
+	}
+}
+
+func statsCommand(m *Model, args []string) (string, tea.Cmd) {
+	m.refreshStats()
+	settings, err := storage.ReadConfig()
+	if err != nil {
+		log.Printf("Failed to read config: %v", err)
+	}
+	var start storage.Stats
+	if m.session != nil {
+		start = m.session.StartingStats()
+	}
+
+	var out strings.Builder
+	out.WriteString("**Usage:**\n\n")
+	if m.session != nil {
+		session := subtractUsage(m.stats.Total(), start.Total())
+		fmt.Fprintf(&out, "• This session: %s; %s\n", usageLine(m.stats.PromptTokens-start.PromptTokens,
+			m.stats.CompletionTokens-start.CompletionTokens, m.stats.Cost-start.Cost), requestsLine(session))
+	}
+	answers := []int{}
+	var last *tools.TurnUsage
+	for _, msg := range m.history {
+		if usage, ok := tools.UsageOf(msg); ok {
+			answers = append(answers, usage.PromptTokens+usage.CompletionTokens)
+			last = usage
+		}
+	}
+	if last != nil {
+		line := usageLine(last.PromptTokens, last.CompletionTokens, 0)
+		if last.Estimated {
+			line += ", partly estimated"
+		}
+		fmt.Fprintf(&out, "• Last answer: %s\n", line)
+	}
+	fmt.Fprintf(&out, "• All sessions: %s; %s\n", usageLine(m.stats.PromptTokens, m.stats.CompletionTokens, m.stats.Cost), requestsLine(m.stats.Total()))
+	if untracked := m.stats.TotalTokens - m.stats.PromptTokens - m.stats.CompletionTokens; untracked > 0 {
+		fmt.Fprintf(&out, "• Estimated before usage tracking: %d tokens\n", untracked)
+	}
+	if len(answers) > 1 {
+		fmt.Fprintf(&out, "• Tokens per answer: `%s` (%d answers)\n", sparkline(answers), len(answers))
+	}
+	if len(m.stats.Models) == 0 {
+		out.WriteString("\nNo model usage recorded yet")
+		return out.String(), nil
+	}
+
+	writeModelsTable(&out, m.stats, settings)
+	writeToolsTable(&out, m.stats, start)
+	if m.session != nil {
+		if summary, err := m.session.Summary(m.stats); err == nil {
+			writeSessionsTable(&out, summary)
+		} else {
+			log.Printf("Failed to summarize the session: %v", err)
+		}
+	}
+	out.WriteString("\nTokens are as the provider counted them, or estimated at four characters a token for requests it didn't report")
+	return out.String(), nil
+}
+
+// writeModelsTable breaks the usage down per model
+func writeModelsTable(out *strings.Builder, stats storage.Stats, settings storage.Config) {
+	models := make([]string, 0, len(stats.Models))
+	for name := range stats.Models {
+		models = append(models, name)
+	}
+	sort.Strings(models)
+	unpriced := false
+	out.WriteString("\n**Models:**\n\n| Model | Requests | Latency | Tool calls | Prompt | Completion | $ per M (prompt / completion) | Cost |\n|---|---|---|---|---|---|---|---|\n")
+	for _, name := range models {
+		usage := stats.Models[name]
+		price := "unknown"
+		if p, ok := tools.PriceOf(name, settings); ok {
+			price = fmt.Sprintf("%.2f / %.2f", p.Prompt, p.Completion)
+		} else {
+			unpriced = true
+		}
+		requests := fmt.Sprint(usage.Requests)
+		if usage.Estimated > 0 {
+			requests += fmt.Sprintf(" (%d estimated)", usage.Estimated)
+		}
+		latency := "–"
+		if usage.Timed > 0 {
+			latency = tools.FormatLatency(usage.AverageLatency())
+		}
+		fmt.Fprintf(out, "| %s | %s | %s | %d | %d | %d | %s | $%.4f |\n", name, requests, latency, usage.ToolCalls,
+			usage.PromptTokens, usage.CompletionTokens, price, usage.Cost)
+	}
+	if unpriced {
+		out.WriteString("\nModels of unknown price are counted as free; set their prices under `model_prices` in config.json\n")
+	}
+}
+
+// writeToolsTable lists the most called tools, this session and overall
+func writeToolsTable(out *strings.Builder, stats, start storage.Stats) {
+	if len(stats.ToolCalls) == 0 {
+		return
+	}
+	names := make([]string, 0, len(stats.ToolCalls))
+	for name := range stats.ToolCalls {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if stats.ToolCalls[names[i]] != stats.ToolCalls[names[j]] {
+			return stats.ToolCalls[names[i]] > stats.ToolCalls[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	out.WriteString("\n**Tools:**\n\n| Tool | This session | All sessions |\n|---|---|---|\n")
+	for _, name := range names[:min(len(names), statsTools)] {
+		fmt.Fprintf(out, "| %s | %d | %d |\n", name, stats.ToolCalls[name]-start.ToolCalls[name], stats.ToolCalls[name])
+	}
+	if len(names) > statsTools {
+		fmt.Fprintf(out, "\nand %d more tools\n", len(names)-statsTools)
+	}
+}
+
+// writeSessionsTable lists the latest logged sessions and this one, with a
+// sparkline of the tokens each used
+func writeSessionsTable(out *strings.Builder, current storage.SessionSummary) {
+	sessions, err := storage.ReadSessions()
+	if err != nil {
+		log.Printf("Failed to read the session log: %v", err)
+	}
+	if len(sessions) == 0 {
+		out.WriteString("\nRun `/sessionlog on` to keep each session's usage and compare sessions here\n")
+		return
+	}
+
+	out.WriteString("\n**Sessions:**\n\n| Ended | Duration | Requests | Latency | Tool calls | Tokens | Cost |\n|---|---|---|---|---|---|---|\n")
+	row := func(ended string, s storage.SessionSummary) {
+		latency := "–"
+		if s.LatencyMS > 0 {
+			latency = tools.FormatLatency(time.Duration(s.LatencyMS) * time.Millisecond)
+		}
+		duration := s.Ended.Sub(s.Started)
+		fmt.Fprintf(out, "| %s | %dh%02dm | %d | %s | %d | %d | $%.4f |\n", ended, int(duration.Hours()), int(duration.Minutes())%60,
+			s.Requests, latency, s.ToolCalls, s.Tokens, s.Cost)
+	}
+	for _, s := range sessions[max(0, len(sessions)-statsSessions):] {
+		row(s.Ended.Format("Jan 2 15:04"), s)
+	}
+	row("now", current)
+
+	tokens := []int{}
+	for _, s := range sessions {
+		tokens = append(tokens, s.Tokens)
+	}
+	tokens = append(tokens, current.Tokens)
+	fmt.Fprintf(out, "\nTokens per session: `%s` (%d sessions)\n", sparkline(tokens), len(tokens))
+}
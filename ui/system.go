@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// systemEditedMsg reports that the editor opened by /system has exited,
+// leaving the edited prompt in path
+type systemEditedMsg struct {
+	path string
+	err  error
+}
+
+// editSystem copies the system prompt to a temporary file and opens it in
+// $EDITOR, suspending the chat until the editor exits
+func editSystem() (tea.Cmd, error) {
+	system, err := storage.ReadSystem()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp("", "l2-system-*.md")
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.WriteString(system)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	path := file.Name()
+	return tea.ExecProcess(exec.Command(editor, path), func(err error) tea.Msg {
+		return systemEditedMsg{path: path, err: err}
+	}), nil
+}
+
+// finishSystemEdit saves the edited system prompt and puts it in place of
+// the one in the history, so the next message is sent with it
+func (m *Model) finishSystemEdit(msg systemEditedMsg) string {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		return errorNotice(fmt.Errorf("the editor failed: %w", msg.err))
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		return errorNotice(err)
+	}
+	system := string(data)
+	if strings.TrimSpace(system) == "" {
+		return "The system prompt can't be empty; the previous one is kept"
+	}
+	if current, err := storage.ReadSystem(); err == nil && current == system {
+		return "The system prompt is unchanged"
+	}
+	if err := storage.WriteSystem(system); err != nil {
+		return errorNotice(err)
+	}
+	m.useSystemPrompt(system)
+	return "✅ **System prompt saved**; it applies from your next message"
+}
+
+// useSystemPrompt replaces the system message in the history
+func (m *Model) useSystemPrompt(system string) {
+	message := schema.SystemMessage(system)
+	tools.StampMessage(message, time.Now())
+	for i, msg := range m.history {
+		if msg.Role == schema.System {
+			m.history[i] = message
+			m.dirty = true
+			return
+		}
+	}
+	m.AddToHistory(message)
+}
+
+func systemCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) > 0 {
+		if args[0] != "show" {
+			return "Usage: `" + commands["system"].usage + "`", nil
+		}
+		system, err := storage.ReadSystem()
+		if err != nil {
+			return errorNotice(err), nil
+		}
+		return "**System prompt:**\n\n```markdown\n" + system + "\n```", nil
+	}
+	if storage.ReadOnly() {
+		return "The system prompt can't be edited in read-only mode", nil
+	}
+	if m.streaming {
+		return "Wait for the current response to finish before editing the system prompt", nil
+	}
+	cmd, err := editSystem()
+	if err != nil {
+		return errorNotice(err), nil
+	}
+	return "Editing the system prompt in your editor…", cmd
+}
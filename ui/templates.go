@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// templatePreviewRunes is how much of each template the list shows
+const templatePreviewRunes = 90
+
+// listTemplates shows the templates and the variables they can use
+func listTemplates() string {
+	templates, custom, err := tools.Templates()
+	if err != nil {
+		return errorNotice(err)
+	}
+	variables, err := tools.TemplateVariables()
+	if err != nil {
+		return errorNotice(err)
+	}
+
+	var out strings.Builder
+	out.WriteString("**Templates:**\n\n")
+	for _, name := range tools.TemplateNames(templates) {
+		preview := []rune(strings.Join(strings.Fields(templates[name]), " "))
+		if len(preview) > templatePreviewRunes {
+			preview = append(preview[:templatePreviewRunes], '…')
+		}
+		saved := ""
+		if custom[name] {
+			saved = " (saved)"
+		}
+		fmt.Fprintf(&out, "• **%s**%s: %s\n", name, saved, string(preview))
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out.WriteString("\n**Variables:**\n\n")
+	for _, name := range names {
+		fmt.Fprintf(&out, "• `{{%s}}`: %s\n", name, variables[name])
+	}
+	out.WriteString("\n`/templates <name> [variable=value...]` puts a template in the input with the variables filled in")
+	return out.String()
+}
+
+// useTemplate fills in a template and puts it in the input to edit and send
+func (m *Model) useTemplate(name string, args []string) string {
+	templates, _, err := tools.Templates()
+	if err != nil {
+		return errorNotice(err)
+	}
+	text, ok := templates[name]
+	if !ok {
+		return fmt.Sprintf("No template named **%s**; `/templates` lists them", name)
+	}
+	variables, err := tools.TemplateVariables()
+	if err != nil {
+		return errorNotice(err)
+	}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return "Give variables as `name=value`, such as `count=20`"
+		}
+		variables[key] = value
+	}
+
+	filled, missing := tools.FillTemplate(text, variables)
+	m.ta.SetValue(filled)
+	if len(missing) > 0 {
+		return fmt.Sprintf("Template **%s** is in the input; fill in `{{%s}}` before sending", name, strings.Join(missing, "}}`, `{{"))
+	}
+	return fmt.Sprintf("Template **%s** is in the input; edit it and press enter to send", name)
+}
+
+func templatesCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		return listTemplates(), nil
+	}
+	switch args[0] {
+	case "save":
+		if len(args) < 3 {
+			return "Usage: `/templates save <name> <text...>`; write `{{variable}}` where a value goes", nil
+		}
+		if err := tools.SaveTemplate(args[1], strings.Join(args[2:], " ")); err != nil {
+			return errorNotice(err), nil
+		}
+		return fmt.Sprintf("✅ **Saved** template %s", strings.ToLower(args[1])), nil
+	case "rm":
+		if len(args) != 2 {
+			return "Usage: `/templates rm <name>`", nil
+		}
+		if err := tools.DeleteTemplate(args[1]); err != nil {
+			return errorNotice(err), nil
+		}
+		return fmt.Sprintf("Removed template %s", args[1]), nil
+	}
+	if m.streaming {
+		return "Wait for the current response to finish before using a template", nil
+	}
+	return m.useTemplate(args[0], args[1:]), nil
+}
@@ -0,0 +1,333 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cloudwego/eino/schema"
+)
+
+// toolCall is a tool the model called during the session, with its
+// arguments and result as they arrive
+type toolCall struct {
+	number    int
+	id        string
+	index     int // Position of the call in the model's reply; -1 if not given
+	name      string
+	arguments strings.Builder
+	result    string
+}
+
+// done reports whether the tool has returned
+func (c *toolCall) done() bool {
+	return c.result != ""
+}
+
+// failed reports whether the tool returned an error
+func (c *toolCall) failed() bool {
+	return strings.Contains(c.result, `"success":false`)
+}
+
+// summary is the one-line form of a call shown while it is collapsed
+func (c *toolCall) summary() string {
+	status := "⏳ running"
+	if c.done() {
+		var result struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal([]byte(c.result), &result)
+		status = "✅ " + result.Message
+		if c.failed() {
+			status = "❌ " + result.Message
+		}
+	}
+	return fmt.Sprintf("- **#%d** `%s` %s", c.number, c.name, status)
+}
+
+// recordToolCall adds a streamed tool call, or the next fragment of its
+// arguments, to the session's calls
+func (m *Model) recordToolCall(call schema.ToolCall) {
+	m.toolMu.Lock()
+	defer m.toolMu.Unlock()
+
+	index := -1
+	if call.Index != nil {
+		index = *call.Index
+	}
+	if call.Function.Name != "" {
+		c := &toolCall{number: len(m.toolCalls) + 1, id: call.ID, index: index, name: call.Function.Name}
+		c.arguments.WriteString(call.Function.Arguments)
+		m.toolCalls = append(m.toolCalls, c)
+		return
+	}
+	// Later chunks of a streamed call only carry more of its arguments
+	for i := len(m.toolCalls) - 1; i >= m.firstCall; i-- {
+		if c := m.toolCalls[i]; !c.done() && (c.index == index || index < 0) {
+			c.arguments.WriteString(call.Function.Arguments)
+			return
+		}
+	}
+}
+
+// recordToolResult attaches a tool message to the call it answers
+func (m *Model) recordToolResult(msg *schema.Message) {
+	m.toolMu.Lock()
+	defer m.toolMu.Unlock()
+
+	for _, c := range m.toolCalls[m.firstCall:] {
+		if !c.done() && (msg.ToolCallID == "" || c.id == "" || c.id == msg.ToolCallID) {
+			c.result = msg.Content
+			return
+		}
+	}
+}
+
+// latestToolCalls returns the latest response's tool calls and results as
+// history items. When the stream failed, calls that never returned are left
+// out: their arguments may be cut short and they didn't run.
+func (m *Model) latestToolCalls() []*schema.Message {
+	m.toolMu.Lock()
+	defer m.toolMu.Unlock()
+
+	items := []*schema.Message{}
+	for _, c := range m.toolCalls[m.firstCall:] {
+		if m.streamErr != nil && !c.done() {
+			continue
+		}
+		items = append(items, tools.ToolCallItem(c.id, c.name, c.arguments.String(), c.result))
+	}
+	return items
+}
+
+// toolPanel renders the tool calls of the latest response, collapsed to one
+// line each except for the expanded call
+func (m *Model) toolPanel() string {
+	m.toolMu.Lock()
+	defer m.toolMu.Unlock()
+
+	calls := m.toolCalls[m.firstCall:]
+	var expanded *toolCall
+	if m.expandedTool > 0 && m.expandedTool <= len(m.toolCalls) {
+		expanded = m.toolCalls[m.expandedTool-1]
+	}
+	if len(calls) == 0 && expanded == nil {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("=== Tool Calls ===\n\n")
+	for _, c := range calls {
+		out.WriteString(c.summary() + "\n")
+	}
+	if expanded != nil {
+		out.WriteString("\n" + expandToolCall(expanded))
+	} else {
+		out.WriteString("\n`/tools <#>` shows a call's arguments and result")
+	}
+	return out.String()
+}
+
+// expandToolCall renders a call's arguments and its pretty-printed result
+func expandToolCall(c *toolCall) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("#### #%d %s\n\n", c.number, c.name))
+	if args := c.arguments.String(); args != "" && args != "{}" {
+		out.WriteString("```json\n" + formatArguments(args) + "\n```\n\n")
+	}
+	if !c.done() {
+		out.WriteString("⏳ Waiting for the result\n")
+		return out.String()
+	}
+	out.WriteString(prettyToolResult(c.result))
+	return out.String()
+}
+
+// prettyToolResult renders a tool result: lexicon entries, phoneme
+// inventories and syllabified words as lists and tables, multi-line text as
+// markdown, and any other fields as JSON
+func prettyToolResult(content string) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		return "```\n" + content + "\n```\n"
+	}
+
+	var out strings.Builder
+	var message string
+	json.Unmarshal(fields["message"], &message)
+	if strings.Contains(content, `"success":false`) {
+		out.WriteString("❌ **Error:** " + message + "\n\n")
+	} else {
+		out.WriteString("✅ **" + message + "**\n\n")
+	}
+	delete(fields, "success")
+	delete(fields, "message")
+
+	var entries []tools.LexiconEntry
+	if json.Unmarshal(fields["entries"], &entries) == nil && len(entries) > 0 {
+		out.WriteString(formatLexiconEntries(entries))
+		delete(fields, "entries")
+	}
+	var inventory storage.PhonemeInventory
+	if json.Unmarshal(fields["inventory"], &inventory) == nil && !inventory.Empty() {
+		out.WriteString(tools.RenderInventory(inventory))
+		delete(fields, "inventory")
+	}
+	var words []tools.SyllabifiedWord
+	if json.Unmarshal(fields["words"], &words) == nil && len(words) > 0 {
+		out.WriteString(formatSyllabifiedWords(words))
+		delete(fields, "words")
+	}
+	for _, key := range []string{"phonemes", "allophones"} {
+		var symbols []string
+		if json.Unmarshal(fields[key], &symbols) == nil && len(symbols) > 0 {
+			out.WriteString(fmt.Sprintf("**%s:** /%s/\n\n", strings.ToUpper(key[:1])+key[1:], strings.Join(symbols, "/ /")))
+			delete(fields, key)
+		}
+	}
+	var warnings []string
+	if json.Unmarshal(fields["warnings"], &warnings) == nil {
+		for _, warning := range warnings {
+			out.WriteString("⚠️ " + warning + "\n\n")
+		}
+		delete(fields, "warnings")
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	rest := map[string]json.RawMessage{}
+	for _, key := range keys {
+		// Tables, reports and file contents are already formatted text
+		var text string
+		if json.Unmarshal(fields[key], &text) == nil && strings.Contains(text, "\n") {
+			out.WriteString(text + "\n\n")
+			continue
+		}
+		rest[key] = fields[key]
+	}
+	if len(rest) > 0 {
+		data, err := json.MarshalIndent(rest, "", "  ")
+		if err == nil {
+			out.WriteString("```json\n" + string(data) + "\n```\n")
+		}
+	}
+	return out.String()
+}
+
+// formatSyllabifiedWords renders syllabified words as a markdown table
+func formatSyllabifiedWords(words []tools.SyllabifiedWord) string {
+	var out strings.Builder
+	out.WriteString("| Word | Syllables | Skeleton | Violations |\n|---|---|---|---|\n")
+	for _, w := range words {
+		violations := []string{}
+		for _, v := range w.Violations {
+			violations = append(violations, v.Detail)
+		}
+		out.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", w.Word, strings.Join(w.Syllables, "."), w.Skeleton, strings.Join(violations, "; ")))
+	}
+	return out.String() + "\n"
+}
+
+func toolsCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) == 0 {
+		m.showTools = !m.showTools
+	} else {
+		switch args[0] {
+		case "on":
+			m.showTools = true
+		case "off":
+			m.showTools = false
+			m.expandedTool = 0
+		case "collapse":
+			m.expandedTool = 0
+		default:
+			n, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+			if err != nil {
+				return "Usage: `" + commands["tools"].usage + "`", nil
+			}
+			if n < 1 || n > len(m.toolCalls) {
+				return fmt.Sprintf("No tool call #%d", n), nil
+			}
+			m.showTools = true
+			m.expandedTool = n
+			return "", nil
+		}
+	}
+	if m.showTools {
+		return "Tool call panel **on**", nil
+	}
+	return "Tool call panel **off**", nil
+}
+
+// pendingCalls renders the latest response's tool calls that haven't
+// returned yet, with their arguments as far as they have streamed in
+func (m *Model) pendingCalls() string {
+	m.toolMu.Lock()
+	defer m.toolMu.Unlock()
+
+	var out strings.Builder
+	for _, c := range m.toolCalls[m.firstCall:] {
+		if c.done() {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("\n\n🔧 **%s**\n\n", c.name))
+		for _, field := range partialArguments(c.arguments.String()) {
+			out.WriteString("- " + field + "\n")
+		}
+	}
+	return out.String()
+}
+
+// partialArguments reads the fields of tool call arguments that may still be
+// streaming in as "key: value" lines; the last value may be cut off
+func partialArguments(raw string) []string {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+	var fields []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return fields
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fields // End of the object
+		}
+		start := decoder.InputOffset()
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			if rest := strings.TrimLeft(raw[start:], " \t\r\n:"); rest != "" {
+				fields = append(fields, fmt.Sprintf("**%s:** %s…", key, partialValue(rest)))
+			}
+			return fields
+		}
+		fields = append(fields, fmt.Sprintf("**%s:** %s", key, partialValue(string(value))))
+	}
+}
+
+// argumentEscapes undoes the JSON escapes common in argument strings,
+// keeping values on one line
+var argumentEscapes = strings.NewReplacer(`\n`, " ", `\t`, " ", `\"`, `"`, `\\`, `\`)
+
+// partialValue shows a JSON value, complete or cut off, as plain text
+func partialValue(value string) string {
+	var text string
+	if json.Unmarshal([]byte(value), &text) == nil {
+		return strings.ReplaceAll(text, "\n", " ")
+	}
+	if strings.HasPrefix(value, `"`) {
+		return argumentEscapes.Replace(strings.TrimPrefix(value, `"`))
+	}
+	return value
+}
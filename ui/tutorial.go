@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tutorialLesson is one step of the guided tutorial
+type tutorialLesson struct {
+	title   string
+	intro   string // What the step is about and what to answer
+	example string // Answer used when the user runs /tutorial next
+	prompt  string // Instruction sent with the user's answer in place of %s
+}
+
+// tutorialLessons build a first sketch of a language with the real tools, so
+// everything made during the tutorial stays in the user's data
+var tutorialLessons = []tutorialLesson{
+	{
+		title: "Sounds",
+		intro: "Every word of your language is built from its **phoneme inventory**, the consonants and vowels it uses. " +
+			"Describe the sounds you want in your own words, such as *soft and flowing, no harsh clusters* or *like Hawaiian with a few fricatives*.",
+		example: "a compact inventory: the consonants p t k m n s h l w j and the vowels a e i o u",
+		prompt: "Tutorial step 1 of 4, the phoneme inventory. The user describes the sounds they want: %s\n\n" +
+			"Declare a fitting inventory with set_phoneme_inventory, with features and romanizations for every phoneme. " +
+			"Then explain in a few sentences, for someone new to conlanging, what an inventory is and why you chose these sounds.",
+	},
+	{
+		title: "Syllables",
+		intro: "**Phonotactics** decide how sounds may combine: the shape of a syllable, which clusters are allowed and which sounds may end a word. " +
+			"Say how your words should feel, such as *simple open syllables* or *heavy consonant clusters allowed*.",
+		example: "mostly simple syllables: an optional consonant, a vowel, and an optional nasal at the end",
+		prompt: "Tutorial step 2 of 4, phonotactics. The user wants syllables like this: %s\n\n" +
+			"Store matching rules with set_phonotactics, using a template such as (C)V(N) and any onsets, codas and forbidden clusters. " +
+			"Show three or four example syllables that fit, check them with check_phonotactics, and briefly explain the template notation.",
+	},
+	{
+		title: "First words",
+		intro: "Time for a **lexicon**. The model will coin 20 basic words that obey your inventory and phonotactics and add them to your dictionary. " +
+			"Name a theme or some meanings you care about, such as *nature and family* or *words for a seafaring people*.",
+		example: "everyday basics: pronouns, family, body parts, nature and a few common verbs",
+		prompt: "Tutorial step 3 of 4, the first words. Theme: %s\n\n" +
+			"Coin exactly 20 basic words for this theme that use only the declared phonemes, including at least two pronouns, several nouns and a few verbs. " +
+			"Check them with check_phonotactics, fix any that fail, then add each one with add_lexicon_entry with its part of speech and definition. " +
+			"Finish with a short table of the words and one sentence on how they were built.",
+	},
+	{
+		title: "A first sentence",
+		intro: "Finally, put the words to work. Choose a **word order** and give a short sentence to translate, " +
+			"such as *I see the water* or *the mother gives the child a fish*.",
+		example: "subject-object-verb order; translate \"I see the river\"",
+		prompt: "Tutorial step 4 of 4, a sample sentence. The user asks for: %s\n\n" +
+			"Store the basic word order and case marking with set_syntax, coining any words the sentence needs with add_lexicon_entry. " +
+			"Translate the sentence and show it as an interlinear gloss with gloss_text, then explain each line of the gloss.",
+	},
+}
+
+// tutorialState is the guided tutorial in progress
+type tutorialState struct {
+	lesson  int  // Index of the current lesson
+	running bool // The current lesson's response is streaming
+}
+
+// intro renders the current lesson's introduction
+func (t *tutorialState) intro() string {
+	lesson := tutorialLessons[t.lesson]
+	return fmt.Sprintf("🎓 **Tutorial %d/%d: %s**\n\n%s\n\nType your answer and press enter, or `/tutorial next` to go with *%s*. `/tutorial quit` leaves the tutorial.",
+		t.lesson+1, len(tutorialLessons), lesson.title, lesson.intro, lesson.example)
+}
+
+// answerTutorial sends the user's answer to the current lesson with its instructions
+func (m *Model) answerTutorial(answer string) tea.Cmd {
+	m.tutorial.running = true
+	m.notice = ""
+	return m.sendMessage(fmt.Sprintf(tutorialLessons[m.tutorial.lesson].prompt, answer))
+}
+
+// continueTutorial moves on to the next lesson once a lesson's response has
+// finished, or repeats the lesson if it failed
+func (m *Model) continueTutorial() {
+	if m.tutorial == nil || !m.tutorial.running {
+		return
+	}
+	m.tutorial.running = false
+	if m.responseFailed {
+		m.notice = "Something went wrong in that step; answer again to retry it, or `/tutorial next` to use the example.\n\n" + m.tutorial.intro()
+		return
+	}
+	m.tutorial.lesson++
+	if m.tutorial.lesson == len(tutorialLessons) {
+		m.tutorial = nil
+		m.notice = "🎓 **Tutorial complete!** Your inventory, phonotactics, first words and word order are saved. " +
+			"Browse the words with `/lexicon`, grow the vocabulary with `/sprint`, or ask for a grammar sketch."
+		return
+	}
+	m.notice = m.tutorial.intro()
+}
+
+func tutorialCommand(m *Model, args []string) (string, tea.Cmd) {
+	if m.streaming {
+		return "Wait for the current response to finish", nil
+	}
+	action := ""
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "":
+		if m.tutorial == nil {
+			if m.plan != nil && m.plan.active() {
+				return "Finish or abort the running plan before starting the tutorial", nil
+			}
+			m.tutorial = &tutorialState{}
+		}
+		return m.tutorial.intro(), nil
+	case "next":
+		if m.tutorial == nil {
+			return "No tutorial is running; start one with `/tutorial`", nil
+		}
+		return "", m.answerTutorial(tutorialLessons[m.tutorial.lesson].example)
+	case "quit":
+		if m.tutorial == nil {
+			return "No tutorial is running", nil
+		}
+		m.tutorial = nil
+		return "Left the tutorial; everything made so far stays saved", nil
+	}
+	return "Usage: `" + commands["tutorial"].usage + "`", nil
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"log"
+	"slices"
+
+	"l2/storage"
+	"l2/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func verbosityCommand(m *Model, args []string) (string, tea.Cmd) {
+	if len(args) > 0 {
+		if !slices.Contains(tools.VerbosityLevels, args[0]) {
+			return "Usage: `" + commands["verbosity"].usage + "`", nil
+		}
+		m.verbosity = args[0]
+
+		err := storage.UpdateConfig(func(config *storage.Config) error {
+			config.Verbosity = m.verbosity
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to save verbosity setting: %v", err)
+		}
+	}
+
+	switch m.verbosity {
+	case tools.VerbosityTerse:
+		return "Verbosity **terse**: answers give just what was asked for, without explanation", nil
+	case tools.VerbosityTeacher:
+		return "Verbosity **teacher**: answers explain the reasoning and end with a short exercise", nil
+	}
+	return "Verbosity **normal**", nil
+}